@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"alexandria/internal/dates"
+	"alexandria/internal/logger"
+	"alexandria/internal/ticket"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	archiveProject      string
+	archiveClosedBefore string
+	archiveDryRun       bool
+)
+
+var archiveCmd = &cobra.Command{
+	Use:   "archive",
+	Short: "Move long-closed tickets out of the live ticket tables",
+	Long: `Move a project's tickets that were closed longer ago than --closed-before into the
+archive tables, with their tags, files, comments, time logs, status history and
+linked commits. List, reports and stats then no longer have to read them.
+
+Archived tickets stay reachable: "list --include-archived" lists them alongside
+live tickets and "view" falls back to the archive when given an ID. They can no
+longer be updated, and "undo" no longer applies to them.
+
+Examples:
+  alexandria archive --closed-before 90d --project Backend --dry-run
+  alexandria archive --closed-before 90d --project Backend
+  alexandria list --project Backend --status closed --include-archived`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := applyDefaultProject(&archiveProject); err != nil {
+			return err
+		}
+		if archiveProject == "" {
+			logger.Log.Error("validation failed", "error", "project is required")
+			return errNoProject
+		}
+
+		age, err := dates.ParseDuration(archiveClosedBefore)
+		if err != nil || age < 0 {
+			logger.Log.Error("validation failed", "error", "invalid closed-before", "closed_before", archiveClosedBefore)
+			return fmt.Errorf("invalid --closed-before: %s (use a duration like 90d or 12w)", archiveClosedBefore)
+		}
+		cutoff := time.Now().Add(-age)
+
+		store, err := ticketStore(cmd)
+		if err != nil {
+			return err
+		}
+		ctx := cmd.Context()
+
+		if archiveDryRun {
+			closed := ticket.StatusClosed
+			tickets, err := store.List(ctx, ticket.Filters{Project: &archiveProject, Status: &closed})
+			if err != nil {
+				logger.Log.Error("failed to list tickets", "error", err)
+				return fmt.Errorf("failed to list tickets: %w", err)
+			}
+			count := 0
+			for _, t := range tickets {
+				if t.ClosedAt != nil && t.ClosedAt.Before(cutoff) {
+					fmt.Printf("Would archive %d: %s (closed %s)\n", t.ID, t.Title, t.ClosedAt.Local().Format("2006-01-02"))
+					count++
+				}
+			}
+			fmt.Printf("Dry run: %d ticket(s) would be archived.\n", count)
+			return nil
+		}
+
+		archived, err := store.Archive(ctx, archiveProject, cutoff)
+		if err != nil {
+			logger.Log.Error("failed to archive tickets", "error", err, "project", archiveProject)
+			return fmt.Errorf("failed to archive tickets: %w", err)
+		}
+
+		for _, t := range archived {
+			fmt.Printf("Archived %d: %s\n", t.ID, t.Title)
+		}
+		fmt.Printf("Archived %d ticket(s) closed before %s from project %s.\n", len(archived), cutoff.Local().Format("2006-01-02"), archiveProject)
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(archiveCmd)
+
+	archiveCmd.Flags().StringVar(&archiveProject, "project", "", projectFlagUsage)
+	archiveCmd.Flags().StringVar(&archiveClosedBefore, "closed-before", "", "Archive tickets closed longer ago than this (e.g. 90d, 12w)")
+	archiveCmd.Flags().BoolVar(&archiveDryRun, "dry-run", false, "List the tickets that would be archived without moving them")
+	archiveCmd.MarkFlagRequired("closed-before")
+}
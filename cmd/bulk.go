@@ -0,0 +1,291 @@
+package cmd
+
+import (
+	"alexandria/internal/logger"
+	"alexandria/internal/ticket"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// bulkConfirmLimit is the most tickets a bulk command changes without --yes
+const bulkConfirmLimit = 10
+
+// bulkPreviewLines is how many matching tickets are listed before the rest are counted
+const bulkPreviewLines = 20
+
+var (
+	bulkWhere      string
+	bulkYes        bool
+	bulkDryRun     bool
+	bulkStatus     string
+	bulkPriority   string
+	bulkType       string
+	bulkAssignee   string
+	bulkAddTags    string
+	bulkRemoveTags string
+)
+
+var bulkCmd = &cobra.Command{
+	Use:   "bulk",
+	Short: "Update, tag or delete every ticket matching a filter",
+	Long: `Change every ticket matching a --where expression in a single transaction: either
+all of them change or none do. The matching tickets are listed first, and more than
+` + fmt.Sprint(bulkConfirmLimit) + ` tickets are only changed with --yes.
+
+--where takes the same filters as "list --where". ` + ticket.WhereHelp + `
+
+Without project: the project in .alexandria.toml applies, as it does for list.
+
+Examples:
+  alexandria bulk update --status closed --where 'project:X tag:sprint-3 status:in-progress'
+  alexandria bulk tag --add sprint-4 --remove sprint-3 --where 'project:X tag:sprint-3 status:open'
+  alexandria bulk delete --where 'project:X type:task status:closed' --dry-run`,
+}
+
+var bulkUpdateCmd = &cobra.Command{
+	Use:   "update",
+	Short: "Set the status, priority, type or assignee of matching tickets",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if bulkStatus == "" && bulkPriority == "" && bulkType == "" && bulkAssignee == "" {
+			logger.Log.Error("validation failed", "error", "nothing to update")
+			return fmt.Errorf("nothing to update (pass --status, --priority, --type or --assigned-to)")
+		}
+		if bulkStatus != "" && !ticket.Status(bulkStatus).Valid() {
+			logger.Log.Error("validation failed", "error", "invalid status", "status", bulkStatus)
+			return fmt.Errorf("invalid status: %s (must be: open, in-progress, or closed)", bulkStatus)
+		}
+		if bulkPriority != "" && !ticket.Priority(bulkPriority).Valid() {
+			logger.Log.Error("validation failed", "error", "invalid priority", "priority", bulkPriority)
+			return fmt.Errorf("invalid priority: %s (must be: undefined, low, medium, or high)", bulkPriority)
+		}
+		if bulkType != "" && !ticket.Type(bulkType).Valid() {
+			logger.Log.Error("validation failed", "error", "invalid type", "type", bulkType)
+			return fmt.Errorf("invalid type: %s (must be: bug, feature, or task)", bulkType)
+		}
+
+		store, tickets, err := bulkSelect(cmd)
+		if err != nil {
+			return err
+		}
+		if ok, err := bulkConfirm("update", tickets); !ok || err != nil {
+			return err
+		}
+
+		wasClosed := make(map[int64]bool, len(tickets))
+		for i := range tickets {
+			t := &tickets[i]
+			wasClosed[t.ID] = t.Status == ticket.StatusClosed
+			if bulkStatus != "" {
+				t.Status = ticket.Status(bulkStatus)
+			}
+			if bulkPriority != "" {
+				t.Priority = ticket.Priority(bulkPriority)
+			}
+			if bulkType != "" {
+				t.Type = ticket.Type(bulkType)
+			}
+			if bulkAssignee != "" {
+				assignee := bulkAssignee
+				t.AssignedTo = &assignee
+			}
+		}
+
+		if err := store.UpdateMany(cmd.Context(), tickets); err != nil {
+			logger.Log.Error("failed to update tickets", "error", err, "count", len(tickets))
+			return fmt.Errorf("failed to update tickets: %w", err)
+		}
+
+		fmt.Printf("Updated %d ticket(s).\n", len(tickets))
+		for i := range tickets {
+			if tickets[i].Status == ticket.StatusClosed && !wasClosed[tickets[i].ID] {
+				warnOpenChildren(cmd.Context(), store, &tickets[i])
+			}
+		}
+		return nil
+	},
+}
+
+var bulkTagCmd = &cobra.Command{
+	Use:   "tag",
+	Short: "Add or remove tags on matching tickets",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		add, remove := splitTags(bulkAddTags), splitTags(bulkRemoveTags)
+		if len(add) == 0 && len(remove) == 0 {
+			logger.Log.Error("validation failed", "error", "no tags given")
+			return fmt.Errorf("no tags given (pass --add and/or --remove)")
+		}
+
+		store, tickets, err := bulkSelect(cmd)
+		if err != nil {
+			return err
+		}
+		if ok, err := bulkConfirm("tag", tickets); !ok || err != nil {
+			return err
+		}
+
+		// Only tickets whose tags actually change are written
+		var changed []ticket.Ticket
+		for _, t := range tickets {
+			tags := retag(t.Tags, add, remove)
+			if strings.Join(tags, ",") == strings.Join(t.Tags, ",") {
+				continue
+			}
+			t.Tags = tags
+			changed = append(changed, t)
+		}
+
+		if len(changed) > 0 {
+			if err := store.UpdateMany(cmd.Context(), changed); err != nil {
+				logger.Log.Error("failed to tag tickets", "error", err, "count", len(changed))
+				return fmt.Errorf("failed to tag tickets: %w", err)
+			}
+		}
+
+		fmt.Printf("Retagged %d ticket(s); %d already had the requested tags.\n", len(changed), len(tickets)-len(changed))
+		return nil
+	},
+}
+
+var bulkDeleteCmd = &cobra.Command{
+	Use:   "delete",
+	Short: "Move matching tickets to the trash",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		store, tickets, err := bulkSelect(cmd)
+		if err != nil {
+			return err
+		}
+		if ok, err := bulkConfirm("delete", tickets); !ok || err != nil {
+			return err
+		}
+
+		if err := store.DeleteMany(cmd.Context(), tickets); err != nil {
+			logger.Log.Error("failed to delete tickets", "error", err, "count", len(tickets))
+			return fmt.Errorf("failed to delete tickets: %w", err)
+		}
+
+		fmt.Printf("Moved %d ticket(s) to the trash.\n", len(tickets))
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(bulkCmd)
+	bulkCmd.AddCommand(bulkUpdateCmd)
+	bulkCmd.AddCommand(bulkTagCmd)
+	bulkCmd.AddCommand(bulkDeleteCmd)
+
+	bulkCmd.PersistentFlags().StringVar(&bulkWhere, "where", "", "Filter expression selecting the tickets to change (required)")
+	bulkCmd.PersistentFlags().BoolVarP(&bulkYes, "yes", "y", false, fmt.Sprintf("Change more than %d tickets without stopping", bulkConfirmLimit))
+	bulkCmd.PersistentFlags().BoolVar(&bulkDryRun, "dry-run", false, "List the matching tickets without changing them")
+	bulkCmd.MarkPersistentFlagRequired("where")
+
+	bulkUpdateCmd.Flags().StringVar(&bulkStatus, "status", "", "New status (open, in-progress, closed)")
+	bulkUpdateCmd.Flags().StringVarP(&bulkPriority, "priority", "p", "", "New priority (undefined, low, medium, high)")
+	bulkUpdateCmd.Flags().StringVar(&bulkType, "type", "", "New type (bug, feature, task)")
+	bulkUpdateCmd.Flags().StringVarP(&bulkAssignee, "assigned-to", "a", "", "New assignee")
+
+	bulkTagCmd.Flags().StringVar(&bulkAddTags, "add", "", "Comma-separated tags to add")
+	bulkTagCmd.Flags().StringVar(&bulkRemoveTags, "remove", "", "Comma-separated tags to remove")
+}
+
+// bulkSelect loads the tickets matching --where with the same filters list uses
+func bulkSelect(cmd *cobra.Command) (ticket.TicketStore, []ticket.Ticket, error) {
+	filters, err := ticket.ParseWhere(bulkWhere)
+	if err != nil {
+		logger.Log.Error("validation failed", "error", err, "where", bulkWhere)
+		return nil, nil, err
+	}
+
+	if filters.Project == nil {
+		var project string
+		if err := applyDefaultProject(&project); err != nil {
+			return nil, nil, err
+		}
+		if project != "" {
+			filters.Project = &project
+		}
+	}
+
+	// An empty expression would select every ticket in every project
+	if filters.IsEmpty() {
+		logger.Log.Error("validation failed", "error", "empty filter", "where", bulkWhere)
+		return nil, nil, fmt.Errorf("--where must select something, e.g. 'project:X status:open'")
+	}
+
+	store, err := ticketStore(cmd)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	logger.Log.Debug("selecting tickets for bulk change", "where", bulkWhere)
+	tickets, err := store.List(cmd.Context(), filters)
+	if err != nil {
+		logger.Log.Error("failed to list tickets", "error", err)
+		return nil, nil, fmt.Errorf("failed to list tickets: %w", err)
+	}
+	return store, tickets, nil
+}
+
+// bulkConfirm previews the tickets a bulk command will change and reports whether
+// to go ahead. It refuses, with an error, to change more than bulkConfirmLimit
+// tickets without --yes.
+func bulkConfirm(action string, tickets []ticket.Ticket) (bool, error) {
+	if len(tickets) == 0 {
+		fmt.Println("No tickets found.")
+		return false, nil
+	}
+
+	fmt.Printf("%d ticket(s) match:\n", len(tickets))
+	for i, t := range tickets {
+		if i == bulkPreviewLines {
+			fmt.Printf("  ... and %d more\n", len(tickets)-i)
+			break
+		}
+		fmt.Printf("  %-6d %-18s %-13s %s\n", t.ID, t.Project, t.Status, t.Title)
+	}
+
+	if bulkDryRun {
+		fmt.Printf("Dry run: nothing to %s.\n", action)
+		return false, nil
+	}
+	if len(tickets) > bulkConfirmLimit && !bulkYes {
+		logger.Log.Error("bulk change needs confirmation", "action", action, "count", len(tickets))
+		return false, fmt.Errorf("refusing to %s %d tickets without --yes (more than %d)", action, len(tickets), bulkConfirmLimit)
+	}
+	return true, nil
+}
+
+// splitTags parses a comma-separated tag list, dropping empty entries
+func splitTags(value string) []string {
+	var tags []string
+	for _, tag := range strings.Split(value, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// retag returns tags without those in remove and with those in add, keeping order
+func retag(tags, add, remove []string) []string {
+	drop := make(map[string]bool, len(remove))
+	for _, tag := range remove {
+		drop[tag] = true
+	}
+
+	result := []string{}
+	seen := make(map[string]bool)
+	for _, tag := range append(append([]string{}, tags...), add...) {
+		if drop[tag] || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		result = append(result, tag)
+	}
+	return result
+}
@@ -0,0 +1,187 @@
+package cmd
+
+import (
+	"alexandria/internal/logger"
+	"alexandria/internal/report"
+	"alexandria/internal/ticket"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	chartSprint  string
+	chartProject string
+	chartSince   string
+	chartUntil   string
+	chartFormat  string
+	chartWidth   int
+)
+
+var chartCmd = &cobra.Command{
+	Use:   "chart",
+	Short: "Draw burndown and cumulative flow charts",
+	Long: `Draw charts reconstructed from ticket status history and close timestamps.
+Charts render in the terminal with Unicode blocks, or as CSV or SVG with --format.`,
+}
+
+var burndownCmd = &cobra.Command{
+	Use:   "burndown",
+	Short: "Draw a sprint burndown chart",
+	Long: `Draw the work remaining in a sprint at the end of each day, with an ideal line
+falling to zero. A sprint is the set of tickets tagged with the sprint name. Story points
+are used when any ticket in the sprint has them, otherwise tickets are counted.
+
+Examples:
+  alexandria chart burndown --sprint sprint-3
+  alexandria chart burndown --sprint sprint-3 --since 2026-10-01 --format svg > burndown.svg`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		logger.Log.Debug("drawing burndown", "sprint", chartSprint, "project", chartProject)
+
+		if chartSprint == "" {
+			logger.Log.Error("validation failed", "error", "sprint is required")
+			return fmt.Errorf("sprint is required")
+		}
+
+		if err := applyDefaultProject(&chartProject); err != nil {
+			return err
+		}
+
+		filters := ticket.Filters{Tags: []string{chartSprint}}
+		if chartProject != "" {
+			filters.Project = &chartProject
+		}
+
+		tickets, history, err := loadChartData(cmd, filters)
+		if err != nil {
+			return err
+		}
+		if len(tickets) == 0 {
+			fmt.Printf("No tickets found in sprint %s.\n", chartSprint)
+			return nil
+		}
+
+		// Default the range to the sprint's first ticket
+		from := tickets[0].CreatedAt
+		for _, t := range tickets {
+			if t.CreatedAt.Before(from) {
+				from = t.CreatedAt
+			}
+		}
+
+		from, to, err := chartRange(from)
+		if err != nil {
+			return err
+		}
+
+		series := report.Burndown("Burndown for "+chartSprint, tickets, history, from, to)
+		return report.Render(os.Stdout, series, chartFormat, chartWidth)
+	},
+}
+
+var cfdCmd = &cobra.Command{
+	Use:   "cfd",
+	Short: "Draw a cumulative flow diagram",
+	Long: `Draw the number of tickets in each status at the end of each day.
+
+Examples:
+  alexandria chart cfd --project Alexandria --since 30d
+  alexandria chart cfd --project Alexandria --since 2w --format csv`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		logger.Log.Debug("drawing cumulative flow", "project", chartProject, "since", chartSince)
+
+		if err := applyDefaultProject(&chartProject); err != nil {
+			return err
+		}
+		if chartProject == "" {
+			logger.Log.Error("validation failed", "error", "project is required")
+			return errNoProject
+		}
+
+		tickets, history, err := loadChartData(cmd, ticket.Filters{Project: &chartProject})
+		if err != nil {
+			return err
+		}
+
+		from, to, err := chartRange(time.Now().AddDate(0, 0, -30))
+		if err != nil {
+			return err
+		}
+
+		series := report.CumulativeFlow("Cumulative flow for "+chartProject, tickets, history, from, to)
+		return report.Render(os.Stdout, series, chartFormat, chartWidth)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(chartCmd)
+	chartCmd.AddCommand(burndownCmd)
+	chartCmd.AddCommand(cfdCmd)
+
+	for _, c := range []*cobra.Command{burndownCmd, cfdCmd} {
+		c.Flags().StringVar(&chartSince, "since", "", "Start of the chart, as a date or a duration ago (e.g. 30d)")
+		c.Flags().StringVar(&chartUntil, "until", "", "End of the chart (defaults to now)")
+		c.Flags().StringVar(&chartFormat, "format", report.FormatText, "Output format (text, csv, svg)")
+		c.Flags().IntVar(&chartWidth, "width", 50, "Bar width in characters for text output")
+	}
+	burndownCmd.Flags().StringVar(&chartSprint, "sprint", "", "Sprint tag to chart (required)")
+	burndownCmd.Flags().StringVar(&chartProject, "project", "", "Limit the sprint to a project")
+	cfdCmd.Flags().StringVar(&chartProject, "project", "", projectFlagUsage)
+}
+
+// loadChartData loads the tickets matching filters and the status history needed to replay them
+func loadChartData(cmd *cobra.Command, filters ticket.Filters) ([]ticket.Ticket, map[int64][]ticket.StatusChange, error) {
+	store, err := ticketStore(cmd)
+	if err != nil {
+		return nil, nil, err
+	}
+	ctx := cmd.Context()
+
+	tickets, err := store.List(ctx, filters)
+	if err != nil {
+		logger.Log.Error("failed to list tickets", "error", err)
+		return nil, nil, fmt.Errorf("failed to list tickets: %w", err)
+	}
+
+	history, err := store.StatusHistory(ctx)
+	if err != nil {
+		logger.Log.Error("failed to load status history", "error", err)
+		return nil, nil, fmt.Errorf("failed to load status history: %w", err)
+	}
+
+	return tickets, history, nil
+}
+
+// chartRange resolves --since and --until, falling back to defaultFrom and now
+func chartRange(defaultFrom time.Time) (time.Time, time.Time, error) {
+	now := time.Now()
+	from, to := defaultFrom, now
+
+	if chartSince != "" {
+		var err error
+		if from, err = parseSince(chartSince, now); err != nil {
+			logger.Log.Error("validation failed", "error", err, "since", chartSince)
+			return time.Time{}, time.Time{}, err
+		}
+	}
+	if chartUntil != "" {
+		until, err := parseOptionalDate(chartUntil)
+		if err != nil || until == nil {
+			logger.Log.Error("validation failed", "error", err, "until", chartUntil)
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid --until value: %s", chartUntil)
+		}
+		// Days after today have no history to replay
+		if until.AddDate(0, 0, 1).Before(now) {
+			to = until.AddDate(0, 0, 1).Add(-time.Nanosecond)
+		}
+	}
+
+	if from.After(to) {
+		return time.Time{}, time.Time{}, fmt.Errorf("chart start %s is after its end %s", from.Format("2006-01-02"), to.Format("2006-01-02"))
+	}
+	return from, to, nil
+}
@@ -0,0 +1,192 @@
+package cmd
+
+import (
+	"alexandria/internal/logger"
+	"alexandria/internal/ticket"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var checkUndo bool
+
+var checkCmd = &cobra.Command{
+	Use:   "check",
+	Short: "Manage the checklist of small steps inside a ticket",
+	Long: `A checklist breaks a ticket into small steps that do not deserve tickets of their
+own. Items keep the order they were added in and are numbered from 1, as shown by
+"check list" and view. Without a ticket reference the checklist of the ticket for the
+current git branch is used (see checkout).
+
+Tickets with items still to do can be listed with --where incomplete-checklist.
+
+Examples:
+  alexandria check add ALX-4 "write migration"
+  alexandria check done ALX-4 1
+  alexandria check done ALX-4 1 --undo
+  alexandria check rm ALX-4 2`,
+}
+
+var checkAddCmd = &cobra.Command{
+	Use:   "add [ref] <text>",
+	Short: "Add an item to the end of a ticket's checklist",
+	Args:  cobra.RangeArgs(1, 2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ref, text := "", args[0]
+		if len(args) == 2 {
+			ref, text = args[0], args[1]
+		}
+
+		text = strings.TrimSpace(text)
+		if text == "" {
+			logger.Log.Error("validation failed", "error", "empty checklist item")
+			return fmt.Errorf("checklist item text is required")
+		}
+
+		store, err := ticketStore(cmd)
+		if err != nil {
+			return err
+		}
+		ctx := cmd.Context()
+
+		t, err := resolveTicketOrCurrent(ctx, store, ref)
+		if err != nil {
+			return err
+		}
+
+		if _, err := store.AddCheckItem(ctx, t.ID, text); err != nil {
+			return fmt.Errorf("failed to add checklist item: %w", err)
+		}
+
+		fmt.Printf("Added item %d to the checklist of ticket %d: %s\n", len(t.Checklist)+1, t.ID, text)
+		return nil
+	},
+}
+
+var checkDoneCmd = &cobra.Command{
+	Use:   "done [ref] <item>",
+	Short: "Check off a checklist item, or uncheck it with --undo",
+	Args:  cobra.RangeArgs(1, 2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		store, t, n, err := checklistTarget(cmd, args)
+		if err != nil {
+			return err
+		}
+
+		item, err := store.SetCheckItemDone(cmd.Context(), t.ID, n, !checkUndo)
+		if err != nil {
+			return fmt.Errorf("failed to update checklist item: %w", err)
+		}
+		t.Checklist[n-1] = *item
+
+		done, total := t.ChecklistProgress()
+		verb := "Checked off"
+		if checkUndo {
+			verb = "Unchecked"
+		}
+		fmt.Printf("%s item %d of ticket %d: %s (%d/%d done)\n", verb, n, t.ID, item.Text, done, total)
+		return nil
+	},
+}
+
+var checkRmCmd = &cobra.Command{
+	Use:   "rm [ref] <item>",
+	Short: "Remove an item from a ticket's checklist; later items move up",
+	Args:  cobra.RangeArgs(1, 2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		store, t, n, err := checklistTarget(cmd, args)
+		if err != nil {
+			return err
+		}
+
+		item, err := store.RemoveCheckItem(cmd.Context(), t.ID, n)
+		if err != nil {
+			return fmt.Errorf("failed to remove checklist item: %w", err)
+		}
+
+		fmt.Printf("Removed item %d from the checklist of ticket %d: %s\n", n, t.ID, item.Text)
+		return nil
+	},
+}
+
+var checkListCmd = &cobra.Command{
+	Use:   "list [ref]",
+	Short: "Show a ticket's checklist",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		store, err := ticketStore(cmd)
+		if err != nil {
+			return err
+		}
+
+		ref := ""
+		if len(args) == 1 {
+			ref = args[0]
+		}
+		t, err := resolveTicketOrCurrent(cmd.Context(), store, ref)
+		if err != nil {
+			return err
+		}
+
+		if len(t.Checklist) == 0 {
+			fmt.Printf("Ticket %d has no checklist.\n", t.ID)
+			return nil
+		}
+		done, total := t.ChecklistProgress()
+		fmt.Printf("Checklist of ticket %d: %s (%d/%d done)\n", t.ID, t.Title, done, total)
+		printChecklist(t.Checklist)
+		return nil
+	},
+}
+
+// checklistTarget resolves the "[ref] <item>" arguments of check done and check rm
+// to the ticket and the number of one of its checklist items
+func checklistTarget(cmd *cobra.Command, args []string) (ticket.TicketStore, *ticket.Ticket, int, error) {
+	ref, number := "", args[0]
+	if len(args) == 2 {
+		ref, number = args[0], args[1]
+	}
+
+	n, err := strconv.Atoi(number)
+	if err != nil || n < 1 {
+		logger.Log.Error("validation failed", "error", "invalid checklist item", "item", number)
+		return nil, nil, 0, fmt.Errorf("invalid checklist item: %s (use its number, e.g. 1)", number)
+	}
+
+	store, err := ticketStore(cmd)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	t, err := resolveTicketOrCurrent(cmd.Context(), store, ref)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	if n > len(t.Checklist) {
+		logger.Log.Error("validation failed", "error", "no such checklist item", "ticket_id", t.ID, "item", n)
+		return nil, nil, 0, fmt.Errorf("ticket %d has no checklist item %d (it has %d)", t.ID, n, len(t.Checklist))
+	}
+	return store, t, n, nil
+}
+
+// printChecklist prints numbered checklist items with a box showing whether each is done
+func printChecklist(items []ticket.CheckItem) {
+	for i, c := range items {
+		box := "[ ]"
+		if c.Done() {
+			box = "[x]"
+		}
+		fmt.Printf("  %d. %s %s\n", i+1, box, c.Text)
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(checkCmd)
+	checkCmd.AddCommand(checkAddCmd)
+	checkCmd.AddCommand(checkDoneCmd)
+	checkCmd.AddCommand(checkRmCmd)
+	checkCmd.AddCommand(checkListCmd)
+
+	checkDoneCmd.Flags().BoolVar(&checkUndo, "undo", false, "Mark the item as not done again")
+}
@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"alexandria/internal/gitlink"
+	"alexandria/internal/logger"
+	"alexandria/internal/ticket"
+	"encoding/json"
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+var currentOutput string
+
+var checkoutCmd = &cobra.Command{
+	Use:   "checkout <ref>",
+	Short: "Switch to a git branch for a ticket and start work on it",
+	Long: `Create or switch to a git branch named after a ticket, such as alx-42-fix-login-bug,
+and move the ticket to in-progress. An existing branch for the ticket is reused even if
+the ticket has been renamed since.
+
+While the branch is checked out, commands such as view, log, start and comment add
+use its ticket when no ticket is given.
+
+Examples:
+  alexandria checkout ALX-42`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		store, err := ticketStore(cmd)
+		if err != nil {
+			return err
+		}
+		ctx := cmd.Context()
+
+		t, err := resolveTicketRef(ctx, store, args[0])
+		if err != nil {
+			return err
+		}
+
+		branch, err := gitlink.FindBranch(t.ID)
+		if err != nil {
+			return fmt.Errorf("failed to list branches: %w", err)
+		}
+
+		if branch != "" {
+			if err := gitlink.Checkout(branch, false); err != nil {
+				return fmt.Errorf("failed to switch branch: %w", err)
+			}
+			fmt.Printf("Switched to branch '%s'\n", branch)
+		} else {
+			branch = gitlink.BranchName(t.ID, t.Title)
+			if err := gitlink.Checkout(branch, true); err != nil {
+				return fmt.Errorf("failed to create branch: %w", err)
+			}
+			fmt.Printf("Switched to a new branch '%s'\n", branch)
+		}
+
+		if t.Status != ticket.StatusInProgress {
+			t.Status = ticket.StatusInProgress
+			if err := store.Update(ctx, t.Project, t); err != nil {
+				logger.Log.Error("failed to update ticket", "error", err, "id", t.ID)
+				return fmt.Errorf("failed to update ticket: %w", err)
+			}
+			fmt.Printf("Ticket %d is now %s\n", t.ID, t.Status)
+		}
+
+		logger.Log.Info("checked out ticket", "id", t.ID, "branch", branch)
+		return nil
+	},
+}
+
+var currentCmd = &cobra.Command{
+	Use:   "current",
+	Short: "Show the ticket for the current git branch",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		store, err := ticketStore(cmd)
+		if err != nil {
+			return err
+		}
+		ctx := cmd.Context()
+
+		t, err := resolveCurrentTicket(ctx, store)
+		if err != nil {
+			return err
+		}
+
+		switch currentOutput {
+		case "json":
+			jsonData, err := json.MarshalIndent(t, "", "  ")
+			if err != nil {
+				logger.Log.Error("failed to marshal ticket", "error", err)
+				return fmt.Errorf("failed to marshal ticket: %w", err)
+			}
+			fmt.Println(string(jsonData))
+		case "summary":
+			fmt.Printf("%s-%d: %s [%s] (%s)\n", gitlink.RefPrefix, t.ID, t.Title, t.Status, t.Project)
+		default:
+			logger.Log.Error("invalid output format", "format", currentOutput)
+			return fmt.Errorf("invalid output format: %s (must be: json or summary)", currentOutput)
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(checkoutCmd)
+	rootCmd.AddCommand(currentCmd)
+
+	currentCmd.Flags().StringVarP(&currentOutput, "output", "o", "summary", "Output format (json, summary)")
+}
@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"alexandria/internal/logger"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var commentCmd = &cobra.Command{
+	Use:   "comment",
+	Short: "Manage ticket comments",
+}
+
+var commentAddCmd = &cobra.Command{
+	Use:   "add [ref] <text>",
+	Short: "Add a comment to a ticket",
+	Long: `Add a comment to a ticket. Without a ticket reference the comment is added to the
+ticket for the current git branch (see checkout).
+
+Examples:
+  alexandria comment add ALX-42 "Reproduced on staging"
+  alexandria comment add "Fixed by retrying the token refresh"`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ref, text := "", args[0]
+		if len(args) == 2 {
+			ref, text = args[0], args[1]
+		}
+
+		text = strings.TrimSpace(text)
+		if text == "" {
+			logger.Log.Error("validation failed", "error", "empty comment")
+			return fmt.Errorf("comment text is required")
+		}
+
+		store, err := ticketStore(cmd)
+		if err != nil {
+			return err
+		}
+		ctx := cmd.Context()
+
+		t, err := resolveTicketOrCurrent(ctx, store, ref)
+		if err != nil {
+			return err
+		}
+
+		if err := store.AddComment(ctx, t.ID, text); err != nil {
+			return fmt.Errorf("failed to add comment: %w", err)
+		}
+
+		fmt.Printf("Added comment to ticket %d: %s\n", t.ID, t.Title)
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(commentCmd)
+	commentCmd.AddCommand(commentAddCmd)
+}
@@ -1,8 +1,9 @@
 package cmd
 
 import (
-	"alexandria/internal/database"
+	"alexandria/internal/dates"
 	"alexandria/internal/logger"
+	"alexandria/internal/templates"
 	"alexandria/internal/ticket"
 	"encoding/json"
 	"fmt"
@@ -13,15 +14,21 @@ import (
 )
 
 var (
-	title       string
-	description string
-	ticketType  string
+	title        string
+	description  string
+	ticketType   string
 	criticalpath bool
-	priority    string
-	assignedTo  string
-	tags        string
-	createdBy   string
-	project     string
+	priority     string
+	assignedTo   string
+	tags         string
+	createdBy    string
+	project      string
+	dueDate      string
+	startDate    string
+	storyPoints  string
+	estimate     string
+	templateName string
+	parentRef    string
 )
 
 var createCmd = &cobra.Command{
@@ -37,68 +44,168 @@ var createCmd = &cobra.Command{
 			return fmt.Errorf("title is required")
 		}
 
+		// Project, assignee and tags not given on the command line come from the directory config
+		defaults, err := resolveDefaults()
+		if err != nil {
+			return err
+		}
+
+		store, err := ticketStore(cmd)
+		if err != nil {
+			return err
+		}
+		ctx := cmd.Context()
+
+		// A child ticket goes in its parent's project unless told otherwise, ahead of the
+		// directory default, and the template is looked up in that project too
+		var parentID *int64
+		if parentRef != "" {
+			ref, err := ticket.ParseRef(parentRef)
+			if err != nil {
+				logger.Log.Error("validation failed", "error", err, "parent", parentRef)
+				return err
+			}
+			parent, err := ticket.CheckParent(ctx, store, 0, ref)
+			if err != nil {
+				logger.Log.Error("validation failed", "error", err, "parent", parentRef)
+				return err
+			}
+			parentID = &parent.ID
+			if project == "" {
+				project = parent.Project
+			}
+			logger.Log.Debug("set parent", "parent", parent.ID)
+		}
+		if project == "" {
+			project = defaults.Project
+		}
+
+		// Validate project is provided
+		if project == "" {
+			logger.Log.Error("validation failed", "error", "project is required")
+			return errNoProject
+		}
+
+		// A template fills in the type, priority, tags and description not given on the command line
+		typeValue, priorityValue, tagsValue, descValue := ticketType, priority, tags, description
+		var tmplTags []string
+		if templateName != "" {
+			tmpl, err := loadTemplate(cmd, project, templateName)
+			if err != nil {
+				return err
+			}
+			if !cmd.Flags().Changed("type") && tmpl.Type != "" {
+				typeValue = tmpl.Type
+			}
+			if !cmd.Flags().Changed("priority") && tmpl.Priority != "" {
+				priorityValue = tmpl.Priority
+			}
+			if tagsValue == "" {
+				tmplTags = tmpl.Tags
+			}
+			if descValue == "" {
+				descValue = templates.Expand(tmpl.Description, templateVars(project, createdBy))
+			}
+		}
+
 		// Parse type
-		tType := ticket.Type(ticketType)
+		tType := ticket.Type(typeValue)
 		if !tType.Valid() {
-			logger.Log.Error("validation failed", "error", "invalid type", "type", ticketType)
-			return fmt.Errorf("invalid type: %s (must be: bug, feature, or task)", ticketType)
+			logger.Log.Error("validation failed", "error", "invalid type", "type", typeValue)
+			return fmt.Errorf("invalid type: %s (must be: bug, feature, or task)", typeValue)
 		}
 
 		// Parse priority
-		tPriority := ticket.Priority(priority)
+		tPriority := ticket.Priority(priorityValue)
 		if !tPriority.Valid() {
-			logger.Log.Error("validation failed", "error", "invalid priority", "priority", priority)
-			return fmt.Errorf("invalid priority: %s (must be: low, medium, or high)", priority)
+			logger.Log.Error("validation failed", "error", "invalid priority", "priority", priorityValue)
+			return fmt.Errorf("invalid priority: %s (must be: low, medium, or high)", priorityValue)
 		}
 
 		// Parse tags
 		var tagList []string
-		if tags != "" {
-			tagList = strings.Split(tags, ",")
+		if tagsValue != "" {
+			tagList = strings.Split(tagsValue, ",")
 			for i, tag := range tagList {
 				tagList[i] = strings.TrimSpace(tag)
 			}
 			logger.Log.Debug("parsed tags", "count", len(tagList), "tags", tagList)
+		} else if len(tmplTags) > 0 {
+			tagList = append([]string{}, tmplTags...)
+			logger.Log.Debug("using template tags", "tags", tagList)
+		} else if len(defaults.Tags) > 0 {
+			tagList = append([]string{}, defaults.Tags...)
+			logger.Log.Debug("using default tags", "tags", tagList)
 		}
 
 		// Create ticket (ID will be auto-generated by database)
 		newTicket := ticket.Ticket{
 			Type:         tType,
 			Title:        title,
-			Description:  description,
+			Description:  descValue,
 			CriticalPath: criticalpath,
 			Status:       ticket.StatusOpen, // Default to open
 			Priority:     tPriority,
 			Tags:         tagList,
 			CreatedAt:    time.Now(),
 			UpdatedAt:    time.Now(),
+			ParentID:     parentID,
 		}
 
 		// Handle optional fields
 		if assignedTo != "" {
 			newTicket.AssignedTo = &assignedTo
 			logger.Log.Debug("assigned ticket", "assignee", assignedTo)
+		} else if defaults.Assignee != "" {
+			assignee := defaults.Assignee
+			newTicket.AssignedTo = &assignee
+			logger.Log.Debug("assigned ticket to default assignee", "assignee", assignee)
 		}
 		if createdBy != "" {
 			newTicket.CreatedBy = &createdBy
 			logger.Log.Debug("set creator", "creator", createdBy)
 		}
-
-		// Validate project is provided
-		if project == "" {
-			logger.Log.Error("validation failed", "error", "project is required")
-			return fmt.Errorf("project is required")
+		if dueDate != "" {
+			due, err := dates.Parse(dueDate, time.Now())
+			if err != nil {
+				logger.Log.Error("validation failed", "error", err, "due", dueDate)
+				return fmt.Errorf("invalid due date: %w", err)
+			}
+			newTicket.DueAt = &due
+			logger.Log.Debug("set due date", "due", due)
+		}
+		if startDate != "" {
+			start, err := dates.Parse(startDate, time.Now())
+			if err != nil {
+				logger.Log.Error("validation failed", "error", err, "start", startDate)
+				return fmt.Errorf("invalid start date: %w", err)
+			}
+			newTicket.StartAt = &start
+			logger.Log.Debug("set start date", "start", start)
+		}
+		if storyPoints != "" {
+			p, err := parseOptionalPoints(storyPoints)
+			if err != nil {
+				logger.Log.Error("validation failed", "error", err, "points", storyPoints)
+				return err
+			}
+			newTicket.StoryPoints = p
+			logger.Log.Debug("set story points", "points", storyPoints)
+		}
+		if estimate != "" {
+			e, err := parseOptionalHours(estimate)
+			if err != nil {
+				logger.Log.Error("validation failed", "error", err, "estimate", estimate)
+				return err
+			}
+			newTicket.EstimateHours = e
+			logger.Log.Debug("set estimate", "estimate", estimate)
 		}
 
 		// Save ticket to database
-		db := database.GetDB()
-		if db == nil {
-			logger.Log.Error("database not initialized")
-			return fmt.Errorf("database not initialized")
-		}
 
 		logger.Log.Debug("saving ticket to database", "project", project)
-		if err := newTicket.Create(db, project); err != nil {
+		if err := store.Create(ctx, project, &newTicket); err != nil {
 			logger.Log.Error("failed to save ticket", "error", err, "project", project)
 			return fmt.Errorf("failed to save ticket: %w", err)
 		}
@@ -130,12 +237,35 @@ func init() {
 	createCmd.Flags().StringVarP(&assignedTo, "assigned-to", "a", "", "Assign ticket to user")
 	createCmd.Flags().StringVar(&createdBy, "created-by", "", "Ticket creator")
 	createCmd.Flags().StringVar(&tags, "tags", "", "Comma-separated list of tags")
-	createCmd.Flags().StringVar(&project, "project", "", "Project name (required)")
+	createCmd.Flags().StringVar(&project, "project", "", projectFlagUsage)
+	createCmd.Flags().StringVar(&dueDate, "due", "", "Due date (e.g. 2026-11-01, friday, tomorrow, 3d)")
+	createCmd.Flags().StringVar(&startDate, "start", "", "Start date (e.g. 2026-11-01, monday, today)")
+	createCmd.Flags().StringVar(&storyPoints, "points", "", "Story point estimate")
+	createCmd.Flags().StringVar(&estimate, "estimate", "", "Hours estimate, e.g. 6 or 4h30m")
+	createCmd.Flags().StringVar(&templateName, "template", "", "Start from a project template (see 'alexandria template')")
+	createCmd.Flags().StringVar(&parentRef, "parent", "", "Make the ticket a child of another, e.g. ALX-10 (defaults the project to the parent's)")
 	if err := createCmd.MarkFlagRequired("title"); err != nil {
 		panic(err)
 	}
-	if err := createCmd.MarkFlagRequired("project"); err != nil {
-		panic(err)
+}
+
+// loadTemplate reads a project's template from the database
+func loadTemplate(cmd *cobra.Command, project, name string) (*templates.Template, error) {
+	if project == "" {
+		logger.Log.Error("validation failed", "error", "project is required to use a template")
+		return nil, errNoProject
 	}
 
+	// Templates live in the database alongside the tickets
+	db, err := sqlDB(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	tmpl, err := templates.Get(db, project, name)
+	if err != nil {
+		return nil, err
+	}
+	logger.Log.Debug("applying template", "project", project, "name", name)
+	return tmpl, nil
 }
@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"alexandria/internal/config"
+	"alexandria/internal/logger"
+	"alexandria/internal/ticket"
+	"context"
+	"fmt"
+)
+
+// errNoProject is returned when a command needs a project and none was given or configured
+var errNoProject = fmt.Errorf("project is required (pass --project or set project in %s)", config.DirConfigNames[0])
+
+// projectFlagUsage describes a --project flag that falls back to the directory config
+const projectFlagUsage = "Project name (defaults to the project in .alexandria.toml)"
+
+// resolveDefaults loads the settings in effect for the current directory
+func resolveDefaults() (*config.Config, error) {
+	cfg, err := config.Resolve()
+	if err != nil {
+		logger.Log.Error("failed to load config", "error", err)
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+	return cfg, nil
+}
+
+// applyDefaultProject fills an empty --project value from the directory config
+func applyDefaultProject(project *string) error {
+	if *project != "" {
+		return nil
+	}
+
+	cfg, err := resolveDefaults()
+	if err != nil {
+		return err
+	}
+	if cfg.Project != "" {
+		logger.Log.Debug("using default project", "project", cfg.Project)
+		*project = cfg.Project
+	}
+	return nil
+}
+
+// findTicket loads a ticket by ID or title. Without a project an ID is looked up
+// across every project, since IDs are unique, but a title cannot be.
+func findTicket(ctx context.Context, store ticket.TicketStore, project string, id int64, title string) (*ticket.Ticket, error) {
+	if project == "" {
+		if id == 0 {
+			logger.Log.Error("validation failed", "error", "project is required to find a ticket by title")
+			return nil, errNoProject
+		}
+		return store.Get(ctx, id)
+	}
+	return store.Find(ctx, project, id, title)
+}
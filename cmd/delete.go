@@ -1,9 +1,7 @@
 package cmd
 
 import (
-	"alexandria/internal/database"
 	"alexandria/internal/logger"
-	"alexandria/internal/ticket"
 	"fmt"
 	"strconv"
 
@@ -19,7 +17,9 @@ var (
 var deleteCmd = &cobra.Command{
 	Use:   "delete",
 	Short: "Delete a ticket from the database",
-	Long:  `Delete a ticket and all its related data (tags, files, comments) by ID or title.`,
+	Long: `Delete a ticket by ID or title. The ticket moves to the trash with all its related
+data (tags, files, comments), where "alexandria restore" can bring it back until
+"alexandria trash purge" removes it for good.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		logger.Log.Debug("deleting ticket", "id", deleteID, "title", deleteTitle, "project", deleteProject)
 
@@ -29,10 +29,9 @@ var deleteCmd = &cobra.Command{
 			return fmt.Errorf("either --id or --title must be provided")
 		}
 
-		// Validate that project is provided
-		if deleteProject == "" {
-			logger.Log.Error("validation failed", "error", "project is required")
-			return fmt.Errorf("project is required")
+		// Fall back to the project in the directory config
+		if err := applyDefaultProject(&deleteProject); err != nil {
+			return err
 		}
 
 		// Parse ID if provided
@@ -48,18 +47,25 @@ var deleteCmd = &cobra.Command{
 		}
 
 		// Get database connection
-		db := database.GetDB()
-		if db == nil {
-			logger.Log.Error("database not initialized")
-			return fmt.Errorf("database not initialized")
+		store, err := ticketStore(cmd)
+		if err != nil {
+			return err
 		}
+		ctx := cmd.Context()
 
-		// Create a ticket instance for deletion
-		t := &ticket.Ticket{}
+		// Resolve the ticket so it can be deleted by ID
+		t, err := findTicket(ctx, store, deleteProject, ticketID, deleteTitle)
+		if err == errNoProject {
+			return err
+		}
+		if err != nil {
+			logger.Log.Error("failed to find ticket", "error", err, "project", deleteProject)
+			return fmt.Errorf("failed to delete ticket: %w", err)
+		}
 
-		// Call the Delete method
-		logger.Log.Debug("calling delete method", "project", deleteProject, "id", ticketID, "title", deleteTitle)
-		if err := t.Delete(db, deleteProject, ticketID, deleteTitle); err != nil {
+		deleteProject = t.Project
+		logger.Log.Debug("deleting ticket", "project", deleteProject, "id", t.ID, "title", t.Title)
+		if err := store.Delete(ctx, deleteProject, t.ID); err != nil {
 			logger.Log.Error("failed to delete ticket", "error", err, "project", deleteProject)
 			return fmt.Errorf("failed to delete ticket: %w", err)
 		}
@@ -72,6 +78,7 @@ var deleteCmd = &cobra.Command{
 			logger.Log.Info("ticket deleted successfully", "title", deleteTitle, "project", deleteProject)
 			fmt.Printf("Successfully deleted ticket with title: %s from project: %s\n", deleteTitle, deleteProject)
 		}
+		fmt.Printf("It is in the trash; run 'alexandria restore %d' to bring it back.\n", t.ID)
 
 		return nil
 	},
@@ -82,9 +89,5 @@ func init() {
 
 	deleteCmd.Flags().StringVarP(&deleteID, "id", "i", "", "Ticket ID to delete")
 	deleteCmd.Flags().StringVarP(&deleteTitle, "title", "t", "", "Ticket title to delete")
-	deleteCmd.Flags().StringVarP(&deleteProject, "project", "p", "", "Project name (required)")
-	if err := createCmd.MarkFlagRequired("project"); err != nil {
-		panic(err)
-	}
-
+	deleteCmd.Flags().StringVarP(&deleteProject, "project", "p", "", projectFlagUsage)
 }
@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"alexandria/internal/dates"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// parseOptionalDate parses a date flag, returning nil when the value is "none"
+func parseOptionalDate(value string) (*time.Time, error) {
+	if strings.EqualFold(value, "none") {
+		return nil, nil
+	}
+	t, err := dates.Parse(value, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// parseOptionalPoints parses a story point flag, returning nil when the value is "none"
+func parseOptionalPoints(value string) (*float64, error) {
+	if strings.EqualFold(value, "none") {
+		return nil, nil
+	}
+	p, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil || p < 0 {
+		return nil, fmt.Errorf("invalid story points: %s (must be a non-negative number)", value)
+	}
+	return &p, nil
+}
+
+// parseOptionalHours parses an hours estimate given as a number (6, 1.5) or a
+// duration (4h30m), returning nil when the value is "none"
+func parseOptionalHours(value string) (*float64, error) {
+	if strings.EqualFold(value, "none") {
+		return nil, nil
+	}
+	s := strings.TrimSpace(value)
+	if h, err := strconv.ParseFloat(s, 64); err == nil && h >= 0 {
+		return &h, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return nil, fmt.Errorf("invalid estimate: %s (use hours like 6 or a duration like 4h30m)", value)
+	}
+	h := d.Hours()
+	return &h, nil
+}
+
+// parseSince parses a lookback flag given either as a duration before now (30d, 2w)
+// or as a date (2026-10-01, monday)
+func parseSince(value string, now time.Time) (time.Time, error) {
+	if d, err := dates.ParseDuration(value); err == nil && d > 0 {
+		return dates.StartOfDay(now.Add(-d)), nil
+	}
+	t, err := dates.Parse(value, now)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid --since value: %s (use a duration like 30d or a date)", value)
+	}
+	return t, nil
+}
@@ -0,0 +1,203 @@
+package cmd
+
+import (
+	"alexandria/internal/gitlink"
+	"alexandria/internal/logger"
+	"alexandria/internal/ticket"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	gitHooksForce bool
+	gitScanSince  string
+)
+
+var gitCmd = &cobra.Command{
+	Use:   "git",
+	Short: "Link git commits to tickets",
+	Long: `Link git commits to the tickets they reference.
+
+Commit messages reference tickets as ` + gitlink.RefPrefix + `-42. A reference preceded by
+"fixes", "closes" or "resolves" (e.g. "fixes ` + gitlink.RefPrefix + `-42") also closes the ticket.`,
+}
+
+var gitInstallHooksCmd = &cobra.Command{
+	Use:   "install-hooks",
+	Short: "Install commit-msg and post-commit hooks in the current repository",
+	Long: `Install git hooks in the current repository. The commit-msg hook rejects commits
+that reference tickets which do not exist, and the post-commit hook records each
+commit against the tickets it references.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		binary, err := os.Executable()
+		if err != nil {
+			logger.Log.Error("failed to locate executable", "error", err)
+			return fmt.Errorf("failed to locate executable: %w", err)
+		}
+
+		paths, err := gitlink.InstallHooks(binary, gitHooksForce)
+		if err != nil {
+			return fmt.Errorf("failed to install hooks: %w", err)
+		}
+
+		for _, path := range paths {
+			fmt.Printf("Installed %s\n", path)
+		}
+		return nil
+	},
+}
+
+var gitScanCmd = &cobra.Command{
+	Use:   "scan",
+	Short: "Link existing commits to tickets from git log",
+	Long: `Scan git log and link commits to the tickets they reference. Commits already
+linked are skipped, so scanning the same history again is safe.
+
+Examples:
+  alexandria git scan
+  alexandria git scan --since v1.2.0`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		store, err := ticketStore(cmd)
+		if err != nil {
+			return err
+		}
+		ctx := cmd.Context()
+
+		revisions := []string{"--reverse"}
+		if gitScanSince != "" {
+			revisions = append(revisions, gitScanSince+"..HEAD")
+		}
+
+		commits, err := gitlink.Log(revisions...)
+		if err != nil {
+			return fmt.Errorf("failed to read git log: %w", err)
+		}
+
+		var linked, closed int
+		for _, c := range commits {
+			l, cl, err := linkCommit(ctx, store, c)
+			if err != nil {
+				return err
+			}
+			linked += l
+			closed += cl
+		}
+
+		fmt.Printf("Scanned %d commit(s): %d new link(s), %d ticket(s) closed\n", len(commits), linked, closed)
+		return nil
+	},
+}
+
+var gitCommitMsgCmd = &cobra.Command{
+	Use:          "commit-msg <file>",
+	Short:        "Validate ticket references in a commit message (used by the commit-msg hook)",
+	Hidden:       true,
+	SilenceUsage: true,
+	Args:         cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		data, err := os.ReadFile(args[0])
+		if err != nil {
+			logger.Log.Error("failed to read commit message", "error", err, "file", args[0])
+			return fmt.Errorf("failed to read commit message: %w", err)
+		}
+
+		store, err := ticketStore(cmd)
+		if err != nil {
+			return err
+		}
+		ctx := cmd.Context()
+
+		var missing []string
+		for _, ref := range gitlink.ParseRefs(gitlink.StripComments(string(data))) {
+			if _, err := store.Get(ctx, ref.ID); err != nil {
+				missing = append(missing, fmt.Sprintf("%s-%d", gitlink.RefPrefix, ref.ID))
+			}
+		}
+
+		if len(missing) > 0 {
+			logger.Log.Error("commit references unknown tickets", "refs", missing)
+			return fmt.Errorf("commit message references tickets that do not exist: %s", strings.Join(missing, ", "))
+		}
+		return nil
+	},
+}
+
+var gitPostCommitCmd = &cobra.Command{
+	Use:          "post-commit",
+	Short:        "Link the latest commit to its tickets (used by the post-commit hook)",
+	Hidden:       true,
+	SilenceUsage: true,
+	Args:         cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		store, err := ticketStore(cmd)
+		if err != nil {
+			return err
+		}
+		ctx := cmd.Context()
+
+		head, err := gitlink.Head()
+		if err != nil {
+			return fmt.Errorf("failed to read commit: %w", err)
+		}
+
+		_, _, err = linkCommit(ctx, store, *head)
+		return err
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(gitCmd)
+	gitCmd.AddCommand(gitInstallHooksCmd)
+	gitCmd.AddCommand(gitScanCmd)
+	gitCmd.AddCommand(gitCommitMsgCmd)
+	gitCmd.AddCommand(gitPostCommitCmd)
+
+	gitInstallHooksCmd.Flags().BoolVar(&gitHooksForce, "force", false, "Replace existing hooks not installed by alexandria")
+	gitScanCmd.Flags().StringVar(&gitScanSince, "since", "", "Only scan commits after this revision (tag, branch or SHA)")
+}
+
+// linkCommit records a commit against every ticket it references and closes those it
+// fixes. Tickets are only closed when the link is new, so a ticket reopened after its
+// fixing commit stays open on a rescan. References to unknown tickets are skipped.
+func linkCommit(ctx context.Context, store ticket.TicketStore, c gitlink.Commit) (int, int, error) {
+	var linked, closed int
+	for _, ref := range gitlink.ParseRefs(c.Message) {
+		t, err := store.Get(ctx, ref.ID)
+		if err != nil {
+			logger.Log.Warn("skipping reference to unknown ticket", "id", ref.ID, "sha", c.SHA)
+			continue
+		}
+
+		isNew, err := store.LinkCommit(ctx, t.ID, ticket.Commit{
+			SHA:         c.SHA,
+			Summary:     c.Summary(),
+			Author:      c.Author,
+			CommittedAt: c.CommittedAt,
+		})
+		if err != nil {
+			return linked, closed, err
+		}
+		if !isNew {
+			continue
+		}
+		linked++
+
+		if ref.Fixes && t.Status != ticket.StatusClosed {
+			t.Status = ticket.StatusClosed
+			if err := store.Update(ctx, t.Project, t); err != nil {
+				logger.Log.Error("failed to close ticket", "error", err, "id", t.ID)
+				return linked, closed, fmt.Errorf("failed to close ticket: %w", err)
+			}
+			closed++
+			fmt.Printf("Closed %s-%d: %s\n", gitlink.RefPrefix, t.ID, t.Title)
+			warnOpenChildren(ctx, store, t)
+		}
+	}
+	return linked, closed, nil
+}
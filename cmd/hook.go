@@ -0,0 +1,261 @@
+package cmd
+
+import (
+	"alexandria/internal/config"
+	"alexandria/internal/logger"
+	"alexandria/internal/webhooks"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// deliveriesLimit is how many deliveries "hook deliveries" shows by default
+const deliveriesLimit = 20
+
+var (
+	hookProject      string
+	hookURL          string
+	hookEvents       string
+	hookSecret       string
+	deliveriesStatus string
+	deliveriesMax    int
+)
+
+var hookCmd = &cobra.Command{
+	Use:   "hook",
+	Short: "Manage webhooks that are called when tickets change",
+	Long: `Webhooks post a JSON description of a changed ticket to a URL whenever a ticket in
+their project is created, updated, changes status, is commented on, deleted or
+restored. Events: ` + strings.Join(webhooks.Events, ", ") + `.
+
+Each request carries an X-Alexandria-Signature header holding "sha256=" and the
+hex HMAC-SHA256 of the body keyed with the hook's secret, so receivers can check
+it came from Alexandria. Deliveries are queued in the database and sent when the
+command that queued them finishes. Failed deliveries are retried with exponential
+backoff by later commands or "alexandria hook deliver", up to ` + strconv.Itoa(webhooks.MaxAttempts) + ` attempts.
+
+Examples:
+  alexandria hook add --project Backend --url https://ci.example.com/hooks/alexandria --events created,status_changed
+  alexandria hook list --project Backend
+  alexandria hook deliveries --status failed`,
+}
+
+var hookAddCmd = &cobra.Command{
+	Use:   "add",
+	Short: "Subscribe a URL to a project's ticket events",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := applyDefaultProject(&hookProject); err != nil {
+			return err
+		}
+		if hookProject == "" {
+			logger.Log.Error("validation failed", "error", "project is required")
+			return errNoProject
+		}
+
+		events, err := webhooks.ParseEvents(hookEvents)
+		if err != nil {
+			logger.Log.Error("validation failed", "error", err, "events", hookEvents)
+			return err
+		}
+
+		secret := hookSecret
+		if secret == "" {
+			if secret, err = webhooks.NewSecret(); err != nil {
+				return err
+			}
+		}
+
+		db, err := sqlDB(cmd)
+		if err != nil {
+			return err
+		}
+
+		h := &webhooks.Hook{
+			Project:   hookProject,
+			URL:       hookURL,
+			Events:    events,
+			Secret:    secret,
+			CreatedBy: config.CurrentUser(),
+		}
+		if err := webhooks.Add(db, h); err != nil {
+			return err
+		}
+
+		fmt.Printf("Added webhook %d for project '%s': %s\n", h.ID, h.Project, h.URL)
+		fmt.Printf("Events: %s\n", strings.Join(h.Events, ", "))
+		if hookSecret == "" {
+			fmt.Printf("Secret: %s (shown only now; use it to verify X-Alexandria-Signature)\n", secret)
+		}
+		return nil
+	},
+}
+
+var hookListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List webhooks, optionally only a project's",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := applyDefaultProject(&hookProject); err != nil {
+			return err
+		}
+
+		db, err := sqlDB(cmd)
+		if err != nil {
+			return err
+		}
+
+		hooks, err := webhooks.List(db, hookProject)
+		if err != nil {
+			return err
+		}
+		if len(hooks) == 0 {
+			fmt.Println("No webhooks found.")
+			return nil
+		}
+
+		fmt.Printf("%-4s %-18s %-45s %s\n", "ID", "PROJECT", "URL", "EVENTS")
+		fmt.Println(strings.Repeat("-", 100))
+		for _, h := range hooks {
+			fmt.Printf("%-4d %-18s %-45s %s\n", h.ID, h.Project, h.URL, strings.Join(h.Events, ","))
+		}
+		return nil
+	},
+}
+
+var hookRmCmd = &cobra.Command{
+	Use:   "rm <id>",
+	Short: "Remove a webhook and its deliveries",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		id, err := strconv.ParseInt(args[0], 10, 64)
+		if err != nil {
+			logger.Log.Error("failed to parse webhook ID", "error", err, "id", args[0])
+			return fmt.Errorf("invalid webhook ID: %s (must be a number)", args[0])
+		}
+
+		db, err := sqlDB(cmd)
+		if err != nil {
+			return err
+		}
+		if err := webhooks.Remove(db, id); err != nil {
+			return err
+		}
+
+		fmt.Printf("Removed webhook %d\n", id)
+		return nil
+	},
+}
+
+var hookDeliveriesCmd = &cobra.Command{
+	Use:   "deliveries",
+	Short: "Show recent webhook deliveries and their status",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := applyDefaultProject(&hookProject); err != nil {
+			return err
+		}
+		switch deliveriesStatus {
+		case "", webhooks.StatusPending, webhooks.StatusDelivered, webhooks.StatusFailed:
+		default:
+			logger.Log.Error("validation failed", "error", "invalid status", "status", deliveriesStatus)
+			return fmt.Errorf("invalid status: %s (must be: %s, %s, or %s)", deliveriesStatus,
+				webhooks.StatusPending, webhooks.StatusDelivered, webhooks.StatusFailed)
+		}
+
+		db, err := sqlDB(cmd)
+		if err != nil {
+			return err
+		}
+
+		deliveries, err := webhooks.ListDeliveries(db, hookProject, deliveriesStatus, deliveriesMax)
+		if err != nil {
+			return err
+		}
+		if len(deliveries) == 0 {
+			fmt.Println("No deliveries found.")
+			return nil
+		}
+
+		fmt.Printf("%-6s %-5s %-15s %-7s %-10s %-8s %-17s %s\n", "ID", "HOOK", "EVENT", "TICKET", "STATUS", "ATTEMPTS", "LAST", "DETAIL")
+		fmt.Println(strings.Repeat("-", 100))
+		for _, d := range deliveries {
+			last, detail := d.CreatedAt, d.LastError
+			switch {
+			case d.DeliveredAt != nil:
+				last, detail = *d.DeliveredAt, fmt.Sprintf("HTTP %d", d.ResponseCode)
+			case d.Status == webhooks.StatusPending && d.Attempts > 0:
+				detail = fmt.Sprintf("retry at %s: %s", d.NextAttemptAt.Local().Format("15:04:05"), d.LastError)
+			}
+			fmt.Printf("%-6d %-5d %-15s %-7d %-10s %-8d %-17s %s\n",
+				d.ID, d.WebhookID, d.Event, d.TicketID, d.Status, d.Attempts, last.Local().Format("2006-01-02 15:04"), detail)
+		}
+		return nil
+	},
+}
+
+var hookDeliverCmd = &cobra.Command{
+	Use:   "deliver",
+	Short: "Send queued deliveries that are due, including retries",
+	Long: `Send every queued delivery whose next attempt is due. Deliveries are normally sent
+when the command that queued them finishes; run this, for example from cron, to
+retry failed ones without waiting for the next ticket change.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		db, err := sqlDB(cmd)
+		if err != nil {
+			return err
+		}
+
+		res, err := webhooks.Deliver(cmd.Context(), db, http.DefaultClient, time.Now())
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Delivered %d, will retry %d, gave up on %d.\n", res.Delivered, res.Retrying, res.Failed)
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(hookCmd)
+	hookCmd.AddCommand(hookAddCmd)
+	hookCmd.AddCommand(hookListCmd)
+	hookCmd.AddCommand(hookRmCmd)
+	hookCmd.AddCommand(hookDeliveriesCmd)
+	hookCmd.AddCommand(hookDeliverCmd)
+
+	hookCmd.PersistentFlags().StringVar(&hookProject, "project", "", projectFlagUsage)
+	hookAddCmd.Flags().StringVar(&hookURL, "url", "", "URL to post events to (required)")
+	hookAddCmd.Flags().StringVar(&hookEvents, "events", "", "Comma-separated events to send (default: all)")
+	hookAddCmd.Flags().StringVar(&hookSecret, "secret", "", "Secret for signing payloads (default: generated)")
+	hookAddCmd.MarkFlagRequired("url")
+	hookDeliveriesCmd.Flags().StringVar(&deliveriesStatus, "status", "", "Only show deliveries with this status (pending, delivered, failed)")
+	hookDeliveriesCmd.Flags().IntVar(&deliveriesMax, "limit", deliveriesLimit, "Number of deliveries to show")
+}
+
+// deliverQueued sends the deliveries queued while the command ran. Problems are
+// reported as warnings since the ticket changes themselves have been saved.
+func deliverQueued(cmd *cobra.Command) {
+	d := depsFrom(cmd)
+	if d == nil || d.db == nil {
+		return
+	}
+	store, ok := d.tickets.(*webhooks.Store)
+	if !ok || store.Queued() == 0 {
+		return
+	}
+
+	res, err := webhooks.Deliver(cmd.Context(), d.db, http.DefaultClient, time.Now())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to send webhooks: %v\n", err)
+		return
+	}
+	if res.Retrying > 0 || res.Failed > 0 {
+		fmt.Fprintf(os.Stderr, "Warning: %d webhook delivery(s) failed; see 'alexandria hook deliveries'\n", res.Retrying+res.Failed)
+	}
+}
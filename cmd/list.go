@@ -1,12 +1,16 @@
 package cmd
 
 import (
-	"alexandria/internal/database"
+	"alexandria/internal/dates"
 	"alexandria/internal/logger"
 	"alexandria/internal/ticket"
+	"alexandria/internal/views"
+	"database/sql"
 	"encoding/json"
 	"fmt"
 	"strings"
+	"time"
+	"unicode/utf8"
 
 	"github.com/spf13/cobra"
 )
@@ -18,7 +22,14 @@ var (
 	filterAssignedTo string
 	filterTags       string
 	filterProject    string
+	filterOverdue    bool
+	filterDueWithin  string
 	outputFormat     string
+	listSort         string
+	listView         string
+	listWhere        string
+	listArchived     bool
+	listTree         bool
 )
 
 var listCmd = &cobra.Command{
@@ -26,17 +37,48 @@ var listCmd = &cobra.Command{
 	Short: "List tickets from the database",
 	Long:  `List all tickets from the database with optional filtering by status, type, priority, assigned user, or tags.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		logger.Log.Debug("listing tickets", "project", filterProject, "status", filterStatus, "type", filterType, "output", outputFormat)
-
 		// Get database connection
-		db := database.GetDB()
-		if db == nil {
-			logger.Log.Error("database not initialized")
-			return fmt.Errorf("database not initialized")
+		store, err := ticketStore(cmd)
+		if err != nil {
+			return err
 		}
+		ctx := cmd.Context()
 
-		// Build filters
-		filters := ticket.Filters{}
+		if listView != "" {
+			// Saved views live in the database alongside the tickets
+			db, err := sqlDB(cmd)
+			if err != nil {
+				return err
+			}
+			if err := applyView(cmd, db, listView); err != nil {
+				return err
+			}
+		}
+
+		logger.Log.Debug("listing tickets", "project", filterProject, "status", filterStatus, "type", filterType, "output", outputFormat, "sort", listSort)
+
+		if err := ticket.ValidateSort(listSort); err != nil {
+			logger.Log.Error("validation failed", "error", err, "sort", listSort)
+			return err
+		}
+		if listTree && outputFormat != "table" {
+			logger.Log.Error("validation failed", "error", "--tree needs table output", "format", outputFormat)
+			return fmt.Errorf("--tree only applies to table output")
+		}
+
+		// Build filters, starting from any --where expression; the other flags narrow it
+		filters, err := ticket.ParseWhere(listWhere)
+		if err != nil {
+			logger.Log.Error("validation failed", "error", err, "where", listWhere)
+			return err
+		}
+
+		// Inside a tree with a directory config, list that project unless told otherwise
+		if filters.Project == nil {
+			if err := applyDefaultProject(&filterProject); err != nil {
+				return err
+			}
+		}
 
 		if filterStatus != "" {
 			status := ticket.Status(filterStatus)
@@ -83,20 +125,48 @@ var listCmd = &cobra.Command{
 			for i, tag := range tagList {
 				tagList[i] = strings.TrimSpace(tag)
 			}
-			filters.Tags = tagList
+			filters.Tags = append(filters.Tags, tagList...)
 			logger.Log.Debug("applying tags filter", "count", len(tagList))
 		}
 
+		if filterOverdue {
+			filters.Overdue = true
+			logger.Log.Debug("applying overdue filter")
+		}
+
+		if filterDueWithin != "" {
+			within, err := dates.ParseDuration(filterDueWithin)
+			if err != nil || within <= 0 {
+				logger.Log.Error("validation failed", "error", "invalid due-within", "due_within", filterDueWithin)
+				return fmt.Errorf("invalid due-within: %s (use a duration like 7d, 2w or 48h)", filterDueWithin)
+			}
+			filters.DueWithin = within
+			logger.Log.Debug("applying due-within filter", "due_within", within)
+		}
+
 		// Query tickets
 		logger.Log.Debug("querying tickets with filters")
-		tickets, err := ticket.List(db, filters)
+		tickets, err := store.List(ctx, filters)
 		if err != nil {
 			logger.Log.Error("failed to list tickets", "error", err)
 			return fmt.Errorf("failed to list tickets: %w", err)
 		}
 
+		if listArchived {
+			archived, err := store.ListArchived(ctx, filters)
+			if err != nil {
+				logger.Log.Error("failed to list archived tickets", "error", err)
+				return fmt.Errorf("failed to list archived tickets: %w", err)
+			}
+			tickets = append(tickets, archived...)
+		}
+
 		logger.Log.Info("tickets retrieved", "count", len(tickets))
 
+		if err := ticket.SortTickets(tickets, listSort); err != nil {
+			return err
+		}
+
 		if len(tickets) == 0 {
 			logger.Log.Debug("no tickets found")
 			fmt.Println("No tickets found.")
@@ -115,7 +185,12 @@ var listCmd = &cobra.Command{
 			fmt.Println(string(jsonData))
 
 		case "table":
-			printTicketsTable(tickets)
+			if listTree {
+				tickets, depths := treeOrder(tickets)
+				printTicketsTable(tickets, depths)
+			} else {
+				printTicketsTable(tickets, nil)
+			}
 
 		case "summary":
 			printTicketsSummary(tickets)
@@ -132,21 +207,82 @@ var listCmd = &cobra.Command{
 func init() {
 	rootCmd.AddCommand(listCmd)
 
-	listCmd.Flags().StringVar(&filterProject, "project", "", "Filter tickets by project")
+	listCmd.Flags().StringVar(&filterProject, "project", "", "Filter tickets by project (defaults to the project in .alexandria.toml)")
 	listCmd.Flags().StringVar(&filterStatus, "status", "", "Filter by status (open, in-progress, closed)")
 	listCmd.Flags().StringVar(&filterType, "type", "", "Filter by type (bug, feature, task)")
 	listCmd.Flags().StringVar(&filterPriority, "priority", "", "Filter by priority (undefined, low, medium, high)")
 	listCmd.Flags().StringVar(&filterAssignedTo, "assigned-to", "", "Filter by assigned user")
 	listCmd.Flags().StringVar(&filterTags, "tags", "", "Filter by tags (comma-separated)")
+	listCmd.Flags().BoolVar(&filterOverdue, "overdue", false, "Only show open tickets past their due date")
+	listCmd.Flags().StringVar(&filterDueWithin, "due-within", "", "Only show open tickets due within a duration (e.g. 7d, 2w)")
 	listCmd.Flags().StringVarP(&outputFormat, "output", "o", "table", "Output format (json, table, summary)")
+	listCmd.Flags().StringVar(&listSort, "sort", "created:desc", "Sort order as field[:asc|desc] (id, created, updated, due, priority, status, title)")
+	listCmd.Flags().StringVar(&listView, "view", "", "Apply a saved view; flags given on the command line override it")
+	listCmd.Flags().StringVar(&listWhere, "where", "", "Filter expression, e.g. 'project:X tag:sprint-3 status:open' (see 'bulk --help')")
+	listCmd.Flags().BoolVar(&listArchived, "include-archived", false, "Also list archived tickets (see 'archive --help')")
+	listCmd.Flags().BoolVar(&listTree, "tree", false, "Nest child tickets under their parents")
+}
+
+// applyView parses a saved view's flags into the list command. Flags set explicitly
+// on the command line are reapplied afterwards so they take precedence.
+func applyView(cmd *cobra.Command, db *sql.DB, name string) error {
+	v, err := views.Get(db, name)
+	if err != nil {
+		return err
+	}
+	logger.Log.Debug("applying view", "name", v.Name, "scope", v.Scope, "args", v.Args)
+
+	names, err := listArgFlags(cmd, v.Args)
+	if err != nil {
+		logger.Log.Error("invalid saved view", "error", err, "name", v.Name)
+		return fmt.Errorf("invalid saved view '%s': %w", v.Name, err)
+	}
+
+	explicit := make(map[string]string)
+	for _, flagName := range names {
+		if cmd.Flags().Changed(flagName) {
+			explicit[flagName] = cmd.Flags().Lookup(flagName).Value.String()
+		}
+	}
+
+	if err := cmd.Flags().Parse(v.Args); err != nil {
+		logger.Log.Error("failed to apply view", "error", err, "name", v.Name)
+		return fmt.Errorf("failed to apply view '%s': %w", v.Name, err)
+	}
+
+	for flagName, value := range explicit {
+		if err := cmd.Flags().Set(flagName, value); err != nil {
+			return fmt.Errorf("failed to apply --%s: %w", flagName, err)
+		}
+	}
+	return nil
 }
 
-// printTicketsTable prints tickets in a table format
-func printTicketsTable(tickets []ticket.Ticket) {
+// treeOrder puts each listed ticket after its listed parent, keeping the sort
+// order among siblings, and returns how deeply each ticket is nested
+func treeOrder(tickets []ticket.Ticket) ([]ticket.Ticket, map[int64]int) {
+	ordered := make([]ticket.Ticket, 0, len(tickets))
+	depths := make(map[int64]int, len(tickets))
+	for _, root := range ticket.BuildTree(tickets) {
+		ordered = append(ordered, root.Ticket)
+		root.Walk(func(n *ticket.Node, depth int) {
+			ordered = append(ordered, n.Ticket)
+			depths[n.Ticket.ID] = depth
+		})
+	}
+	return ordered, depths
+}
+
+// printTicketsTable prints tickets in a table format, indenting the titles of
+// tickets nested depths levels deep under their parents
+func printTicketsTable(tickets []ticket.Ticket, depths map[int64]int) {
+	now := time.Now()
+	overdue := 0
+
 	// Print header
-	fmt.Printf("%-6s %-18s %-10s %-10s %-10s %-35s %-13s %-12s\n",
-		"ID", "PROJECT", "TYPE", "PRIORITY", "CRITICAL", "TITLE", "STATUS", "ASSIGNED TO")
-	fmt.Println(strings.Repeat("-", 114))
+	fmt.Printf("%-6s %-18s %-10s %-10s %-10s %-35s %-13s %-9s %-12s %-11s\n",
+		"ID", "PROJECT", "TYPE", "PRIORITY", "CRITICAL", "TITLE", "STATUS", "CHECKLIST", "ASSIGNED TO", "DUE")
+	fmt.Println(strings.Repeat("-", 136))
 
 	// Print rows
 	for _, t := range tickets {
@@ -155,28 +291,61 @@ func printTicketsTable(tickets []ticket.Ticket) {
 			assignedTo = *t.AssignedTo
 		}
 
-		// Truncate title if too long
-		title := t.Title
-		if len(title) > 35 {
-			title = title[:32] + "..."
+		// Truncate title if too long, leaving room for the nesting
+		indent := ""
+		if d := depths[t.ID]; d > 0 {
+			indent = strings.Repeat("  ", d-1) + "└ "
+		}
+		title, width := t.Title, 35-utf8.RuneCountInString(indent)
+		if len(title) > width {
+			title = title[:width-3] + "..."
+		}
+		title = indent + title
+
+		status := string(t.Status)
+		if t.ArchivedAt != nil {
+			status = "archived"
+		}
+
+		checklist := "-"
+		if done, total := t.ChecklistProgress(); total > 0 {
+			checklist = fmt.Sprintf("%d/%d", done, total)
 		}
 
-		fmt.Printf("%-6d %-18s %-10s %-10s %-10t %-35s %-13s %-12s\n",
+		// Overdue tickets are flagged with a trailing "!"
+		due := "-"
+		if t.DueAt != nil {
+			due = t.DueAt.Format("2006-01-02")
+			if t.IsOverdue(now) {
+				due += "!"
+				overdue++
+			}
+		}
+
+		fmt.Printf("%-6d %-18s %-10s %-10s %-10t %-35s %-13s %-9s %-12s %-11s\n",
 			t.ID,
 			t.Project,
 			t.Type,
 			t.Priority,
 			t.CriticalPath,
 			title,
-			t.Status,
-			assignedTo)
+			status,
+			checklist,
+			assignedTo,
+			due)
 	}
 
 	fmt.Printf("\nTotal: %d ticket(s)\n", len(tickets))
+	if overdue > 0 {
+		fmt.Printf("Overdue: %d ticket(s) (marked with !)\n", overdue)
+	}
 }
 
 // printTicketsSummary prints a summary of each ticket
 func printTicketsSummary(tickets []ticket.Ticket) {
+	now := time.Now()
+	overdue := 0
+
 	for i, t := range tickets {
 		if i > 0 {
 			fmt.Println()
@@ -214,11 +383,32 @@ func printTicketsSummary(tickets []ticket.Ticket) {
 			fmt.Printf("Comments: %d\n", len(t.Comments))
 		}
 
+		if done, total := t.ChecklistProgress(); total > 0 {
+			fmt.Printf("Checklist: %d/%d done\n", done, total)
+		}
+
+		if t.StartAt != nil {
+			fmt.Printf("Start: %s\n", t.StartAt.Format("2006-01-02"))
+		}
+
+		if t.DueAt != nil {
+			if t.IsOverdue(now) {
+				overdue++
+				fmt.Printf("Due: %s (OVERDUE)\n", t.DueAt.Format("2006-01-02"))
+			} else {
+				fmt.Printf("Due: %s\n", t.DueAt.Format("2006-01-02"))
+			}
+		}
+
 		fmt.Printf("Created: %s | Updated: %s\n", t.CreatedAt.Format("2006-01-02 15:04"), t.UpdatedAt.Format("2006-01-02 15:04"))
+		if t.ArchivedAt != nil {
+			fmt.Printf("Archived: %s\n", t.ArchivedAt.Format("2006-01-02 15:04"))
+		}
 		fmt.Println(strings.Repeat("-", 80))
 	}
 
 	fmt.Printf("\nTotal: %d ticket(s)\n", len(tickets))
+	if overdue > 0 {
+		fmt.Printf("Overdue: %d ticket(s)\n", overdue)
+	}
 }
-
-
@@ -0,0 +1,283 @@
+package cmd
+
+import (
+	"alexandria/internal/config"
+	"alexandria/internal/logger"
+	"alexandria/internal/notify"
+	"alexandria/internal/users"
+	"database/sql"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	notifyProject     string
+	notifyAddr        string
+	notifyFrom        string
+	notifyUsername    string
+	notifyPasswordEnv string
+	notifyMode        string
+	notifyEvents      string
+)
+
+var notifyCmd = &cobra.Command{
+	Use:   "notify",
+	Short: "Configure email notifications",
+	Long: `Email the people involved in a ticket when it is assigned, commented on or closed:
+its assignee, its creator and its watchers (see watch), except whoever made the
+change. Addresses come from the email column of the users table (see user add).
+
+Notifications are turned on per project, in the user config with "notify enable"
+or in a directory config:
+
+  [notify]
+  projects = ["Backend"]
+
+Each user chooses with "notify prefs" whether to get an email per notification,
+one digest sent by "notify digest", or none, and for which events: ` + strings.Join(notify.Events, ", ") + `.
+
+Examples:
+  alexandria notify smtp --addr smtp.example.com:587 --from alexandria@example.com --username alexandria
+  alexandria notify enable --project Backend
+  alexandria notify prefs --mode digest --events assigned,closed
+  alexandria notify digest`,
+}
+
+var notifyEnableCmd = &cobra.Command{
+	Use:         "enable",
+	Short:       "Send notifications for a project's tickets",
+	Args:        cobra.NoArgs,
+	Annotations: map[string]string{noDatabase: ""},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := applyDefaultProject(&notifyProject); err != nil {
+			return err
+		}
+		if notifyProject == "" {
+			logger.Log.Error("validation failed", "error", "project is required")
+			return errNoProject
+		}
+
+		err := config.UpdateNotify(func(n *config.Notify) {
+			if !n.Enabled(notifyProject) {
+				n.Projects = append(n.Projects, notifyProject)
+			}
+		})
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Notifications enabled for project '%s'\n", notifyProject)
+		return nil
+	},
+}
+
+var notifyDisableCmd = &cobra.Command{
+	Use:         "disable",
+	Short:       "Stop sending notifications for a project's tickets",
+	Args:        cobra.NoArgs,
+	Annotations: map[string]string{noDatabase: ""},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := applyDefaultProject(&notifyProject); err != nil {
+			return err
+		}
+		if notifyProject == "" {
+			logger.Log.Error("validation failed", "error", "project is required")
+			return errNoProject
+		}
+
+		err := config.UpdateNotify(func(n *config.Notify) {
+			var kept []string
+			for _, p := range n.Projects {
+				if p != notifyProject {
+					kept = append(kept, p)
+				}
+			}
+			n.Projects = kept
+		})
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Notifications disabled for project '%s'\n", notifyProject)
+		return nil
+	},
+}
+
+var notifySMTPCmd = &cobra.Command{
+	Use:   "smtp",
+	Short: "Set the SMTP server notifications are sent through",
+	Long: `Set the SMTP server notifications are sent through. The password is not stored: it
+is read from the environment variable named by --password-env, or
+ALEXANDRIA_SMTP_PASSWORD, and only sent over TLS or to localhost.`,
+	Args:        cobra.NoArgs,
+	Annotations: map[string]string{noDatabase: ""},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		settings := &config.Notify{
+			SMTPAddr:    notifyAddr,
+			From:        notifyFrom,
+			Username:    notifyUsername,
+			PasswordEnv: notifyPasswordEnv,
+		}
+		if _, err := notify.NewSMTPMailer(settings); err != nil {
+			logger.Log.Error("validation failed", "error", err)
+			return err
+		}
+
+		err := config.UpdateNotify(func(n *config.Notify) {
+			n.SMTPAddr, n.From, n.Username, n.PasswordEnv = notifyAddr, notifyFrom, notifyUsername, notifyPasswordEnv
+		})
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Notifications will be sent from %s through %s\n", notifyFrom, notifyAddr)
+		return nil
+	},
+}
+
+var notifyPrefsCmd = &cobra.Command{
+	Use:   "prefs",
+	Short: "Show or change your notification preferences",
+	Long: `Show or change how you are notified: --mode immediate sends an email per
+notification, digest collects them into one email sent by "notify digest", and off
+sends none. --events limits which events you are notified of.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		db, err := sqlDB(cmd)
+		if err != nil {
+			return err
+		}
+
+		prefs, err := notify.GetPrefs(db, config.CurrentUser())
+		if err != nil {
+			return err
+		}
+
+		if cmd.Flags().Changed("mode") || cmd.Flags().Changed("events") {
+			if cmd.Flags().Changed("mode") {
+				prefs.Mode = notifyMode
+			}
+			if cmd.Flags().Changed("events") {
+				if prefs.Events, err = notify.ParseEvents(notifyEvents); err != nil {
+					logger.Log.Error("validation failed", "error", err, "events", notifyEvents)
+					return err
+				}
+			}
+			if err := notify.SetPrefs(db, prefs); err != nil {
+				return err
+			}
+		}
+
+		email, err := users.Email(db, prefs.Username)
+		if err != nil {
+			return err
+		}
+		if email == "" {
+			email = "(none; see alexandria user add)"
+		}
+
+		fmt.Printf("User:   %s\n", prefs.Username)
+		fmt.Printf("Email:  %s\n", email)
+		fmt.Printf("Mode:   %s\n", prefs.Mode)
+		fmt.Printf("Events: %s\n", strings.Join(prefs.Events, ", "))
+		return nil
+	},
+}
+
+var notifySendCmd = &cobra.Command{
+	Use:   "send",
+	Short: "Send queued notifications, including ones that failed before",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return sendNotifications(cmd, notify.Send)
+	},
+}
+
+var notifyDigestCmd = &cobra.Command{
+	Use:   "digest",
+	Short: "Send each digest user one email with their collected notifications",
+	Long: `Send each user whose mode is digest one email listing the notifications collected
+since their last digest. Run it on a schedule, for example daily from cron.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return sendNotifications(cmd, notify.SendDigests)
+	},
+}
+
+// sendNotifications mails queued notifications with send through the configured SMTP server
+func sendNotifications(cmd *cobra.Command, send func(db *sql.DB, mailer notify.Mailer, now time.Time) (notify.Result, error)) error {
+	db, err := sqlDB(cmd)
+	if err != nil {
+		return err
+	}
+	cfg, err := config.Resolve()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	mailer, err := notify.NewSMTPMailer(cfg.Notify)
+	if err != nil {
+		return err
+	}
+
+	res, err := send(db, mailer, time.Now())
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Sent %d email(s), will retry %d, gave up on %d.\n", res.Sent, res.Retrying, res.Failed)
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(notifyCmd)
+	notifyCmd.AddCommand(notifyEnableCmd)
+	notifyCmd.AddCommand(notifyDisableCmd)
+	notifyCmd.AddCommand(notifySMTPCmd)
+	notifyCmd.AddCommand(notifyPrefsCmd)
+	notifyCmd.AddCommand(notifySendCmd)
+	notifyCmd.AddCommand(notifyDigestCmd)
+
+	notifyEnableCmd.Flags().StringVar(&notifyProject, "project", "", projectFlagUsage)
+	notifyDisableCmd.Flags().StringVar(&notifyProject, "project", "", projectFlagUsage)
+	notifySMTPCmd.Flags().StringVar(&notifyAddr, "addr", "", "SMTP server as host:port (required)")
+	notifySMTPCmd.Flags().StringVar(&notifyFrom, "from", "", "Sender address (required)")
+	notifySMTPCmd.Flags().StringVar(&notifyUsername, "username", "", "SMTP login, if the server needs one")
+	notifySMTPCmd.Flags().StringVar(&notifyPasswordEnv, "password-env", "", "Environment variable holding the SMTP password (default: ALEXANDRIA_SMTP_PASSWORD)")
+	notifySMTPCmd.MarkFlagRequired("addr")
+	notifySMTPCmd.MarkFlagRequired("from")
+	notifyPrefsCmd.Flags().StringVar(&notifyMode, "mode", "", "How to notify you: immediate, digest or off")
+	notifyPrefsCmd.Flags().StringVar(&notifyEvents, "events", "", "Comma-separated events to notify you of (default: all)")
+}
+
+// sendQueued mails the notifications queued while the command ran for immediate
+// delivery. Problems are reported as warnings since the ticket changes themselves
+// have been saved.
+func sendQueued(cmd *cobra.Command) {
+	d := depsFrom(cmd)
+	if d == nil || d.db == nil || d.notifier == nil || d.notifier.Queued() == 0 {
+		return
+	}
+
+	cfg, err := config.Resolve()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to send notifications: %v\n", err)
+		return
+	}
+	mailer, err := notify.NewSMTPMailer(cfg.Notify)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: notifications queued but not sent: %v\n", err)
+		return
+	}
+
+	res, err := notify.Send(d.db, mailer, time.Now())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to send notifications: %v\n", err)
+		return
+	}
+	if res.Retrying > 0 || res.Failed > 0 {
+		fmt.Fprintf(os.Stderr, "Warning: %d notification email(s) could not be sent; retry with 'alexandria notify send'\n", res.Retrying+res.Failed)
+	}
+}
@@ -0,0 +1,161 @@
+package cmd
+
+import (
+	"alexandria/internal/config"
+	"alexandria/internal/logger"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	profileType     string
+	profilePath     string
+	profileURL      string
+	profileTokenEnv string
+)
+
+var profileCmd = &cobra.Command{
+	Use:   "profile",
+	Short: "Manage named database profiles",
+	Long: `Profiles name the ticket databases you work with, so you can move between them
+without editing .env files. Select one for a single command with --profile, for a
+directory tree with "profile" in .alexandria.toml, or as your default with
+"alexandria profile use".
+
+Turso tokens are never stored in the config: a profile names the environment
+variable that holds its token.
+
+Examples:
+  alexandria profile add personal --type sqlite --path ~/tickets/personal.db
+  alexandria profile add team --type turso --url libsql://team.turso.io --token-env TEAM_TURSO_TOKEN
+  alexandria profile add client-x --type postgres --url postgres://me@db.client-x.com/tickets
+  alexandria profile use team
+  alexandria list --profile personal`,
+	Annotations: map[string]string{noDatabase: ""},
+}
+
+var profileAddCmd = &cobra.Command{
+	Use:   "add <name>",
+	Short: "Add or replace a profile",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+		logger.Log.Debug("adding profile", "profile", name, "type", profileType)
+
+		// A relative path would otherwise depend on where later commands are run
+		path := profilePath
+		if path != "" {
+			abs, err := filepath.Abs(path)
+			if err != nil {
+				logger.Log.Error("failed to resolve path", "error", err, "path", path)
+				return fmt.Errorf("failed to resolve path: %w", err)
+			}
+			path = abs
+		}
+
+		p := config.Profile{Type: profileType, Path: path, URL: profileURL, TokenEnv: profileTokenEnv}
+		if err := config.AddProfile(name, p); err != nil {
+			logger.Log.Error("failed to add profile", "error", err, "profile", name)
+			return fmt.Errorf("failed to add profile: %w", err)
+		}
+
+		fmt.Printf("Added %s profile '%s'. Run 'alexandria profile use %s' to make it the default.\n", profileType, name, name)
+		return nil
+	},
+}
+
+var profileUseCmd = &cobra.Command{
+	Use:   "use <name>",
+	Short: "Make a profile the default",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+		if err := config.UseProfile(name); err != nil {
+			logger.Log.Error("failed to use profile", "error", err, "profile", name)
+			return fmt.Errorf("failed to use profile: %w", err)
+		}
+
+		fmt.Printf("Now using profile '%s'.\n", name)
+		if dir, path, err := config.LoadDirFromWorkingDir(); err == nil && (dir.Profile != "" || dir.DatabaseType != "") {
+			fmt.Printf("Note: %s selects its own database, which takes precedence in this directory.\n", path)
+		}
+		return nil
+	},
+}
+
+var profileListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List profiles, marking the one in use",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Resolve()
+		if err != nil {
+			logger.Log.Error("failed to load config", "error", err)
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+
+		names := cfg.ProfileNames()
+		if len(names) == 0 {
+			fmt.Println("No profiles. Add one with 'alexandria profile add'.")
+			return nil
+		}
+
+		current := cfg.Profile
+		if profileName != "" {
+			current = profileName
+		}
+
+		fmt.Printf("  %-16s %-10s %s\n", "NAME", "TYPE", "DATABASE")
+		fmt.Println(strings.Repeat("-", 80))
+		for _, name := range names {
+			p, _ := cfg.LookupProfile(name)
+			marker := " "
+			if name == current {
+				marker = "*"
+			}
+			fmt.Printf("%s %-16s %-10s %s\n", marker, name, p.Type, describeProfile(p))
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(profileCmd)
+	profileCmd.AddCommand(profileAddCmd)
+	profileCmd.AddCommand(profileUseCmd)
+	profileCmd.AddCommand(profileListCmd)
+
+	profileAddCmd.Flags().StringVar(&profileType, "type", "", "Database type: sqlite, turso, sync or postgres (required)")
+	profileAddCmd.Flags().StringVar(&profilePath, "path", "", "SQLite file, or the replica file for sync")
+	profileAddCmd.Flags().StringVar(&profileURL, "url", "", "Turso URL or PostgreSQL connection string")
+	profileAddCmd.Flags().StringVar(&profileTokenEnv, "token-env", "", "Environment variable holding the Turso auth token")
+	profileAddCmd.MarkFlagRequired("type")
+}
+
+// describeProfile summarises where a profile's database lives, hiding secrets
+func describeProfile(p config.Profile) string {
+	switch p.Type {
+	case config.DBTypeTurso, config.DBTypeSync:
+		url := p.URL
+		if url == "" {
+			url = "$TURSO_URL"
+		}
+		desc := fmt.Sprintf("%s (token from $%s)", url, p.TokenVariable())
+		if p.Type == config.DBTypeSync && p.Path != "" {
+			desc = p.Path + " <-> " + desc
+		}
+		return desc
+	case config.DBTypePostgres:
+		if p.URL == "" {
+			return "$POSTGRES_DSN"
+		}
+		return redactDSN(p.URL)
+	}
+	if p.Path == "" {
+		return "default database"
+	}
+	return p.Path
+}
@@ -0,0 +1,270 @@
+package cmd
+
+import (
+	"alexandria/internal/config"
+	"alexandria/internal/dates"
+	"alexandria/internal/gitlink"
+	"alexandria/internal/logger"
+	"alexandria/internal/recur"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	recurProject  string
+	recurEvery    string
+	recurAt       string
+	recurCron     string
+	recurRRule    string
+	recurTemplate string
+	recurTitle    string
+	recurAssignee string
+	recurDue      string
+	recurDryRun   bool
+)
+
+var recurCmd = &cobra.Command{
+	Use:   "recur",
+	Short: "Create tickets from templates on a schedule",
+	Long: `Recurring schedules create a ticket from a project template each time they come
+round, for chores such as weekly on-call checks. Schedules are given with --every
+(day, weekday, week, month, hour or weekday names, with --at HH:MM), a cron
+expression or an iCalendar RRULE, and are evaluated in local time.
+
+"recur run" creates the tickets that are due. It is safe to run as often as
+wanted, so run it from cron every few minutes: each occurrence of a schedule gets
+exactly one ticket, and the ticket records the schedule that created it. If runs
+are missed, only the latest due occurrence gets a ticket.
+
+The ticket title defaults to the schedule name and the date; --title may use the
+template placeholders {{date}} (the day of the occurrence), {{user}} and {{project}}.
+
+Examples:
+  alexandria recur add --every monday --template weekly-ops --project OPS
+  alexandria recur add standup-notes --cron "30 9 * * 1-5" --template notes --title "Standup {{date}}"
+  alexandria recur add month-end --rrule "FREQ=MONTHLY;BYMONTHDAY=28" --template close-books --due 3d
+  alexandria recur run`,
+}
+
+var recurAddCmd = &cobra.Command{
+	Use:   "add [name]",
+	Short: "Add a recurring schedule (the name defaults to the template's)",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := applyDefaultProject(&recurProject); err != nil {
+			return err
+		}
+		if recurProject == "" {
+			logger.Log.Error("validation failed", "error", "project is required")
+			return errNoProject
+		}
+
+		spec, schedule, err := parseSchedule(cmd)
+		if err != nil {
+			logger.Log.Error("validation failed", "error", err)
+			return err
+		}
+		if recurDue != "" {
+			if _, err := dates.ParseDuration(recurDue); err != nil {
+				logger.Log.Error("validation failed", "error", err, "due", recurDue)
+				return fmt.Errorf("invalid --due: %w", err)
+			}
+		}
+
+		name := recurTemplate
+		if len(args) == 1 {
+			name = args[0]
+		}
+
+		db, err := sqlDB(cmd)
+		if err != nil {
+			return err
+		}
+
+		r := &recur.Recurrence{
+			Project:    recurProject,
+			Name:       name,
+			Template:   recurTemplate,
+			Title:      recurTitle,
+			Spec:       spec,
+			Cron:       schedule.String(),
+			AssignedTo: recurAssignee,
+			DueIn:      recurDue,
+			CreatedBy:  config.CurrentUser(),
+		}
+		if err := recur.Add(db, r); err != nil {
+			return err
+		}
+
+		fmt.Printf("Added schedule '%s' to project '%s': %s (cron: %s)\n", r.Name, r.Project, r.Spec, r.Cron)
+		if next := r.Next(time.Now()); !next.IsZero() {
+			fmt.Printf("Next ticket: %s\n", next.Format("Mon 2006-01-02 15:04"))
+		}
+		return nil
+	},
+}
+
+// parseSchedule reads the one schedule flag given to "recur add", returning how it
+// was written and the cron expression it stands for
+func parseSchedule(cmd *cobra.Command) (string, *recur.Cron, error) {
+	given := 0
+	for _, name := range []string{"every", "cron", "rrule"} {
+		if cmd.Flags().Changed(name) {
+			given++
+		}
+	}
+	if given != 1 {
+		return "", nil, fmt.Errorf("give exactly one of --every, --cron or --rrule")
+	}
+	if recurAt != "" && !cmd.Flags().Changed("every") {
+		return "", nil, fmt.Errorf("--at only applies to --every")
+	}
+
+	switch {
+	case cmd.Flags().Changed("every"):
+		c, err := recur.ParseEvery(recurEvery, recurAt)
+		spec := "every " + recurEvery
+		if recurAt != "" {
+			spec += " at " + recurAt
+		}
+		return spec, c, err
+	case cmd.Flags().Changed("cron"):
+		c, err := recur.ParseCron(recurCron)
+		return "cron " + recurCron, c, err
+	default:
+		c, err := recur.ParseRRule(recurRRule)
+		return "rrule " + recurRRule, c, err
+	}
+}
+
+var recurListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List recurring schedules, optionally only a project's",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := applyDefaultProject(&recurProject); err != nil {
+			return err
+		}
+
+		db, err := sqlDB(cmd)
+		if err != nil {
+			return err
+		}
+
+		schedules, err := recur.List(db, recurProject)
+		if err != nil {
+			return err
+		}
+		if len(schedules) == 0 {
+			fmt.Println("No recurring schedules found.")
+			return nil
+		}
+
+		now := time.Now()
+		fmt.Printf("%-18s %-12s %-16s %-28s %-17s %s\n", "NAME", "PROJECT", "TEMPLATE", "SCHEDULE", "LAST RUN", "NEXT RUN")
+		fmt.Println(strings.Repeat("-", 110))
+		for _, r := range schedules {
+			last, next := "never", "never"
+			if r.LastRunAt != nil {
+				last = r.LastRunAt.Local().Format("2006-01-02 15:04")
+			}
+			if t := r.Next(now); !t.IsZero() {
+				next = t.Format("2006-01-02 15:04")
+			}
+			fmt.Printf("%-18s %-12s %-16s %-28s %-17s %s\n", r.Name, r.Project, r.Template, r.Spec, last, next)
+		}
+		return nil
+	},
+}
+
+var recurRmCmd = &cobra.Command{
+	Use:   "rm <name>",
+	Short: "Remove a recurring schedule; tickets it created are kept",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := applyDefaultProject(&recurProject); err != nil {
+			return err
+		}
+		if recurProject == "" {
+			logger.Log.Error("validation failed", "error", "project is required")
+			return errNoProject
+		}
+
+		db, err := sqlDB(cmd)
+		if err != nil {
+			return err
+		}
+		if err := recur.Remove(db, recurProject, args[0]); err != nil {
+			return err
+		}
+
+		fmt.Printf("Removed schedule '%s' from project '%s'\n", args[0], recurProject)
+		return nil
+	},
+}
+
+var recurRunCmd = &cobra.Command{
+	Use:   "run",
+	Short: "Create the tickets that are due; safe to run repeatedly, e.g. from cron",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		db, err := sqlDB(cmd)
+		if err != nil {
+			return err
+		}
+		store, err := ticketStore(cmd)
+		if err != nil {
+			return err
+		}
+
+		spawned, err := recur.Run(cmd.Context(), db, store, time.Now(), recurDryRun)
+		if err != nil {
+			return err
+		}
+		if len(spawned) == 0 {
+			fmt.Println("No recurring tickets are due.")
+			return nil
+		}
+
+		failed := 0
+		for _, s := range spawned {
+			when := s.Occurrence.Local().Format("2006-01-02 15:04")
+			switch {
+			case s.Error != "":
+				failed++
+				fmt.Printf("Schedule '%s' in project '%s' for %s failed: %s\n", s.Schedule, s.Project, when, s.Error)
+			case recurDryRun:
+				fmt.Printf("Would create a ticket from schedule '%s' in project '%s' for %s\n", s.Schedule, s.Project, when)
+			default:
+				fmt.Printf("Created %s-%d from schedule '%s' in project '%s' for %s\n", gitlink.RefPrefix, s.TicketID, s.Schedule, s.Project, when)
+			}
+		}
+		if failed > 0 {
+			return fmt.Errorf("%d recurring schedule(s) failed", failed)
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(recurCmd)
+	recurCmd.AddCommand(recurAddCmd)
+	recurCmd.AddCommand(recurListCmd)
+	recurCmd.AddCommand(recurRmCmd)
+	recurCmd.AddCommand(recurRunCmd)
+
+	recurCmd.PersistentFlags().StringVar(&recurProject, "project", "", projectFlagUsage)
+	recurAddCmd.Flags().StringVar(&recurEvery, "every", "", "How often: day, weekday, week, month, hour, or weekday names like monday,thursday")
+	recurAddCmd.Flags().StringVar(&recurAt, "at", "", "Time of day for --every, as HH:MM (default: 00:00)")
+	recurAddCmd.Flags().StringVar(&recurCron, "cron", "", `Cron expression, e.g. "0 9 * * 1"`)
+	recurAddCmd.Flags().StringVar(&recurRRule, "rrule", "", `iCalendar RRULE, e.g. "FREQ=WEEKLY;BYDAY=MO;BYHOUR=9"`)
+	recurAddCmd.Flags().StringVar(&recurTemplate, "template", "", "Project template the tickets are created from (required)")
+	recurAddCmd.Flags().StringVar(&recurTitle, "title", "", "Ticket title, may use {{date}} (default: the schedule name and date)")
+	recurAddCmd.Flags().StringVarP(&recurAssignee, "assigned-to", "a", "", "Assign the tickets to user")
+	recurAddCmd.Flags().StringVar(&recurDue, "due", "", "Make tickets due this long after they are created, e.g. 2d")
+	recurAddCmd.MarkFlagRequired("template")
+	recurRunCmd.Flags().BoolVar(&recurDryRun, "dry-run", false, "Show the tickets that are due without creating them")
+}
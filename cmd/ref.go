@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"alexandria/internal/gitlink"
+	"alexandria/internal/logger"
+	"alexandria/internal/ticket"
+	"context"
+	"fmt"
+)
+
+// resolveTicketRef loads the ticket named by a reference such as 42 or ALX-42
+func resolveTicketRef(ctx context.Context, store ticket.TicketStore, ref string) (*ticket.Ticket, error) {
+	id, err := ticket.ParseRef(ref)
+	if err != nil {
+		logger.Log.Error("validation failed", "error", err, "ref", ref)
+		return nil, err
+	}
+
+	t, err := store.Get(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find ticket %s: %w", ref, err)
+	}
+	return t, nil
+}
+
+// resolveCurrentTicket loads the ticket named by the checked out git branch
+func resolveCurrentTicket(ctx context.Context, store ticket.TicketStore) (*ticket.Ticket, error) {
+	branch, err := gitlink.CurrentBranch()
+	if err != nil {
+		return nil, fmt.Errorf("no ticket given and the current branch could not be read: %w", err)
+	}
+
+	id, ok := gitlink.RefFromBranch(branch)
+	if !ok {
+		logger.Log.Error("branch does not name a ticket", "branch", branch)
+		return nil, fmt.Errorf("no ticket given and branch '%s' does not name one (use alexandria checkout <ref>)", branch)
+	}
+	logger.Log.Debug("inferred ticket from branch", "branch", branch, "id", id)
+
+	t, err := store.Get(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find ticket for branch '%s': %w", branch, err)
+	}
+	return t, nil
+}
+
+// resolveTicketOrCurrent loads the referenced ticket, or the current branch's ticket if ref is empty
+func resolveTicketOrCurrent(ctx context.Context, store ticket.TicketStore, ref string) (*ticket.Ticket, error) {
+	if ref == "" {
+		return resolveCurrentTicket(ctx, store)
+	}
+	return resolveTicketRef(ctx, store, ref)
+}
+
+// parseRefOnly parses a ticket reference without checking that the ticket exists
+func parseRefOnly(ref string) (int64, error) {
+	id, err := ticket.ParseRef(ref)
+	if err != nil {
+		logger.Log.Error("validation failed", "error", err, "ref", ref)
+		return 0, err
+	}
+	return id, nil
+}
@@ -0,0 +1,149 @@
+package cmd
+
+import (
+	"alexandria/internal/logger"
+	"alexandria/internal/report"
+	"alexandria/internal/ticket"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	reportProject      string
+	reportGroupBy      string
+	reportSprintPrefix string
+	reportOutput       string
+)
+
+var reportCmd = &cobra.Command{
+	Use:   "report",
+	Short: "Generate project reports",
+	Long:  `Generate reports computed from ticket history, estimates and worklogs.`,
+}
+
+var velocityCmd = &cobra.Command{
+	Use:   "velocity",
+	Short: "Report completed points per week or sprint",
+	Long: `Report the story points completed per week or per sprint from closed tickets,
+along with the average cycle time and, where worklogs exist, how actual time compares
+to the hours estimate.
+
+Sprints are identified by ticket tags that start with --sprint-prefix (default "sprint-").
+Cycle time runs from a ticket's start date (or creation if unset) to when it was closed.
+
+Examples:
+  alexandria report velocity --project Alexandria
+  alexandria report velocity --project Alexandria --by sprint
+  alexandria report velocity --project Alexandria -o json`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		logger.Log.Debug("building velocity report", "project", reportProject, "group_by", reportGroupBy)
+
+		if err := applyDefaultProject(&reportProject); err != nil {
+			return err
+		}
+		if reportProject == "" {
+			logger.Log.Error("validation failed", "error", "project is required")
+			return errNoProject
+		}
+
+		store, err := ticketStore(cmd)
+		if err != nil {
+			return err
+		}
+		ctx := cmd.Context()
+
+		closed := ticket.StatusClosed
+		tickets, err := store.List(ctx, ticket.Filters{Project: &reportProject, Status: &closed})
+		if err != nil {
+			logger.Log.Error("failed to list tickets", "error", err)
+			return fmt.Errorf("failed to list tickets: %w", err)
+		}
+
+		logged, err := store.LoggedByTicket(ctx)
+		if err != nil {
+			logger.Log.Error("failed to load worklogs", "error", err)
+			return fmt.Errorf("failed to load worklogs: %w", err)
+		}
+
+		v, err := report.BuildVelocity(reportProject, tickets, logged, report.VelocityOptions{
+			GroupBy:      reportGroupBy,
+			SprintPrefix: reportSprintPrefix,
+		})
+		if err != nil {
+			logger.Log.Error("failed to build velocity report", "error", err)
+			return err
+		}
+
+		switch reportOutput {
+		case "json":
+			jsonData, err := json.MarshalIndent(v, "", "  ")
+			if err != nil {
+				logger.Log.Error("failed to marshal report", "error", err)
+				return fmt.Errorf("failed to marshal report: %w", err)
+			}
+			fmt.Println(string(jsonData))
+		case "table":
+			printVelocity(v)
+		default:
+			logger.Log.Error("invalid output format", "format", reportOutput)
+			return fmt.Errorf("invalid output format: %s (must be: json or table)", reportOutput)
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(reportCmd)
+	reportCmd.AddCommand(velocityCmd)
+
+	velocityCmd.Flags().StringVar(&reportProject, "project", "", projectFlagUsage)
+	velocityCmd.Flags().StringVar(&reportGroupBy, "by", report.GroupByWeek, "Group completed work by week or sprint")
+	velocityCmd.Flags().StringVar(&reportSprintPrefix, "sprint-prefix", "sprint-", "Tag prefix that identifies a sprint")
+	velocityCmd.Flags().StringVarP(&reportOutput, "output", "o", "table", "Output format (json, table)")
+}
+
+// printVelocity prints a velocity report as a table
+func printVelocity(v *report.Velocity) {
+	fmt.Printf("Velocity for project %s (by %s)\n\n", v.Project, v.GroupBy)
+
+	if len(v.Periods) == 0 {
+		fmt.Println("No closed tickets found.")
+		return
+	}
+
+	fmt.Printf("%-16s %8s %8s %10s %11s %8s\n", "PERIOD", "TICKETS", "POINTS", "EST HOURS", "ACTUAL HRS", "RATIO")
+	fmt.Println(strings.Repeat("-", 66))
+	for _, p := range v.Periods {
+		ratio := "-"
+		if p.EstimateRatio != nil {
+			ratio = fmt.Sprintf("%.2f", *p.EstimateRatio)
+		}
+		fmt.Printf("%-16s %8d %8.1f %10.1f %11.1f %8s\n", p.Label, p.Tickets, p.Points, p.EstimateHours, p.ActualHours, ratio)
+	}
+
+	fmt.Printf("\nAverage velocity: %.1f points per %s\n", v.AveragePoints, v.GroupBy)
+	fmt.Printf("Average cycle time: %s\n", formatCycleTime(v.AverageCycleHours))
+	if v.EstimateRatio != nil {
+		fmt.Printf("Estimate vs actual: %.2f (actual/estimate across %d ticket(s) with worklogs)\n", *v.EstimateRatio, v.EstimatedTickets)
+	}
+	if v.Ungrouped > 0 {
+		fmt.Printf("Closed tickets without a sprint tag: %d\n", v.Ungrouped)
+	}
+}
+
+// formatCycleTime renders hours as days and hours, e.g. 3d 4h
+func formatCycleTime(hours float64) string {
+	d := time.Duration(hours * float64(time.Hour)).Round(time.Hour)
+	days := int(d / (24 * time.Hour))
+	rem := int((d % (24 * time.Hour)) / time.Hour)
+	if days > 0 {
+		return fmt.Sprintf("%dd %dh", days, rem)
+	}
+	return fmt.Sprintf("%dh", rem)
+}
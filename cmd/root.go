@@ -1,8 +1,12 @@
 package cmd
 
 import (
+	"alexandria/internal/config"
 	"alexandria/internal/database"
 	"alexandria/internal/logger"
+	"alexandria/internal/notify"
+	"alexandria/internal/ticket"
+	"alexandria/internal/webhooks"
 	"fmt"
 	"os"
 
@@ -11,7 +15,11 @@ import (
 	"github.com/common-nighthawk/go-figure"
 )
 
-var verbose bool
+var (
+	verbose     bool
+	profileName string
+	dbPath      string
+)
 
 var rootCmd = &cobra.Command{
 	Use:   "alexandria",
@@ -33,20 +41,59 @@ var rootCmd = &cobra.Command{
 		_ = godotenv.Load()
 		logger.Log.Debug("loaded environment variables")
 
+		// Changes are journaled under the current user so they can undo them
+		cmd.SetContext(ticket.WithActor(cmd.Context(), config.CurrentUser()))
+
+		// Dependencies supplied by the caller (such as an in-memory store in tests) take
+		// the place of the configured database
+		if depsFrom(cmd) != nil {
+			logger.Log.Debug("using injected ticket store")
+			return nil
+		}
+
+		// Commands that manage the configuration work even when the database can't be reached
+		if !needsDatabase(cmd) {
+			logger.Log.Debug("skipping database connection", "command", cmd.Name())
+			return nil
+		}
+
 		// Initialize database with default path
-		logger.Log.Debug("initializing database connection")
-		if err := database.Init(""); err != nil {
+		logger.Log.Debug("initializing database connection", "profile", profileName, "path", dbPath)
+		if err := database.Init(profileName, dbPath); err != nil {
 			logger.Log.Error("failed to initialize database", "error", err)
 			return fmt.Errorf("failed to initialize database: %w", err)
 		}
 		logger.Log.Info("database initialized successfully")
+
+		db := database.GetDB()
+		d := &deps{tickets: ticket.NewSQLStore(db), db: db}
+
+		// Ticket changes email the people involved in projects with notifications turned on
+		cfg, err := config.Resolve()
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+		if cfg.Notify != nil && len(cfg.Notify.Projects) > 0 {
+			d.notifier = notify.NewStore(d.tickets, db, cfg.Notify.Enabled)
+			d.tickets = d.notifier
+		}
+
+		// Ticket changes queue deliveries to the project's webhooks
+		d.tickets = webhooks.NewStore(d.tickets, db)
+		cmd.SetContext(withDeps(cmd.Context(), d))
 		return nil
 	},
+	PersistentPostRun: func(cmd *cobra.Command, args []string) {
+		deliverQueued(cmd)
+		sendQueued(cmd)
+	},
 }
 
 func init() {
 	rootCmd.CompletionOptions.DisableDefaultCmd = true
 	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "Enable verbose logging")
+	rootCmd.PersistentFlags().StringVar(&profileName, "profile", "", "Database profile to use (see alexandria profile list)")
+	rootCmd.PersistentFlags().StringVar(&dbPath, "db", "", "SQLite file to use instead of the configured database, or :memory: for a throwaway one")
 }
 
 func Execute() {
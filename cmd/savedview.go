@@ -0,0 +1,152 @@
+package cmd
+
+import (
+	"alexandria/internal/config"
+	"alexandria/internal/logger"
+	"alexandria/internal/views"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var viewPersonal bool
+
+var viewSaveCmd = &cobra.Command{
+	Use:   "save <name> -- [list flags]",
+	Short: "Save list filters, output format and sort order as a named view",
+	Long: `Save a set of list flags under a name so they can be reused with "list --view".
+Shared views are stored in the database, so everyone using the same database (for
+example on Turso) can use them. Personal views are stored in your config file.
+
+Examples:
+  alexandria view save mybugs -- --assigned-to sam --type bug --status open
+  alexandria view save due-soon --personal -- --due-within 7d --sort due -o summary
+  alexandria list --view mybugs`,
+	Args: cobra.MinimumNArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name, listArgs := args[0], args[1:]
+		logger.Log.Debug("saving view", "name", name, "args", listArgs, "personal", viewPersonal)
+
+		if _, err := listArgFlags(listCmd, listArgs); err != nil {
+			logger.Log.Error("validation failed", "error", err)
+			return err
+		}
+
+		db, err := sqlDB(cmd)
+		if err != nil {
+			return err
+		}
+
+		v := &views.View{Name: name, Args: listArgs, Scope: views.ScopeShared, CreatedBy: config.CurrentUser()}
+		if viewPersonal {
+			v.Scope = views.ScopePersonal
+		}
+
+		if err := views.Save(db, v); err != nil {
+			logger.Log.Error("failed to save view", "error", err, "name", name)
+			return fmt.Errorf("failed to save view: %w", err)
+		}
+
+		fmt.Printf("Saved %s view '%s': list %s\n", v.Scope, name, strings.Join(listArgs, " "))
+		return nil
+	},
+}
+
+var viewListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List saved views",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		db, err := sqlDB(cmd)
+		if err != nil {
+			return err
+		}
+
+		all, err := views.List(db)
+		if err != nil {
+			logger.Log.Error("failed to list views", "error", err)
+			return fmt.Errorf("failed to list views: %w", err)
+		}
+
+		if len(all) == 0 {
+			fmt.Println("No saved views.")
+			return nil
+		}
+
+		fmt.Printf("%-20s %-10s %s\n", "NAME", "SCOPE", "ARGUMENTS")
+		fmt.Println(strings.Repeat("-", 80))
+		for _, v := range all {
+			fmt.Printf("%-20s %-10s %s\n", v.Name, v.Scope, strings.Join(v.Args, " "))
+		}
+		return nil
+	},
+}
+
+var viewRmCmd = &cobra.Command{
+	Use:   "rm <name>",
+	Short: "Delete a saved view",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		db, err := sqlDB(cmd)
+		if err != nil {
+			return err
+		}
+
+		scope := views.ScopeShared
+		if viewPersonal {
+			scope = views.ScopePersonal
+		}
+
+		if err := views.Delete(db, args[0], scope); err != nil {
+			logger.Log.Error("failed to delete view", "error", err, "name", args[0])
+			return fmt.Errorf("failed to delete view: %w", err)
+		}
+
+		fmt.Printf("Deleted %s view '%s'\n", scope, args[0])
+		return nil
+	},
+}
+
+func init() {
+	viewCmd.AddCommand(viewSaveCmd)
+	viewCmd.AddCommand(viewListCmd)
+	viewCmd.AddCommand(viewRmCmd)
+
+	viewSaveCmd.Flags().BoolVar(&viewPersonal, "personal", false, "Store the view in your config instead of the database")
+	viewRmCmd.Flags().BoolVar(&viewPersonal, "personal", false, "Delete a personal view instead of a shared one")
+}
+
+// listArgFlags checks that args only contains flags understood by the list command
+// and returns their long names
+func listArgFlags(list *cobra.Command, args []string) ([]string, error) {
+	var names []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if !strings.HasPrefix(arg, "-") || arg == "-" || arg == "--" {
+			return nil, fmt.Errorf("unexpected argument in view: %s (only list flags can be saved)", arg)
+		}
+
+		name, _, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		flag := list.Flags().Lookup(name)
+		if flag == nil && !strings.HasPrefix(arg, "--") && len(name) == 1 {
+			flag = list.Flags().ShorthandLookup(name)
+		}
+		if flag == nil {
+			return nil, fmt.Errorf("unknown list flag in view: %s", arg)
+		}
+		if flag.Name == "view" {
+			return nil, fmt.Errorf("a saved view cannot refer to another view")
+		}
+
+		// Non-boolean flags take the next argument as their value
+		if !hasValue && flag.Value.Type() != "bool" {
+			if i+1 >= len(args) {
+				return nil, fmt.Errorf("flag %s needs a value", arg)
+			}
+			i++
+		}
+		names = append(names, flag.Name)
+	}
+	return names, nil
+}
@@ -5,28 +5,40 @@ import (
 	"alexandria/internal/database"
 	"alexandria/internal/logger"
 	"fmt"
+	"net/url"
 	"os"
+	"regexp"
 
 	"github.com/spf13/cobra"
 )
 
-var showStatus bool
+var (
+	showStatus   bool
+	migrateFrom  string
+	migrateTo    string
+	migrateMerge bool
+)
 
 var switchCmd = &cobra.Command{
-	Use:   "source [sqlite|turso]",
-	Short: "Switch between SQLite and Turso databases",
-	Long: `Switch the active database between local SQLite and Turso cloud database.
+	Use:   "source [sqlite|turso|sync|postgres]",
+	Short: "Switch between SQLite, Turso, synced and PostgreSQL databases",
+	Long: `Switch the active database between local SQLite, Turso cloud database, a local
+replica that syncs with Turso (see "alexandria sync"), and PostgreSQL.
 
 Examples:
   alexandria source sqlite   # Switch to local SQLite database
   alexandria source turso    # Switch to Turso cloud database
-  alexandria source --status # Show current database configuration`,
+  alexandria source sync     # Work offline against a replica synced with Turso
+  alexandria source postgres # Switch to the PostgreSQL database in POSTGRES_DSN
+  alexandria source --status # Show current database configuration
+  alexandria source migrate --from sqlite --to turso  # Copy tickets to Turso`,
+	Annotations: map[string]string{noDatabase: ""},
 	Args: func(cmd *cobra.Command, args []string) error {
 		if showStatus {
 			return nil
 		}
 		if len(args) != 1 {
-			return fmt.Errorf("requires exactly one argument: sqlite or turso")
+			return fmt.Errorf("requires exactly one argument: sqlite, turso or sync")
 		}
 		return nil
 	},
@@ -40,19 +52,9 @@ Examples:
 		dbType := args[0]
 		logger.Log.Debug("switching database", "target", dbType)
 
-		// Validate database type
-		if dbType != config.DBTypeSQLite && dbType != config.DBTypeTurso {
-			logger.Log.Error("invalid database type", "type", dbType)
-			return fmt.Errorf("invalid database type: %s (must be sqlite or turso)", dbType)
-		}
-
-		// If switching to Turso, validate environment variables
-		if dbType == config.DBTypeTurso {
-			logger.Log.Debug("validating Turso environment variables")
-			if err := validateTursoEnv(); err != nil {
-				logger.Log.Error("Turso environment validation failed", "error", err)
-				return err
-			}
+		// Validate database type and the environment variables it needs
+		if err := validateSource(dbType); err != nil {
+			return err
 		}
 
 		// Switch the database
@@ -73,35 +75,151 @@ Examples:
 
 		// Reinitialize database with new configuration
 		logger.Log.Debug("initializing new database connection", "database_type", dbType)
-		if err := database.Init(""); err != nil {
+		if err := database.Init("", dbPath); err != nil {
 			logger.Log.Error("failed to initialize database", "error", err, "database_type", dbType)
 			return fmt.Errorf("failed to connect to %s database: %w", dbType, err)
 		}
 
 		logger.Log.Info("successfully switched database", "database_type", dbType)
 		fmt.Printf("Successfully switched to %s database.\n", dbType)
+		if dbType == config.DBTypeSync {
+			fmt.Println("Run 'alexandria sync' to fetch tickets from Turso.")
+		}
+		if dir, path, err := config.LoadDirFromWorkingDir(); err == nil && dir.DatabaseType != "" && dir.DatabaseType != dbType {
+			fmt.Printf("Note: %s selects the %s database, which takes precedence in this directory.\n", path, dir.DatabaseType)
+		}
+
+		return nil
+	},
+}
+
+var migrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Copy tickets and users from one database source to another",
+	Long: `Copy tickets with their tags, files, comments, worklogs, history and linked commits,
+along with users and shared views, from one database source to another. IDs and
+timestamps are kept, and everything is copied in one transaction.
+
+The target must be empty unless --merge is given. When merging, rows already in the
+target are skipped, and the migration stops if a ticket or user ID is taken by a
+different record.
+
+The active source is not changed; switch to the target afterwards with
+"alexandria source <target>".
+
+--from and --to take a database type or the name of a profile.
+
+Examples:
+  alexandria source migrate --from sqlite --to turso
+  alexandria source migrate --from turso --to sqlite --merge
+  alexandria source migrate --from personal --to team`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Load()
+		if err != nil {
+			logger.Log.Error("failed to load config", "error", err)
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+
+		var profiles []config.Profile
+		for _, name := range []string{migrateFrom, migrateTo} {
+			p, err := cfg.LookupSource(name)
+			if err != nil {
+				logger.Log.Error("invalid migration source", "error", err, "source", name)
+				return err
+			}
+			if err := validateProfile(p); err != nil {
+				return err
+			}
+			profiles = append(profiles, p)
+		}
+		if migrateFrom == migrateTo {
+			logger.Log.Error("validation failed", "error", "source and target are the same", "type", migrateFrom)
+			return fmt.Errorf("--from and --to must be different")
+		}
+
+		src, err := database.Open(profiles[0])
+		if err != nil {
+			return fmt.Errorf("failed to open %s database: %w", migrateFrom, err)
+		}
+		defer src.Close()
+
+		dst, err := database.Open(profiles[1])
+		if err != nil {
+			return fmt.Errorf("failed to open %s database: %w", migrateTo, err)
+		}
+		defer dst.Close()
+
+		fmt.Printf("Migrating from %s to %s...\n", migrateFrom, migrateTo)
+		counts, err := database.Migrate(src, dst, migrateMerge)
+		if err != nil {
+			return fmt.Errorf("migration failed: %w", err)
+		}
 
+		fmt.Printf("%-24s %8s %8s %8s\n", "TABLE", "SOURCE", "COPIED", "SKIPPED")
+		for _, c := range counts {
+			fmt.Printf("%-24s %8d %8d %8d\n", c.Table, c.Source, c.Copied, c.Skipped)
+		}
+		if profiles[1].Name != "" {
+			fmt.Printf("\nMigration complete. Run 'alexandria profile use %s' to use the %s database.\n", migrateTo, migrateTo)
+		} else {
+			fmt.Printf("\nMigration complete. Run 'alexandria source %s' to use the %s database.\n", migrateTo, migrateTo)
+		}
 		return nil
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(switchCmd)
+	switchCmd.AddCommand(migrateCmd)
 	switchCmd.Flags().BoolVar(&showStatus, "status", false, "Show current database configuration")
+
+	migrateCmd.Flags().StringVar(&migrateFrom, "from", "", "Database to copy from: sqlite, turso, sync, postgres or a profile (required)")
+	migrateCmd.Flags().StringVar(&migrateTo, "to", "", "Database to copy to: sqlite, turso, sync, postgres or a profile (required)")
+	migrateCmd.Flags().BoolVar(&migrateMerge, "merge", false, "Add to a target that already has data")
+	migrateCmd.MarkFlagRequired("from")
+	migrateCmd.MarkFlagRequired("to")
+}
+
+// validateSource checks a database type is supported and its environment variables are set
+func validateSource(dbType string) error {
+	if !config.ValidDBType(dbType) {
+		logger.Log.Error("invalid database type", "type", dbType)
+		return fmt.Errorf("invalid database type: %s (must be sqlite, turso, sync or postgres)", dbType)
+	}
+	return validateProfile(config.Profile{Type: dbType})
 }
 
-// validateTursoEnv checks if required Turso environment variables are set
-func validateTursoEnv() error {
-	tursoURL := os.Getenv("TURSO_URL")
-	tursoToken := os.Getenv("TURSO_AUTH_TOKEN")
+// validateProfile checks the credentials a profile needs are available
+func validateProfile(p config.Profile) error {
+	switch p.Type {
+	case config.DBTypeTurso, config.DBTypeSync:
+		logger.Log.Debug("validating Turso settings", "profile", p.Name)
+		if err := validateTursoEnv(p); err != nil {
+			logger.Log.Error("Turso environment validation failed", "error", err)
+			return err
+		}
+	case config.DBTypePostgres:
+		if p.PostgresDSN() == "" {
+			logger.Log.Error("Postgres environment variable missing", "variable", "POSTGRES_DSN")
+			return fmt.Errorf("POSTGRES_DSN environment variable is not set")
+		}
+	}
+	return nil
+}
+
+// validateTursoEnv checks the Turso URL and auth token of a profile are set
+func validateTursoEnv(p config.Profile) error {
+	tursoURL := p.TursoURL()
+	tursoToken := p.TursoToken()
 
 	if tursoURL == "" {
 		logger.Log.Error("Turso environment variable missing", "variable", "TURSO_URL")
 		return fmt.Errorf("TURSO_URL environment variable is not set")
 	}
 	if tursoToken == "" {
-		logger.Log.Error("Turso environment variable missing", "variable", "TURSO_AUTH_TOKEN")
-		return fmt.Errorf("TURSO_AUTH_TOKEN environment variable is not set")
+		logger.Log.Error("Turso environment variable missing", "variable", p.TokenVariable())
+		return fmt.Errorf("%s environment variable is not set", p.TokenVariable())
 	}
 
 	logger.Log.Debug("Turso environment variables validated", "url", tursoURL)
@@ -110,20 +228,43 @@ func validateTursoEnv() error {
 
 // showDatabaseStatus displays the current database configuration
 func showDatabaseStatus() error {
-	cfg, err := config.Load()
+	cfg, err := config.Resolve()
 	if err != nil {
 		logger.Log.Error("failed to load config", "error", err)
 		return fmt.Errorf("failed to load config: %w", err)
 	}
 
-	logger.Log.Debug("loaded database config", "type", cfg.DatabaseType)
+	p, err := cfg.ActiveProfile(profileName)
+	if err != nil {
+		return err
+	}
+
+	logger.Log.Debug("loaded database config", "type", p.Type, "profile", p.Name)
 
 	fmt.Println("Current Database Configuration:")
 	fmt.Println("================================")
-	fmt.Printf("Database Type: %s\n", cfg.DatabaseType)
+	if p.Name != "" {
+		fmt.Printf("Profile: %s\n", p.Name)
+	}
+	fmt.Printf("Database Type: %s\n", p.Type)
+	switch {
+	case dbPath != "":
+		fmt.Printf("Database Path: %s (from --db)\n", dbPath)
+	case p.Path != "":
+		fmt.Printf("Database Path: %s\n", p.Path)
+	case (p.Type == config.DBTypeSQLite || p.Type == config.DBTypeSync) && os.Getenv(database.DBPathEnv) != "":
+		fmt.Printf("Database Path: %s (from %s)\n", os.Getenv(database.DBPathEnv), database.DBPathEnv)
+	}
+
+	if _, path, err := config.LoadDirFromWorkingDir(); err == nil && path != "" {
+		fmt.Printf("Directory Config: %s\n", path)
+		if cfg.Project != "" {
+			fmt.Printf("Default Project: %s\n", cfg.Project)
+		}
+	}
 
-	if cfg.DatabaseType == config.DBTypeTurso {
-		tursoURL := os.Getenv("TURSO_URL")
+	if p.Type == config.DBTypeTurso || p.Type == config.DBTypeSync {
+		tursoURL := p.TursoURL()
 		if tursoURL != "" {
 			fmt.Printf("Turso URL: %s\n", tursoURL)
 		} else {
@@ -131,19 +272,41 @@ func showDatabaseStatus() error {
 			logger.Log.Warn("Turso URL not set")
 		}
 
-		tursoToken := os.Getenv("TURSO_AUTH_TOKEN")
+		tursoToken := p.TursoToken()
 		if tursoToken != "" {
 			// Show only first few characters of the token for security
 			tokenPreview := tursoToken
 			if len(tursoToken) > 8 {
 				tokenPreview = tursoToken[:8] + "..."
 			}
-			fmt.Printf("Turso Token: %s\n", tokenPreview)
+			fmt.Printf("Turso Token: %s (from %s)\n", tokenPreview, p.TokenVariable())
 		} else {
-			fmt.Println("Warning: TURSO_AUTH_TOKEN environment variable is not set")
-			logger.Log.Warn("Turso auth token not set")
+			fmt.Printf("Warning: %s environment variable is not set\n", p.TokenVariable())
+			logger.Log.Warn("Turso auth token not set", "variable", p.TokenVariable())
+		}
+	}
+
+	if p.Type == config.DBTypePostgres {
+		if dsn := p.PostgresDSN(); dsn != "" {
+			fmt.Printf("Postgres DSN: %s\n", redactDSN(dsn))
+		} else {
+			fmt.Println("Warning: POSTGRES_DSN environment variable is not set")
+			logger.Log.Warn("Postgres DSN not set")
 		}
 	}
 
 	return nil
 }
+
+// redactDSN hides the password in a PostgreSQL connection string
+func redactDSN(dsn string) string {
+	if u, err := url.Parse(dsn); err == nil && u.User != nil {
+		if _, ok := u.User.Password(); ok {
+			u.User = url.UserPassword(u.User.Username(), "xxxxx")
+		}
+		return u.String()
+	}
+	return passwordField.ReplaceAllString(dsn, "password=xxxxx")
+}
+
+var passwordField = regexp.MustCompile(`password=\S+`)
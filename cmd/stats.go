@@ -0,0 +1,142 @@
+package cmd
+
+import (
+	"alexandria/internal/logger"
+	"alexandria/internal/report"
+	"alexandria/internal/ticket"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	statsProject string
+	statsWeeks   int
+	statsOldest  int
+	statsOutput  string
+)
+
+var statsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Show a statistics dashboard for a project",
+	Long: `Show ticket counts by status, type, priority and assignee, open critical-path
+tickets, tickets opened and closed per week, the median age of open tickets and the
+open tickets that have gone longest without an update.
+
+Examples:
+  alexandria stats --project Alexandria
+  alexandria stats --project Alexandria --weeks 8 -o json`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		logger.Log.Debug("building stats", "project", statsProject, "weeks", statsWeeks)
+
+		if err := applyDefaultProject(&statsProject); err != nil {
+			return err
+		}
+		if statsProject == "" {
+			logger.Log.Error("validation failed", "error", "project is required")
+			return errNoProject
+		}
+		if statsWeeks < 1 {
+			return fmt.Errorf("--weeks must be at least 1")
+		}
+		if statsOldest < 0 {
+			return fmt.Errorf("--oldest cannot be negative")
+		}
+
+		store, err := ticketStore(cmd)
+		if err != nil {
+			return err
+		}
+		ctx := cmd.Context()
+
+		tickets, err := store.List(ctx, ticket.Filters{Project: &statsProject})
+		if err != nil {
+			logger.Log.Error("failed to list tickets", "error", err)
+			return fmt.Errorf("failed to list tickets: %w", err)
+		}
+
+		stats := report.BuildStats(statsProject, tickets, time.Now(), statsWeeks, statsOldest)
+
+		switch statsOutput {
+		case "json":
+			jsonData, err := json.MarshalIndent(stats, "", "  ")
+			if err != nil {
+				logger.Log.Error("failed to marshal stats", "error", err)
+				return fmt.Errorf("failed to marshal stats: %w", err)
+			}
+			fmt.Println(string(jsonData))
+		case "table":
+			printStats(stats)
+		default:
+			logger.Log.Error("invalid output format", "format", statsOutput)
+			return fmt.Errorf("invalid output format: %s (must be: json or table)", statsOutput)
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(statsCmd)
+
+	statsCmd.Flags().StringVar(&statsProject, "project", "", projectFlagUsage)
+	statsCmd.Flags().IntVar(&statsWeeks, "weeks", 4, "Number of weeks of opened/closed history")
+	statsCmd.Flags().IntVar(&statsOldest, "oldest", 5, "Number of untouched open tickets to list")
+	statsCmd.Flags().StringVarP(&statsOutput, "output", "o", "table", "Output format (json, table)")
+}
+
+// printStats prints the dashboard as text
+func printStats(s *report.Stats) {
+	fmt.Printf("Statistics for project %s (%d ticket(s))\n", s.Project, s.Total)
+
+	printCounts("By status", s.ByStatus)
+	printCounts("By type", s.ByType)
+	printCounts("By priority", s.ByPriority)
+	printCounts("By assignee", s.ByAssignee)
+
+	fmt.Printf("\nOpen critical-path tickets: %d\n", s.OpenCriticalPath)
+	fmt.Printf("Median age of open tickets: %.1f day(s)\n", s.MedianOpenAgeDays)
+
+	fmt.Printf("\n%-10s %8s %8s\n", "WEEK", "OPENED", "CLOSED")
+	fmt.Println(strings.Repeat("-", 28))
+	for _, w := range s.Weekly {
+		fmt.Printf("%-10s %8d %8d\n", w.Week, w.Opened, w.Closed)
+	}
+
+	if len(s.OldestUntouched) > 0 {
+		fmt.Println("\nOldest untouched tickets:")
+		fmt.Printf("%-6s %-35s %-13s %-12s %s\n", "ID", "TITLE", "STATUS", "UPDATED", "IDLE")
+		fmt.Println(strings.Repeat("-", 78))
+		for _, t := range s.OldestUntouched {
+			title := t.Title
+			if len(title) > 35 {
+				title = title[:32] + "..."
+			}
+			fmt.Printf("%-6d %-35s %-13s %-12s %dd\n", t.ID, title, t.Status, t.UpdatedAt.Format("2006-01-02"), t.IdleDays)
+		}
+	}
+}
+
+// printCounts prints a labelled set of counts, largest first
+func printCounts(label string, counts map[string]int) {
+	keys := make([]string, 0, len(counts))
+	for k := range counts {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if counts[keys[i]] != counts[keys[j]] {
+			return counts[keys[i]] > counts[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+
+	fmt.Printf("\n%s:\n", label)
+	for _, k := range keys {
+		fmt.Printf("  %-20s %d\n", k, counts[k])
+	}
+}
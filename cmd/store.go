@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"alexandria/internal/logger"
+	"alexandria/internal/notify"
+	"alexandria/internal/ticket"
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+// deps holds what commands read and write through. The root command fills it in from
+// the configured database unless the caller has already supplied one, which lets
+// tests run commands against ticket.MemoryStore.
+type deps struct {
+	tickets  ticket.TicketStore
+	db       *sql.DB       // nil when tickets is not backed by a database
+	notifier *notify.Store // set when email notifications are turned on for any project
+}
+
+type depsKey struct{}
+
+// withDeps returns a context carrying d, for use with rootCmd.ExecuteContext
+func withDeps(ctx context.Context, d *deps) context.Context {
+	return context.WithValue(ctx, depsKey{}, d)
+}
+
+// depsFrom returns the dependencies stored in the command's context, or nil
+func depsFrom(cmd *cobra.Command) *deps {
+	if ctx := cmd.Context(); ctx != nil {
+		if d, ok := ctx.Value(depsKey{}).(*deps); ok {
+			return d
+		}
+	}
+	return nil
+}
+
+// ticketStore returns the store commands should use for tickets
+func ticketStore(cmd *cobra.Command) (ticket.TicketStore, error) {
+	d := depsFrom(cmd)
+	if d == nil || d.tickets == nil {
+		logger.Log.Error("database not initialized")
+		return nil, fmt.Errorf("database not initialized")
+	}
+	return d.tickets, nil
+}
+
+// sqlDB returns the database for commands that work with tables directly, such as
+// saved views and sync, which have no in-memory equivalent
+func sqlDB(cmd *cobra.Command) (*sql.DB, error) {
+	d := depsFrom(cmd)
+	if d == nil || d.db == nil {
+		logger.Log.Error("database not initialized")
+		return nil, fmt.Errorf("database not initialized")
+	}
+	return d.db, nil
+}
+
+// noDatabase marks a command, through its annotations, as one that runs without a
+// database connection
+const noDatabase = "no-database"
+
+// needsDatabase reports whether the command, or a command it belongs to, needs the
+// database opened before it runs
+func needsDatabase(cmd *cobra.Command) bool {
+	for c := cmd; c != nil; c = c.Parent() {
+		if _, ok := c.Annotations[noDatabase]; ok {
+			return false
+		}
+	}
+	return true
+}
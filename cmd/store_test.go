@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"alexandria/internal/notify"
+	"alexandria/internal/testdb"
+	"alexandria/internal/ticket"
+	"alexandria/internal/users"
+	"context"
+	"testing"
+)
+
+// run executes the CLI against store. Flag values persist between runs, as they
+// would not in the real binary, so tests pass every flag they rely on. Cobra only
+// hands the context down to a subcommand that has none yet, so run sets it on the
+// subcommand itself rather than leaving it with an earlier test's store.
+func run(t *testing.T, store ticket.TicketStore, args ...string) error {
+	t.Helper()
+	ctx := withDeps(context.Background(), &deps{tickets: store})
+	if sub, _, err := rootCmd.Find(args); err == nil {
+		sub.SetContext(ctx)
+	}
+	rootCmd.SetArgs(args)
+	return rootCmd.ExecuteContext(ctx)
+}
+
+func TestCommandsUseInjectedStore(t *testing.T) {
+	store := ticket.NewMemoryStore()
+	ctx := context.Background()
+
+	if err := run(t, store, "create", "--project", "mem", "--title", "In memory", "--priority", "high"); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	tk, err := store.Find(ctx, "mem", 0, "In memory")
+	if err != nil {
+		t.Fatalf("ticket was not created in the injected store: %v", err)
+	}
+	if tk.Priority != ticket.PriorityHigh {
+		t.Errorf("priority = %s, want high", tk.Priority)
+	}
+
+	if err := run(t, store, "update", "--project", "mem", "--title", "In memory", "--status", "closed"); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if tk, _ = store.Get(ctx, tk.ID); tk.Status != ticket.StatusClosed {
+		t.Errorf("status = %s, want closed", tk.Status)
+	}
+
+	// Comments are added once each, however often the ticket is updated
+	if err := run(t, store, "update", "--project", "mem", "--title", "In memory", "--status", "closed", "--comments", "one,two"); err != nil {
+		t.Fatalf("update with comments: %v", err)
+	}
+	if tk, _ = store.Get(ctx, tk.ID); len(tk.Comments) != 2 || tk.Comments[1] != "two" {
+		t.Errorf("comments = %v, want [one two]", tk.Comments)
+	}
+
+	if err := run(t, store, "delete", "--project", "mem", "--title", "In memory"); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if _, err := store.Get(ctx, tk.ID); err == nil {
+		t.Error("ticket still exists after delete")
+	}
+}
+
+func TestUpdateCommentsNotify(t *testing.T) {
+	t.Setenv("ALEXANDRIA_USER", "alice")
+	db := testdb.Open(t)
+	if err := users.Save(db, &users.User{Username: "bob", Email: "bob@example.com", Role: users.RoleUser}); err != nil {
+		t.Fatalf("failed to add user: %v", err)
+	}
+	store := notify.NewStore(ticket.NewSQLStore(db), db, func(string) bool { return true })
+	ctx := ticket.WithActor(context.Background(), "alice")
+
+	assignee := "bob"
+	tk := &ticket.Ticket{Title: "Noisy", Type: ticket.TypeBug, Status: ticket.StatusOpen, Priority: ticket.PriorityLow, AssignedTo: &assignee}
+	if err := store.Create(ctx, "mail", tk); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	assigned := store.Queued()
+
+	if err := run(t, store, "update", "--project", "mail", "--title", "Noisy", "--status", "open", "--comments", "one,two"); err != nil {
+		t.Fatalf("update with comments: %v", err)
+	}
+	// Bob hears about each comment
+	if got := store.Queued() - assigned; got != 2 {
+		t.Errorf("queued %d notifications for the comments, want 2", got)
+	}
+}
+
+func TestDatabaseOnlyCommandsNeedDatabase(t *testing.T) {
+	err := run(t, ticket.NewMemoryStore(), "view", "list")
+	if err == nil {
+		t.Fatal("expected saved views to require a database")
+	}
+}
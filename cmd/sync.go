@@ -0,0 +1,212 @@
+package cmd
+
+import (
+	"alexandria/internal/config"
+	"alexandria/internal/database"
+	"alexandria/internal/dbsync"
+	"alexandria/internal/logger"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	syncStatus bool
+	syncKeep   string
+	syncOutput string
+)
+
+var syncCmd = &cobra.Command{
+	Use:   "sync",
+	Short: "Push local changes to Turso and pull remote changes",
+	Long: `Synchronise the local replica with Turso. Requires the sync source
+("alexandria source sync").
+
+Changes made while offline are kept in an outbox and pushed in order. A field
+changed both locally and remotely since the last sync is reported as a conflict;
+other fields of the same ticket still sync. Conflicted tickets keep their local
+version until resolved with "alexandria sync resolve".
+
+Tickets created offline may get a new ID when pushed, which is reported.
+
+Examples:
+  alexandria sync
+  alexandria sync --status
+  alexandria sync resolve ALX-42 --keep remote`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		local, err := replicaDB(cmd)
+		if err != nil {
+			return err
+		}
+
+		if syncStatus {
+			status, err := dbsync.GetStatus(local)
+			if err != nil {
+				return fmt.Errorf("failed to read sync status: %w", err)
+			}
+			return printSyncStatus(status)
+		}
+
+		remote, err := database.OpenRemote()
+		if err != nil {
+			status, statusErr := dbsync.GetStatus(local)
+			if statusErr == nil {
+				fmt.Printf("Remote unreachable; %d change(s) kept in the outbox.\n", status.Pending)
+			}
+			logger.Log.Error("failed to connect to remote", "error", err)
+			return fmt.Errorf("failed to connect to remote: %w", err)
+		}
+		defer remote.Close()
+
+		res, err := dbsync.Sync(local, remote)
+		if res != nil {
+			printRenumbered(res.Renumbered)
+		}
+		if err != nil {
+			logger.Log.Error("sync failed", "error", err)
+			return fmt.Errorf("sync failed: %w", err)
+		}
+
+		if syncOutput == "json" {
+			jsonData, err := json.MarshalIndent(res, "", "  ")
+			if err != nil {
+				logger.Log.Error("failed to marshal sync result", "error", err)
+				return fmt.Errorf("failed to marshal sync result: %w", err)
+			}
+			fmt.Println(string(jsonData))
+			return nil
+		}
+
+		fmt.Printf("Pushed %d change(s), pulled %d row(s).\n", res.Pushed, res.Pulled)
+		printConflicts(res.Conflicts)
+		return nil
+	},
+}
+
+var syncResolveCmd = &cobra.Command{
+	Use:   "resolve <ref>",
+	Short: "Resolve sync conflicts on a ticket",
+	Long: `Resolve the sync conflicts held against a ticket by keeping either the local or the
+remote value of every conflicting field. Run "alexandria sync" afterwards to finish.
+
+Examples:
+  alexandria sync resolve ALX-42 --keep local
+  alexandria sync resolve 42 --keep remote`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if syncKeep != dbsync.KeepLocal && syncKeep != dbsync.KeepRemote {
+			logger.Log.Error("validation failed", "error", "invalid keep", "keep", syncKeep)
+			return fmt.Errorf("--keep must be %s or %s", dbsync.KeepLocal, dbsync.KeepRemote)
+		}
+
+		local, err := replicaDB(cmd)
+		if err != nil {
+			return err
+		}
+
+		// The ticket may have been deleted remotely, so only the reference is parsed
+		id, err := parseRefOnly(args[0])
+		if err != nil {
+			return err
+		}
+
+		remote, err := database.OpenRemote()
+		if err != nil {
+			logger.Log.Error("failed to connect to remote", "error", err)
+			return fmt.Errorf("failed to connect to remote: %w", err)
+		}
+		defer remote.Close()
+
+		n, err := dbsync.Resolve(local, remote, id, syncKeep)
+		if err != nil {
+			return fmt.Errorf("failed to resolve conflicts: %w", err)
+		}
+
+		fmt.Printf("Resolved %d conflict(s) on ticket %d keeping the %s version. Run 'alexandria sync' to finish.\n", n, id, syncKeep)
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(syncCmd)
+	syncCmd.AddCommand(syncResolveCmd)
+
+	syncCmd.Flags().BoolVar(&syncStatus, "status", false, "Show pending changes and conflicts without syncing")
+	syncCmd.Flags().StringVarP(&syncOutput, "output", "o", "summary", "Output format (json, summary)")
+	syncResolveCmd.Flags().StringVar(&syncKeep, "keep", "", "Version to keep: local or remote (required)")
+	syncResolveCmd.MarkFlagRequired("keep")
+}
+
+// replicaDB returns the local database, checking that sync mode is active
+func replicaDB(cmd *cobra.Command) (*sql.DB, error) {
+	// The connection may come from --profile, so ask the database rather than the config
+	dbType := database.Active().Type
+	if dbType != config.DBTypeSync {
+		logger.Log.Error("sync mode not active", "database_type", dbType)
+		return nil, fmt.Errorf("sync requires the sync source (current source is %s); run 'alexandria source sync' or use a sync profile", dbType)
+	}
+
+	return sqlDB(cmd)
+}
+
+// printSyncStatus shows the outbox and any conflicts
+func printSyncStatus(status *dbsync.Status) error {
+	if syncOutput == "json" {
+		jsonData, err := json.MarshalIndent(status, "", "  ")
+		if err != nil {
+			logger.Log.Error("failed to marshal sync status", "error", err)
+			return fmt.Errorf("failed to marshal sync status: %w", err)
+		}
+		fmt.Println(string(jsonData))
+		return nil
+	}
+
+	if status.LastSync != nil {
+		fmt.Printf("Last sync: %s\n", status.LastSync.Local().Format("2006-01-02 15:04"))
+	} else {
+		fmt.Println("Last sync: never")
+	}
+	fmt.Printf("Pending changes: %d\n", status.Pending)
+	printConflicts(status.Conflicts)
+	return nil
+}
+
+// printRenumbered reports tickets that were given a new ID by the remote
+func printRenumbered(ids map[int64]int64) {
+	locals := make([]int64, 0, len(ids))
+	for id := range ids {
+		locals = append(locals, id)
+	}
+	sort.Slice(locals, func(i, j int) bool { return locals[i] < locals[j] })
+	for _, id := range locals {
+		fmt.Printf("Ticket %d created offline is now ticket %d\n", id, ids[id])
+	}
+}
+
+// printConflicts lists conflicting fields with their base, local and remote values
+func printConflicts(conflicts []dbsync.Conflict) {
+	if len(conflicts) == 0 {
+		return
+	}
+
+	fmt.Printf("\nConflicts (%d):\n", len(conflicts))
+	for _, c := range conflicts {
+		fmt.Printf("  ticket %d (%s): %s\n", c.TicketID, c.Table, c.Reason)
+		for _, f := range c.Fields {
+			fmt.Printf("    %s: base %s, local %s, remote %s\n", f.Field, quoteValue(f.Base), quoteValue(f.Local), quoteValue(f.Remote))
+		}
+	}
+	fmt.Println("\nResolve with: alexandria sync resolve <ref> --keep local|remote")
+}
+
+// quoteValue formats a nullable value for display
+func quoteValue(v *string) string {
+	if v == nil {
+		return "(none)"
+	}
+	return fmt.Sprintf("%q", *v)
+}
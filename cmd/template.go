@@ -0,0 +1,237 @@
+package cmd
+
+import (
+	"alexandria/internal/config"
+	"alexandria/internal/gitlink"
+	"alexandria/internal/logger"
+	"alexandria/internal/templates"
+	"alexandria/internal/ticket"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	templateProject         string
+	templateType            string
+	templatePriority        string
+	templateTags            string
+	templateDescription     string
+	templateDescriptionFile string
+)
+
+var templateCmd = &cobra.Command{
+	Use:   "template",
+	Short: "Manage ticket templates",
+	Long: `Templates hold the type, priority, tags and description skeleton for a kind of
+ticket, such as a bug report. They belong to a project and are stored in the
+database, so everyone using it shares them. Use one with "create --template".
+
+The description may contain placeholders, which are filled in when a ticket is
+created: {{user}}, {{date}}, {{branch}} (the current git branch) and {{project}}.
+
+Examples:
+  alexandria template add bug-report --project Backend --type bug --priority high \
+    --tags triage --description-file bug-report.md
+  alexandria template list --project Backend
+  alexandria create --project Backend --template bug-report --title "Login fails"`,
+}
+
+var templateAddCmd = &cobra.Command{
+	Use:   "add <name>",
+	Short: "Add or replace a project's template",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+		if err := applyDefaultProject(&templateProject); err != nil {
+			return err
+		}
+		if templateProject == "" {
+			logger.Log.Error("validation failed", "error", "project is required")
+			return errNoProject
+		}
+		logger.Log.Debug("adding template", "project", templateProject, "name", name)
+
+		if templateType != "" && !ticket.Type(templateType).Valid() {
+			logger.Log.Error("validation failed", "error", "invalid type", "type", templateType)
+			return fmt.Errorf("invalid type: %s (must be: bug, feature, or task)", templateType)
+		}
+		if templatePriority != "" && !ticket.Priority(templatePriority).Valid() {
+			logger.Log.Error("validation failed", "error", "invalid priority", "priority", templatePriority)
+			return fmt.Errorf("invalid priority: %s (must be: low, medium, or high)", templatePriority)
+		}
+
+		desc := templateDescription
+		if templateDescriptionFile != "" {
+			if desc != "" {
+				return fmt.Errorf("--description and --description-file cannot be used together")
+			}
+			data, err := os.ReadFile(templateDescriptionFile)
+			if err != nil {
+				logger.Log.Error("failed to read description file", "error", err, "path", templateDescriptionFile)
+				return fmt.Errorf("failed to read description file: %w", err)
+			}
+			desc = string(data)
+		}
+
+		db, err := sqlDB(cmd)
+		if err != nil {
+			return err
+		}
+
+		t := &templates.Template{
+			Project:     templateProject,
+			Name:        name,
+			Type:        templateType,
+			Priority:    templatePriority,
+			Tags:        splitTags(templateTags),
+			Description: desc,
+			CreatedBy:   config.CurrentUser(),
+		}
+		if err := templates.Save(db, t); err != nil {
+			logger.Log.Error("failed to save template", "error", err, "name", name)
+			return fmt.Errorf("failed to save template: %w", err)
+		}
+
+		fmt.Printf("Saved template '%s' for project '%s'\n", name, templateProject)
+		return nil
+	},
+}
+
+var templateListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List a project's templates",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := applyDefaultProject(&templateProject); err != nil {
+			return err
+		}
+		if templateProject == "" {
+			logger.Log.Error("validation failed", "error", "project is required")
+			return errNoProject
+		}
+
+		db, err := sqlDB(cmd)
+		if err != nil {
+			return err
+		}
+
+		all, err := templates.List(db, templateProject)
+		if err != nil {
+			logger.Log.Error("failed to list templates", "error", err)
+			return fmt.Errorf("failed to list templates: %w", err)
+		}
+
+		if len(all) == 0 {
+			fmt.Printf("No templates in project '%s'.\n", templateProject)
+			return nil
+		}
+
+		fmt.Printf("%-20s %-10s %-10s %s\n", "NAME", "TYPE", "PRIORITY", "TAGS")
+		fmt.Println(strings.Repeat("-", 80))
+		for _, t := range all {
+			fmt.Printf("%-20s %-10s %-10s %s\n", t.Name, t.Type, t.Priority, strings.Join(t.Tags, ","))
+		}
+		return nil
+	},
+}
+
+var templateShowCmd = &cobra.Command{
+	Use:   "show <name>",
+	Short: "Show a template, including its description skeleton",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := applyDefaultProject(&templateProject); err != nil {
+			return err
+		}
+		if templateProject == "" {
+			logger.Log.Error("validation failed", "error", "project is required")
+			return errNoProject
+		}
+
+		db, err := sqlDB(cmd)
+		if err != nil {
+			return err
+		}
+
+		t, err := templates.Get(db, templateProject, args[0])
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Template:  %s (project %s)\n", t.Name, t.Project)
+		fmt.Printf("Type:      %s\n", t.Type)
+		fmt.Printf("Priority:  %s\n", t.Priority)
+		fmt.Printf("Tags:      %s\n", strings.Join(t.Tags, ", "))
+		if t.Description != "" {
+			fmt.Println("\nDescription:")
+			fmt.Println(t.Description)
+		}
+		return nil
+	},
+}
+
+var templateRmCmd = &cobra.Command{
+	Use:   "rm <name>",
+	Short: "Delete a project's template",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := applyDefaultProject(&templateProject); err != nil {
+			return err
+		}
+		if templateProject == "" {
+			logger.Log.Error("validation failed", "error", "project is required")
+			return errNoProject
+		}
+
+		db, err := sqlDB(cmd)
+		if err != nil {
+			return err
+		}
+
+		if err := templates.Delete(db, templateProject, args[0]); err != nil {
+			logger.Log.Error("failed to delete template", "error", err, "name", args[0])
+			return fmt.Errorf("failed to delete template: %w", err)
+		}
+
+		fmt.Printf("Deleted template '%s' from project '%s'\n", args[0], templateProject)
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(templateCmd)
+	templateCmd.AddCommand(templateAddCmd)
+	templateCmd.AddCommand(templateListCmd)
+	templateCmd.AddCommand(templateShowCmd)
+	templateCmd.AddCommand(templateRmCmd)
+
+	templateCmd.PersistentFlags().StringVar(&templateProject, "project", "", projectFlagUsage)
+	templateAddCmd.Flags().StringVar(&templateType, "type", "", "Default ticket type (bug, feature, task)")
+	templateAddCmd.Flags().StringVarP(&templatePriority, "priority", "p", "", "Default priority (low, medium, high)")
+	templateAddCmd.Flags().StringVar(&templateTags, "tags", "", "Comma-separated list of default tags")
+	templateAddCmd.Flags().StringVarP(&templateDescription, "description", "d", "", "Description skeleton")
+	templateAddCmd.Flags().StringVar(&templateDescriptionFile, "description-file", "", "Read the description skeleton from a Markdown file")
+}
+
+// templateVars returns the placeholder values for a ticket created in project
+func templateVars(project, user string) map[string]string {
+	if user == "" {
+		user = config.CurrentUser()
+	}
+	// Outside a git repository {{branch}} expands to nothing
+	branch, err := gitlink.CurrentBranch()
+	if err != nil {
+		logger.Log.Debug("no git branch for template", "error", err)
+		branch = ""
+	}
+	return map[string]string{
+		templates.VarUser:    user,
+		templates.VarDate:    time.Now().Format("2006-01-02"),
+		templates.VarBranch:  branch,
+		templates.VarProject: project,
+	}
+}
@@ -0,0 +1,182 @@
+package cmd
+
+import (
+	"alexandria/internal/config"
+	"alexandria/internal/dates"
+	"alexandria/internal/logger"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	timesheetWeek     bool
+	timesheetSince    string
+	timesheetUser     string
+	timesheetAllUsers bool
+	timesheetOutput   string
+)
+
+// timesheetRow is the time logged against a single ticket
+type timesheetRow struct {
+	TicketID int64   `json:"ticket_id"`
+	Project  string  `json:"project"`
+	Title    string  `json:"title"`
+	Hours    float64 `json:"hours"`
+}
+
+// timesheetReport is the JSON form of a timesheet
+type timesheetReport struct {
+	User     string             `json:"user,omitempty"`
+	From     time.Time          `json:"from"`
+	To       time.Time          `json:"to"`
+	Tickets  []timesheetRow     `json:"tickets"`
+	Projects map[string]float64 `json:"projects"`
+	Total    float64            `json:"total_hours"`
+}
+
+var timesheetCmd = &cobra.Command{
+	Use:   "timesheet",
+	Short: "Report hours logged per ticket and project",
+	Long: `Summarise worklogs as hours per ticket and per project. Defaults to the current
+week (starting Monday) for the current user.
+
+Examples:
+  alexandria timesheet --week
+  alexandria timesheet --since 2026-10-01 --all-users
+  alexandria timesheet --user sam -o json`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		now := time.Now()
+		from := dates.StartOfWeek(now)
+		if timesheetSince != "" {
+			if timesheetWeek {
+				return fmt.Errorf("--week and --since cannot be used together")
+			}
+			var err error
+			from, err = dates.Parse(timesheetSince, now)
+			if err != nil {
+				logger.Log.Error("validation failed", "error", err, "since", timesheetSince)
+				return fmt.Errorf("invalid --since: %w", err)
+			}
+		}
+
+		user := timesheetUser
+		if user == "" && !timesheetAllUsers {
+			user = config.CurrentUser()
+		}
+		logger.Log.Debug("building timesheet", "user", user, "from", from)
+
+		store, err := ticketStore(cmd)
+		if err != nil {
+			return err
+		}
+		ctx := cmd.Context()
+
+		logs, err := store.ListWorklogs(ctx, 0, user, from)
+		if err != nil {
+			logger.Log.Error("failed to load worklogs", "error", err)
+			return fmt.Errorf("failed to load worklogs: %w", err)
+		}
+
+		// Aggregate by ticket, then by project
+		perTicket := make(map[int64]time.Duration)
+		for _, w := range logs {
+			perTicket[w.TicketID] += w.Duration()
+		}
+
+		report := timesheetReport{User: user, From: from, To: now, Projects: map[string]float64{}}
+		for id, d := range perTicket {
+			row := timesheetRow{TicketID: id, Hours: roundHours(d)}
+			if t, err := store.Get(ctx, id); err == nil {
+				row.Project = t.Project
+				row.Title = t.Title
+			} else {
+				row.Project = "(deleted)"
+			}
+			report.Tickets = append(report.Tickets, row)
+			report.Projects[row.Project] += row.Hours
+			report.Total += row.Hours
+		}
+		sort.Slice(report.Tickets, func(i, j int) bool {
+			if report.Tickets[i].Project != report.Tickets[j].Project {
+				return report.Tickets[i].Project < report.Tickets[j].Project
+			}
+			return report.Tickets[i].TicketID < report.Tickets[j].TicketID
+		})
+
+		switch timesheetOutput {
+		case "json":
+			jsonData, err := json.MarshalIndent(report, "", "  ")
+			if err != nil {
+				logger.Log.Error("failed to marshal timesheet", "error", err)
+				return fmt.Errorf("failed to marshal timesheet: %w", err)
+			}
+			fmt.Println(string(jsonData))
+		case "table":
+			printTimesheet(report)
+		default:
+			logger.Log.Error("invalid output format", "format", timesheetOutput)
+			return fmt.Errorf("invalid output format: %s (must be: json or table)", timesheetOutput)
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(timesheetCmd)
+
+	timesheetCmd.Flags().BoolVar(&timesheetWeek, "week", false, "Report the current week, starting Monday (the default without --since)")
+	timesheetCmd.Flags().StringVar(&timesheetSince, "since", "", "Report from a date instead of the current week (e.g. 2026-10-01, monday)")
+	timesheetCmd.Flags().StringVar(&timesheetUser, "user", "", "Report a different user (defaults to the current user)")
+	timesheetCmd.Flags().BoolVar(&timesheetAllUsers, "all-users", false, "Report time logged by every user")
+	timesheetCmd.Flags().StringVarP(&timesheetOutput, "output", "o", "table", "Output format (json, table)")
+}
+
+// roundHours converts a duration to hours rounded to two decimal places
+func roundHours(d time.Duration) float64 {
+	return float64(d.Round(36*time.Second)) / float64(time.Hour)
+}
+
+// printTimesheet prints a timesheet report as tables
+func printTimesheet(report timesheetReport) {
+	who := report.User
+	if who == "" {
+		who = "all users"
+	}
+	fmt.Printf("Timesheet for %s: %s to %s\n\n", who, report.From.Format("2006-01-02"), report.To.Format("2006-01-02"))
+
+	if len(report.Tickets) == 0 {
+		fmt.Println("No time logged.")
+		return
+	}
+
+	fmt.Printf("%-6s %-18s %-35s %8s\n", "ID", "PROJECT", "TITLE", "HOURS")
+	fmt.Println(strings.Repeat("-", 70))
+	for _, row := range report.Tickets {
+		title := row.Title
+		if len(title) > 35 {
+			title = title[:32] + "..."
+		}
+		fmt.Printf("%-6d %-18s %-35s %8.2f\n", row.TicketID, row.Project, title, row.Hours)
+	}
+
+	projects := make([]string, 0, len(report.Projects))
+	for p := range report.Projects {
+		projects = append(projects, p)
+	}
+	sort.Strings(projects)
+
+	fmt.Printf("\n%-61s %8s\n", "PROJECT", "HOURS")
+	fmt.Println(strings.Repeat("-", 70))
+	for _, p := range projects {
+		fmt.Printf("%-61s %8.2f\n", p, report.Projects[p])
+	}
+
+	fmt.Printf("\nTotal: %.2f hour(s)\n", report.Total)
+}
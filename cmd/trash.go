@@ -0,0 +1,147 @@
+package cmd
+
+import (
+	"alexandria/internal/dates"
+	"alexandria/internal/logger"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	trashProject   string
+	trashOlderThan string
+	trashAll       bool
+)
+
+var trashCmd = &cobra.Command{
+	Use:   "trash",
+	Short: "List or purge deleted tickets",
+	Long: `Deleted tickets go to the trash, where they are hidden from list and view but
+keep their tags, files, comments and time logs. "alexandria restore" brings one
+back; "alexandria trash purge" removes old ones for good.
+
+Examples:
+  alexandria trash list
+  alexandria restore ALX-42
+  alexandria trash purge --older-than 30d`,
+}
+
+var trashListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List deleted tickets, most recently deleted first",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		store, err := ticketStore(cmd)
+		if err != nil {
+			return err
+		}
+
+		tickets, err := store.ListTrash(cmd.Context(), trashProject)
+		if err != nil {
+			logger.Log.Error("failed to list trash", "error", err)
+			return fmt.Errorf("failed to list trash: %w", err)
+		}
+
+		if len(tickets) == 0 {
+			fmt.Println("The trash is empty.")
+			return nil
+		}
+
+		fmt.Printf("%-6s %-18s %-35s %-17s\n", "ID", "PROJECT", "TITLE", "DELETED")
+		fmt.Println(strings.Repeat("-", 80))
+		for _, t := range tickets {
+			title := t.Title
+			if len(title) > 35 {
+				title = title[:32] + "..."
+			}
+			fmt.Printf("%-6d %-18s %-35s %-17s\n", t.ID, t.Project, title, t.DeletedAt.Local().Format("2006-01-02 15:04"))
+		}
+		fmt.Printf("\nTotal: %d ticket(s)\n", len(tickets))
+		return nil
+	},
+}
+
+var trashPurgeCmd = &cobra.Command{
+	Use:   "purge",
+	Short: "Permanently remove tickets that have been in the trash for a while",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var before time.Time
+		switch {
+		case trashAll && trashOlderThan != "":
+			return fmt.Errorf("--older-than and --all cannot be used together")
+		case trashAll:
+			before = time.Now()
+		case trashOlderThan != "":
+			age, err := dates.ParseDuration(trashOlderThan)
+			if err != nil || age < 0 {
+				logger.Log.Error("validation failed", "error", "invalid older-than", "older_than", trashOlderThan)
+				return fmt.Errorf("invalid --older-than: %s (use a duration like 30d or 2w)", trashOlderThan)
+			}
+			before = time.Now().Add(-age)
+		default:
+			return fmt.Errorf("pass --older-than (e.g. 30d) or --all")
+		}
+
+		store, err := ticketStore(cmd)
+		if err != nil {
+			return err
+		}
+
+		purged, err := store.Purge(cmd.Context(), before)
+		if err != nil {
+			logger.Log.Error("failed to purge trash", "error", err)
+			return fmt.Errorf("failed to purge trash: %w", err)
+		}
+
+		for _, t := range purged {
+			fmt.Printf("Purged %d: %s\n", t.ID, t.Title)
+		}
+		fmt.Printf("Permanently removed %d ticket(s) from the trash.\n", len(purged))
+		return nil
+	},
+}
+
+var restoreCmd = &cobra.Command{
+	Use:   "restore <ref>",
+	Short: "Bring a deleted ticket back from the trash",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		id, err := parseRefOnly(args[0])
+		if err != nil {
+			return err
+		}
+
+		store, err := ticketStore(cmd)
+		if err != nil {
+			return err
+		}
+		ctx := cmd.Context()
+
+		if err := store.Restore(ctx, id); err != nil {
+			logger.Log.Error("failed to restore ticket", "error", err, "id", id)
+			return fmt.Errorf("failed to restore ticket: %w", err)
+		}
+
+		t, err := store.Get(ctx, id)
+		if err != nil {
+			return fmt.Errorf("failed to load restored ticket: %w", err)
+		}
+		fmt.Printf("Restored ticket %d: %s (project %s)\n", t.ID, t.Title, t.Project)
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(trashCmd)
+	rootCmd.AddCommand(restoreCmd)
+	trashCmd.AddCommand(trashListCmd)
+	trashCmd.AddCommand(trashPurgeCmd)
+
+	trashListCmd.Flags().StringVar(&trashProject, "project", "", "Only list deleted tickets from this project")
+	trashPurgeCmd.Flags().StringVar(&trashOlderThan, "older-than", "", "Purge tickets deleted longer ago than this (e.g. 30d, 2w)")
+	trashPurgeCmd.Flags().BoolVar(&trashAll, "all", false, "Purge everything in the trash")
+}
@@ -0,0 +1,107 @@
+package cmd
+
+import (
+	"alexandria/internal/config"
+	"alexandria/internal/logger"
+	"alexandria/internal/ticket"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// undoListLimit is how many undoable changes --list shows
+const undoListLimit = 20
+
+var (
+	undoSteps int
+	undoList  bool
+)
+
+var undoCmd = &cobra.Command{
+	Use:   "undo",
+	Short: "Revert your most recent create, update or delete",
+	Long: `Revert the most recent change you made to a ticket. Undoing an update puts back the
+ticket's fields, tags and files but keeps its comments; undoing a delete
+takes the ticket out of the trash; undoing a create moves the ticket to the trash.
+
+Changes are attributed to the current user (ALEXANDRIA_USER, or your login name).
+A change is not undone if someone else has changed the same ticket since, including
+by commenting on it or changing its checklist.
+
+Examples:
+  alexandria undo
+  alexandria undo --steps 3
+  alexandria undo --list`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if undoSteps < 1 {
+			logger.Log.Error("validation failed", "error", "invalid steps", "steps", undoSteps)
+			return fmt.Errorf("--steps must be at least 1")
+		}
+
+		store, err := ticketStore(cmd)
+		if err != nil {
+			return err
+		}
+		ctx := cmd.Context()
+		user := config.CurrentUser()
+
+		if undoList {
+			entries, err := store.ListJournal(ctx, user, undoListLimit)
+			if err != nil {
+				logger.Log.Error("failed to list journal", "error", err)
+				return fmt.Errorf("failed to list changes: %w", err)
+			}
+			if len(entries) == 0 {
+				fmt.Printf("Nothing to undo for %s.\n", user)
+				return nil
+			}
+
+			fmt.Printf("%-17s %-8s %-6s %s\n", "WHEN", "CHANGE", "ID", "TITLE")
+			fmt.Println(strings.Repeat("-", 80))
+			for _, e := range entries {
+				fmt.Printf("%-17s %-8s %-6d %s\n", e.At.Local().Format("2006-01-02 15:04"), e.Op, e.TicketID, journalTitle(ctx, store, e))
+			}
+			return nil
+		}
+
+		for i := 0; i < undoSteps; i++ {
+			e, err := store.Undo(ctx, user)
+			if errors.Is(err, ticket.ErrNothingToUndo) {
+				if i == 0 {
+					fmt.Printf("Nothing to undo for %s.\n", user)
+				} else {
+					fmt.Printf("Nothing more to undo after %d step(s).\n", i)
+				}
+				return nil
+			}
+			if err != nil {
+				logger.Log.Error("failed to undo", "error", err, "step", i+1)
+				return fmt.Errorf("failed to undo: %w", err)
+			}
+			fmt.Printf("Undid %s of ticket %d: %s\n", e.Op, e.TicketID, journalTitle(ctx, store, *e))
+		}
+		return nil
+	},
+}
+
+// journalTitle names the ticket a journal entry changed, preferring its current title
+func journalTitle(ctx context.Context, store ticket.TicketStore, e ticket.JournalEntry) string {
+	if t, err := store.Get(ctx, e.TicketID); err == nil {
+		return t.Title
+	}
+	if e.Before != nil {
+		return e.Before.Title
+	}
+	return "(in the trash)"
+}
+
+func init() {
+	rootCmd.AddCommand(undoCmd)
+
+	undoCmd.Flags().IntVar(&undoSteps, "steps", 1, "Number of changes to undo, most recent first")
+	undoCmd.Flags().BoolVar(&undoList, "list", false, "List the changes that can be undone instead of undoing one")
+}
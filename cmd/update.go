@@ -1,10 +1,12 @@
 package cmd
 
 import (
-	"alexandria/internal/database"
+	"alexandria/internal/gitlink"
 	"alexandria/internal/logger"
 	"alexandria/internal/ticket"
+	"context"
 	"fmt"
+	"os"
 	"strconv"
 	"strings"
 
@@ -26,6 +28,11 @@ var (
 	updateTags       string
 	updateFiles      string
 	updateComments   string
+	updateDue        string
+	updateStart      string
+	updatePoints     string
+	updateEstimate   string
+	updateParent     string
 )
 
 var updateCmd = &cobra.Command{
@@ -41,10 +48,9 @@ var updateCmd = &cobra.Command{
 			return fmt.Errorf("either --id or --title must be provided to identify the ticket")
 		}
 
-		// Validate that project is provided
-		if updateProject == "" {
-			logger.Log.Error("validation failed", "error", "project is required")
-			return fmt.Errorf("project is required")
+		// Fall back to the project in the directory config
+		if err := applyDefaultProject(&updateProject); err != nil {
+			return err
 		}
 
 		// Parse ID if provided
@@ -60,42 +66,29 @@ var updateCmd = &cobra.Command{
 		}
 
 		// Get database connection
-		db := database.GetDB()
-		if db == nil {
-			logger.Log.Error("database not initialized")
-			return fmt.Errorf("database not initialized")
+		store, err := ticketStore(cmd)
+		if err != nil {
+			return err
 		}
+		ctx := cmd.Context()
 
 		// First, fetch the existing ticket to preserve current values
 		logger.Log.Debug("fetching existing ticket")
-		filters := ticket.Filters{}
-		tickets, err := ticket.List(db, filters)
-		if err != nil {
-			logger.Log.Error("failed to fetch tickets", "error", err)
-			return fmt.Errorf("failed to fetch tickets: %w", err)
-		}
-
-		// Find the ticket to update
-		var existingTicket *ticket.Ticket
-		for i := range tickets {
-			if ticketID != 0 && tickets[i].ID == ticketID {
-				existingTicket = &tickets[i]
-				break
-			} else if updateFindTitle != "" && tickets[i].Title == updateFindTitle {
-				existingTicket = &tickets[i]
-				break
-			}
+		existingTicket, err := findTicket(ctx, store, updateProject, ticketID, updateFindTitle)
+		if err == errNoProject {
+			return err
 		}
-
-		if existingTicket == nil {
+		if err != nil {
 			if ticketID != 0 {
-				logger.Log.Error("ticket not found", "id", ticketID)
+				logger.Log.Error("ticket not found", "id", ticketID, "error", err)
 				return fmt.Errorf("ticket with ID '%d' not found", ticketID)
 			}
-			logger.Log.Error("ticket not found", "title", updateFindTitle)
+			logger.Log.Error("ticket not found", "title", updateFindTitle, "error", err)
 			return fmt.Errorf("ticket with title '%s' not found", updateFindTitle)
 		}
 
+		wasClosed := existingTicket.Status == ticket.StatusClosed
+
 		logger.Log.Debug("found existing ticket", "id", existingTicket.ID, "title", existingTicket.Title)
 
 		// Track if at least one field is being updated
@@ -185,27 +178,99 @@ var updateCmd = &cobra.Command{
 			logger.Log.Debug("updating files", "count", len(fileList))
 		}
 
+		// Comments are added on their own after the fields are saved
+		var commentList []string
 		if updateComments != "" {
-			commentList := strings.Split(updateComments, ",")
+			commentList = strings.Split(updateComments, ",")
 			for i, comment := range commentList {
 				commentList[i] = strings.TrimSpace(comment)
 			}
-			existingTicket.Comments = commentList
+			logger.Log.Debug("adding comments", "count", len(commentList))
+		}
+
+		if updateDue != "" {
+			due, err := parseOptionalDate(updateDue)
+			if err != nil {
+				logger.Log.Error("validation failed", "error", err, "due", updateDue)
+				return fmt.Errorf("invalid due date: %w", err)
+			}
+			existingTicket.DueAt = due
+			hasUpdates = true
+			logger.Log.Debug("updating due date", "due", due)
+		}
+
+		if updateStart != "" {
+			start, err := parseOptionalDate(updateStart)
+			if err != nil {
+				logger.Log.Error("validation failed", "error", err, "start", updateStart)
+				return fmt.Errorf("invalid start date: %w", err)
+			}
+			existingTicket.StartAt = start
+			hasUpdates = true
+			logger.Log.Debug("updating start date", "start", start)
+		}
+
+		if updatePoints != "" {
+			points, err := parseOptionalPoints(updatePoints)
+			if err != nil {
+				logger.Log.Error("validation failed", "error", err, "points", updatePoints)
+				return err
+			}
+			existingTicket.StoryPoints = points
 			hasUpdates = true
-			logger.Log.Debug("updating comments", "count", len(commentList))
+			logger.Log.Debug("updating story points", "points", updatePoints)
 		}
 
-		// Check if at least one field is being updated
-		if !hasUpdates {
+		if updateEstimate != "" {
+			estimate, err := parseOptionalHours(updateEstimate)
+			if err != nil {
+				logger.Log.Error("validation failed", "error", err, "estimate", updateEstimate)
+				return err
+			}
+			existingTicket.EstimateHours = estimate
+			hasUpdates = true
+			logger.Log.Debug("updating estimate", "estimate", updateEstimate)
+		}
+
+		if updateParent != "" {
+			if updateParent == "none" {
+				existingTicket.ParentID = nil
+			} else {
+				parentID, err := ticket.ParseRef(updateParent)
+				if err != nil {
+					logger.Log.Error("validation failed", "error", err, "parent", updateParent)
+					return err
+				}
+				if _, err := ticket.CheckParent(ctx, store, existingTicket.ID, parentID); err != nil {
+					logger.Log.Error("validation failed", "error", err, "parent", updateParent)
+					return err
+				}
+				existingTicket.ParentID = &parentID
+			}
+			hasUpdates = true
+			logger.Log.Debug("updating parent", "parent", updateParent)
+		}
+
+		// Check if at least one field is being updated or a comment added
+		if !hasUpdates && len(commentList) == 0 {
 			logger.Log.Error("validation failed", "error", "no fields to update")
 			return fmt.Errorf("no fields specified to update")
 		}
 
 		// Call the Update method
-		logger.Log.Debug("calling update method", "project", updateProject, "id", ticketID, "title", updateFindTitle)
-		if err := existingTicket.Update(db, updateProject, ticketID, updateFindTitle); err != nil {
-			logger.Log.Error("failed to update ticket", "error", err, "project", updateProject)
-			return fmt.Errorf("failed to update ticket: %w", err)
+		updateProject = existingTicket.Project
+		if hasUpdates {
+			logger.Log.Debug("calling update method", "project", updateProject, "id", ticketID, "title", updateFindTitle)
+			if err := store.Update(ctx, updateProject, existingTicket); err != nil {
+				logger.Log.Error("failed to update ticket", "error", err, "project", updateProject)
+				return fmt.Errorf("failed to update ticket: %w", err)
+			}
+		}
+		for _, comment := range commentList {
+			if err := store.AddComment(ctx, existingTicket.ID, comment); err != nil {
+				logger.Log.Error("failed to add comment", "error", err, "id", existingTicket.ID)
+				return fmt.Errorf("failed to add comment: %w", err)
+			}
 		}
 
 		// Success message
@@ -216,6 +281,9 @@ var updateCmd = &cobra.Command{
 			logger.Log.Info("ticket updated successfully", "title", updateFindTitle, "project", updateProject)
 			fmt.Printf("Successfully updated ticket with title: %s in project: %s\n", updateFindTitle, updateProject)
 		}
+		if !wasClosed && existingTicket.Status == ticket.StatusClosed {
+			warnOpenChildren(ctx, store, existingTicket)
+		}
 
 		return nil
 	},
@@ -227,7 +295,7 @@ func init() {
 	// Flags to identify the ticket
 	updateCmd.Flags().StringVarP(&updateID, "id", "i", "", "Ticket ID to update")
 	updateCmd.Flags().StringVarP(&updateFindTitle, "title", "t", "", "Find ticket by title to update")
-	updateCmd.Flags().StringVar(&updateProject, "project", "", "Project name (required)")
+	updateCmd.Flags().StringVar(&updateProject, "project", "", projectFlagUsage)
 
 	// Flags for fields to update
 	updateCmd.Flags().StringVar(&updateTitle, "new-title", "", "New title for the ticket")
@@ -241,6 +309,24 @@ func init() {
 	updateCmd.Flags().StringVar(&updateTags, "tags", "", "Comma-separated list of tags (replaces existing)")
 	updateCmd.Flags().StringVar(&updateFiles, "files", "", "Comma-separated list of file paths (replaces existing)")
 	updateCmd.Flags().StringVar(&updateComments, "comments", "", "Comma-separated list of comments to add")
+	updateCmd.Flags().StringVar(&updateDue, "due", "", "New due date (e.g. 2026-11-01, friday, 3d, or 'none' to clear)")
+	updateCmd.Flags().StringVar(&updateStart, "start", "", "New start date (e.g. 2026-11-01, monday, or 'none' to clear)")
+	updateCmd.Flags().StringVar(&updatePoints, "points", "", "New story point estimate (or 'none' to clear)")
+	updateCmd.Flags().StringVar(&updateEstimate, "estimate", "", "New hours estimate, e.g. 6 or 4h30m (or 'none' to clear)")
+	updateCmd.Flags().StringVar(&updateParent, "parent", "", "New parent ticket, e.g. ALX-10 (or 'none' to make it top-level)")
+}
 
-	updateCmd.MarkFlagRequired("project")
+// warnOpenChildren warns when a ticket that was just closed still has open
+// tickets below it. Closing is allowed since the remaining work may be dropped.
+func warnOpenChildren(ctx context.Context, store ticket.TicketStore, t *ticket.Ticket) {
+	tree, err := ticket.Subtree(ctx, store, t)
+	if err != nil {
+		logger.Log.Warn("failed to load child tickets", "error", err, "id", t.ID)
+		return
+	}
+	rollup := tree.Rollup()
+	if open := rollup.Total - rollup.Done; open > 0 {
+		fmt.Fprintf(os.Stderr, "Warning: %s-%d was closed with %d of its %d child ticket(s) still open\n",
+			gitlink.RefPrefix, t.ID, open, rollup.Total)
+	}
 }
@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"alexandria/internal/logger"
+	"alexandria/internal/users"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	userEmail string
+	userName  string
+	userRole  string
+)
+
+var userCmd = &cobra.Command{
+	Use:   "user",
+	Short: "Manage the users notifications are sent to",
+	Long: `Manage users. A user's username is what ALEXANDRIA_USER or their login name gives,
+and is what tickets are assigned to; their email address is where notifications
+about those tickets go (see notify).
+
+Examples:
+  alexandria user add alice --email alice@example.com --name "Alice Smith"
+  alexandria user list`,
+}
+
+var userAddCmd = &cobra.Command{
+	Use:   "add <username>",
+	Short: "Add a user, or change an existing user's email, name or role",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		db, err := sqlDB(cmd)
+		if err != nil {
+			return err
+		}
+
+		u := &users.User{Username: args[0], Email: userEmail, Fullname: userName, Role: userRole}
+		if err := users.Save(db, u); err != nil {
+			logger.Log.Error("failed to save user", "error", err, "username", u.Username)
+			return err
+		}
+
+		fmt.Printf("Saved user %s <%s>\n", u.Username, u.Email)
+		return nil
+	},
+}
+
+var userListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List users",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		db, err := sqlDB(cmd)
+		if err != nil {
+			return err
+		}
+
+		list, err := users.List(db)
+		if err != nil {
+			return err
+		}
+		if len(list) == 0 {
+			fmt.Println("No users found.")
+			return nil
+		}
+
+		fmt.Printf("%-18s %-32s %-24s %s\n", "USERNAME", "EMAIL", "NAME", "ROLE")
+		fmt.Println(strings.Repeat("-", 84))
+		for _, u := range list {
+			fmt.Printf("%-18s %-32s %-24s %s\n", u.Username, u.Email, u.Fullname, u.Role)
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(userCmd)
+	userCmd.AddCommand(userAddCmd)
+	userCmd.AddCommand(userListCmd)
+
+	userAddCmd.Flags().StringVar(&userEmail, "email", "", "Email address (required)")
+	userAddCmd.Flags().StringVar(&userName, "name", "", "Full name (default: the username)")
+	userAddCmd.Flags().StringVar(&userRole, "role", users.RoleUser, "Role: admin, user or viewer")
+	userAddCmd.MarkFlagRequired("email")
+}
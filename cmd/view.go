@@ -1,12 +1,17 @@
 package cmd
 
 import (
-	"alexandria/internal/database"
+	"alexandria/internal/dates"
+	"alexandria/internal/gitlink"
 	"alexandria/internal/logger"
+	"alexandria/internal/notify"
+	"alexandria/internal/recur"
 	"alexandria/internal/ticket"
+	"context"
 	"encoding/json"
 	"fmt"
 	"strconv"
+	"strings"
 
 	"github.com/spf13/cobra"
 )
@@ -20,49 +25,57 @@ var (
 var viewCmd = &cobra.Command{
 	Use:   "view",
 	Short: "View a single ticket's details",
-	Long:  `View the full details of a ticket by ID or title.`,
+	Long: `View the full details of a ticket by ID or title. Titles need --project; with
+neither an ID nor a title, the ticket for the current git branch is shown (see checkout).
+An ID that is not found among the live tickets is looked up in the archive.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		logger.Log.Debug("viewing ticket", "id", viewID, "title", viewTitle, "project", viewProject)
 
-		// Validate that at least one identifier is provided
-		if viewID == "" && viewTitle == "" {
-			logger.Log.Error("validation failed", "error", "no identifier provided")
-			return fmt.Errorf("either --id or --title must be provided")
-		}
-
-		// Validate that project is provided
-		if viewProject == "" {
-			logger.Log.Error("validation failed", "error", "project is required")
-			return fmt.Errorf("project is required")
+		// Get database connection
+		store, err := ticketStore(cmd)
+		if err != nil {
+			return err
 		}
+		ctx := cmd.Context()
+
+		var t *ticket.Ticket
+		switch {
+		case viewTitle != "":
+			// Titles are only unique within a project
+			if err := applyDefaultProject(&viewProject); err != nil {
+				return err
+			}
+			if viewProject == "" {
+				logger.Log.Error("validation failed", "error", "project is required")
+				return errNoProject
+			}
+			logger.Log.Debug("finding ticket", "project", viewProject, "title", viewTitle)
+			if t, err = store.Find(ctx, viewProject, 0, viewTitle); err != nil {
+				logger.Log.Error("failed to view ticket", "error", err, "project", viewProject)
+				return fmt.Errorf("failed to view ticket: %w", err)
+			}
 
-		// Parse ID if provided
-		var ticketID int64
-		if viewID != "" {
-			var err error
-			ticketID, err = strconv.ParseInt(viewID, 10, 64)
+		case viewID != "" && viewProject != "":
+			ticketID, err := strconv.ParseInt(viewID, 10, 64)
 			if err != nil {
 				logger.Log.Error("failed to parse ticket ID", "error", err, "id", viewID)
 				return fmt.Errorf("invalid ID format: %s (must be a number)", viewID)
 			}
-			logger.Log.Debug("parsed ticket ID", "id", ticketID)
-		}
-
-		// Get database connection
-		db := database.GetDB()
-		if db == nil {
-			logger.Log.Error("database not initialized")
-			return fmt.Errorf("database not initialized")
-		}
-
-		// Create a ticket instance to populate
-		t := &ticket.Ticket{}
+			logger.Log.Debug("finding ticket", "project", viewProject, "id", ticketID)
+			if t, err = store.Find(ctx, viewProject, ticketID, ""); err != nil {
+				if t = findArchived(ctx, store, viewID, viewProject); t == nil {
+					logger.Log.Error("failed to view ticket", "error", err, "project", viewProject)
+					return fmt.Errorf("failed to view ticket: %w", err)
+				}
+			}
 
-		// Call the View method
-		logger.Log.Debug("calling view method", "project", viewProject, "id", ticketID, "title", viewTitle)
-		if err := t.View(db, viewProject, ticketID, viewTitle); err != nil {
-			logger.Log.Error("failed to view ticket", "error", err, "project", viewProject)
-			return fmt.Errorf("failed to view ticket: %w", err)
+		default:
+			// IDs are unique across projects; with no identifier use the current branch
+			if t, err = resolveTicketOrCurrent(ctx, store, viewID); err != nil {
+				if t = findArchived(ctx, store, viewID, ""); t == nil {
+					return fmt.Errorf("failed to view ticket: %w", err)
+				}
+			}
 		}
 
 		logger.Log.Info("ticket retrieved successfully", "id", t.ID, "title", t.Title)
@@ -74,18 +87,110 @@ var viewCmd = &cobra.Command{
 			return fmt.Errorf("failed to marshal ticket: %w", err)
 		}
 
+		if t.ArchivedAt != nil {
+			// Time logs and commits of archived tickets stay in the archive tables
+			fmt.Printf("Ticket details (archived %s):\n", t.ArchivedAt.Local().Format("2006-01-02"))
+			fmt.Println(string(jsonData))
+			return nil
+		}
+
 		fmt.Println("Ticket details:")
 		fmt.Println(string(jsonData))
 
+		// Summarise time tracked against the ticket
+		logged, entries, err := store.TotalLogged(ctx, t.ID)
+		if err != nil {
+			logger.Log.Error("failed to load worklogs", "error", err, "id", t.ID)
+			return fmt.Errorf("failed to load worklogs: %w", err)
+		}
+		if entries > 0 {
+			fmt.Printf("Time logged: %s (%d entries)\n", dates.FormatDuration(logged), entries)
+		}
+
+		if done, total := t.ChecklistProgress(); total > 0 {
+			fmt.Printf("Checklist: %d/%d done\n", done, total)
+			printChecklist(t.Checklist)
+		}
+
+		// Show where the ticket sits in its hierarchy and how far the work below it has got
+		if t.ParentID != nil {
+			ref := fmt.Sprintf("%s-%d", gitlink.RefPrefix, *t.ParentID)
+			if parent, err := store.Get(ctx, *t.ParentID); err == nil {
+				ref += " " + parent.Title
+			}
+			fmt.Printf("Parent: %s\n", ref)
+		}
+		tree, err := ticket.Subtree(ctx, store, t)
+		if err != nil {
+			logger.Log.Error("failed to load child tickets", "error", err, "id", t.ID)
+			return err
+		}
+		if len(tree.Children) > 0 {
+			r := tree.Rollup()
+			fmt.Printf("Children: %d/%d done, %s point(s) remaining\n", r.Done, r.Total, strconv.FormatFloat(r.RemainingPoints, 'f', -1, 64))
+			tree.Walk(func(n *ticket.Node, depth int) {
+				c := n.Ticket
+				fmt.Printf("%s%s-%d [%s] %s\n", strings.Repeat("  ", depth), gitlink.RefPrefix, c.ID, c.Status, c.Title)
+			})
+		}
+
+		// Watchers and schedule runs live in the database only, so views backed by
+		// another store have none
+		if d := depsFrom(cmd); d != nil && d.db != nil {
+			watchers, err := notify.Watchers(d.db, t.ID)
+			if err != nil {
+				return err
+			}
+			if len(watchers) > 0 {
+				fmt.Printf("Watchers: %s\n", strings.Join(watchers, ", "))
+			}
+			run, err := recur.SpawnedBy(d.db, t.ID)
+			if err != nil {
+				return err
+			}
+			if run != nil {
+				fmt.Printf("Created by schedule '%s' for %s\n", run.Schedule, run.Occurrence.Local().Format("2006-01-02 15:04"))
+			}
+		}
+
+		commits, err := store.ListCommits(ctx, t.ID)
+		if err != nil {
+			logger.Log.Error("failed to load commits", "error", err, "id", t.ID)
+			return fmt.Errorf("failed to load commits: %w", err)
+		}
+		if len(commits) > 0 {
+			fmt.Printf("Linked commits (%d):\n", len(commits))
+			for _, c := range commits {
+				fmt.Printf("  %s %s %s (%s)\n", c.ShortSHA(), c.CommittedAt.Format("2006-01-02"), c.Summary, c.Author)
+			}
+		}
+
 		return nil
 	},
 }
 
+// findArchived looks a ticket reference up in the archive once the live lookup has
+// failed, returning nil when it is not archived either (or belongs to another project)
+func findArchived(ctx context.Context, store ticket.TicketStore, ref, project string) *ticket.Ticket {
+	if ref == "" {
+		return nil
+	}
+	id, err := ticket.ParseRef(ref)
+	if err != nil {
+		return nil
+	}
+	t, err := store.GetArchived(ctx, id)
+	if err != nil || (project != "" && t.Project != project) {
+		return nil
+	}
+	logger.Log.Debug("found ticket in the archive", "id", id)
+	return t
+}
+
 func init() {
 	rootCmd.AddCommand(viewCmd)
 
-	viewCmd.Flags().StringVarP(&viewID, "id", "i", "", "Ticket ID to view")
+	viewCmd.Flags().StringVarP(&viewID, "id", "i", "", "Ticket ID or reference to view")
 	viewCmd.Flags().StringVarP(&viewTitle, "title", "t", "", "Ticket title to view")
-	viewCmd.Flags().StringVarP(&viewProject, "project", "p", "", "Project name (required)")
-	viewCmd.MarkFlagRequired("project")
+	viewCmd.Flags().StringVarP(&viewProject, "project", "p", "", "Project name (required with --title unless set in .alexandria.toml)")
 }
@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"alexandria/internal/config"
+	"alexandria/internal/notify"
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+var watchUser string
+
+var watchCmd = &cobra.Command{
+	Use:   "watch [ref]",
+	Short: "Get notifications about a ticket",
+	Long: `Add yourself, or --user, to a ticket's watchers. Watchers are notified by email when
+the ticket is commented on or closed (see notify). Without a ticket reference the
+ticket for the current git branch is watched.
+
+Examples:
+  alexandria watch ALX-42
+  alexandria watch ALX-42 --user bob`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return setWatching(cmd, args, true)
+	},
+}
+
+var unwatchCmd = &cobra.Command{
+	Use:   "unwatch [ref]",
+	Short: "Stop getting notifications about a ticket",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return setWatching(cmd, args, false)
+	},
+}
+
+// setWatching adds the user to or removes them from the watchers of the ticket named by args
+func setWatching(cmd *cobra.Command, args []string, watch bool) error {
+	store, err := ticketStore(cmd)
+	if err != nil {
+		return err
+	}
+	db, err := sqlDB(cmd)
+	if err != nil {
+		return err
+	}
+
+	ref := ""
+	if len(args) == 1 {
+		ref = args[0]
+	}
+	t, err := resolveTicketOrCurrent(cmd.Context(), store, ref)
+	if err != nil {
+		return err
+	}
+
+	user := watchUser
+	if user == "" {
+		user = config.CurrentUser()
+	}
+
+	if watch {
+		added, err := notify.Watch(db, t.ID, user)
+		if err != nil {
+			return err
+		}
+		if !added {
+			fmt.Printf("%s is already watching ticket %d: %s\n", user, t.ID, t.Title)
+			return nil
+		}
+		fmt.Printf("%s is now watching ticket %d: %s\n", user, t.ID, t.Title)
+		return nil
+	}
+
+	removed, err := notify.Unwatch(db, t.ID, user)
+	if err != nil {
+		return err
+	}
+	if !removed {
+		fmt.Printf("%s was not watching ticket %d: %s\n", user, t.ID, t.Title)
+		return nil
+	}
+	fmt.Printf("%s stopped watching ticket %d: %s\n", user, t.ID, t.Title)
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(watchCmd)
+	rootCmd.AddCommand(unwatchCmd)
+
+	watchCmd.Flags().StringVar(&watchUser, "user", "", "User to add (default: you)")
+	unwatchCmd.Flags().StringVar(&watchUser, "user", "", "User to remove (default: you)")
+}
@@ -0,0 +1,168 @@
+package cmd
+
+import (
+	"alexandria/internal/config"
+	"alexandria/internal/dates"
+	"alexandria/internal/logger"
+	"alexandria/internal/ticket"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	timerNote string
+)
+
+var startCmd = &cobra.Command{
+	Use:   "start [ref]",
+	Short: "Start a timer on a ticket",
+	Long: `Start tracking time against a ticket. The timer is stored in the database, so it
+keeps running after the command exits. Starting a new timer stops any timer you already
+have running and records it as a worklog. Without a ticket reference the ticket for the
+current git branch is used (see checkout).
+
+Examples:
+  alexandria start 42
+  alexandria start ALX-42 --note "pairing on the login fix"`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ref := ""
+		if len(args) == 1 {
+			ref = args[0]
+		}
+		logger.Log.Debug("starting timer", "ref", ref)
+
+		store, err := ticketStore(cmd)
+		if err != nil {
+			return err
+		}
+		ctx := cmd.Context()
+
+		t, err := resolveTicketOrCurrent(ctx, store, ref)
+		if err != nil {
+			return err
+		}
+
+		timer := &ticket.Timer{
+			User:      config.CurrentUser(),
+			TicketID:  t.ID,
+			StartedAt: time.Now(),
+			Note:      timerNote,
+		}
+
+		stopped, err := store.StartTimer(ctx, timer)
+		if err != nil {
+			logger.Log.Error("failed to start timer", "error", err, "ticket_id", t.ID)
+			return fmt.Errorf("failed to start timer: %w", err)
+		}
+
+		if stopped != nil {
+			fmt.Printf("Stopped timer on ticket %d: logged %s\n", stopped.TicketID, dates.FormatDuration(stopped.Duration()))
+		}
+		fmt.Printf("Started timer on ticket %d: %s\n", t.ID, t.Title)
+		return nil
+	},
+}
+
+var stopCmd = &cobra.Command{
+	Use:   "stop",
+	Short: "Stop the running timer and log the time",
+	Long: `Stop your running timer and record the elapsed time as a worklog entry.
+
+Examples:
+  alexandria stop
+  alexandria stop --note "fixed, waiting on review"`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		user := config.CurrentUser()
+		logger.Log.Debug("stopping timer", "user", user)
+
+		store, err := ticketStore(cmd)
+		if err != nil {
+			return err
+		}
+		ctx := cmd.Context()
+
+		w, err := store.StopTimer(ctx, user, time.Now(), timerNote)
+		if err != nil {
+			logger.Log.Error("failed to stop timer", "error", err, "user", user)
+			return fmt.Errorf("failed to stop timer: %w", err)
+		}
+
+		fmt.Printf("Logged %s on ticket %d\n", dates.FormatDuration(w.Duration()), w.TicketID)
+		return nil
+	},
+}
+
+var logCmd = &cobra.Command{
+	Use:   "log [ref] <duration> [note]",
+	Short: "Log time spent on a ticket",
+	Long: `Manually record time spent on a ticket. The entry is recorded as ending now.
+Without a ticket reference the ticket for the current git branch is used (see checkout).
+
+Examples:
+  alexandria log 42 1h30m "code review"
+  alexandria log ALX-42 45m
+  alexandria log 2h "pairing"`,
+	Args: cobra.RangeArgs(1, 3),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// A leading duration means the ticket comes from the current branch
+		ref := ""
+		if _, err := dates.ParseDuration(args[0]); err != nil {
+			ref, args = args[0], args[1:]
+		}
+		if len(args) == 0 || len(args) > 2 {
+			logger.Log.Error("validation failed", "error", "invalid arguments", "args", args)
+			return fmt.Errorf("usage: alexandria log [ref] <duration> [note]")
+		}
+		logger.Log.Debug("logging time", "ref", ref, "duration", args[0])
+
+		duration, err := dates.ParseDuration(args[0])
+		if err != nil || duration <= 0 {
+			logger.Log.Error("validation failed", "error", "invalid duration", "duration", args[0])
+			return fmt.Errorf("invalid duration: %s (use a value like 1h30m or 45m)", args[0])
+		}
+
+		store, err := ticketStore(cmd)
+		if err != nil {
+			return err
+		}
+		ctx := cmd.Context()
+
+		t, err := resolveTicketOrCurrent(ctx, store, ref)
+		if err != nil {
+			return err
+		}
+
+		end := time.Now()
+		w := &ticket.Worklog{
+			TicketID:  t.ID,
+			User:      config.CurrentUser(),
+			StartedAt: end.Add(-duration),
+			EndedAt:   end,
+		}
+		if len(args) == 2 {
+			w.Note = strings.TrimSpace(args[1])
+		}
+
+		if err := store.AddWorklog(ctx, w); err != nil {
+			logger.Log.Error("failed to log time", "error", err, "ticket_id", t.ID)
+			return fmt.Errorf("failed to log time: %w", err)
+		}
+
+		fmt.Printf("Logged %s on ticket %d: %s\n", dates.FormatDuration(duration), t.ID, t.Title)
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(startCmd)
+	rootCmd.AddCommand(stopCmd)
+	rootCmd.AddCommand(logCmd)
+
+	startCmd.Flags().StringVarP(&timerNote, "note", "n", "", "Note to record with the worklog")
+	stopCmd.Flags().StringVarP(&timerNote, "note", "n", "", "Note to record with the worklog (replaces the start note)")
+}
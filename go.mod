@@ -3,8 +3,10 @@ module alexandria
 go 1.23
 
 require (
+	github.com/BurntSushi/toml v1.5.0
 	github.com/common-nighthawk/go-figure v0.0.0-20210622060536-734e95fb86be
 	github.com/joho/godotenv v1.5.1
+	github.com/lib/pq v1.10.9
 	github.com/mattn/go-sqlite3 v1.14.32
 	github.com/spf13/cobra v1.10.1
 	github.com/tursodatabase/libsql-client-go v0.0.0-20240902231107-85af5b9d094d
@@ -0,0 +1,130 @@
+package config
+
+import (
+	"alexandria/internal/logger"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/BurntSushi/toml"
+)
+
+// DirConfigNames are the directory config files looked for, in order of preference
+var DirConfigNames = []string{".alexandria.toml", ".alexandria.json"}
+
+// FindDirConfig walks up from dir looking for a directory config file and returns
+// its path, or "" if there is none between dir and the filesystem root
+func FindDirConfig(dir string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve directory: %w", err)
+	}
+
+	for {
+		for _, name := range DirConfigNames {
+			path := filepath.Join(dir, name)
+			if info, err := os.Stat(path); err == nil && !info.IsDir() {
+				return path, nil
+			}
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", nil
+		}
+		dir = parent
+	}
+}
+
+// LoadDir reads the directory config that applies to dir. It returns an empty
+// Config and an empty path when no file is found.
+func LoadDir(dir string) (*Config, string, error) {
+	path, err := FindDirConfig(dir)
+	if err != nil {
+		logger.Log.Error("failed to search for directory config", "error", err, "dir", dir)
+		return nil, "", err
+	}
+	if path == "" {
+		logger.Log.Debug("no directory config found", "dir", dir)
+		return &Config{}, "", nil
+	}
+
+	logger.Log.Debug("loading directory config", "path", path)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		logger.Log.Error("failed to read directory config", "error", err, "path", path)
+		return nil, "", fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	var cfg Config
+	if filepath.Ext(path) == ".toml" {
+		err = toml.Unmarshal(data, &cfg)
+	} else {
+		err = json.Unmarshal(data, &cfg)
+	}
+	if err != nil {
+		logger.Log.Error("failed to parse directory config", "error", err, "path", path)
+		return nil, "", fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	if cfg.DatabaseType != "" && !ValidDBType(cfg.DatabaseType) {
+		logger.Log.Error("invalid database type in directory config", "type", cfg.DatabaseType, "path", path)
+		return nil, "", fmt.Errorf("invalid database_type %q in %s", cfg.DatabaseType, path)
+	}
+
+	logger.Log.Debug("directory config loaded", "path", path, "project", cfg.Project, "database_type", cfg.DatabaseType)
+	return &cfg, path, nil
+}
+
+// LoadDirFromWorkingDir reads the directory config that applies to the current directory
+func LoadDirFromWorkingDir() (*Config, string, error) {
+	wd, err := os.Getwd()
+	if err != nil {
+		logger.Log.Error("failed to get working directory", "error", err)
+		return nil, "", fmt.Errorf("failed to get working directory: %w", err)
+	}
+	return LoadDir(wd)
+}
+
+// Resolve returns the user config with the directory config for the current
+// directory laid over it. Use Load instead when the result will be saved, so
+// directory settings are not copied into the user config.
+func Resolve() (*Config, error) {
+	cfg, err := Load()
+	if err != nil {
+		return nil, err
+	}
+
+	dir, path, err := LoadDirFromWorkingDir()
+	if err != nil {
+		return nil, err
+	}
+	if path == "" {
+		return cfg, nil
+	}
+
+	// The directory's choice of database replaces the user's, whichever way it is given
+	if dir.DatabaseType != "" {
+		cfg.DatabaseType = dir.DatabaseType
+		cfg.Profile = ""
+	}
+	if dir.Profile != "" {
+		cfg.Profile = dir.Profile
+	}
+	if dir.Project != "" {
+		cfg.Project = dir.Project
+	}
+	if dir.Assignee != "" {
+		cfg.Assignee = dir.Assignee
+	}
+	if len(dir.Tags) > 0 {
+		cfg.Tags = dir.Tags
+	}
+	if dir.Notify != nil {
+		cfg.Notify = cfg.Notify.merge(dir.Notify)
+	}
+
+	logger.Log.Debug("applied directory config", "path", path, "database_type", cfg.DatabaseType, "profile", cfg.Profile)
+	return cfg, nil
+}
@@ -0,0 +1,85 @@
+package config
+
+import (
+	"alexandria/internal/logger"
+	"fmt"
+	"os"
+)
+
+// Notify configures email notifications. It can be set in the user config and in
+// a directory config, whose non-empty settings take precedence, so a repository
+// can turn notifications on for its project while the SMTP server stays personal.
+type Notify struct {
+	Projects    []string `json:"projects,omitempty" toml:"projects"`         // projects whose tickets send email, "*" for all
+	SMTPAddr    string   `json:"smtp_addr,omitempty" toml:"smtp_addr"`       // SMTP server as host:port
+	From        string   `json:"from,omitempty" toml:"from"`                 // sender address
+	Username    string   `json:"username,omitempty" toml:"username"`         // SMTP login, if the server needs one
+	PasswordEnv string   `json:"password_env,omitempty" toml:"password_env"` // environment variable holding the SMTP password
+}
+
+// Enabled reports whether tickets in project send notifications
+func (n *Notify) Enabled(project string) bool {
+	if n == nil {
+		return false
+	}
+	for _, p := range n.Projects {
+		if p == "*" || p == project {
+			return true
+		}
+	}
+	return false
+}
+
+// Password returns the SMTP password from the configured environment variable,
+// falling back to ALEXANDRIA_SMTP_PASSWORD
+func (n *Notify) Password() string {
+	if n.PasswordEnv != "" {
+		return os.Getenv(n.PasswordEnv)
+	}
+	return os.Getenv("ALEXANDRIA_SMTP_PASSWORD")
+}
+
+// merge returns n with the non-empty settings of over laid over it
+func (n *Notify) merge(over *Notify) *Notify {
+	merged := Notify{}
+	if n != nil {
+		merged = *n
+	}
+	if len(over.Projects) > 0 {
+		merged.Projects = over.Projects
+	}
+	if over.SMTPAddr != "" {
+		merged.SMTPAddr = over.SMTPAddr
+	}
+	if over.From != "" {
+		merged.From = over.From
+	}
+	if over.Username != "" {
+		merged.Username = over.Username
+	}
+	if over.PasswordEnv != "" {
+		merged.PasswordEnv = over.PasswordEnv
+	}
+	return &merged
+}
+
+// UpdateNotify changes the notification settings in the user config and saves it
+func UpdateNotify(change func(n *Notify)) error {
+	logger.Log.Debug("updating notification settings")
+
+	config, err := Load()
+	if err != nil {
+		return fmt.Errorf("failed to update notification settings: %w", err)
+	}
+	if config.Notify == nil {
+		config.Notify = &Notify{}
+	}
+	change(config.Notify)
+	if err := Save(config); err != nil {
+		logger.Log.Error("failed to save notification settings", "error", err)
+		return fmt.Errorf("failed to update notification settings: %w", err)
+	}
+
+	logger.Log.Info("notification settings updated", "projects", config.Notify.Projects, "smtp_addr", config.Notify.SMTPAddr)
+	return nil
+}
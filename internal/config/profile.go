@@ -0,0 +1,167 @@
+package config
+
+import (
+	"alexandria/internal/logger"
+	"fmt"
+	"os"
+	"sort"
+)
+
+// Profile is a named database connection, so one user can switch between several
+// ticket databases. Secrets are not stored: a Turso token is read from the
+// environment variable named by TokenEnv.
+type Profile struct {
+	Name     string `json:"-"`
+	Type     string `json:"type"`                // database type, as for Config.DatabaseType
+	Path     string `json:"path,omitempty"`      // SQLite file, or the replica file for sync
+	URL      string `json:"url,omitempty"`       // Turso URL or PostgreSQL DSN
+	TokenEnv string `json:"token_env,omitempty"` // environment variable holding the Turso auth token
+}
+
+// TursoURL returns the profile's Turso URL, falling back to TURSO_URL
+func (p Profile) TursoURL() string {
+	if p.URL != "" {
+		return p.URL
+	}
+	return os.Getenv("TURSO_URL")
+}
+
+// TursoToken returns the Turso auth token from the profile's token variable,
+// falling back to TURSO_AUTH_TOKEN
+func (p Profile) TursoToken() string {
+	if p.TokenEnv != "" {
+		return os.Getenv(p.TokenEnv)
+	}
+	return os.Getenv("TURSO_AUTH_TOKEN")
+}
+
+// TokenVariable returns the name of the environment variable holding the Turso auth token
+func (p Profile) TokenVariable() string {
+	if p.TokenEnv != "" {
+		return p.TokenEnv
+	}
+	return "TURSO_AUTH_TOKEN"
+}
+
+// PostgresDSN returns the profile's PostgreSQL connection string, falling back to POSTGRES_DSN
+func (p Profile) PostgresDSN() string {
+	if p.URL != "" {
+		return p.URL
+	}
+	return os.Getenv("POSTGRES_DSN")
+}
+
+// Validate checks that the profile names a known database type and has what that type needs
+func (p Profile) Validate() error {
+	if !ValidDBType(p.Type) {
+		return fmt.Errorf("invalid database type: %s (must be %s, %s, %s or %s)",
+			p.Type, DBTypeSQLite, DBTypeTurso, DBTypeSync, DBTypePostgres)
+	}
+	if p.TokenEnv != "" && p.Type != DBTypeTurso && p.Type != DBTypeSync {
+		return fmt.Errorf("a token variable only applies to %s and %s profiles", DBTypeTurso, DBTypeSync)
+	}
+	if p.Path != "" && p.Type != DBTypeSQLite && p.Type != DBTypeSync {
+		return fmt.Errorf("a path only applies to %s and %s profiles", DBTypeSQLite, DBTypeSync)
+	}
+	return nil
+}
+
+// ActiveProfile returns the connection to use. A non-empty name selects a profile
+// explicitly; otherwise the config's selected profile is used, and without one the
+// plain DatabaseType with credentials from the environment.
+func (c *Config) ActiveProfile(name string) (Profile, error) {
+	if name == "" {
+		name = c.Profile
+	}
+	if name == "" {
+		return Profile{Type: c.DatabaseType}, nil
+	}
+	return c.LookupProfile(name)
+}
+
+// LookupProfile returns the named profile
+func (c *Config) LookupProfile(name string) (Profile, error) {
+	p, ok := c.Profiles[name]
+	if !ok {
+		logger.Log.Error("profile not found", "profile", name)
+		return Profile{}, fmt.Errorf("profile %q not found (see alexandria profile list)", name)
+	}
+	p.Name = name
+	return p, nil
+}
+
+// LookupSource resolves a profile name or a bare database type such as "sqlite",
+// which stands for that type with credentials from the environment
+func (c *Config) LookupSource(name string) (Profile, error) {
+	if _, ok := c.Profiles[name]; ok {
+		return c.LookupProfile(name)
+	}
+	if ValidDBType(name) {
+		return Profile{Type: name}, nil
+	}
+	return Profile{}, fmt.Errorf("%q is neither a profile nor a database type (%s, %s, %s or %s)",
+		name, DBTypeSQLite, DBTypeTurso, DBTypeSync, DBTypePostgres)
+}
+
+// ProfileNames returns the names of the configured profiles in alphabetical order
+func (c *Config) ProfileNames() []string {
+	names := make([]string, 0, len(c.Profiles))
+	for name := range c.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// AddProfile saves a profile in the user config, replacing any profile of the same name
+func AddProfile(name string, p Profile) error {
+	logger.Log.Debug("adding profile", "profile", name, "type", p.Type)
+
+	if name == "" {
+		return fmt.Errorf("profile name is required")
+	}
+	if ValidDBType(name) {
+		return fmt.Errorf("profile name %q is reserved for the database type", name)
+	}
+	if err := p.Validate(); err != nil {
+		logger.Log.Error("invalid profile", "error", err, "profile", name)
+		return err
+	}
+
+	config, err := Load()
+	if err != nil {
+		return fmt.Errorf("failed to add profile: %w", err)
+	}
+	if config.Profiles == nil {
+		config.Profiles = make(map[string]Profile)
+	}
+	config.Profiles[name] = p
+	if err := Save(config); err != nil {
+		logger.Log.Error("failed to save profile", "error", err, "profile", name)
+		return fmt.Errorf("failed to add profile: %w", err)
+	}
+
+	logger.Log.Info("profile added", "profile", name, "type", p.Type)
+	return nil
+}
+
+// UseProfile makes a profile the one used when no other is selected
+func UseProfile(name string) error {
+	logger.Log.Debug("selecting profile", "profile", name)
+
+	config, err := Load()
+	if err != nil {
+		return fmt.Errorf("failed to use profile: %w", err)
+	}
+	if _, err := config.LookupProfile(name); err != nil {
+		return err
+	}
+	config.Profile = name
+	if err := Save(config); err != nil {
+		logger.Log.Error("failed to save config", "error", err, "profile", name)
+		return fmt.Errorf("failed to use profile: %w", err)
+	}
+
+	logger.Log.Info("profile selected", "profile", name)
+	return nil
+}
@@ -5,20 +5,41 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"os/user"
 	"path/filepath"
 )
 
-// Config represents the application configuration
+// Config represents the application configuration. The user config in the home
+// directory and the .alexandria.toml or .alexandria.json file of a directory tree
+// share this shape; see Resolve.
 type Config struct {
-	DatabaseType string `json:"database_type"` // "sqlite" or "turso"
+	DatabaseType string              `json:"database_type" toml:"database_type"` // "sqlite", "turso", "sync" or "postgres"
+	Views        map[string][]string `json:"views,omitempty" toml:"-"`           // personal saved views: name -> list arguments
+	Project      string              `json:"project,omitempty" toml:"project"`   // default project for commands
+	Assignee     string              `json:"assignee,omitempty" toml:"assignee"` // default assignee for new tickets
+	Tags         []string            `json:"tags,omitempty" toml:"tags"`         // default tags for new tickets
+	Profile      string              `json:"profile,omitempty" toml:"profile"`   // selected profile, which takes the place of DatabaseType
+	Profiles     map[string]Profile  `json:"profiles,omitempty" toml:"-"`        // named database connections
+	Notify       *Notify             `json:"notify,omitempty" toml:"notify"`     // email notifications
 }
 
 // DBType constants
 const (
-	DBTypeSQLite = "sqlite"
-	DBTypeTurso  = "turso"
+	DBTypeSQLite   = "sqlite"
+	DBTypeTurso    = "turso"
+	DBTypeSync     = "sync" // local SQLite replica synced with Turso
+	DBTypePostgres = "postgres"
 )
 
+// ValidDBType reports whether dbType is a supported database type
+func ValidDBType(dbType string) bool {
+	switch dbType {
+	case DBTypeSQLite, DBTypeTurso, DBTypeSync, DBTypePostgres:
+		return true
+	}
+	return false
+}
+
 // getConfigPath returns the path to the config file
 func getConfigPath() (string, error) {
 	homeDir, err := os.UserHomeDir()
@@ -115,26 +136,38 @@ func Save(config *Config) error {
 	return nil
 }
 
-// GetCurrentDBType returns the currently configured database type
+// GetCurrentDBType returns the database type in effect for the current directory,
+// taking the selected profile into account
 func GetCurrentDBType() (string, error) {
-	config, err := Load()
+	config, err := Resolve()
 	if err != nil {
 		return "", err
 	}
-	return config.DatabaseType, nil
+	p, err := config.ActiveProfile("")
+	if err != nil {
+		return "", err
+	}
+	return p.Type, nil
 }
 
 // SwitchDB changes the database type to the specified type
 func SwitchDB(dbType string) error {
 	logger.Log.Debug("switching database type", "from", "current", "to", dbType)
 
-	if dbType != DBTypeSQLite && dbType != DBTypeTurso {
+	if !ValidDBType(dbType) {
 		logger.Log.Error("invalid database type requested", "type", dbType)
-		return fmt.Errorf("invalid database type: %s (must be %s or %s)",
-			dbType, DBTypeSQLite, DBTypeTurso)
+		return fmt.Errorf("invalid database type: %s (must be %s, %s, %s or %s)",
+			dbType, DBTypeSQLite, DBTypeTurso, DBTypeSync, DBTypePostgres)
 	}
 
-	config := &Config{DatabaseType: dbType}
+	// Preserve the rest of the configuration, such as personal views
+	config, err := Load()
+	if err != nil {
+		logger.Log.Error("failed to load config during database switch", "error", err)
+		return fmt.Errorf("failed to switch database: %w", err)
+	}
+	config.DatabaseType = dbType
+	config.Profile = "" // a plain source replaces any selected profile
 	if err := Save(config); err != nil {
 		logger.Log.Error("failed to save config during database switch", "error", err, "type", dbType)
 		return fmt.Errorf("failed to switch database: %w", err)
@@ -143,3 +176,18 @@ func SwitchDB(dbType string) error {
 	logger.Log.Info("database type switched", "database_type", dbType)
 	return nil
 }
+
+// CurrentUser returns the name recorded against worklogs and other per-user data.
+// ALEXANDRIA_USER takes precedence over the operating system account name.
+func CurrentUser() string {
+	if name := os.Getenv("ALEXANDRIA_USER"); name != "" {
+		return name
+	}
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		return u.Username
+	}
+	if name := os.Getenv("USER"); name != "" {
+		return name
+	}
+	return "unknown"
+}
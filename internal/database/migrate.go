@@ -0,0 +1,339 @@
+package database
+
+import (
+	"alexandria/internal/dialect"
+	"alexandria/internal/logger"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// migrateTable describes how a table is copied between databases
+type migrateTable struct {
+	name string
+	key  []string
+	// autoID tables hold rows that only matter for their content, so when merging
+	// a row whose ID is taken by a different row is copied under a new ID
+	autoID bool
+}
+
+// migrateTables lists the tables copied by Migrate, parents before children.
+// Tables added to the schema that hold user data belong here too.
+var migrateTables = []migrateTable{
+	{name: "tickets", key: []string{"id"}},
+	{name: "users", key: []string{"id"}},
+	{name: "ticket_tags", key: []string{"ticket_id", "tag"}},
+	{name: "ticket_files", key: []string{"id"}, autoID: true},
+	{name: "ticket_comments", key: []string{"id"}, autoID: true},
+	{name: "ticket_worklogs", key: []string{"id"}, autoID: true},
+	{name: "ticket_status_history", key: []string{"id"}, autoID: true},
+	{name: "ticket_commits", key: []string{"ticket_id", "sha"}},
+	{name: "ticket_checklist_items", key: []string{"id"}, autoID: true},
+	{name: "saved_views", key: []string{"name"}},
+	{name: "ticket_templates", key: []string{"project", "name"}},
+	{name: "archived_tickets", key: []string{"id"}},
+	{name: "archived_ticket_tags", key: []string{"ticket_id", "tag"}},
+	{name: "archived_ticket_files", key: []string{"id"}, autoID: true},
+	{name: "archived_ticket_comments", key: []string{"id"}, autoID: true},
+	{name: "archived_ticket_worklogs", key: []string{"id"}, autoID: true},
+	{name: "archived_ticket_status_history", key: []string{"id"}, autoID: true},
+	{name: "archived_ticket_commits", key: []string{"ticket_id", "sha"}},
+	{name: "archived_ticket_checklist_items", key: []string{"id"}, autoID: true},
+	{name: "webhooks", key: []string{"id"}},
+	{name: "ticket_watchers", key: []string{"ticket_id", "username"}},
+	{name: "notification_prefs", key: []string{"username"}},
+	{name: "recurring_schedules", key: []string{"project", "name"}},
+	{name: "schedule_runs", key: []string{"project", "schedule", "occurrence"}},
+}
+
+// TableCount records how many rows of a table were migrated
+type TableCount struct {
+	Table   string `json:"table"`
+	Source  int    `json:"source"`
+	Copied  int    `json:"copied"`
+	Skipped int    `json:"skipped"` // identical rows already in the target
+}
+
+// Migrate copies every ticket and user from src to dst in one transaction,
+// keeping IDs and timestamps. The target must be empty unless merge is set;
+// when merging, rows already present are skipped and a ticket or user whose ID
+// is taken by a different record aborts the migration. Row counts are checked
+// before committing.
+func Migrate(src, dst *sql.DB, merge bool) ([]TableCount, error) {
+	logger.Log.Debug("migrating database", "merge", merge)
+
+	if !merge {
+		for _, t := range migrateTables {
+			n, err := countRows(dst, t.name)
+			if err != nil {
+				return nil, err
+			}
+			if n > 0 {
+				logger.Log.Error("target database is not empty", "table", t.name, "rows", n)
+				return nil, fmt.Errorf("target database already has %d row(s) in %s; use --merge to add to it", n, t.name)
+			}
+		}
+	}
+
+	tx, err := dst.Begin()
+	if err != nil {
+		logger.Log.Error("failed to begin transaction", "error", err)
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	d := dialect.For(dst)
+	var counts []TableCount
+	for _, t := range migrateTables {
+		count, err := migrateRows(src, tx, t)
+		if err != nil {
+			return nil, err
+		}
+		counts = append(counts, count)
+
+		// Rows inserted with explicit IDs do not advance PostgreSQL's ID sequences
+		if t.key[0] == "id" {
+			if query := d.ResetIDQuery(t.name); query != "" {
+				if _, err := tx.Exec(query); err != nil {
+					logger.Log.Error("failed to reset ID sequence", "error", err, "table", t.name)
+					return nil, fmt.Errorf("failed to reset ID sequence for %s: %w", t.name, err)
+				}
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		logger.Log.Error("failed to commit migration", "error", err)
+		return nil, fmt.Errorf("failed to commit migration: %w", err)
+	}
+
+	logger.Log.Info("database migrated", "tables", len(counts))
+	return counts, nil
+}
+
+// migrateRows copies one table and checks the target gained the expected rows
+func migrateRows(src *sql.DB, tx *sql.Tx, t migrateTable) (TableCount, error) {
+	count := TableCount{Table: t.name}
+
+	cols, err := tableColumns(src, t.name)
+	if err != nil {
+		return count, err
+	}
+	rows, err := readRows(src, t.name, cols, "")
+	if err != nil {
+		logger.Log.Error("failed to read source table", "error", err, "table", t.name)
+		return count, fmt.Errorf("failed to read %s: %w", t.name, err)
+	}
+	count.Source = len(rows)
+
+	before, err := countRows(tx, t.name)
+	if err != nil {
+		return count, err
+	}
+
+	insert := insertQuery(t.name, cols)
+	for _, r := range rows {
+		existing, err := findRow(tx, t, cols, r)
+		if err != nil {
+			return count, err
+		}
+
+		switch {
+		case existing == nil:
+			if _, err := tx.Exec(insert, r.values(cols)...); err != nil {
+				logger.Log.Error("failed to copy row", "error", err, "table", t.name)
+				return count, fmt.Errorf("failed to copy %s: %w", t.name, err)
+			}
+			count.Copied++
+		case existing.equal(r):
+			count.Skipped++
+		case t.autoID:
+			// Another row already uses this ID, so the copy gets a new one
+			var rest []string
+			for _, col := range cols {
+				if col != "id" {
+					rest = append(rest, col)
+				}
+			}
+			if _, err := tx.Exec(insertQuery(t.name, rest), r.values(rest)...); err != nil {
+				logger.Log.Error("failed to copy row", "error", err, "table", t.name)
+				return count, fmt.Errorf("failed to copy %s: %w", t.name, err)
+			}
+			count.Copied++
+		default:
+			logger.Log.Error("row conflicts with target", "table", t.name, "key", r.describe(t.key))
+			return count, fmt.Errorf("%s %s already exists in the target with different content", t.name, r.describe(t.key))
+		}
+	}
+
+	after, err := countRows(tx, t.name)
+	if err != nil {
+		return count, err
+	}
+	if after-before != count.Copied || count.Copied+count.Skipped != count.Source {
+		logger.Log.Error("row count mismatch", "table", t.name, "source", count.Source, "copied", count.Copied, "skipped", count.Skipped, "target_added", after-before)
+		return count, fmt.Errorf("row count mismatch in %s: %d in source, %d added to target", t.name, count.Source, after-before)
+	}
+
+	logger.Log.Debug("migrated table", "table", t.name, "copied", count.Copied, "skipped", count.Skipped)
+	return count, nil
+}
+
+// timeLayout is the layout the SQLite and Turso drivers store times in. Times read
+// from any database are written in it, so copies between SQLite and Turso are exact
+// and PostgreSQL parses them as timestamps.
+const timeLayout = "2006-01-02 15:04:05.999999999-07:00"
+
+// valueRow holds a row's values by column, with nil for NULL
+type valueRow map[string]interface{}
+
+func (r valueRow) values(cols []string) []interface{} {
+	args := make([]interface{}, len(cols))
+	for i, col := range cols {
+		args[i] = r[col]
+	}
+	return args
+}
+
+// equal compares rows by value, so a row read back from another kind of database
+// matches the row it was copied from
+func (r valueRow) equal(other valueRow) bool {
+	for col, v := range r {
+		if compareKey(v) != compareKey(other[col]) {
+			return false
+		}
+	}
+	return true
+}
+
+func (r valueRow) describe(key []string) string {
+	parts := make([]string, len(key))
+	for i, col := range key {
+		parts[i] = fmt.Sprintf("%s=%v", col, r[col])
+	}
+	return strings.Join(parts, ",")
+}
+
+// normalize converts a scanned value into one every driver accepts unchanged
+func normalize(v interface{}) interface{} {
+	switch val := v.(type) {
+	case []byte:
+		return string(val)
+	case time.Time:
+		return val.Format(timeLayout)
+	}
+	return v
+}
+
+// compareKey renders a normalized value so equal values from different drivers match
+func compareKey(v interface{}) string {
+	switch val := v.(type) {
+	case nil:
+		return "NULL"
+	case bool:
+		if val {
+			return "1"
+		}
+		return "0"
+	case string:
+		if t, err := time.Parse(timeLayout, val); err == nil {
+			return t.UTC().Format(time.RFC3339Nano)
+		}
+		return val
+	}
+	return fmt.Sprint(v)
+}
+
+// rowQuerier is satisfied by both *sql.DB and *sql.Tx
+type rowQuerier interface {
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// readRows reads rows of a table, optionally filtered by a WHERE clause
+func readRows(q rowQuerier, table string, cols []string, where string, args ...interface{}) ([]valueRow, error) {
+	query := fmt.Sprintf("SELECT %s FROM %s", strings.Join(cols, ", "), table)
+	if where != "" {
+		query += " WHERE " + where
+	}
+
+	rows, err := q.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []valueRow
+	for rows.Next() {
+		values := make([]interface{}, len(cols))
+		dest := make([]interface{}, len(cols))
+		for i := range values {
+			dest[i] = &values[i]
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+		r := make(valueRow, len(cols))
+		for i, col := range cols {
+			r[col] = normalize(values[i])
+		}
+		result = append(result, r)
+	}
+	return result, rows.Err()
+}
+
+// findRow returns the target row with the same key as r, or nil if there is none
+func findRow(tx *sql.Tx, t migrateTable, cols []string, r valueRow) (valueRow, error) {
+	conds := make([]string, len(t.key))
+	args := make([]interface{}, len(t.key))
+	for i, col := range t.key {
+		conds[i] = col + " = ?"
+		args[i] = r[col]
+	}
+
+	rows, err := readRows(tx, t.name, cols, strings.Join(conds, " AND "), args...)
+	if err != nil {
+		logger.Log.Error("failed to read target row", "error", err, "table", t.name)
+		return nil, fmt.Errorf("failed to read target %s: %w", t.name, err)
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+	return rows[0], nil
+}
+
+// tableColumns returns the column names of a table
+func tableColumns(db *sql.DB, table string) ([]string, error) {
+	rows, err := db.Query(dialect.For(db).ColumnsQuery(table))
+	if err != nil {
+		logger.Log.Error("failed to read table info", "error", err, "table", table)
+		return nil, fmt.Errorf("failed to read table info for %s: %w", table, err)
+	}
+	defer rows.Close()
+
+	var cols []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("failed to scan table info: %w", err)
+		}
+		cols = append(cols, name)
+	}
+	return cols, rows.Err()
+}
+
+// countRows returns the number of rows in a table
+func countRows(q rowQuerier, table string) (int, error) {
+	var n int
+	if err := q.QueryRow(fmt.Sprintf("SELECT COUNT(*) FROM %s", table)).Scan(&n); err != nil {
+		logger.Log.Error("failed to count rows", "error", err, "table", table)
+		return 0, fmt.Errorf("failed to count rows in %s: %w", table, err)
+	}
+	return n, nil
+}
+
+func insertQuery(table string, cols []string) string {
+	marks := strings.TrimSuffix(strings.Repeat("?, ", len(cols)), ", ")
+	return fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", table, strings.Join(cols, ", "), marks)
+}
@@ -1,6 +1,7 @@
 package database
 
 import (
+	"alexandria/internal/dialect"
 	"alexandria/internal/logger"
 	"database/sql"
 	"fmt"
@@ -9,6 +10,7 @@ import (
 // InitSchema creates all necessary tables and indexes if they don't exist
 func InitSchema(db *sql.DB) error {
 	logger.Log.Debug("initializing database schema")
+	d := dialect.For(db)
 
 	// Create tables in order (parent tables first, then child tables)
 	schemas := []struct {
@@ -20,21 +22,119 @@ func InitSchema(db *sql.DB) error {
 		{"ticket_files table", createTicketFilesTable},
 		{"ticket_comments table", createTicketCommentsTable},
 		{"users table", createUsersTable},
+		{"ticket_worklogs table", createTicketWorklogsTable},
+		{"active_timers table", createActiveTimersTable},
+		{"ticket_status_history table", createTicketStatusHistoryTable},
+		{"saved_views table", createSavedViewsTable},
+		{"ticket_commits table", createTicketCommitsTable},
+		{"ticket_templates table", createTicketTemplatesTable},
+		{"ticket_journal table", createTicketJournalTable},
+		{"archive tables", createArchiveTables},
+		{"webhooks table", createWebhooksTable},
+		{"webhook_deliveries table", createWebhookDeliveriesTable},
+		{"ticket_watchers table", createTicketWatchersTable},
+		{"notification_prefs table", createNotificationPrefsTable},
+		{"notifications table", createNotificationsTable},
+		{"recurring_schedules table", createRecurringSchedulesTable},
+		{"schedule_runs table", createScheduleRunsTable},
+		{"ticket_checklist_items table", createTicketChecklistItemsTable},
 		{"indexes", createTicketsIndexes},
 	}
 
 	for _, schema := range schemas {
 		logger.Log.Debug("creating schema", "name", schema.name)
-		if _, err := db.Exec(schema.script); err != nil {
+		if _, err := db.Exec(d.DDL(schema.script)); err != nil {
 			logger.Log.Error("failed to execute schema", "error", err, "name", schema.name)
 			return fmt.Errorf("failed to execute schema: %w", err)
 		}
 	}
 
+	// Bring databases created by older versions up to date
+	for _, m := range columnMigrations {
+		added, err := addColumnIfMissing(db, m.table, m.column, m.definition)
+		if err != nil {
+			return err
+		}
+		if added && m.backfill != "" {
+			logger.Log.Debug("backfilling column", "table", m.table, "column", m.column)
+			if _, err := db.Exec(m.backfill); err != nil {
+				logger.Log.Error("failed to backfill column", "error", err, "table", m.table, "column", m.column)
+				return fmt.Errorf("failed to backfill %s.%s: %w", m.table, m.column, err)
+			}
+		}
+	}
+
 	logger.Log.Debug("database schema initialized successfully")
 	return nil
 }
 
+// columnMigrations lists columns added to existing tables after their initial
+// release, each with an optional statement filling it in for existing rows
+var columnMigrations = []struct {
+	table      string
+	column     string
+	definition string
+	backfill   string
+}{
+	{"tickets", "due_at", "DATETIME", ""},
+	{"tickets", "start_at", "DATETIME", ""},
+	{"tickets", "story_points", "REAL", ""},
+	{"tickets", "estimate_hours", "REAL", ""},
+	{"tickets", "closed_at", "DATETIME", backfillClosedAt},
+	{"tickets", "deleted_at", "DATETIME", ""},
+	{"tickets", "parent_id", "INTEGER", ""},
+	{"archived_tickets", "parent_id", "INTEGER", ""},
+}
+
+// backfillClosedAt dates tickets closed before closed_at existed by their last
+// change to closed, or by their last update when no history was recorded
+const backfillClosedAt = `
+UPDATE tickets SET closed_at = COALESCE(
+    (SELECT MAX(h.changed_at) FROM ticket_status_history h WHERE h.ticket_id = tickets.id AND h.to_status = 'closed'),
+    updated_at
+) WHERE status = 'closed' AND closed_at IS NULL`
+
+// addColumnIfMissing adds a column to a table unless it already exists, reporting
+// whether it was added
+func addColumnIfMissing(db *sql.DB, table, column, definition string) (bool, error) {
+	exists, err := columnExists(db, table, column)
+	if err != nil {
+		return false, err
+	}
+	if exists {
+		return false, nil
+	}
+
+	logger.Log.Debug("adding column", "table", table, "column", column)
+	query := fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, dialect.For(db).DDL(definition))
+	if _, err := db.Exec(query); err != nil {
+		logger.Log.Error("failed to add column", "error", err, "table", table, "column", column)
+		return false, fmt.Errorf("failed to add column %s.%s: %w", table, column, err)
+	}
+	return true, nil
+}
+
+// columnExists reports whether a table already has the named column
+func columnExists(db *sql.DB, table, column string) (bool, error) {
+	rows, err := db.Query(dialect.For(db).ColumnsQuery(table))
+	if err != nil {
+		logger.Log.Error("failed to read table info", "error", err, "table", table)
+		return false, fmt.Errorf("failed to read table info for %s: %w", table, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return false, fmt.Errorf("failed to scan table info: %w", err)
+		}
+		if name == column {
+			return true, nil
+		}
+	}
+	return false, rows.Err()
+}
+
 const createTicketsTable = `
 CREATE TABLE IF NOT EXISTS tickets (
     id INTEGER PRIMARY KEY AUTOINCREMENT,
@@ -48,7 +148,14 @@ CREATE TABLE IF NOT EXISTS tickets (
     created_by TEXT,
     assigned_to TEXT,
     created_at DATETIME NOT NULL,
-    updated_at DATETIME NOT NULL
+    updated_at DATETIME NOT NULL,
+    due_at DATETIME,
+    start_at DATETIME,
+    story_points REAL,
+    estimate_hours REAL,
+    closed_at DATETIME,
+    deleted_at DATETIME,
+    parent_id INTEGER
 );`
 
 const createTicketTagsTable = `
@@ -88,6 +195,257 @@ const createUsersTable = `
       updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
 );`
 
+const createTicketWorklogsTable = `
+CREATE TABLE IF NOT EXISTS ticket_worklogs (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    ticket_id INTEGER NOT NULL,
+    username TEXT NOT NULL,
+    started_at DATETIME NOT NULL,
+    ended_at DATETIME NOT NULL,
+    note TEXT,
+    FOREIGN KEY (ticket_id) REFERENCES tickets(id) ON DELETE CASCADE
+);`
+
+const createActiveTimersTable = `
+CREATE TABLE IF NOT EXISTS active_timers (
+    username TEXT PRIMARY KEY,
+    ticket_id INTEGER NOT NULL,
+    started_at DATETIME NOT NULL,
+    note TEXT,
+    FOREIGN KEY (ticket_id) REFERENCES tickets(id) ON DELETE CASCADE
+);`
+
+const createTicketStatusHistoryTable = `
+CREATE TABLE IF NOT EXISTS ticket_status_history (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    ticket_id INTEGER NOT NULL,
+    from_status TEXT,
+    to_status TEXT NOT NULL,
+    changed_at DATETIME NOT NULL,
+    FOREIGN KEY (ticket_id) REFERENCES tickets(id) ON DELETE CASCADE
+);`
+
+const createSavedViewsTable = `
+CREATE TABLE IF NOT EXISTS saved_views (
+    name TEXT PRIMARY KEY,
+    args TEXT NOT NULL,
+    created_by TEXT,
+    created_at DATETIME NOT NULL,
+    updated_at DATETIME NOT NULL
+);`
+
+const createTicketCommitsTable = `
+CREATE TABLE IF NOT EXISTS ticket_commits (
+    ticket_id INTEGER NOT NULL,
+    sha TEXT NOT NULL,
+    summary TEXT NOT NULL,
+    author TEXT,
+    committed_at DATETIME NOT NULL,
+    PRIMARY KEY (ticket_id, sha),
+    FOREIGN KEY (ticket_id) REFERENCES tickets(id) ON DELETE CASCADE
+);`
+
+const createTicketTemplatesTable = `
+CREATE TABLE IF NOT EXISTS ticket_templates (
+    project TEXT NOT NULL,
+    name TEXT NOT NULL,
+    type TEXT,
+    priority TEXT,
+    tags TEXT,
+    description TEXT,
+    created_by TEXT,
+    created_at DATETIME NOT NULL,
+    updated_at DATETIME NOT NULL,
+    PRIMARY KEY (project, name)
+);`
+
+const createTicketJournalTable = `
+CREATE TABLE IF NOT EXISTS ticket_journal (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    ticket_id INTEGER NOT NULL,
+    username TEXT NOT NULL,
+    op TEXT NOT NULL,
+    before_image TEXT,
+    created_at DATETIME NOT NULL,
+    undone_at DATETIME,
+    FOREIGN KEY (ticket_id) REFERENCES tickets(id) ON DELETE CASCADE
+);`
+
+// createArchiveTables mirror the ticket tables for tickets moved out of them by
+// archiving. They have no foreign keys since the tickets are gone from tickets.
+const createArchiveTables = `
+CREATE TABLE IF NOT EXISTS archived_tickets (
+    id INTEGER PRIMARY KEY,
+    project TEXT NOT NULL,
+    type TEXT NOT NULL,
+    title TEXT NOT NULL,
+    description TEXT,
+    critical_path BOOLEAN DEFAULT 0,
+    status TEXT NOT NULL,
+    priority TEXT NOT NULL,
+    created_by TEXT,
+    assigned_to TEXT,
+    created_at DATETIME NOT NULL,
+    updated_at DATETIME NOT NULL,
+    due_at DATETIME,
+    start_at DATETIME,
+    story_points REAL,
+    estimate_hours REAL,
+    closed_at DATETIME,
+    deleted_at DATETIME,
+    archived_at DATETIME NOT NULL,
+    parent_id INTEGER
+);
+CREATE TABLE IF NOT EXISTS archived_ticket_tags (
+    ticket_id INTEGER NOT NULL,
+    tag TEXT NOT NULL,
+    PRIMARY KEY (ticket_id, tag)
+);
+CREATE TABLE IF NOT EXISTS archived_ticket_files (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    ticket_id INTEGER NOT NULL,
+    file_path TEXT NOT NULL
+);
+CREATE TABLE IF NOT EXISTS archived_ticket_comments (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    ticket_id INTEGER NOT NULL,
+    comment_text TEXT NOT NULL,
+    created_at DATETIME NOT NULL
+);
+CREATE TABLE IF NOT EXISTS archived_ticket_worklogs (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    ticket_id INTEGER NOT NULL,
+    username TEXT NOT NULL,
+    started_at DATETIME NOT NULL,
+    ended_at DATETIME NOT NULL,
+    note TEXT
+);
+CREATE TABLE IF NOT EXISTS archived_ticket_status_history (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    ticket_id INTEGER NOT NULL,
+    from_status TEXT,
+    to_status TEXT NOT NULL,
+    changed_at DATETIME NOT NULL
+);
+CREATE TABLE IF NOT EXISTS archived_ticket_commits (
+    ticket_id INTEGER NOT NULL,
+    sha TEXT NOT NULL,
+    summary TEXT NOT NULL,
+    author TEXT,
+    committed_at DATETIME NOT NULL,
+    PRIMARY KEY (ticket_id, sha)
+);
+CREATE TABLE IF NOT EXISTS archived_ticket_checklist_items (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    ticket_id INTEGER NOT NULL,
+    position INTEGER NOT NULL,
+    item_text TEXT NOT NULL,
+    done_at DATETIME,
+    created_at DATETIME NOT NULL
+);`
+
+const createWebhooksTable = `
+CREATE TABLE IF NOT EXISTS webhooks (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    project TEXT NOT NULL,
+    url TEXT NOT NULL,
+    events TEXT NOT NULL,
+    secret TEXT NOT NULL,
+    created_by TEXT,
+    created_at DATETIME NOT NULL
+);`
+
+// Deliveries are a local queue, so they have no foreign key that a sync or
+// migration rewriting webhooks could cascade through
+const createWebhookDeliveriesTable = `
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    webhook_id INTEGER NOT NULL,
+    event TEXT NOT NULL,
+    ticket_id INTEGER NOT NULL,
+    payload TEXT NOT NULL,
+    status TEXT NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at DATETIME NOT NULL,
+    last_error TEXT,
+    response_code INTEGER,
+    created_at DATETIME NOT NULL,
+    delivered_at DATETIME
+);`
+
+const createTicketWatchersTable = `
+CREATE TABLE IF NOT EXISTS ticket_watchers (
+    ticket_id INTEGER NOT NULL,
+    username TEXT NOT NULL,
+    created_at DATETIME NOT NULL,
+    PRIMARY KEY (ticket_id, username),
+    FOREIGN KEY (ticket_id) REFERENCES tickets(id) ON DELETE CASCADE
+);`
+
+const createNotificationPrefsTable = `
+CREATE TABLE IF NOT EXISTS notification_prefs (
+    username TEXT PRIMARY KEY,
+    mode TEXT NOT NULL CHECK(mode IN ('immediate', 'digest', 'off')),
+    events TEXT NOT NULL,
+    updated_at DATETIME NOT NULL
+);`
+
+// Like webhook deliveries, notifications are a local queue without foreign keys
+const createNotificationsTable = `
+CREATE TABLE IF NOT EXISTS notifications (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    username TEXT NOT NULL,
+    email TEXT NOT NULL,
+    event TEXT NOT NULL,
+    ticket_id INTEGER NOT NULL,
+    subject TEXT NOT NULL,
+    body TEXT NOT NULL,
+    digest BOOLEAN DEFAULT 0,
+    status TEXT NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT,
+    created_at DATETIME NOT NULL,
+    sent_at DATETIME
+);`
+
+const createRecurringSchedulesTable = `
+CREATE TABLE IF NOT EXISTS recurring_schedules (
+    project TEXT NOT NULL,
+    name TEXT NOT NULL,
+    template TEXT NOT NULL,
+    title TEXT,
+    spec TEXT NOT NULL,
+    cron TEXT NOT NULL,
+    assigned_to TEXT,
+    due_in TEXT,
+    created_by TEXT,
+    created_at DATETIME NOT NULL,
+    last_run_at DATETIME,
+    PRIMARY KEY (project, name)
+);`
+
+// Runs have no foreign key to tickets so the claim on an occurrence outlives the
+// ticket it created being archived or purged, and is never created again
+const createScheduleRunsTable = `
+CREATE TABLE IF NOT EXISTS schedule_runs (
+    project TEXT NOT NULL,
+    schedule TEXT NOT NULL,
+    occurrence DATETIME NOT NULL,
+    ticket_id INTEGER,
+    created_at DATETIME NOT NULL,
+    PRIMARY KEY (project, schedule, occurrence)
+);`
+
+const createTicketChecklistItemsTable = `
+CREATE TABLE IF NOT EXISTS ticket_checklist_items (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    ticket_id INTEGER NOT NULL,
+    position INTEGER NOT NULL,
+    item_text TEXT NOT NULL,
+    done_at DATETIME,
+    created_at DATETIME NOT NULL,
+    FOREIGN KEY (ticket_id) REFERENCES tickets(id) ON DELETE CASCADE
+);`
 
 const createTicketsIndexes = `
 CREATE INDEX IF NOT EXISTS idx_tickets_project ON tickets(project);
@@ -95,5 +453,21 @@ CREATE INDEX IF NOT EXISTS idx_tickets_status ON tickets(status);
 CREATE INDEX IF NOT EXISTS idx_tickets_priority ON tickets(priority);
 CREATE INDEX IF NOT EXISTS idx_tickets_type ON tickets(type);
 CREATE INDEX IF NOT EXISTS idx_tickets_type ON tickets(type);
+CREATE INDEX IF NOT EXISTS idx_worklogs_ticket ON ticket_worklogs(ticket_id);
+CREATE INDEX IF NOT EXISTS idx_worklogs_started ON ticket_worklogs(started_at);
+CREATE INDEX IF NOT EXISTS idx_status_history_ticket ON ticket_status_history(ticket_id);
+CREATE INDEX IF NOT EXISTS idx_ticket_commits_sha ON ticket_commits(sha);
+CREATE INDEX IF NOT EXISTS idx_journal_user ON ticket_journal(username);
+CREATE INDEX IF NOT EXISTS idx_archived_tickets_project ON archived_tickets(project);
+CREATE INDEX IF NOT EXISTS idx_archived_tags_ticket ON archived_ticket_tags(ticket_id);
+CREATE INDEX IF NOT EXISTS idx_archived_files_ticket ON archived_ticket_files(ticket_id);
+CREATE INDEX IF NOT EXISTS idx_archived_comments_ticket ON archived_ticket_comments(ticket_id);
+CREATE INDEX IF NOT EXISTS idx_webhooks_project ON webhooks(project);
+CREATE INDEX IF NOT EXISTS idx_deliveries_status ON webhook_deliveries(status);
+CREATE INDEX IF NOT EXISTS idx_watchers_username ON ticket_watchers(username);
+CREATE INDEX IF NOT EXISTS idx_notifications_status ON notifications(status);
+CREATE INDEX IF NOT EXISTS idx_schedule_runs_ticket ON schedule_runs(ticket_id);
+CREATE INDEX IF NOT EXISTS idx_checklist_items_ticket ON ticket_checklist_items(ticket_id);
+CREATE INDEX IF NOT EXISTS idx_archived_checklist_items_ticket ON archived_ticket_checklist_items(ticket_id);
 CREATE UNIQUE INDEX IF NOT EXISTS idx_users_username ON users(username);
 CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email ON users(LOWER(email));`
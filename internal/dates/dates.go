@@ -0,0 +1,158 @@
+package dates
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// dateLayouts are the absolute formats accepted by Parse
+var dateLayouts = []string{
+	"2006-01-02",
+	"2006-01-02 15:04",
+	"2006-01-02T15:04",
+	time.RFC3339,
+}
+
+// Parse converts a user supplied date into a time relative to now.
+// It accepts absolute dates (2026-11-01, 2026-11-01 17:00), the words
+// today, tomorrow and yesterday, weekday names (friday, next friday)
+// and offsets such as 3d, +2w or -1d.
+func Parse(value string, now time.Time) (time.Time, error) {
+	s := strings.ToLower(strings.TrimSpace(value))
+	if s == "" {
+		return time.Time{}, fmt.Errorf("empty date")
+	}
+
+	today := StartOfDay(now)
+
+	switch s {
+	case "today", "now":
+		return today, nil
+	case "tomorrow":
+		return today.AddDate(0, 0, 1), nil
+	case "yesterday":
+		return today.AddDate(0, 0, -1), nil
+	}
+
+	// Weekday names, optionally prefixed with "next"
+	next := false
+	if strings.HasPrefix(s, "next ") {
+		next = true
+		s = strings.TrimSpace(strings.TrimPrefix(s, "next "))
+	}
+	if wd, ok := parseWeekday(s); ok {
+		days := (int(wd) - int(today.Weekday()) + 7) % 7
+		if next && days == 0 {
+			days = 7
+		} else if next {
+			days += 7
+		}
+		return today.AddDate(0, 0, days), nil
+	}
+	if next {
+		return time.Time{}, fmt.Errorf("invalid date: %s", value)
+	}
+
+	// Relative offsets such as 3d, +2w, -1d
+	if d, err := ParseDuration(strings.TrimPrefix(s, "+")); err == nil {
+		if d%(24*time.Hour) == 0 {
+			return today.Add(d), nil
+		}
+		return now.Add(d), nil
+	}
+
+	for _, layout := range dateLayouts {
+		if t, err := time.ParseInLocation(layout, strings.TrimSpace(value), now.Location()); err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("invalid date: %s (use YYYY-MM-DD, today, tomorrow, a weekday or an offset like 3d)", value)
+}
+
+// ParseDuration extends time.ParseDuration with day (d) and week (w) units,
+// so values like 7d, 2w or 1d12h are accepted
+func ParseDuration(value string) (time.Duration, error) {
+	s := strings.TrimSpace(value)
+	if s == "" {
+		return 0, fmt.Errorf("empty duration")
+	}
+
+	sign := time.Duration(1)
+	if strings.HasPrefix(s, "-") {
+		sign = -1
+		s = s[1:]
+	}
+
+	var total time.Duration
+	rest := s
+	for _, unit := range []struct {
+		suffix string
+		size   time.Duration
+	}{
+		{"w", 7 * 24 * time.Hour},
+		{"d", 24 * time.Hour},
+	} {
+		idx := strings.Index(rest, unit.suffix)
+		if idx < 0 {
+			continue
+		}
+		n, err := strconv.Atoi(rest[:idx])
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration: %s", value)
+		}
+		total += time.Duration(n) * unit.size
+		rest = rest[idx+1:]
+	}
+
+	if rest != "" {
+		d, err := time.ParseDuration(rest)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration: %s", value)
+		}
+		total += d
+	}
+
+	return sign * total, nil
+}
+
+// StartOfDay returns midnight at the start of t's day in t's location
+func StartOfDay(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+}
+
+// parseWeekday matches full and three letter weekday names
+func parseWeekday(s string) (time.Weekday, bool) {
+	for wd := time.Sunday; wd <= time.Saturday; wd++ {
+		name := strings.ToLower(wd.String())
+		if s == name || s == name[:3] {
+			return wd, true
+		}
+	}
+	return 0, false
+}
+
+// StartOfWeek returns midnight on the Monday of t's week
+func StartOfWeek(t time.Time) time.Time {
+	day := StartOfDay(t)
+	offset := (int(day.Weekday()) + 6) % 7
+	return day.AddDate(0, 0, -offset)
+}
+
+// FormatDuration renders a duration as hours and minutes, e.g. 1h30m or 45m
+func FormatDuration(d time.Duration) string {
+	d = d.Round(time.Minute)
+	h := int(d / time.Hour)
+	m := int((d % time.Hour) / time.Minute)
+	switch {
+	case h > 0 && m > 0:
+		return fmt.Sprintf("%dh%02dm", h, m)
+	case h > 0:
+		return fmt.Sprintf("%dh", h)
+	default:
+		return fmt.Sprintf("%dm", m)
+	}
+}
@@ -0,0 +1,107 @@
+package dates_test
+
+import (
+	"alexandria/internal/dates"
+	"testing"
+	"time"
+)
+
+// monday is the time the tests parse relative to: Monday 12 October 2026, 10:30
+var monday = time.Date(2026, 10, 12, 10, 30, 0, 0, time.UTC)
+
+func day(d int) time.Time {
+	return time.Date(2026, 10, d, 0, 0, 0, 0, time.UTC)
+}
+
+func TestParse(t *testing.T) {
+	friday := time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		value string
+		now   time.Time
+		want  time.Time
+	}{
+		{"today", monday, day(12)},
+		{"now", monday, day(12)},
+		{"Tomorrow", monday, day(13)},
+		{"yesterday", monday, day(11)},
+
+		// A bare weekday is the next one on or after today, so friday on a Friday is today
+		{"friday", monday, day(16)},
+		{"fri", monday, day(16)},
+		{"friday", friday, day(16)},
+		{"monday", monday, day(12)},
+		{"sunday", monday, day(18)},
+
+		// next skips the coming one: next friday on a Monday is 11 days out, and on a
+		// Friday a week away
+		{"next friday", monday, day(23)},
+		{"next friday", friday, day(23)},
+		{"next monday", monday, day(19)},
+
+		// Whole days count from midnight, anything finer from now
+		{"3d", monday, day(15)},
+		{"+2w", monday, day(26)},
+		{"-1d", monday, day(11)},
+		{"36h", monday, monday.Add(36 * time.Hour)},
+
+		{"2026-11-01", monday, time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)},
+		{"2026-11-01 17:00", monday, time.Date(2026, 11, 1, 17, 0, 0, 0, time.UTC)},
+		{"2026-11-01T17:00", monday, time.Date(2026, 11, 1, 17, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		got, err := dates.Parse(tt.value, tt.now)
+		if err != nil {
+			t.Errorf("Parse(%q, %s): %v", tt.value, tt.now.Weekday(), err)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("Parse(%q, %s) = %s, want %s", tt.value, tt.now.Weekday(), got, tt.want)
+		}
+	}
+
+	for _, value := range []string{"", "next", "next week", "soon", "2026-13-01"} {
+		if got, err := dates.Parse(value, monday); err == nil {
+			t.Errorf("Parse(%q) = %s, want an error", value, got)
+		}
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"90m", 90 * time.Minute},
+		{"1h30m", 90 * time.Minute},
+		{"7d", 7 * 24 * time.Hour},
+		{"2w", 14 * 24 * time.Hour},
+		{"1d12h", 36 * time.Hour},
+		{"1w2d", 9 * 24 * time.Hour},
+		{"-1d", -24 * time.Hour},
+	}
+	for _, tt := range tests {
+		got, err := dates.ParseDuration(tt.value)
+		if err != nil || got != tt.want {
+			t.Errorf("ParseDuration(%q) = %s, %v; want %s", tt.value, got, err, tt.want)
+		}
+	}
+
+	for _, value := range []string{"", "d", "xd", "3 days", "1y"} {
+		if got, err := dates.ParseDuration(value); err == nil {
+			t.Errorf("ParseDuration(%q) = %s, want an error", value, got)
+		}
+	}
+}
+
+func TestStartOfWeek(t *testing.T) {
+	for d := 12; d <= 18; d++ {
+		at := time.Date(2026, 10, d, 23, 59, 0, 0, time.UTC)
+		if got := dates.StartOfWeek(at); !got.Equal(day(12)) {
+			t.Errorf("StartOfWeek(%s) = %s, want Monday the 12th", at.Weekday(), got)
+		}
+	}
+	if got := dates.StartOfWeek(day(19)); !got.Equal(day(19)) {
+		t.Errorf("StartOfWeek(Monday the 19th) = %s, want itself", got)
+	}
+}
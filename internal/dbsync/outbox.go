@@ -0,0 +1,272 @@
+package dbsync
+
+import (
+	"alexandria/internal/logger"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Outbox operations recorded by the triggers
+const (
+	opInsert = "insert"
+	opUpdate = "update"
+	opDelete = "delete"
+)
+
+// row holds column values as text, with nil for NULL. Values are compared as
+// text so rows read through different drivers can be matched exactly.
+type row map[string]*string
+
+// equalValue reports whether two column values are the same
+func equalValue(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
+
+// entry is one change recorded in the outbox
+type entry struct {
+	id       int64
+	table    table
+	op       string
+	key      row
+	old      row
+	new      row
+	conflict *Conflict
+}
+
+// identity names the row an entry changes, such as "tickets:id=4"
+func (e *entry) identity() string {
+	parts := make([]string, len(e.table.key))
+	for i, col := range e.table.key {
+		v := "NULL"
+		if e.key[col] != nil {
+			v = *e.key[col]
+		}
+		parts[i] = col + "=" + v
+	}
+	return e.table.name + ":" + strings.Join(parts, ",")
+}
+
+// ticketID returns the ticket the changed row belongs to, or 0 if it has none
+func (e *entry) ticketID() int64 {
+	if e.table.ticket == "" {
+		return 0
+	}
+	for _, r := range []row{e.key, e.new, e.old} {
+		if v := r[e.table.ticket]; v != nil {
+			id, _ := strconv.ParseInt(*v, 10, 64)
+			return id
+		}
+	}
+	return 0
+}
+
+// owner identifies what a conflict holds back: the whole ticket for ticket data,
+// otherwise just the row
+func (e *entry) owner() string {
+	if id := e.ticketID(); id != 0 {
+		return "ticket:" + strconv.FormatInt(id, 10)
+	}
+	return e.identity()
+}
+
+// remap rewrites references to tickets that were renumbered when pushed
+func (e *entry) remap(ticketIDs map[int64]int64) {
+	if e.table.ticket == "" {
+		return
+	}
+	for _, r := range []row{e.key, e.new, e.old} {
+		for _, col := range append([]string{e.table.ticket}, e.table.refs...) {
+			v := r[col]
+			if v == nil {
+				continue
+			}
+			id, err := strconv.ParseInt(*v, 10, 64)
+			if err != nil {
+				continue
+			}
+			if remote, ok := ticketIDs[id]; ok {
+				s := strconv.FormatInt(remote, 10)
+				r[col] = &s
+			}
+		}
+	}
+}
+
+// decodeRow parses a JSON object written by the triggers
+func decodeRow(value sql.NullString) (row, error) {
+	r := row{}
+	if !value.Valid {
+		return r, nil
+	}
+
+	dec := json.NewDecoder(strings.NewReader(value.String))
+	dec.UseNumber()
+	var raw map[string]interface{}
+	if err := dec.Decode(&raw); err != nil {
+		return nil, err
+	}
+
+	for col, v := range raw {
+		switch val := v.(type) {
+		case nil:
+			r[col] = nil
+		case string:
+			r[col] = &val
+		case json.Number:
+			s := val.String()
+			r[col] = &s
+		default:
+			s := fmt.Sprint(val)
+			r[col] = &s
+		}
+	}
+	return r, nil
+}
+
+// loadEntries reads the outbox, oldest change first
+func loadEntries(db *sql.DB) ([]*entry, error) {
+	rows, err := db.Query(`SELECT id, table_name, op, row_key, old_values, new_values, conflict FROM sync_outbox ORDER BY id`)
+	if err != nil {
+		logger.Log.Error("failed to query outbox", "error", err)
+		return nil, fmt.Errorf("failed to query outbox: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []*entry
+	for rows.Next() {
+		e := &entry{}
+		var tableName string
+		var key, oldValues, newValues, conflict sql.NullString
+		if err := rows.Scan(&e.id, &tableName, &e.op, &key, &oldValues, &newValues, &conflict); err != nil {
+			logger.Log.Error("failed to scan outbox entry", "error", err)
+			return nil, fmt.Errorf("failed to scan outbox entry: %w", err)
+		}
+
+		t, ok := tableByName(tableName)
+		if !ok {
+			logger.Log.Warn("skipping outbox entry for unknown table", "table", tableName, "id", e.id)
+			continue
+		}
+		e.table = t
+
+		if e.key, err = decodeRow(key); err != nil {
+			return nil, fmt.Errorf("failed to parse outbox entry %d: %w", e.id, err)
+		}
+		if e.old, err = decodeRow(oldValues); err != nil {
+			return nil, fmt.Errorf("failed to parse outbox entry %d: %w", e.id, err)
+		}
+		if e.new, err = decodeRow(newValues); err != nil {
+			return nil, fmt.Errorf("failed to parse outbox entry %d: %w", e.id, err)
+		}
+		if conflict.Valid {
+			e.conflict = &Conflict{}
+			if err := json.Unmarshal([]byte(conflict.String), e.conflict); err != nil {
+				return nil, fmt.Errorf("failed to parse conflict for outbox entry %d: %w", e.id, err)
+			}
+		}
+		entries = append(entries, e)
+	}
+
+	if err := rows.Err(); err != nil {
+		logger.Log.Error("error iterating outbox", "error", err)
+		return nil, fmt.Errorf("error iterating outbox: %w", err)
+	}
+	return entries, nil
+}
+
+// compact drops changes that cancel out before reaching the remote: rows inserted
+// and deleted again, along with any edits in between, and rows deleted and
+// reinserted unchanged (which happens to tags on every ticket update).
+func compact(entries []*entry) (kept []*entry, dropped []int64) {
+	drop := make(map[int]bool)
+	lastInsert := make(map[string]int)
+	lastDelete := make(map[string]int)
+
+	for i, e := range entries {
+		id := e.identity()
+		switch e.op {
+		case opInsert:
+			if j, ok := lastDelete[id]; ok && rowsEqual(entries[j].old, e.new) {
+				drop[i], drop[j] = true, true
+				delete(lastDelete, id)
+				continue
+			}
+			lastInsert[id] = i
+		case opDelete:
+			if j, ok := lastInsert[id]; ok {
+				for k := j; k <= i; k++ {
+					if entries[k].identity() == id {
+						drop[k] = true
+					}
+				}
+				delete(lastInsert, id)
+				continue
+			}
+			lastDelete[id] = i
+		}
+	}
+
+	for i, e := range entries {
+		if drop[i] {
+			dropped = append(dropped, e.id)
+		} else {
+			kept = append(kept, e)
+		}
+	}
+	return kept, dropped
+}
+
+// rowsEqual reports whether two rows hold the same columns and values
+func rowsEqual(a, b row) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for col, v := range a {
+		other, ok := b[col]
+		if !ok || !equalValue(v, other) {
+			return false
+		}
+	}
+	return true
+}
+
+// deleteEntries removes entries from the outbox
+func deleteEntries(db *sql.DB, ids []int64) error {
+	for _, id := range ids {
+		if _, err := db.Exec(`DELETE FROM sync_outbox WHERE id = ?`, id); err != nil {
+			logger.Log.Error("failed to remove outbox entry", "error", err, "id", id)
+			return fmt.Errorf("failed to remove outbox entry: %w", err)
+		}
+	}
+	return nil
+}
+
+// saveConflict records why an entry could not be pushed
+func saveConflict(db *sql.DB, e *entry) error {
+	data, err := json.Marshal(e.conflict)
+	if err != nil {
+		return fmt.Errorf("failed to marshal conflict: %w", err)
+	}
+	if _, err := db.Exec(`UPDATE sync_outbox SET conflict = ? WHERE id = ?`, string(data), e.id); err != nil {
+		logger.Log.Error("failed to record conflict", "error", err, "id", e.id)
+		return fmt.Errorf("failed to record conflict: %w", err)
+	}
+	return nil
+}
+
+// sortedColumns returns a row's column names in a stable order
+func sortedColumns(r row) []string {
+	cols := make([]string, 0, len(r))
+	for col := range r {
+		cols = append(cols, col)
+	}
+	sort.Strings(cols)
+	return cols
+}
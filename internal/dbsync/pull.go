@@ -0,0 +1,296 @@
+package dbsync
+
+import (
+	"alexandria/internal/logger"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// pull replaces the replica's synced tables with the remote's contents. Rows
+// belonging to tickets with unresolved conflicts keep their local versions.
+// The pull runs in one local transaction, so an interrupted pull changes nothing.
+func pull(local, remote *sql.DB, res *Result) error {
+	entries, err := loadEntries(local)
+	if err != nil {
+		return err
+	}
+	held := make(map[string]bool)
+	for _, e := range entries {
+		held[e.owner()] = true
+	}
+
+	ticketIDs, err := loadIDMap(local)
+	if err != nil {
+		return err
+	}
+
+	tx, err := local.Begin()
+	if err != nil {
+		logger.Log.Error("failed to begin transaction", "error", err)
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Tickets are replaced before their child rows, so foreign keys are checked at commit
+	if _, err := tx.Exec("PRAGMA defer_foreign_keys = ON"); err != nil {
+		return fmt.Errorf("failed to defer foreign keys: %w", err)
+	}
+	if _, err := tx.Exec(`INSERT OR REPLACE INTO sync_state (key, value) VALUES (?, '1')`, applyingKey); err != nil {
+		return fmt.Errorf("failed to mark sync in progress: %w", err)
+	}
+
+	// Local-only rows such as running timers would be lost to cascading deletes
+	saved, err := saveLocalRows(tx, ticketIDs)
+	if err != nil {
+		return err
+	}
+
+	for i := len(tables) - 1; i >= 0; i-- {
+		if err := clearTable(tx, tables[i], held); err != nil {
+			return err
+		}
+	}
+
+	for _, t := range tables {
+		n, err := copyTable(tx, remote, t, held)
+		if err != nil {
+			return err
+		}
+		res.Pulled += n
+	}
+
+	if err := restoreLocalRows(tx, saved); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(`DELETE FROM sync_state WHERE key = ?`, applyingKey); err != nil {
+		return fmt.Errorf("failed to clear sync marker: %w", err)
+	}
+	if _, err := tx.Exec(`DELETE FROM sync_id_map`); err != nil {
+		return fmt.Errorf("failed to clear renumbered tickets: %w", err)
+	}
+	if _, err := tx.Exec(`INSERT OR REPLACE INTO sync_state (key, value) VALUES (?, ?)`, lastSyncKey, time.Now().UTC().Format(time.RFC3339)); err != nil {
+		return fmt.Errorf("failed to record sync time: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		logger.Log.Error("failed to commit pull", "error", err)
+		return fmt.Errorf("failed to commit pull: %w", err)
+	}
+
+	logger.Log.Debug("pull complete", "rows", res.Pulled)
+	return nil
+}
+
+// clearTable deletes the local rows of a table except those being held back
+func clearTable(tx *sql.Tx, t table, held map[string]bool) error {
+	rows, err := localKeys(tx, t)
+	if err != nil {
+		return err
+	}
+
+	for _, r := range rows {
+		if held[rowOwner(t, r)] {
+			continue
+		}
+		where, args := keyClause(t, r)
+		if _, err := tx.Exec(fmt.Sprintf("DELETE FROM %s WHERE %s", t.name, where), args...); err != nil {
+			logger.Log.Error("failed to clear local row", "error", err, "table", t.name)
+			return fmt.Errorf("failed to clear %s: %w", t.name, err)
+		}
+	}
+	return nil
+}
+
+// copyTable inserts every remote row of a table that is not being held back
+func copyTable(tx *sql.Tx, remote *sql.DB, t table, held map[string]bool) (int, error) {
+	cols, err := txColumns(tx, t.name)
+	if err != nil {
+		return 0, err
+	}
+
+	remoteRows, err := selectText(remote, t.name, cols)
+	if err != nil {
+		logger.Log.Error("failed to read remote table", "error", err, "table", t.name)
+		return 0, fmt.Errorf("failed to read remote %s: %w", t.name, err)
+	}
+
+	// A held row created offline can share an ID with a remote row; the remote row is
+	// skipped until the conflict is resolved and the next pull replaces the held row
+	insert := fmt.Sprintf("INSERT OR IGNORE INTO %s (%s) VALUES (%s)", t.name, strings.Join(cols, ", "), placeholders(len(cols)))
+	copied := 0
+	for _, r := range remoteRows {
+		if held[rowOwner(t, r)] {
+			continue
+		}
+		args := make([]interface{}, len(cols))
+		for i, col := range cols {
+			args[i] = r[col]
+		}
+		if _, err := tx.Exec(insert, args...); err != nil {
+			logger.Log.Error("failed to copy remote row", "error", err, "table", t.name)
+			return 0, fmt.Errorf("failed to copy %s: %w", t.name, err)
+		}
+		copied++
+	}
+	return copied, nil
+}
+
+// localRows are rows of a local-only table saved across a pull
+type localRows struct {
+	table table
+	cols  []string
+	rows  []row
+}
+
+// saveLocalRows reads the local-only tables, following renumbered tickets
+func saveLocalRows(tx *sql.Tx, ticketIDs map[int64]int64) ([]localRows, error) {
+	var saved []localRows
+	for _, t := range localTables {
+		cols, err := txColumns(tx, t.name)
+		if err != nil {
+			return nil, err
+		}
+		rows, err := selectText(tx, t.name, cols)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", t.name, err)
+		}
+		for _, r := range rows {
+			if v := r[t.ticket]; v != nil {
+				id, _ := strconv.ParseInt(*v, 10, 64)
+				if remoteID, ok := ticketIDs[id]; ok {
+					s := strconv.FormatInt(remoteID, 10)
+					r[t.ticket] = &s
+				}
+			}
+		}
+		if _, err := tx.Exec(fmt.Sprintf("DELETE FROM %s", t.name)); err != nil {
+			return nil, fmt.Errorf("failed to clear %s: %w", t.name, err)
+		}
+		saved = append(saved, localRows{table: t, cols: cols, rows: rows})
+	}
+	return saved, nil
+}
+
+// restoreLocalRows puts local-only rows back, dropping those whose ticket no longer exists
+func restoreLocalRows(tx *sql.Tx, saved []localRows) error {
+	for _, s := range saved {
+		insert := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", s.table.name, strings.Join(s.cols, ", "), placeholders(len(s.cols)))
+		for _, r := range s.rows {
+			var exists int
+			if err := tx.QueryRow(`SELECT COUNT(*) FROM tickets WHERE id = ?`, r[s.table.ticket]).Scan(&exists); err != nil {
+				return fmt.Errorf("failed to check ticket: %w", err)
+			}
+			if exists == 0 {
+				continue
+			}
+
+			args := make([]interface{}, len(s.cols))
+			for i, col := range s.cols {
+				args[i] = r[col]
+			}
+			if _, err := tx.Exec(insert, args...); err != nil {
+				logger.Log.Error("failed to restore local row", "error", err, "table", s.table.name)
+				return fmt.Errorf("failed to restore %s: %w", s.table.name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// rowOwner mirrors entry.owner for a row read from a table
+func rowOwner(t table, r row) string {
+	if t.ticket != "" && r[t.ticket] != nil {
+		return "ticket:" + *r[t.ticket]
+	}
+	e := entry{table: t, key: r}
+	return e.identity()
+}
+
+// querier is satisfied by both *sql.DB and *sql.Tx
+type querier interface {
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// selectText reads every row of a table with each column cast to text
+func selectText(q querier, name string, cols []string) ([]row, error) {
+	selects := make([]string, len(cols))
+	for i, col := range cols {
+		selects[i] = fmt.Sprintf("CAST(%s AS TEXT)", col)
+	}
+
+	rows, err := q.Query(fmt.Sprintf("SELECT %s FROM %s", strings.Join(selects, ", "), name))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []row
+	for rows.Next() {
+		values := make([]sql.NullString, len(cols))
+		dest := make([]interface{}, len(cols))
+		for i := range values {
+			dest[i] = &values[i]
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+
+		r := make(row, len(cols))
+		for i, col := range cols {
+			if values[i].Valid {
+				v := values[i].String
+				r[col] = &v
+			} else {
+				r[col] = nil
+			}
+		}
+		result = append(result, r)
+	}
+	return result, rows.Err()
+}
+
+// localKeys reads the key columns of every local row in a table, plus its ticket column
+func localKeys(tx *sql.Tx, t table) ([]row, error) {
+	cols := append([]string{}, t.key...)
+	if t.ticket != "" && !contains(cols, t.ticket) {
+		cols = append(cols, t.ticket)
+	}
+	rows, err := selectText(tx, t.name, cols)
+	if err != nil {
+		logger.Log.Error("failed to read local table", "error", err, "table", t.name)
+		return nil, fmt.Errorf("failed to read %s: %w", t.name, err)
+	}
+	return rows, nil
+}
+
+// txColumns returns the column names of a table within a transaction
+func txColumns(tx *sql.Tx, name string) ([]string, error) {
+	rows, err := selectText(tx, fmt.Sprintf("pragma_table_info('%s')", name), []string{"name"})
+	if err != nil {
+		logger.Log.Error("failed to read table info", "error", err, "table", name)
+		return nil, fmt.Errorf("failed to read table info: %w", err)
+	}
+
+	cols := make([]string, 0, len(rows))
+	for _, r := range rows {
+		cols = append(cols, *r["name"])
+	}
+	if len(cols) == 0 {
+		return nil, fmt.Errorf("table %s does not exist", name)
+	}
+	return cols, nil
+}
+
+// contains reports whether list holds s
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
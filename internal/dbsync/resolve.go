@@ -0,0 +1,82 @@
+package dbsync
+
+import (
+	"alexandria/internal/logger"
+	"database/sql"
+	"fmt"
+)
+
+// Resolution choices for a conflicted ticket
+const (
+	KeepLocal  = "local"
+	KeepRemote = "remote"
+)
+
+// Resolve settles the conflicts held against a ticket. Keeping the local version
+// writes the conflicting local values to the remote; keeping the remote version
+// discards the local changes. Either way the next sync pushes any remaining
+// changes and pulls the ticket again. It returns the number of conflicts resolved.
+func Resolve(local, remote *sql.DB, ticketID int64, keep string) (int, error) {
+	logger.Log.Debug("resolving sync conflicts", "ticket_id", ticketID, "keep", keep)
+
+	if keep != KeepLocal && keep != KeepRemote {
+		return 0, fmt.Errorf("invalid resolution: %s (must be %s or %s)", keep, KeepLocal, KeepRemote)
+	}
+
+	entries, err := loadEntries(local)
+	if err != nil {
+		return 0, err
+	}
+
+	var conflicted, held []*entry
+	for _, e := range entries {
+		if e.ticketID() != ticketID {
+			continue
+		}
+		if e.conflict != nil {
+			conflicted = append(conflicted, e)
+		} else {
+			held = append(held, e)
+		}
+	}
+	if len(conflicted) == 0 {
+		return 0, fmt.Errorf("ticket %d has no sync conflicts", ticketID)
+	}
+
+	if keep == KeepRemote {
+		var ids []int64
+		for _, e := range append(conflicted, held...) {
+			ids = append(ids, e.id)
+		}
+		if err := deleteEntries(local, ids); err != nil {
+			return 0, err
+		}
+		logger.Log.Info("kept remote version", "ticket_id", ticketID, "discarded", len(ids))
+		return len(conflicted), nil
+	}
+
+	for _, e := range conflicted {
+		switch {
+		case e.conflict.Reason == "deleted remotely":
+			return 0, fmt.Errorf("ticket %d was deleted remotely; resolve with --keep %s and recreate it if still needed", ticketID, KeepRemote)
+		case e.op == opDelete:
+			err = deleteRemote(remote, e.table, e.key)
+		default:
+			set := make(map[string]*string)
+			for _, f := range e.conflict.Fields {
+				set[f.Field] = f.Local
+			}
+			err = updateRemote(remote, e.table, e.key, set)
+		}
+		if err != nil {
+			logger.Log.Error("failed to apply local version", "error", err, "ticket_id", ticketID)
+			return 0, fmt.Errorf("failed to apply local version: %w", err)
+		}
+		if err := deleteEntries(local, []int64{e.id}); err != nil {
+			return 0, err
+		}
+	}
+
+	logger.Log.Info("kept local version", "ticket_id", ticketID, "resolved", len(conflicted))
+	return len(conflicted), nil
+}
@@ -0,0 +1,402 @@
+package dbsync
+
+import (
+	"alexandria/internal/logger"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FieldConflict is a column changed both locally and remotely since the last sync
+type FieldConflict struct {
+	Field  string  `json:"field"`
+	Base   *string `json:"base"`
+	Local  *string `json:"local"`
+	Remote *string `json:"remote"`
+}
+
+// Conflict is a local change that could not be pushed because the remote row
+// changed too. The ticket it belongs to is left out of pulls until it is resolved.
+type Conflict struct {
+	OutboxID int64           `json:"outbox_id"`
+	Table    string          `json:"table"`
+	TicketID int64           `json:"ticket_id,omitempty"`
+	Reason   string          `json:"reason"`
+	Fields   []FieldConflict `json:"fields,omitempty"`
+}
+
+// Result summarises a sync
+type Result struct {
+	Pushed     int             `json:"pushed"`
+	Pulled     int             `json:"pulled"`
+	Renumbered map[int64]int64 `json:"renumbered,omitempty"` // tickets created offline: local ID -> remote ID
+	Conflicts  []Conflict      `json:"conflicts,omitempty"`
+}
+
+// Status describes the local replica
+type Status struct {
+	Pending   int        `json:"pending"`
+	Conflicts []Conflict `json:"conflicts,omitempty"`
+	LastSync  *time.Time `json:"last_sync,omitempty"`
+}
+
+// Sync pushes the outbox to the remote and then refreshes the replica from it.
+// The pull is skipped if the push fails part way, so no local change is lost.
+func Sync(local, remote *sql.DB) (*Result, error) {
+	logger.Log.Debug("starting sync")
+
+	res := &Result{Renumbered: make(map[int64]int64)}
+	if err := push(local, remote, res); err != nil {
+		return res, err
+	}
+	if err := pull(local, remote, res); err != nil {
+		return res, err
+	}
+
+	status, err := GetStatus(local)
+	if err != nil {
+		return res, err
+	}
+	res.Conflicts = status.Conflicts
+
+	logger.Log.Info("sync complete", "pushed", res.Pushed, "pulled", res.Pulled, "conflicts", len(res.Conflicts))
+	return res, nil
+}
+
+// GetStatus reports pending changes, unresolved conflicts and the last sync time
+func GetStatus(local *sql.DB) (*Status, error) {
+	entries, err := loadEntries(local)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &Status{}
+	for _, e := range entries {
+		if e.conflict != nil {
+			status.Conflicts = append(status.Conflicts, *e.conflict)
+			continue
+		}
+		status.Pending++
+	}
+
+	var value sql.NullString
+	err = local.QueryRow(`SELECT value FROM sync_state WHERE key = ?`, lastSyncKey).Scan(&value)
+	if err != nil && err != sql.ErrNoRows {
+		logger.Log.Error("failed to read sync state", "error", err)
+		return nil, fmt.Errorf("failed to read sync state: %w", err)
+	}
+	if value.Valid {
+		if t, err := time.Parse(time.RFC3339, value.String); err == nil {
+			status.LastSync = &t
+		}
+	}
+	return status, nil
+}
+
+// push replays pending outbox entries against the remote in the order they were made
+func push(local, remote *sql.DB, res *Result) error {
+	entries, err := loadEntries(local)
+	if err != nil {
+		return err
+	}
+
+	// Tickets with an unresolved conflict are held back until it is resolved
+	blocked := make(map[string]bool)
+	var pending []*entry
+	for _, e := range entries {
+		if e.conflict != nil {
+			blocked[e.owner()] = true
+			continue
+		}
+		pending = append(pending, e)
+	}
+
+	pending, dropped := compact(pending)
+	if err := deleteEntries(local, dropped); err != nil {
+		return err
+	}
+	logger.Log.Debug("pushing outbox", "pending", len(pending), "compacted", len(dropped))
+
+	ticketIDs, err := loadIDMap(local)
+	if err != nil {
+		return err
+	}
+
+	for _, e := range pending {
+		if blocked[e.owner()] {
+			continue
+		}
+		e.remap(ticketIDs)
+
+		switch e.op {
+		case opInsert:
+			err = pushInsert(local, remote, e, ticketIDs, res)
+		case opUpdate:
+			err = pushUpdate(remote, e)
+		case opDelete:
+			err = pushDelete(remote, e)
+		default:
+			err = fmt.Errorf("unknown outbox operation: %s", e.op)
+		}
+		if err != nil {
+			logger.Log.Error("failed to push change", "error", err, "table", e.table.name, "op", e.op)
+			return fmt.Errorf("failed to push change to %s: %w", e.table.name, err)
+		}
+
+		if e.conflict != nil {
+			e.conflict.OutboxID = e.id
+			e.conflict.Table = e.table.name
+			e.conflict.TicketID = e.ticketID()
+			if err := saveConflict(local, e); err != nil {
+				return err
+			}
+			blocked[e.owner()] = true
+			logger.Log.Warn("sync conflict", "table", e.table.name, "ticket_id", e.conflict.TicketID, "reason", e.conflict.Reason)
+			continue
+		}
+
+		if err := deleteEntries(local, []int64{e.id}); err != nil {
+			return err
+		}
+		res.Pushed++
+	}
+	return nil
+}
+
+// pushInsert creates a row on the remote. Rows keyed by an autoincrement ID get a
+// new ID from the remote; renumbered tickets are remembered so later changes to
+// them, and to their tags, comments and so on, follow.
+func pushInsert(local, remote *sql.DB, e *entry, ticketIDs map[int64]int64, res *Result) error {
+	if e.table.autoID {
+		idCol := e.table.key[0]
+		var cols []string
+		var args []interface{}
+		for _, col := range sortedColumns(e.new) {
+			if col == idCol {
+				continue
+			}
+			cols = append(cols, col)
+			args = append(args, e.new[col])
+		}
+
+		query := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s) RETURNING %s",
+			e.table.name, strings.Join(cols, ", "), placeholders(len(cols)), idCol)
+		var remoteID int64
+		if err := remote.QueryRow(query, args...).Scan(&remoteID); err != nil {
+			return err
+		}
+
+		if e.table.name == "tickets" {
+			localID, _ := strconv.ParseInt(*e.key[idCol], 10, 64)
+			if localID != remoteID {
+				ticketIDs[localID] = remoteID
+				res.Renumbered[localID] = remoteID
+				if _, err := local.Exec(`INSERT OR REPLACE INTO sync_id_map (table_name, local_id, remote_id) VALUES (?, ?, ?)`,
+					e.table.name, localID, remoteID); err != nil {
+					return fmt.Errorf("failed to record renumbered ticket: %w", err)
+				}
+			}
+		}
+		return nil
+	}
+
+	existing, err := fetchRemote(remote, e.table, e.key, sortedColumns(e.new))
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		// The same row was created on both sides
+		if fields := differences(nil, e.new, existing); len(fields) > 0 {
+			e.conflict = &Conflict{Reason: "created both locally and remotely", Fields: fields}
+		}
+		return nil
+	}
+
+	cols := sortedColumns(e.new)
+	args := make([]interface{}, len(cols))
+	for i, col := range cols {
+		args[i] = e.new[col]
+	}
+	_, err = remote.Exec(fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)",
+		e.table.name, strings.Join(cols, ", "), placeholders(len(cols))), args...)
+	return err
+}
+
+// pushUpdate applies the fields changed locally. A field also changed remotely to
+// a different value is a conflict; the other fields are still applied.
+func pushUpdate(remote *sql.DB, e *entry) error {
+	current, err := fetchRemote(remote, e.table, e.key, sortedColumns(e.new))
+	if err != nil {
+		return err
+	}
+	if current == nil {
+		e.conflict = &Conflict{Reason: "deleted remotely"}
+		return nil
+	}
+
+	set := make(map[string]*string)
+	var fields []FieldConflict
+	for _, col := range sortedColumns(e.new) {
+		base, mine, theirs := e.old[col], e.new[col], current[col]
+		if equalValue(base, mine) || equalValue(mine, theirs) {
+			continue
+		}
+		if equalValue(base, theirs) {
+			set[col] = mine
+			continue
+		}
+		if !derivedColumns[col] {
+			fields = append(fields, FieldConflict{Field: col, Base: base, Local: mine, Remote: theirs})
+		}
+	}
+
+	if len(fields) > 0 {
+		e.conflict = &Conflict{Reason: "changed both locally and remotely", Fields: fields}
+	}
+	return updateRemote(remote, e.table, e.key, set)
+}
+
+// pushDelete removes a row from the remote unless it changed there since it was
+// last synced. Deleting a ticket also deletes its remaining child rows.
+func pushDelete(remote *sql.DB, e *entry) error {
+	current, err := fetchRemote(remote, e.table, e.key, sortedColumns(e.old))
+	if err != nil {
+		return err
+	}
+	if current == nil {
+		return nil
+	}
+
+	if fields := differences(e.old, nil, current); len(fields) > 0 {
+		e.conflict = &Conflict{Reason: "deleted locally but changed remotely", Fields: fields}
+		return nil
+	}
+	return deleteRemote(remote, e.table, e.key)
+}
+
+// differences lists non-derived columns where the remote differs from the expected
+// row. The expected values come from base for deletes and from mine for inserts.
+func differences(base, mine, theirs row) []FieldConflict {
+	expected := mine
+	if expected == nil {
+		expected = base
+	}
+
+	var fields []FieldConflict
+	for _, col := range sortedColumns(expected) {
+		if derivedColumns[col] || equalValue(expected[col], theirs[col]) {
+			continue
+		}
+		fields = append(fields, FieldConflict{Field: col, Base: base[col], Local: mine[col], Remote: theirs[col]})
+	}
+	return fields
+}
+
+// fetchRemote reads a row from the remote as text, returning nil if it does not exist
+func fetchRemote(remote *sql.DB, t table, key row, cols []string) (row, error) {
+	selects := make([]string, len(cols))
+	for i, col := range cols {
+		selects[i] = fmt.Sprintf("CAST(%s AS TEXT)", col)
+	}
+	where, args := keyClause(t, key)
+
+	values := make([]sql.NullString, len(cols))
+	dest := make([]interface{}, len(cols))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+
+	err := remote.QueryRow(fmt.Sprintf("SELECT %s FROM %s WHERE %s", strings.Join(selects, ", "), t.name, where), args...).Scan(dest...)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	r := make(row, len(cols))
+	for i, col := range cols {
+		if values[i].Valid {
+			v := values[i].String
+			r[col] = &v
+		} else {
+			r[col] = nil
+		}
+	}
+	return r, nil
+}
+
+// updateRemote sets columns on a remote row
+func updateRemote(remote *sql.DB, t table, key row, set map[string]*string) error {
+	if len(set) == 0 {
+		return nil
+	}
+
+	var assignments []string
+	var args []interface{}
+	for _, col := range sortedColumns(set) {
+		assignments = append(assignments, col+" = ?")
+		args = append(args, set[col])
+	}
+	where, keyArgs := keyClause(t, key)
+
+	_, err := remote.Exec(fmt.Sprintf("UPDATE %s SET %s WHERE %s", t.name, strings.Join(assignments, ", "), where), append(args, keyArgs...)...)
+	return err
+}
+
+// deleteRemote deletes a remote row, along with a ticket's child rows
+func deleteRemote(remote *sql.DB, t table, key row) error {
+	if t.name == "tickets" {
+		for i := len(tables) - 1; i > 0; i-- {
+			child := tables[i]
+			if child.ticket == "" {
+				continue
+			}
+			if _, err := remote.Exec(fmt.Sprintf("DELETE FROM %s WHERE %s = ?", child.name, child.ticket), key["id"]); err != nil {
+				return err
+			}
+		}
+	}
+
+	where, args := keyClause(t, key)
+	_, err := remote.Exec(fmt.Sprintf("DELETE FROM %s WHERE %s", t.name, where), args...)
+	return err
+}
+
+// keyClause builds a WHERE clause matching a row by its key
+func keyClause(t table, key row) (string, []interface{}) {
+	parts := make([]string, len(t.key))
+	args := make([]interface{}, len(t.key))
+	for i, col := range t.key {
+		parts[i] = col + " = ?"
+		args[i] = key[col]
+	}
+	return strings.Join(parts, " AND "), args
+}
+
+// placeholders returns n comma-separated "?" markers
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
+
+// loadIDMap reads tickets renumbered by a push that has not been followed by a pull
+func loadIDMap(local *sql.DB) (map[int64]int64, error) {
+	rows, err := local.Query(`SELECT local_id, remote_id FROM sync_id_map WHERE table_name = 'tickets'`)
+	if err != nil {
+		logger.Log.Error("failed to load renumbered tickets", "error", err)
+		return nil, fmt.Errorf("failed to load renumbered tickets: %w", err)
+	}
+	defer rows.Close()
+
+	ids := make(map[int64]int64)
+	for rows.Next() {
+		var localID, remoteID int64
+		if err := rows.Scan(&localID, &remoteID); err != nil {
+			return nil, fmt.Errorf("failed to scan renumbered ticket: %w", err)
+		}
+		ids[localID] = remoteID
+	}
+	return ids, rows.Err()
+}
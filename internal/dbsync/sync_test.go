@@ -0,0 +1,233 @@
+package dbsync_test
+
+import (
+	"alexandria/internal/database"
+	"alexandria/internal/dbsync"
+	"alexandria/internal/logger"
+	"alexandria/internal/ticket"
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// openDBs returns a local replica and a SQLite database standing in for Turso
+func openDBs(t *testing.T) (local, remote *sql.DB) {
+	t.Helper()
+	logger.Init(false)
+
+	dir := t.TempDir()
+	open := func(name string) *sql.DB {
+		db, err := sql.Open("sqlite3", filepath.Join(dir, name)+"?_foreign_keys=on")
+		if err != nil {
+			t.Fatalf("failed to open %s: %v", name, err)
+		}
+		t.Cleanup(func() { db.Close() })
+		if err := database.InitSchema(db); err != nil {
+			t.Fatalf("failed to create schema in %s: %v", name, err)
+		}
+		return db
+	}
+
+	local = open("replica.db")
+	remote = open("remote.db")
+	if err := dbsync.Install(local); err != nil {
+		t.Fatalf("failed to install outbox: %v", err)
+	}
+	return local, remote
+}
+
+func createTicket(t *testing.T, db *sql.DB, title string) int64 {
+	t.Helper()
+	tk := &ticket.Ticket{
+		Title:    title,
+		Type:     ticket.TypeTask,
+		Status:   ticket.StatusOpen,
+		Priority: ticket.PriorityMedium,
+	}
+	if err := ticket.NewSQLStore(db).Create(context.Background(), "sync", tk); err != nil {
+		t.Fatalf("failed to create ticket: %v", err)
+	}
+	return tk.ID
+}
+
+func exec(t *testing.T, db *sql.DB, query string, args ...interface{}) {
+	t.Helper()
+	if _, err := db.Exec(query, args...); err != nil {
+		t.Fatalf("exec %q: %v", query, err)
+	}
+}
+
+func field(t *testing.T, db *sql.DB, id int64, col string) string {
+	t.Helper()
+	var v sql.NullString
+	if err := db.QueryRow("SELECT "+col+" FROM tickets WHERE id = ?", id).Scan(&v); err != nil {
+		t.Fatalf("failed to read %s of ticket %d: %v", col, id, err)
+	}
+	return v.String
+}
+
+func runSync(t *testing.T, local, remote *sql.DB) *dbsync.Result {
+	t.Helper()
+	res, err := dbsync.Sync(local, remote)
+	if err != nil {
+		t.Fatalf("sync failed: %v", err)
+	}
+	return res
+}
+
+func TestOfflineCreateIsRenumbered(t *testing.T) {
+	local, remote := openDBs(t)
+
+	shared := createTicket(t, remote, "Shared")
+	runSync(t, local, remote)
+
+	// Both sides create the next ticket while apart
+	remoteID := createTicket(t, remote, "Created remotely")
+	localID := createTicket(t, local, "Created offline")
+	if localID != remoteID {
+		t.Fatalf("expected colliding IDs, got local %d remote %d", localID, remoteID)
+	}
+	exec(t, local, `INSERT INTO ticket_tags (ticket_id, tag) VALUES (?, 'offline')`, localID)
+	childID := createTicket(t, local, "Child created offline")
+	exec(t, local, `UPDATE tickets SET parent_id = ? WHERE id = ?`, localID, childID)
+
+	res := runSync(t, local, remote)
+	newID, ok := res.Renumbered[localID]
+	if !ok || newID == localID {
+		t.Fatalf("expected ticket %d to be renumbered, got %v", localID, res.Renumbered)
+	}
+
+	if got := field(t, remote, remoteID, "title"); got != "Created remotely" {
+		t.Errorf("remote ticket overwritten: %q", got)
+	}
+	for name, db := range map[string]*sql.DB{"local": local, "remote": remote} {
+		if got := field(t, db, newID, "title"); got != "Created offline" {
+			t.Errorf("%s: renumbered ticket title = %q", name, got)
+		}
+		var tags int
+		db.QueryRow(`SELECT COUNT(*) FROM ticket_tags WHERE ticket_id = ? AND tag = 'offline'`, newID).Scan(&tags)
+		if tags != 1 {
+			t.Errorf("%s: tag did not follow the renumbered ticket", name)
+		}
+		var parent sql.NullInt64
+		db.QueryRow(`SELECT parent_id FROM tickets WHERE title = 'Child created offline'`).Scan(&parent)
+		if parent.Int64 != newID {
+			t.Errorf("%s: child's parent_id = %d, want the renumbered %d", name, parent.Int64, newID)
+		}
+		if got := field(t, db, shared, "title"); got != "Shared" {
+			t.Errorf("%s: shared ticket title = %q", name, got)
+		}
+	}
+
+	status, err := dbsync.GetStatus(local)
+	if err != nil {
+		t.Fatalf("failed to read status: %v", err)
+	}
+	if status.Pending != 0 || len(status.Conflicts) != 0 || status.LastSync == nil {
+		t.Errorf("unexpected status after sync: %+v", status)
+	}
+}
+
+func TestDifferentFieldsMerge(t *testing.T) {
+	local, remote := openDBs(t)
+
+	id := createTicket(t, remote, "Original")
+	runSync(t, local, remote)
+
+	exec(t, local, `UPDATE tickets SET title = 'Local title' WHERE id = ?`, id)
+	exec(t, remote, `UPDATE tickets SET priority = 'high' WHERE id = ?`, id)
+
+	res := runSync(t, local, remote)
+	if len(res.Conflicts) != 0 {
+		t.Fatalf("unexpected conflicts: %+v", res.Conflicts)
+	}
+	for name, db := range map[string]*sql.DB{"local": local, "remote": remote} {
+		if got := field(t, db, id, "title"); got != "Local title" {
+			t.Errorf("%s: title = %q", name, got)
+		}
+		if got := field(t, db, id, "priority"); got != "high" {
+			t.Errorf("%s: priority = %q", name, got)
+		}
+	}
+}
+
+func TestSameFieldConflict(t *testing.T) {
+	for _, keep := range []string{dbsync.KeepLocal, dbsync.KeepRemote} {
+		t.Run(keep, func(t *testing.T) {
+			local, remote := openDBs(t)
+
+			id := createTicket(t, remote, "Original")
+			runSync(t, local, remote)
+
+			exec(t, local, `UPDATE tickets SET title = 'Local title', priority = 'low' WHERE id = ?`, id)
+			exec(t, remote, `UPDATE tickets SET title = 'Remote title' WHERE id = ?`, id)
+
+			res := runSync(t, local, remote)
+			if len(res.Conflicts) != 1 {
+				t.Fatalf("expected one conflict, got %+v", res.Conflicts)
+			}
+			c := res.Conflicts[0]
+			if c.TicketID != id || len(c.Fields) != 1 || c.Fields[0].Field != "title" {
+				t.Fatalf("unexpected conflict: %+v", c)
+			}
+			f := c.Fields[0]
+			if *f.Base != "Original" || *f.Local != "Local title" || *f.Remote != "Remote title" {
+				t.Errorf("unexpected conflict values: base %q local %q remote %q", *f.Base, *f.Local, *f.Remote)
+			}
+
+			// The conflicted ticket keeps its local version until resolved
+			if got := field(t, local, id, "title"); got != "Local title" {
+				t.Errorf("local title replaced before resolution: %q", got)
+			}
+
+			n, err := dbsync.Resolve(local, remote, id, keep)
+			if err != nil {
+				t.Fatalf("failed to resolve: %v", err)
+			}
+			if n != 1 {
+				t.Errorf("resolved %d conflicts, want 1", n)
+			}
+
+			res = runSync(t, local, remote)
+			if len(res.Conflicts) != 0 {
+				t.Fatalf("conflicts remain after resolve: %+v", res.Conflicts)
+			}
+
+			want := map[string]string{dbsync.KeepLocal: "Local title", dbsync.KeepRemote: "Remote title"}[keep]
+			for name, db := range map[string]*sql.DB{"local": local, "remote": remote} {
+				if got := field(t, db, id, "title"); got != want {
+					t.Errorf("%s: title = %q, want %q", name, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestRemoteDeleteIsPulled(t *testing.T) {
+	local, remote := openDBs(t)
+
+	id := createTicket(t, remote, "Doomed")
+	exec(t, remote, `INSERT INTO ticket_tags (ticket_id, tag) VALUES (?, 'x')`, id)
+	runSync(t, local, remote)
+
+	exec(t, remote, `DELETE FROM ticket_tags WHERE ticket_id = ?`, id)
+	exec(t, remote, `DELETE FROM tickets WHERE id = ?`, id)
+
+	runSync(t, local, remote)
+
+	var count int
+	local.QueryRow(`SELECT COUNT(*) FROM tickets WHERE id = ?`, id).Scan(&count)
+	if count != 0 {
+		t.Errorf("remotely deleted ticket still present locally")
+	}
+	status, err := dbsync.GetStatus(local)
+	if err != nil {
+		t.Fatalf("failed to read status: %v", err)
+	}
+	if status.Pending != 0 {
+		t.Errorf("pull left %d changes in the outbox", status.Pending)
+	}
+}
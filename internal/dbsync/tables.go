@@ -0,0 +1,199 @@
+package dbsync
+
+import (
+	"alexandria/internal/logger"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// table describes how the rows of a synced table are identified
+type table struct {
+	name   string
+	key    []string // columns identifying a row
+	autoID bool     // key is an autoincrement ID that the remote assigns on insert
+	ticket string   // column holding the owning ticket's ID, if any
+	refs   []string // other columns holding ticket IDs, remapped like ticket
+}
+
+// tables lists the synced tables, parents before children
+var tables = []table{
+	{name: "tickets", key: []string{"id"}, autoID: true, ticket: "id", refs: []string{"parent_id"}},
+	{name: "ticket_tags", key: []string{"ticket_id", "tag"}, ticket: "ticket_id"},
+	{name: "ticket_files", key: []string{"id"}, autoID: true, ticket: "ticket_id"},
+	{name: "ticket_comments", key: []string{"id"}, autoID: true, ticket: "ticket_id"},
+	{name: "ticket_worklogs", key: []string{"id"}, autoID: true, ticket: "ticket_id"},
+	{name: "ticket_status_history", key: []string{"id"}, autoID: true, ticket: "ticket_id"},
+	{name: "ticket_commits", key: []string{"ticket_id", "sha"}, ticket: "ticket_id"},
+	{name: "ticket_checklist_items", key: []string{"id"}, autoID: true, ticket: "ticket_id"},
+	{name: "saved_views", key: []string{"name"}},
+	{name: "ticket_templates", key: []string{"project", "name"}},
+	{name: "archived_tickets", key: []string{"id"}, ticket: "id"},
+	{name: "archived_ticket_tags", key: []string{"ticket_id", "tag"}, ticket: "ticket_id"},
+	{name: "archived_ticket_files", key: []string{"id"}, ticket: "ticket_id"},
+	{name: "archived_ticket_comments", key: []string{"id"}, ticket: "ticket_id"},
+	{name: "archived_ticket_worklogs", key: []string{"id"}, ticket: "ticket_id"},
+	{name: "archived_ticket_status_history", key: []string{"id"}, ticket: "ticket_id"},
+	{name: "archived_ticket_commits", key: []string{"ticket_id", "sha"}, ticket: "ticket_id"},
+	{name: "archived_ticket_checklist_items", key: []string{"id"}, ticket: "ticket_id"},
+	{name: "webhooks", key: []string{"id"}, autoID: true},
+	{name: "ticket_watchers", key: []string{"ticket_id", "username"}, ticket: "ticket_id"},
+	{name: "notification_prefs", key: []string{"username"}},
+	{name: "recurring_schedules", key: []string{"project", "name"}},
+	{name: "schedule_runs", key: []string{"project", "schedule", "occurrence"}, ticket: "ticket_id"},
+}
+
+// localTables reference tickets but are never synced, such as running timers and
+// the undo journal.
+// They are kept across a pull and follow tickets that are renumbered.
+var localTables = []table{
+	{name: "active_timers", key: []string{"username"}, ticket: "ticket_id"},
+	{name: "ticket_journal", key: []string{"id"}, ticket: "ticket_id"},
+}
+
+// derivedColumns change as a side effect of other edits. They never conflict on
+// their own and are only written when the remote still has the base value.
+var derivedColumns = map[string]bool{
+	"updated_at": true,
+	"closed_at":  true,
+}
+
+// tableByName returns the synced table with the given name
+func tableByName(name string) (table, bool) {
+	for _, t := range tables {
+		if t.name == name {
+			return t, true
+		}
+	}
+	return table{}, false
+}
+
+// applyingKey is set in sync_state while remote changes are being applied
+// locally, so the triggers do not record them in the outbox
+const applyingKey = "applying"
+
+// lastSyncKey records when the replica last completed a pull
+const lastSyncKey = "last_sync"
+
+const createOutboxTable = `
+CREATE TABLE IF NOT EXISTS sync_outbox (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    table_name TEXT NOT NULL,
+    op TEXT NOT NULL,
+    row_key TEXT NOT NULL,
+    old_values TEXT,
+    new_values TEXT,
+    conflict TEXT,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);`
+
+const createStateTable = `
+CREATE TABLE IF NOT EXISTS sync_state (
+    key TEXT PRIMARY KEY,
+    value TEXT
+);`
+
+const createIDMapTable = `
+CREATE TABLE IF NOT EXISTS sync_id_map (
+    table_name TEXT NOT NULL,
+    local_id INTEGER NOT NULL,
+    remote_id INTEGER NOT NULL,
+    PRIMARY KEY (table_name, local_id)
+);`
+
+// Install creates the outbox and the triggers that record local changes in it.
+// Triggers are recreated each time so columns added by migrations are captured.
+func Install(db *sql.DB) error {
+	logger.Log.Debug("installing sync outbox")
+
+	for _, script := range []string{createOutboxTable, createStateTable, createIDMapTable} {
+		if _, err := db.Exec(script); err != nil {
+			logger.Log.Error("failed to create sync table", "error", err)
+			return fmt.Errorf("failed to create sync table: %w", err)
+		}
+	}
+
+	for _, t := range tables {
+		cols, err := columns(db, t.name)
+		if err != nil {
+			return err
+		}
+		for _, script := range triggerScripts(t, cols) {
+			if _, err := db.Exec(script); err != nil {
+				logger.Log.Error("failed to create sync trigger", "error", err, "table", t.name)
+				return fmt.Errorf("failed to create sync trigger for %s: %w", t.name, err)
+			}
+		}
+	}
+
+	logger.Log.Debug("sync outbox installed")
+	return nil
+}
+
+// triggerScripts returns the statements that (re)create the insert, update and
+// delete triggers for a table
+func triggerScripts(t table, cols []string) []string {
+	guard := fmt.Sprintf("WHEN NOT EXISTS (SELECT 1 FROM sync_state WHERE key = '%s')", applyingKey)
+	object := func(prefix string, names []string) string {
+		parts := make([]string, len(names))
+		for i, name := range names {
+			parts[i] = fmt.Sprintf("'%s', %s.%s", name, prefix, name)
+		}
+		return "json_object(" + strings.Join(parts, ", ") + ")"
+	}
+
+	insert := fmt.Sprintf(`CREATE TRIGGER sync_%[1]s_insert AFTER INSERT ON %[1]s %[2]s
+BEGIN
+    INSERT INTO sync_outbox (table_name, op, row_key, new_values) VALUES ('%[1]s', '%[3]s', %[4]s, %[5]s);
+END;`, t.name, guard, opInsert, object("NEW", t.key), object("NEW", cols))
+
+	update := fmt.Sprintf(`CREATE TRIGGER sync_%[1]s_update AFTER UPDATE ON %[1]s %[2]s
+BEGIN
+    INSERT INTO sync_outbox (table_name, op, row_key, old_values, new_values) VALUES ('%[1]s', '%[3]s', %[4]s, %[5]s, %[6]s);
+END;`, t.name, guard, opUpdate, object("OLD", t.key), object("OLD", cols), object("NEW", cols))
+
+	remove := fmt.Sprintf(`CREATE TRIGGER sync_%[1]s_delete AFTER DELETE ON %[1]s %[2]s
+BEGIN
+    INSERT INTO sync_outbox (table_name, op, row_key, old_values) VALUES ('%[1]s', '%[3]s', %[4]s, %[5]s);
+END;`, t.name, guard, opDelete, object("OLD", t.key), object("OLD", cols))
+
+	return []string{
+		fmt.Sprintf("DROP TRIGGER IF EXISTS sync_%s_insert", t.name),
+		fmt.Sprintf("DROP TRIGGER IF EXISTS sync_%s_update", t.name),
+		fmt.Sprintf("DROP TRIGGER IF EXISTS sync_%s_delete", t.name),
+		insert,
+		update,
+		remove,
+	}
+}
+
+// columns returns the column names of a table in definition order
+func columns(db *sql.DB, name string) ([]string, error) {
+	rows, err := db.Query(fmt.Sprintf("PRAGMA table_info(%s)", name))
+	if err != nil {
+		logger.Log.Error("failed to read table info", "error", err, "table", name)
+		return nil, fmt.Errorf("failed to read table info: %w", err)
+	}
+	defer rows.Close()
+
+	var cols []string
+	for rows.Next() {
+		var (
+			cid, notNull, pk int
+			colName, colType string
+			defaultValue     sql.NullString
+		)
+		if err := rows.Scan(&cid, &colName, &colType, &notNull, &defaultValue, &pk); err != nil {
+			logger.Log.Error("failed to scan table info", "error", err, "table", name)
+			return nil, fmt.Errorf("failed to scan table info: %w", err)
+		}
+		cols = append(cols, colName)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating table info: %w", err)
+	}
+	if len(cols) == 0 {
+		return nil, fmt.Errorf("table %s does not exist", name)
+	}
+	return cols, nil
+}
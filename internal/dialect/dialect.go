@@ -0,0 +1,103 @@
+package dialect
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// Dialect covers the SQL that differs between the supported databases. Queries
+// elsewhere are written for SQLite with ? placeholders and insert IDs read with
+// RETURNING id, which SQLite, Turso and PostgreSQL all accept.
+type Dialect interface {
+	// Name identifies the dialect, such as "sqlite"
+	Name() string
+	// DDL translates a SQLite CREATE TABLE or CREATE INDEX script
+	DDL(script string) string
+	// ColumnsQuery returns a query listing a table's column names in order
+	ColumnsQuery(table string) string
+	// ResetIDQuery returns a statement that moves a table's ID generator past rows
+	// inserted with explicit IDs, or "" if the database does this itself
+	ResetIDQuery(table string) string
+}
+
+// SQLite is the dialect of SQLite and Turso
+var SQLite Dialect = sqliteDialect{}
+
+// Postgres is the dialect of PostgreSQL
+var Postgres Dialect = postgresDialect{}
+
+// For returns the dialect of an open database
+func For(db *sql.DB) Dialect {
+	if _, ok := db.Driver().(*postgresDriver); ok {
+		return Postgres
+	}
+	return SQLite
+}
+
+type sqliteDialect struct{}
+
+func (sqliteDialect) Name() string { return "sqlite" }
+
+func (sqliteDialect) DDL(script string) string { return script }
+
+func (sqliteDialect) ColumnsQuery(table string) string {
+	return fmt.Sprintf("SELECT name FROM pragma_table_info('%s')", table)
+}
+
+func (sqliteDialect) ResetIDQuery(table string) string { return "" }
+
+type postgresDialect struct{}
+
+func (postgresDialect) Name() string { return "postgres" }
+
+// postgresTypes maps SQLite column definitions to their PostgreSQL equivalents
+var postgresTypes = strings.NewReplacer(
+	"INTEGER PRIMARY KEY AUTOINCREMENT", "INTEGER GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY",
+	"DATETIME", "TIMESTAMPTZ",
+	"REAL", "DOUBLE PRECISION",
+	"BOOLEAN DEFAULT 0", "BOOLEAN DEFAULT FALSE",
+	" COLLATE NOCASE", "",
+)
+
+func (postgresDialect) DDL(script string) string {
+	return postgresTypes.Replace(script)
+}
+
+func (postgresDialect) ColumnsQuery(table string) string {
+	return fmt.Sprintf(`SELECT column_name FROM information_schema.columns
+		WHERE table_schema = current_schema() AND table_name = '%s' ORDER BY ordinal_position`, table)
+}
+
+func (postgresDialect) ResetIDQuery(table string) string {
+	return fmt.Sprintf(`SELECT setval(pg_get_serial_sequence('%[1]s', 'id'), COALESCE(MAX(id), 1), MAX(id) IS NOT NULL) FROM %[1]s`, table)
+}
+
+// Rebind rewrites ? placeholders as PostgreSQL's numbered $1, $2, ... placeholders,
+// leaving question marks inside quoted strings and identifiers alone
+func Rebind(query string) string {
+	if !strings.Contains(query, "?") {
+		return query
+	}
+
+	var b strings.Builder
+	b.Grow(len(query) + 8)
+	n := 0
+	var quote rune
+	for _, r := range query {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '\'' || r == '"':
+			quote = r
+		case r == '?':
+			n++
+			fmt.Fprintf(&b, "$%d", n)
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
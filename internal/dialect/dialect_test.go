@@ -0,0 +1,57 @@
+package dialect_test
+
+import (
+	"alexandria/internal/dialect"
+	"testing"
+)
+
+func TestRebind(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"SELECT id FROM tickets", "SELECT id FROM tickets"},
+		{"SELECT id FROM tickets WHERE project = ? AND status = ?", "SELECT id FROM tickets WHERE project = $1 AND status = $2"},
+		{"INSERT INTO tags (ticket_id, tag) VALUES (?, ?)", "INSERT INTO tags (ticket_id, tag) VALUES ($1, $2)"},
+
+		// Question marks in strings and identifiers are not placeholders
+		{"SELECT id FROM tickets WHERE title = 'why?' AND id = ?", "SELECT id FROM tickets WHERE title = 'why?' AND id = $1"},
+		{`SELECT "odd?" FROM tickets WHERE id = ?`, `SELECT "odd?" FROM tickets WHERE id = $1`},
+
+		// A quote of the other kind does not end a string, and a doubled quote
+		// escapes itself
+		{`SELECT ? FROM t WHERE a = '"?' AND b = ?`, `SELECT $1 FROM t WHERE a = '"?' AND b = $2`},
+		{`SELECT "it's?" FROM t WHERE id = ?`, `SELECT "it's?" FROM t WHERE id = $1`},
+		{"SELECT id FROM t WHERE a = 'it''s ?' AND b = ?", "SELECT id FROM t WHERE a = 'it''s ?' AND b = $1"},
+	}
+	for _, tt := range tests {
+		if got := dialect.Rebind(tt.query); got != tt.want {
+			t.Errorf("Rebind(%q) = %q, want %q", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestDDL(t *testing.T) {
+	script := `CREATE TABLE tickets (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		title TEXT NOT NULL COLLATE NOCASE,
+		estimate REAL,
+		criticalpath BOOLEAN DEFAULT 0,
+		created_at DATETIME NOT NULL
+	);`
+
+	if got := dialect.SQLite.DDL(script); got != script {
+		t.Errorf("SQLite DDL changed the script:\n%s", got)
+	}
+
+	want := `CREATE TABLE tickets (
+		id INTEGER GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+		title TEXT NOT NULL,
+		estimate DOUBLE PRECISION,
+		criticalpath BOOLEAN DEFAULT FALSE,
+		created_at TIMESTAMPTZ NOT NULL
+	);`
+	if got := dialect.Postgres.DDL(script); got != want {
+		t.Errorf("Postgres DDL =\n%s\nwant\n%s", got, want)
+	}
+}
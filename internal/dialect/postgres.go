@@ -0,0 +1,86 @@
+package dialect
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+
+	"github.com/lib/pq"
+)
+
+// OpenPostgres connects to PostgreSQL. The connection accepts the same ? placeholders
+// as SQLite, rewriting them before queries reach the server.
+func OpenPostgres(dsn string) (*sql.DB, error) {
+	connector, err := pq.NewConnector(dsn)
+	if err != nil {
+		return nil, err
+	}
+	return sql.OpenDB(&postgresConnector{connector: connector}), nil
+}
+
+// postgresDriver identifies connections opened by OpenPostgres
+type postgresDriver struct {
+	driver.Driver
+}
+
+type postgresConnector struct {
+	connector *pq.Connector
+}
+
+func (c *postgresConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &postgresConn{Conn: conn}, nil
+}
+
+func (c *postgresConnector) Driver() driver.Driver {
+	return &postgresDriver{Driver: c.connector.Driver()}
+}
+
+// postgresConn passes everything through to pq after rebinding placeholders
+type postgresConn struct {
+	driver.Conn
+}
+
+func (c *postgresConn) Prepare(query string) (driver.Stmt, error) {
+	return c.Conn.Prepare(Rebind(query))
+}
+
+func (c *postgresConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	return c.Conn.(driver.ConnPrepareContext).PrepareContext(ctx, Rebind(query))
+}
+
+func (c *postgresConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	return c.Conn.(driver.QueryerContext).QueryContext(ctx, Rebind(query), args)
+}
+
+func (c *postgresConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	return c.Conn.(driver.ExecerContext).ExecContext(ctx, Rebind(query), args)
+}
+
+func (c *postgresConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	return c.Conn.(driver.ConnBeginTx).BeginTx(ctx, opts)
+}
+
+func (c *postgresConn) Ping(ctx context.Context) error {
+	if p, ok := c.Conn.(driver.Pinger); ok {
+		return p.Ping(ctx)
+	}
+	return nil
+}
+
+func (c *postgresConn) ResetSession(ctx context.Context) error {
+	if r, ok := c.Conn.(driver.SessionResetter); ok {
+		return r.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c *postgresConn) IsValid() bool {
+	if v, ok := c.Conn.(driver.Validator); ok {
+		return v.IsValid()
+	}
+	return true
+}
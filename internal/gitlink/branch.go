@@ -0,0 +1,85 @@
+package gitlink
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// maxSlugLength limits how much of a ticket title is used in a branch name
+const maxSlugLength = 40
+
+// branchRefPattern matches a ticket reference at the start of a branch name or after a
+// slash, as in alx-42-fix-login or feature/ALX-42
+var branchRefPattern = regexp.MustCompile(`(?i)(?:^|/)` + RefPrefix + `-(\d+)(?:-|$)`)
+
+// nonSlug matches runs of characters that are not allowed in a branch slug
+var nonSlug = regexp.MustCompile(`[^a-z0-9]+`)
+
+// BranchName builds a branch name for a ticket, e.g. alx-42-fix-login-bug
+func BranchName(id int64, title string) string {
+	name := strings.ToLower(RefPrefix) + "-" + strconv.FormatInt(id, 10)
+
+	slug := strings.Trim(nonSlug.ReplaceAllString(strings.ToLower(title), "-"), "-")
+	if len(slug) > maxSlugLength {
+		slug = slug[:maxSlugLength]
+		// Cut at the last whole word where possible
+		if i := strings.LastIndex(slug, "-"); i > 0 {
+			slug = slug[:i]
+		}
+	}
+	if slug != "" {
+		name += "-" + slug
+	}
+	return name
+}
+
+// RefFromBranch extracts the ticket ID from a branch name, reporting false if the
+// branch does not name a ticket
+func RefFromBranch(branch string) (int64, bool) {
+	m := branchRefPattern.FindStringSubmatch(branch)
+	if m == nil {
+		return 0, false
+	}
+	id, err := strconv.ParseInt(m[1], 10, 64)
+	if err != nil || id <= 0 {
+		return 0, false
+	}
+	return id, true
+}
+
+// CurrentBranch returns the name of the checked out branch. symbolic-ref is used
+// rather than rev-parse so a branch with no commits yet still has a name.
+func CurrentBranch() (string, error) {
+	branch, err := Run("symbolic-ref", "--quiet", "--short", "HEAD")
+	if err != nil {
+		return "", fmt.Errorf("not on a branch (detached HEAD or not a git repository)")
+	}
+	return branch, nil
+}
+
+// FindBranch returns an existing local branch for a ticket, or "" if there is none.
+// Branches are matched by ticket ID so renaming a ticket does not orphan its branch.
+func FindBranch(id int64) (string, error) {
+	out, err := Run("branch", "--list", "--format=%(refname:short)")
+	if err != nil {
+		return "", err
+	}
+	for _, branch := range strings.Split(out, "\n") {
+		if branchID, ok := RefFromBranch(strings.TrimSpace(branch)); ok && branchID == id {
+			return strings.TrimSpace(branch), nil
+		}
+	}
+	return "", nil
+}
+
+// Checkout switches to a branch, creating it from the current HEAD if create is set
+func Checkout(branch string, create bool) error {
+	if create {
+		_, err := Run("checkout", "-b", branch)
+		return err
+	}
+	_, err := Run("checkout", branch)
+	return err
+}
@@ -0,0 +1,210 @@
+package gitlink
+
+import (
+	"alexandria/internal/logger"
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RefPrefix is the prefix used for ticket references in commit messages, as in ALX-42
+const RefPrefix = "ALX"
+
+// hookMarker identifies hooks written by InstallHooks so they can be replaced safely
+const hookMarker = "# Installed by alexandria"
+
+// refPattern matches ALX-42, optionally preceded by a closing keyword such as "fixes"
+var refPattern = regexp.MustCompile(`(?i:\b(fix|fixes|fixed|close|closes|closed|resolve|resolves|resolved)[:\s]+)?\b` + RefPrefix + `-(\d+)\b`)
+
+// Ref is a ticket reference found in a commit message
+type Ref struct {
+	ID    int64
+	Fixes bool // the reference was preceded by a closing keyword
+}
+
+// ParseRefs returns the ticket references in a commit message, in order of first
+// appearance. A ticket mentioned several times fixes it if any mention does.
+func ParseRefs(message string) []Ref {
+	var refs []Ref
+	index := make(map[int64]int)
+	for _, m := range refPattern.FindAllStringSubmatch(message, -1) {
+		id, err := strconv.ParseInt(m[2], 10, 64)
+		if err != nil || id <= 0 {
+			continue
+		}
+		fixes := m[1] != ""
+		if i, ok := index[id]; ok {
+			refs[i].Fixes = refs[i].Fixes || fixes
+			continue
+		}
+		index[id] = len(refs)
+		refs = append(refs, Ref{ID: id, Fixes: fixes})
+	}
+	return refs
+}
+
+// StripComments removes the "#" comment lines git adds to commit message templates
+func StripComments(message string) string {
+	var lines []string
+	for _, line := range strings.Split(message, "\n") {
+		if !strings.HasPrefix(line, "#") {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Commit is a commit read from git log
+type Commit struct {
+	SHA         string
+	Author      string
+	CommittedAt time.Time
+	Message     string
+}
+
+// Summary returns the first line of the commit message
+func (c Commit) Summary() string {
+	summary, _, _ := strings.Cut(strings.TrimSpace(c.Message), "\n")
+	return summary
+}
+
+// Run executes git with the given arguments and returns its trimmed output
+func Run(args ...string) (string, error) {
+	logger.Log.Debug("running git", "args", args)
+
+	cmd := exec.Command("git", args...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			msg = err.Error()
+		}
+		logger.Log.Error("git command failed", "error", msg, "args", args)
+		return "", fmt.Errorf("git %s: %s", args[0], msg)
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+// Field and record separators used to split git log output
+const (
+	fieldSep  = "\x1f"
+	recordSep = "\x1e"
+)
+
+// Log reads commits from git log. Extra arguments select the revisions, for example
+// "v1.0..HEAD" or "-1".
+func Log(args ...string) ([]Commit, error) {
+	logArgs := append([]string{"log", "--format=%H" + fieldSep + "%an" + fieldSep + "%cI" + fieldSep + "%B" + recordSep}, args...)
+	out, err := Run(logArgs...)
+	if err != nil {
+		return nil, err
+	}
+
+	var commits []Commit
+	for _, record := range strings.Split(out, recordSep) {
+		record = strings.TrimSpace(record)
+		if record == "" {
+			continue
+		}
+
+		fields := strings.SplitN(record, fieldSep, 4)
+		if len(fields) != 4 {
+			logger.Log.Error("unexpected git log output", "record", record)
+			return nil, fmt.Errorf("unexpected git log output")
+		}
+
+		committedAt, err := time.Parse(time.RFC3339, fields[2])
+		if err != nil {
+			logger.Log.Error("failed to parse commit date", "error", err, "sha", fields[0])
+			return nil, fmt.Errorf("failed to parse commit date: %w", err)
+		}
+
+		commits = append(commits, Commit{
+			SHA:         fields[0],
+			Author:      fields[1],
+			CommittedAt: committedAt,
+			Message:     fields[3],
+		})
+	}
+
+	logger.Log.Debug("commits read", "count", len(commits))
+	return commits, nil
+}
+
+// Head returns the commit currently checked out
+func Head() (*Commit, error) {
+	commits, err := Log("-1", "HEAD")
+	if err != nil {
+		return nil, err
+	}
+	if len(commits) == 0 {
+		return nil, fmt.Errorf("no commits found")
+	}
+	return &commits[0], nil
+}
+
+// hookScripts are the hooks written by InstallHooks. %s is replaced by the path to
+// the alexandria binary.
+var hookScripts = map[string]string{
+	"commit-msg": `#!/bin/sh
+` + hookMarker + `: checks ticket references in the commit message
+exec %s git commit-msg "$1"
+`,
+	"post-commit": `#!/bin/sh
+` + hookMarker + `: links the new commit to the tickets it references
+%s git post-commit || true
+`,
+}
+
+// HookNames lists the hooks written by InstallHooks
+var HookNames = []string{"commit-msg", "post-commit"}
+
+// InstallHooks writes the alexandria hooks into the current repository and returns
+// their paths. Existing hooks not written by alexandria are left alone unless force is set.
+func InstallHooks(binary string, force bool) ([]string, error) {
+	dir, err := Run("rev-parse", "--git-path", "hooks")
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		logger.Log.Error("failed to create hooks directory", "error", err, "dir", dir)
+		return nil, fmt.Errorf("failed to create hooks directory: %w", err)
+	}
+
+	// Check every hook before writing any so a refusal leaves nothing half installed
+	for _, name := range HookNames {
+		path := filepath.Join(dir, name)
+		existing, err := os.ReadFile(path)
+		if err == nil && !force && !strings.Contains(string(existing), hookMarker) {
+			logger.Log.Error("hook already exists", "path", path)
+			return nil, fmt.Errorf("%s already exists and was not installed by alexandria (use --force to replace it)", path)
+		}
+	}
+
+	var paths []string
+	for _, name := range HookNames {
+		path := filepath.Join(dir, name)
+		script := fmt.Sprintf(hookScripts[name], shellQuote(binary))
+		if err := os.WriteFile(path, []byte(script), 0755); err != nil {
+			logger.Log.Error("failed to write hook", "error", err, "path", path)
+			return nil, fmt.Errorf("failed to write hook: %w", err)
+		}
+		logger.Log.Info("hook installed", "path", path)
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
+
+// shellQuote quotes a path for use in a POSIX shell script
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
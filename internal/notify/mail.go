@@ -0,0 +1,243 @@
+package notify
+
+import (
+	"alexandria/internal/config"
+	"alexandria/internal/logger"
+	"bytes"
+	"database/sql"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// Notification statuses
+const (
+	StatusPending = "pending" // waiting to be sent, immediately or in the next digest
+	StatusSent    = "sent"    // accepted by the SMTP server
+	StatusFailed  = "failed"  // gave up after MaxAttempts
+)
+
+// MaxAttempts is how many times a notification is tried before it is marked failed
+const MaxAttempts = 5
+
+// Notification is an email queued for a user
+type Notification struct {
+	ID        int64      `json:"id"`
+	Username  string     `json:"username"`
+	Email     string     `json:"email"`
+	Event     string     `json:"event"`
+	TicketID  int64      `json:"ticket_id"`
+	Subject   string     `json:"subject"`
+	Body      string     `json:"body"`
+	Digest    bool       `json:"digest"` // held for the user's next digest
+	Status    string     `json:"status"`
+	Attempts  int        `json:"attempts"`
+	LastError string     `json:"last_error,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	SentAt    *time.Time `json:"sent_at,omitempty"`
+}
+
+// Mailer sends a plain text email to one address
+type Mailer interface {
+	Mail(to, subject, body string) error
+}
+
+// SMTPMailer sends email through an SMTP server
+type SMTPMailer struct {
+	Addr     string // host:port
+	From     string
+	Username string // SMTP login, empty for servers that need none
+	Password string
+}
+
+// NewSMTPMailer returns a mailer for the configured SMTP server
+func NewSMTPMailer(cfg *config.Notify) (*SMTPMailer, error) {
+	if cfg == nil || cfg.SMTPAddr == "" {
+		return nil, fmt.Errorf("no SMTP server configured (see alexandria notify smtp)")
+	}
+	if _, _, err := net.SplitHostPort(cfg.SMTPAddr); err != nil {
+		return nil, fmt.Errorf("invalid SMTP server: %s (expected host:port)", cfg.SMTPAddr)
+	}
+	if cfg.From == "" {
+		return nil, fmt.Errorf("no sender address configured (see alexandria notify smtp)")
+	}
+	m := &SMTPMailer{Addr: cfg.SMTPAddr, From: cfg.From, Username: cfg.Username}
+	if m.Username != "" {
+		m.Password = cfg.Password()
+	}
+	return m, nil
+}
+
+// Mail sends one message. Login is only attempted when a username is configured,
+// and net/smtp only sends the password over TLS or to localhost.
+func (m *SMTPMailer) Mail(to, subject, body string) error {
+	var auth smtp.Auth
+	if m.Username != "" {
+		host, _, _ := net.SplitHostPort(m.Addr)
+		auth = smtp.PlainAuth("", m.Username, m.Password, host)
+	}
+	return smtp.SendMail(m.Addr, auth, m.From, []string{to}, message(m.From, to, subject, body, time.Now()))
+}
+
+// message formats a plain text email with CRLF line endings
+func message(from, to, subject, body string, date time.Time) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", to)
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", date.Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(strings.ReplaceAll(body, "\r\n", "\n"), "\n", "\r\n"))
+	return b.Bytes()
+}
+
+// Enqueue queues a notification and sets its ID
+func Enqueue(db *sql.DB, n *Notification) error {
+	n.Status = StatusPending
+	err := db.QueryRow(`
+		INSERT INTO notifications (username, email, event, ticket_id, subject, body, digest, status, attempts, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, 0, ?)
+		RETURNING id`,
+		n.Username, n.Email, n.Event, n.TicketID, n.Subject, n.Body, n.Digest, n.Status, n.CreatedAt,
+	).Scan(&n.ID)
+	if err != nil {
+		logger.Log.Error("failed to queue notification", "error", err, "username", n.Username)
+		return fmt.Errorf("failed to queue notification: %w", err)
+	}
+	logger.Log.Debug("notification queued", "id", n.ID, "username", n.Username, "event", n.Event, "digest", n.Digest)
+	return nil
+}
+
+// pending returns the pending notifications that are or are not held for a digest,
+// oldest first
+func pending(db *sql.DB, digest bool) ([]Notification, error) {
+	rows, err := db.Query(`
+		SELECT id, username, email, event, ticket_id, subject, body, attempts, created_at
+		FROM notifications WHERE status = ? AND digest = ? ORDER BY id`, StatusPending, digest)
+	if err != nil {
+		logger.Log.Error("failed to query notifications", "error", err)
+		return nil, fmt.Errorf("failed to query notifications: %w", err)
+	}
+	defer rows.Close()
+
+	var queued []Notification
+	for rows.Next() {
+		n := Notification{Status: StatusPending, Digest: digest}
+		if err := rows.Scan(&n.ID, &n.Username, &n.Email, &n.Event, &n.TicketID, &n.Subject, &n.Body, &n.Attempts, &n.CreatedAt); err != nil {
+			logger.Log.Error("failed to scan notification", "error", err)
+			return nil, fmt.Errorf("failed to scan notification: %w", err)
+		}
+		queued = append(queued, n)
+	}
+	return queued, rows.Err()
+}
+
+// Result counts the emails a call to Send or SendDigests sent, will retry and gave up on
+type Result struct {
+	Sent     int
+	Retrying int
+	Failed   int
+}
+
+// Send mails every pending notification that is not held for a digest. One that
+// cannot be sent stays pending for the next call until MaxAttempts is reached.
+func Send(db *sql.DB, mailer Mailer, now time.Time) (Result, error) {
+	var res Result
+	queued, err := pending(db, false)
+	if err != nil {
+		return res, err
+	}
+
+	for _, n := range queued {
+		sendErr := mailer.Mail(n.Email, n.Subject, n.Body)
+		if err := record(db, []int64{n.ID}, n.Attempts+1, sendErr, now, &res); err != nil {
+			return res, err
+		}
+	}
+
+	logger.Log.Debug("notifications sent", "sent", res.Sent, "retrying", res.Retrying, "failed", res.Failed)
+	return res, nil
+}
+
+// SendDigests mails each user one email listing every notification held for
+// their digest
+func SendDigests(db *sql.DB, mailer Mailer, now time.Time) (Result, error) {
+	var res Result
+	queued, err := pending(db, true)
+	if err != nil {
+		return res, err
+	}
+
+	var users []string
+	byUser := make(map[string][]Notification)
+	for _, n := range queued {
+		if _, ok := byUser[n.Username]; !ok {
+			users = append(users, n.Username)
+		}
+		byUser[n.Username] = append(byUser[n.Username], n)
+	}
+
+	for _, user := range users {
+		list := byUser[user]
+		subject := fmt.Sprintf("Alexandria digest: %d update(s)", len(list))
+		var body strings.Builder
+		ids := make([]int64, len(list))
+		attempts := 0
+		for i, n := range list {
+			ids[i] = n.ID
+			if n.Attempts > attempts {
+				attempts = n.Attempts
+			}
+			fmt.Fprintf(&body, "%s (%s)\n%s\n\n", n.Subject, n.CreatedAt.Local().Format("2006-01-02 15:04"), n.Body)
+		}
+
+		// The newest address is used in case the user has changed it since
+		sendErr := mailer.Mail(list[len(list)-1].Email, subject, body.String())
+		if err := record(db, ids, attempts+1, sendErr, now, &res); err != nil {
+			return res, err
+		}
+	}
+
+	logger.Log.Debug("digests sent", "sent", res.Sent, "retrying", res.Retrying, "failed", res.Failed)
+	return res, nil
+}
+
+// record stores the outcome of an attempt to mail the given notifications
+func record(db *sql.DB, ids []int64, attempts int, sendErr error, now time.Time, res *Result) error {
+	for _, id := range ids {
+		var err error
+		switch {
+		case sendErr == nil:
+			_, err = db.Exec(`UPDATE notifications SET status = ?, attempts = ?, last_error = NULL, sent_at = ? WHERE id = ?`,
+				StatusSent, attempts, now, id)
+		case attempts >= MaxAttempts:
+			_, err = db.Exec(`UPDATE notifications SET status = ?, attempts = ?, last_error = ? WHERE id = ?`,
+				StatusFailed, attempts, sendErr.Error(), id)
+		default:
+			_, err = db.Exec(`UPDATE notifications SET attempts = ?, last_error = ? WHERE id = ?`,
+				attempts, sendErr.Error(), id)
+		}
+		if err != nil {
+			logger.Log.Error("failed to record notification attempt", "error", err, "id", id)
+			return fmt.Errorf("failed to record notification attempt: %w", err)
+		}
+	}
+
+	switch {
+	case sendErr == nil:
+		res.Sent++
+	case attempts >= MaxAttempts:
+		res.Failed++
+	default:
+		res.Retrying++
+	}
+	if sendErr != nil {
+		logger.Log.Warn("failed to send notification", "ids", ids, "attempt", attempts, "error", sendErr)
+	}
+	return nil
+}
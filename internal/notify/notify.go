@@ -0,0 +1,169 @@
+package notify
+
+import (
+	"alexandria/internal/logger"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Events a user can be notified of
+const (
+	EventAssigned  = "assigned"  // the ticket was assigned to the user
+	EventCommented = "commented" // someone commented on a ticket the user is involved in
+	EventClosed    = "closed"    // a ticket the user is involved in was closed
+)
+
+// Events lists every event, in the order they are documented
+var Events = []string{EventAssigned, EventCommented, EventClosed}
+
+// Delivery modes a user can choose
+const (
+	ModeImmediate = "immediate" // an email per notification, sent when the command finishes
+	ModeDigest    = "digest"    // notifications are collected and sent as one email by "notify digest"
+	ModeOff       = "off"       // no email
+)
+
+// Prefs are a user's notification preferences
+type Prefs struct {
+	Username string   `json:"username"`
+	Mode     string   `json:"mode"`
+	Events   []string `json:"events"`
+}
+
+// Wants reports whether the preferences ask for email about event
+func (p *Prefs) Wants(event string) bool {
+	if p.Mode == ModeOff {
+		return false
+	}
+	for _, e := range p.Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseEvents parses a comma-separated event list. An empty list means every event.
+func ParseEvents(value string) ([]string, error) {
+	if strings.TrimSpace(value) == "" {
+		return append([]string{}, Events...), nil
+	}
+
+	var events []string
+	seen := make(map[string]bool)
+	for _, e := range strings.Split(value, ",") {
+		e = strings.TrimSpace(e)
+		if e == "" || seen[e] {
+			continue
+		}
+		known := false
+		for _, k := range Events {
+			known = known || k == e
+		}
+		if !known {
+			return nil, fmt.Errorf("unknown event: %s (must be one of: %s)", e, strings.Join(Events, ", "))
+		}
+		seen[e] = true
+		events = append(events, e)
+	}
+	return events, nil
+}
+
+// GetPrefs returns a user's preferences. Users who have not set any get every
+// event immediately.
+func GetPrefs(db *sql.DB, username string) (*Prefs, error) {
+	p := &Prefs{Username: username}
+	var events string
+	err := db.QueryRow(`SELECT mode, events FROM notification_prefs WHERE username = ?`, username).Scan(&p.Mode, &events)
+	if err == sql.ErrNoRows {
+		p.Mode, p.Events = ModeImmediate, append([]string{}, Events...)
+		return p, nil
+	}
+	if err != nil {
+		logger.Log.Error("failed to load notification preferences", "error", err, "username", username)
+		return nil, fmt.Errorf("failed to load notification preferences: %w", err)
+	}
+	if events != "" {
+		p.Events = strings.Split(events, ",")
+	}
+	return p, nil
+}
+
+// SetPrefs stores a user's preferences
+func SetPrefs(db *sql.DB, p *Prefs) error {
+	logger.Log.Debug("saving notification preferences", "username", p.Username, "mode", p.Mode, "events", p.Events)
+
+	switch p.Mode {
+	case ModeImmediate, ModeDigest, ModeOff:
+	default:
+		return fmt.Errorf("invalid mode: %s (must be %s, %s or %s)", p.Mode, ModeImmediate, ModeDigest, ModeOff)
+	}
+
+	_, err := db.Exec(`
+		INSERT INTO notification_prefs (username, mode, events, updated_at)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT(username) DO UPDATE SET mode = excluded.mode, events = excluded.events, updated_at = excluded.updated_at`,
+		p.Username, p.Mode, strings.Join(p.Events, ","), time.Now(),
+	)
+	if err != nil {
+		logger.Log.Error("failed to save notification preferences", "error", err, "username", p.Username)
+		return fmt.Errorf("failed to save notification preferences: %w", err)
+	}
+
+	logger.Log.Info("notification preferences saved", "username", p.Username, "mode", p.Mode)
+	return nil
+}
+
+// Watch adds a user to a ticket's watchers, reporting whether they were not watching it yet
+func Watch(db *sql.DB, ticketID int64, username string) (bool, error) {
+	logger.Log.Debug("watching ticket", "ticket_id", ticketID, "username", username)
+
+	result, err := db.Exec(`
+		INSERT INTO ticket_watchers (ticket_id, username, created_at)
+		VALUES (?, ?, ?)
+		ON CONFLICT (ticket_id, username) DO NOTHING`,
+		ticketID, username, time.Now(),
+	)
+	if err != nil {
+		logger.Log.Error("failed to watch ticket", "error", err, "ticket_id", ticketID)
+		return false, fmt.Errorf("failed to watch ticket: %w", err)
+	}
+	n, _ := result.RowsAffected()
+	return n > 0, nil
+}
+
+// Unwatch removes a user from a ticket's watchers, reporting whether they were watching it
+func Unwatch(db *sql.DB, ticketID int64, username string) (bool, error) {
+	logger.Log.Debug("unwatching ticket", "ticket_id", ticketID, "username", username)
+
+	result, err := db.Exec(`DELETE FROM ticket_watchers WHERE ticket_id = ? AND username = ?`, ticketID, username)
+	if err != nil {
+		logger.Log.Error("failed to unwatch ticket", "error", err, "ticket_id", ticketID)
+		return false, fmt.Errorf("failed to unwatch ticket: %w", err)
+	}
+	n, _ := result.RowsAffected()
+	return n > 0, nil
+}
+
+// Watchers returns the users watching a ticket, sorted by name
+func Watchers(db *sql.DB, ticketID int64) ([]string, error) {
+	rows, err := db.Query(`SELECT username FROM ticket_watchers WHERE ticket_id = ? ORDER BY username`, ticketID)
+	if err != nil {
+		logger.Log.Error("failed to query watchers", "error", err, "ticket_id", ticketID)
+		return nil, fmt.Errorf("failed to query watchers: %w", err)
+	}
+	defer rows.Close()
+
+	var watchers []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			logger.Log.Error("failed to scan watcher", "error", err)
+			return nil, fmt.Errorf("failed to scan watcher: %w", err)
+		}
+		watchers = append(watchers, name)
+	}
+	return watchers, rows.Err()
+}
@@ -0,0 +1,287 @@
+package notify_test
+
+import (
+	"alexandria/internal/notify"
+	"alexandria/internal/testdb"
+	"alexandria/internal/ticket"
+	"alexandria/internal/users"
+	"context"
+	"database/sql"
+	"net"
+	"net/textproto"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// mail is a message the SMTP stand-in accepted
+type mail struct {
+	to   string
+	data string
+}
+
+// smtpServer is a minimal SMTP server standing in for a real one. It accepts every
+// message, or rejects recipients while reject is set.
+type smtpServer struct {
+	addr   string
+	mu     sync.Mutex
+	reject bool
+	mails  []mail
+}
+
+func newSMTPServer(t *testing.T) *smtpServer {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	s := &smtpServer{addr: ln.Addr().String()}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+func (s *smtpServer) serve(conn net.Conn) {
+	defer conn.Close()
+	tp := textproto.NewConn(conn)
+	tp.PrintfLine("220 localhost ESMTP stand-in")
+
+	var to string
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+		verb := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+		switch verb {
+		case "EHLO", "HELO":
+			tp.PrintfLine("250 localhost")
+		case "MAIL":
+			tp.PrintfLine("250 OK")
+		case "RCPT":
+			s.mu.Lock()
+			reject := s.reject
+			s.mu.Unlock()
+			if reject {
+				tp.PrintfLine("550 mailbox unavailable")
+				continue
+			}
+			to = strings.Trim(strings.TrimPrefix(line[len("RCPT TO:"):], " "), "<>")
+			tp.PrintfLine("250 OK")
+		case "DATA":
+			tp.PrintfLine("354 go ahead")
+			data, err := tp.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			s.mu.Lock()
+			s.mails = append(s.mails, mail{to: to, data: string(data)})
+			s.mu.Unlock()
+			tp.PrintfLine("250 OK")
+		case "RSET", "NOOP":
+			tp.PrintfLine("250 OK")
+		case "QUIT":
+			tp.PrintfLine("221 bye")
+			return
+		default:
+			tp.PrintfLine("502 not implemented")
+		}
+	}
+}
+
+func (s *smtpServer) setReject(reject bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.reject = reject
+}
+
+func (s *smtpServer) received() []mail {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]mail{}, s.mails...)
+}
+
+func (s *smtpServer) mailer() notify.Mailer {
+	return &notify.SMTPMailer{Addr: s.addr, From: "alexandria@example.com"}
+}
+
+// openDB opens a fresh database with the users the tests mention
+func openDB(t *testing.T) *sql.DB {
+	t.Helper()
+	db := testdb.Open(t)
+	for _, name := range []string{"alice", "bob", "carol"} {
+		if err := users.Save(db, &users.User{Username: name, Email: name + "@example.com", Role: users.RoleUser}); err != nil {
+			t.Fatalf("failed to add user %s: %v", name, err)
+		}
+	}
+	return db
+}
+
+func onlyProject(project string) func(string) bool {
+	return func(p string) bool { return p == project }
+}
+
+func send(t *testing.T, db *sql.DB, mailer notify.Mailer) notify.Result {
+	t.Helper()
+	res, err := notify.Send(db, mailer, time.Now())
+	if err != nil {
+		t.Fatalf("send failed: %v", err)
+	}
+	return res
+}
+
+func TestNotifiesPeopleInvolved(t *testing.T) {
+	db := openDB(t)
+	server := newSMTPServer(t)
+	store := notify.NewStore(ticket.NewSQLStore(db), db, onlyProject("Backend"))
+	ctx := ticket.WithActor(context.Background(), "alice")
+
+	if err := notify.SetPrefs(db, &notify.Prefs{Username: "carol", Mode: notify.ModeDigest, Events: notify.Events}); err != nil {
+		t.Fatalf("failed to set preferences: %v", err)
+	}
+
+	creator, assignee := "alice", "bob"
+	tk := &ticket.Ticket{Title: "Fix login", Type: ticket.TypeBug, Status: ticket.StatusOpen, Priority: ticket.PriorityHigh,
+		CreatedBy: &creator, AssignedTo: &assignee}
+	if err := store.Create(ctx, "Backend", tk); err != nil {
+		t.Fatalf("failed to create ticket: %v", err)
+	}
+	if _, err := notify.Watch(db, tk.ID, "carol"); err != nil {
+		t.Fatalf("failed to watch: %v", err)
+	}
+	if err := store.AddComment(ctx, tk.ID, "Reproduced on staging"); err != nil {
+		t.Fatalf("failed to comment: %v", err)
+	}
+	tk.Status = ticket.StatusClosed
+	if err := store.Update(ctx, "Backend", tk); err != nil {
+		t.Fatalf("failed to close ticket: %v", err)
+	}
+
+	// Bob is told of the assignment, comment and close; alice made them all
+	if store.Queued() != 3 {
+		t.Fatalf("expected 3 immediate notifications, got %d", store.Queued())
+	}
+	if res := send(t, db, server.mailer()); res.Sent != 3 {
+		t.Fatalf("expected 3 emails sent, got %+v", res)
+	}
+	got := server.received()
+	for _, m := range got {
+		if m.to != "bob@example.com" {
+			t.Errorf("unexpected recipient %s", m.to)
+		}
+	}
+	if len(got) == 3 {
+		if !strings.Contains(got[0].data, "Subject: [ALX-") || !strings.Contains(got[0].data, "Assigned to you") {
+			t.Errorf("unexpected assignment email:\n%s", got[0].data)
+		}
+		if !strings.Contains(got[1].data, "Reproduced on staging") || !strings.Contains(got[2].data, "alice closed this ticket") {
+			t.Errorf("unexpected comment or close email:\n%s\n%s", got[1].data, got[2].data)
+		}
+	}
+
+	// Carol's comment and close notifications wait for her digest
+	res, err := notify.SendDigests(db, server.mailer(), time.Now())
+	if err != nil || res.Sent != 1 {
+		t.Fatalf("expected one digest, got %+v (%v)", res, err)
+	}
+	got = server.received()
+	digest := got[len(got)-1]
+	if digest.to != "carol@example.com" || !strings.Contains(digest.data, "2 update(s)") ||
+		!strings.Contains(digest.data, "New comment") || !strings.Contains(digest.data, "Closed") {
+		t.Errorf("unexpected digest to %s:\n%s", digest.to, digest.data)
+	}
+
+	// Everything has been sent
+	if res := send(t, db, server.mailer()); res != (notify.Result{}) {
+		t.Errorf("expected nothing left to send, got %+v", res)
+	}
+}
+
+func TestPreferencesAndDisabledProjects(t *testing.T) {
+	db := openDB(t)
+	store := notify.NewStore(ticket.NewSQLStore(db), db, onlyProject("Backend"))
+	ctx := ticket.WithActor(context.Background(), "alice")
+
+	bob, carol := "bob", "carol"
+	other := &ticket.Ticket{Title: "Elsewhere", Type: ticket.TypeTask, Status: ticket.StatusOpen, Priority: ticket.PriorityLow, AssignedTo: &bob}
+	if err := store.Create(ctx, "Frontend", other); err != nil {
+		t.Fatalf("failed to create ticket: %v", err)
+	}
+	if store.Queued() != 0 {
+		t.Errorf("expected no notifications for a disabled project, got %d", store.Queued())
+	}
+
+	if err := notify.SetPrefs(db, &notify.Prefs{Username: "bob", Mode: notify.ModeOff, Events: notify.Events}); err != nil {
+		t.Fatalf("failed to set preferences: %v", err)
+	}
+	if err := notify.SetPrefs(db, &notify.Prefs{Username: "carol", Mode: notify.ModeImmediate, Events: []string{notify.EventClosed}}); err != nil {
+		t.Fatalf("failed to set preferences: %v", err)
+	}
+	if err := notify.SetPrefs(db, &notify.Prefs{Username: "carol", Mode: "sometimes"}); err == nil {
+		t.Error("expected an invalid mode to be rejected")
+	}
+
+	tk := &ticket.Ticket{Title: "Quiet", Type: ticket.TypeTask, Status: ticket.StatusOpen, Priority: ticket.PriorityLow, AssignedTo: &bob}
+	if err := store.Create(ctx, "Backend", tk); err != nil {
+		t.Fatalf("failed to create ticket: %v", err)
+	}
+	tk.AssignedTo = &carol
+	if err := store.Update(ctx, "Backend", tk); err != nil {
+		t.Fatalf("failed to update ticket: %v", err)
+	}
+	if store.Queued() != 0 {
+		t.Errorf("expected bob (off) and carol (closed only) not to be notified, got %d", store.Queued())
+	}
+
+	tk.Status = ticket.StatusClosed
+	if err := store.Update(ctx, "Backend", tk); err != nil {
+		t.Fatalf("failed to close ticket: %v", err)
+	}
+	if store.Queued() != 1 {
+		t.Errorf("expected carol to be notified of the close, got %d", store.Queued())
+	}
+}
+
+func TestFailedEmailsAreRetried(t *testing.T) {
+	db := openDB(t)
+	server := newSMTPServer(t)
+	server.setReject(true)
+
+	n := &notify.Notification{Username: "bob", Email: "bob@example.com", Event: notify.EventAssigned, TicketID: 1,
+		Subject: "[ALX-1] Test: Assigned to you", Body: "Hello", CreatedAt: time.Now()}
+	if err := notify.Enqueue(db, n); err != nil {
+		t.Fatalf("failed to queue: %v", err)
+	}
+
+	if res := send(t, db, server.mailer()); res.Retrying != 1 {
+		t.Fatalf("expected a retry, got %+v", res)
+	}
+	server.setReject(false)
+	if res := send(t, db, server.mailer()); res.Sent != 1 {
+		t.Fatalf("expected the retry to be sent, got %+v", res)
+	}
+
+	server.setReject(true)
+	if err := notify.Enqueue(db, n); err != nil {
+		t.Fatalf("failed to queue: %v", err)
+	}
+	for i := 1; i < notify.MaxAttempts; i++ {
+		send(t, db, server.mailer())
+	}
+	if res := send(t, db, server.mailer()); res.Failed != 1 {
+		t.Fatalf("expected to give up after %d attempts, got %+v", notify.MaxAttempts, res)
+	}
+	if res := send(t, db, server.mailer()); res != (notify.Result{}) {
+		t.Errorf("expected a failed notification not to be retried, got %+v", res)
+	}
+}
@@ -0,0 +1,189 @@
+package notify
+
+import (
+	"alexandria/internal/gitlink"
+	"alexandria/internal/logger"
+	"alexandria/internal/ticket"
+	"alexandria/internal/users"
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Store is a TicketStore that queues email to the people involved in a ticket when
+// it is assigned, commented on or closed: its assignee, its creator and its
+// watchers, except whoever made the change. Only tickets in projects for which
+// enabled returns true send email. Like webhooks, queueing happens after the
+// change is saved and a failure to queue is only logged.
+type Store struct {
+	ticket.TicketStore
+	db      *sql.DB
+	enabled func(project string) bool
+	queued  int
+}
+
+// NewStore wraps tickets, queueing notifications in db for projects enabled reports on
+func NewStore(tickets ticket.TicketStore, db *sql.DB, enabled func(project string) bool) *Store {
+	return &Store{TicketStore: tickets, db: db, enabled: enabled}
+}
+
+// Queued returns how many notifications to send immediately have been queued through the store
+func (s *Store) Queued() int {
+	return s.queued
+}
+
+// Create saves a new ticket and notifies its assignee
+func (s *Store) Create(ctx context.Context, project string, t *ticket.Ticket) error {
+	if err := s.TicketStore.Create(ctx, project, t); err != nil {
+		return err
+	}
+	s.changed(ctx, t.ID, nil)
+	return nil
+}
+
+// Update saves a ticket and notifies a new assignee, and everyone involved when it is closed
+func (s *Store) Update(ctx context.Context, project string, t *ticket.Ticket) error {
+	before, _ := s.TicketStore.Get(ctx, t.ID)
+	if err := s.TicketStore.Update(ctx, project, t); err != nil {
+		return err
+	}
+	s.changed(ctx, t.ID, before)
+	return nil
+}
+
+// UpdateMany saves several tickets and notifies as Update does
+func (s *Store) UpdateMany(ctx context.Context, tickets []ticket.Ticket) error {
+	before := make([]*ticket.Ticket, len(tickets))
+	for i := range tickets {
+		before[i], _ = s.TicketStore.Get(ctx, tickets[i].ID)
+	}
+	if err := s.TicketStore.UpdateMany(ctx, tickets); err != nil {
+		return err
+	}
+	for i := range tickets {
+		s.changed(ctx, tickets[i].ID, before[i])
+	}
+	return nil
+}
+
+// AddComment appends a comment and notifies everyone involved in the ticket
+func (s *Store) AddComment(ctx context.Context, ticketID int64, text string) error {
+	if err := s.TicketStore.AddComment(ctx, ticketID, text); err != nil {
+		return err
+	}
+	t, err := s.TicketStore.Get(ctx, ticketID)
+	if err != nil {
+		logger.Log.Warn("failed to load commented ticket for notifications", "error", err, "id", ticketID)
+		return nil
+	}
+	if !s.enabled(t.Project) {
+		return nil
+	}
+	actor := ticket.ActorFrom(ctx)
+	body := fmt.Sprintf("%s commented:\n\n%s\n", actor, text)
+	s.notify(ctx, EventCommented, t, s.involved(t), "New comment", body)
+	return nil
+}
+
+// changed notifies about a created or updated ticket, compared with how it was
+// before (nil for a new ticket)
+func (s *Store) changed(ctx context.Context, id int64, before *ticket.Ticket) {
+	t, err := s.TicketStore.Get(ctx, id)
+	if err != nil {
+		logger.Log.Warn("failed to load changed ticket for notifications", "error", err, "id", id)
+		return
+	}
+	if !s.enabled(t.Project) {
+		return
+	}
+	actor := ticket.ActorFrom(ctx)
+
+	if t.AssignedTo != nil && *t.AssignedTo != "" && (before == nil || before.AssignedTo == nil || *before.AssignedTo != *t.AssignedTo) {
+		body := fmt.Sprintf("%s assigned this ticket to you.\n", actor)
+		s.notify(ctx, EventAssigned, t, []string{*t.AssignedTo}, "Assigned to you", body)
+	}
+	if before != nil && before.Status != ticket.StatusClosed && t.Status == ticket.StatusClosed {
+		body := fmt.Sprintf("%s closed this ticket.\n", actor)
+		s.notify(ctx, EventClosed, t, s.involved(t), "Closed", body)
+	}
+}
+
+// involved returns the ticket's assignee, creator and watchers
+func (s *Store) involved(t *ticket.Ticket) []string {
+	var names []string
+	if t.AssignedTo != nil {
+		names = append(names, *t.AssignedTo)
+	}
+	if t.CreatedBy != nil {
+		names = append(names, *t.CreatedBy)
+	}
+	watchers, err := Watchers(s.db, t.ID)
+	if err != nil {
+		logger.Log.Warn("failed to load watchers for notifications", "error", err, "id", t.ID)
+	}
+	return append(names, watchers...)
+}
+
+// notify queues the event for each user who wants it and has an email address,
+// skipping the user who made the change
+func (s *Store) notify(ctx context.Context, event string, t *ticket.Ticket, recipients []string, what, body string) {
+	actor := ticket.ActorFrom(ctx)
+	ref := fmt.Sprintf("%s-%d", gitlink.RefPrefix, t.ID)
+	subject := fmt.Sprintf("[%s] %s: %s", ref, t.Title, what)
+	body += "\n" + describe(ref, t)
+
+	seen := map[string]bool{actor: true, "": true}
+	for _, user := range recipients {
+		if seen[user] {
+			continue
+		}
+		seen[user] = true
+
+		prefs, err := GetPrefs(s.db, user)
+		if err != nil || !prefs.Wants(event) {
+			continue
+		}
+		email, err := users.Email(s.db, user)
+		if err != nil || email == "" {
+			logger.Log.Debug("no email address for notification", "username", user, "event", event)
+			continue
+		}
+
+		n := &Notification{
+			Username:  user,
+			Email:     email,
+			Event:     event,
+			TicketID:  t.ID,
+			Subject:   subject,
+			Body:      body,
+			Digest:    prefs.Mode == ModeDigest,
+			CreatedAt: time.Now(),
+		}
+		if err := Enqueue(s.db, n); err != nil {
+			logger.Log.Warn("failed to queue notification", "error", err, "username", user, "event", event)
+			continue
+		}
+		if !n.Digest {
+			s.queued++
+		}
+	}
+}
+
+// describe summarizes a ticket for the end of a notification
+func describe(ref string, t *ticket.Ticket) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s: %s\n", ref, t.Title)
+	fmt.Fprintf(&b, "Project:  %s\n", t.Project)
+	fmt.Fprintf(&b, "Status:   %s\n", t.Status)
+	fmt.Fprintf(&b, "Priority: %s\n", t.Priority)
+	if t.AssignedTo != nil && *t.AssignedTo != "" {
+		fmt.Fprintf(&b, "Assignee: %s\n", *t.AssignedTo)
+	}
+	if t.DueAt != nil {
+		fmt.Fprintf(&b, "Due:      %s\n", t.DueAt.Format("2006-01-02"))
+	}
+	fmt.Fprintf(&b, "\nalexandria view --id %s\n", ref)
+	return b.String()
+}
@@ -0,0 +1,307 @@
+package recur
+
+import (
+	"alexandria/internal/dates"
+	"alexandria/internal/logger"
+	"alexandria/internal/templates"
+	"alexandria/internal/ticket"
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Recurrence creates a ticket from a project template each time its schedule comes round
+type Recurrence struct {
+	Project    string     `json:"project"`
+	Name       string     `json:"name"`
+	Template   string     `json:"template"`
+	Title      string     `json:"title,omitempty"` // may contain template placeholders
+	Spec       string     `json:"spec"`            // the schedule as it was given, e.g. "every monday at 09:00"
+	Cron       string     `json:"cron"`            // the schedule as a cron expression
+	AssignedTo string     `json:"assigned_to,omitempty"`
+	DueIn      string     `json:"due_in,omitempty"` // how long after the occurrence the ticket is due, e.g. 2d
+	CreatedBy  string     `json:"created_by,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastRunAt  *time.Time `json:"last_run_at,omitempty"` // the latest occurrence a ticket was created for
+}
+
+// Next returns the first occurrence of the schedule after t, in local time, or the
+// zero time if it never comes
+func (r *Recurrence) Next(t time.Time) time.Time {
+	c, err := ParseCron(r.Cron)
+	if err != nil {
+		return time.Time{}
+	}
+	return c.Next(t.In(time.Local))
+}
+
+// Spawned records the ticket a schedule created for one occurrence
+type Spawned struct {
+	Project    string    `json:"project"`
+	Schedule   string    `json:"schedule"`
+	Occurrence time.Time `json:"occurrence"`
+	TicketID   int64     `json:"ticket_id,omitempty"` // zero for a dry run or a failure
+	Error      string    `json:"error,omitempty"`
+}
+
+// Add stores a new schedule after checking its template and cron expression
+func Add(db *sql.DB, r *Recurrence) error {
+	logger.Log.Debug("adding recurring schedule", "project", r.Project, "name", r.Name, "cron", r.Cron)
+
+	if r.Project == "" {
+		return fmt.Errorf("schedule project is required")
+	}
+	if r.Name == "" {
+		return fmt.Errorf("schedule name is required")
+	}
+	if _, err := ParseCron(r.Cron); err != nil {
+		return err
+	}
+	if _, err := templates.Get(db, r.Project, r.Template); err != nil {
+		return err
+	}
+
+	r.CreatedAt = time.Now()
+	result, err := db.Exec(`
+		INSERT INTO recurring_schedules (project, name, template, title, spec, cron, assigned_to, due_in, created_by, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (project, name) DO NOTHING`,
+		r.Project, r.Name, r.Template, r.Title, r.Spec, r.Cron, r.AssignedTo, r.DueIn, r.CreatedBy, r.CreatedAt,
+	)
+	if err != nil {
+		logger.Log.Error("failed to add recurring schedule", "error", err, "project", r.Project, "name", r.Name)
+		return fmt.Errorf("failed to add recurring schedule: %w", err)
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("project '%s' already has a schedule named '%s'", r.Project, r.Name)
+	}
+
+	logger.Log.Info("recurring schedule added", "project", r.Project, "name", r.Name)
+	return nil
+}
+
+const scheduleColumns = `project, name, template, title, spec, cron, assigned_to, due_in, created_by, created_at, last_run_at`
+
+// List returns a project's schedules, or every schedule when project is empty
+func List(db *sql.DB, project string) ([]Recurrence, error) {
+	logger.Log.Debug("listing recurring schedules", "project", project)
+
+	query := `SELECT ` + scheduleColumns + ` FROM recurring_schedules`
+	var args []interface{}
+	if project != "" {
+		query += ` WHERE project = ?`
+		args = append(args, project)
+	}
+	query += ` ORDER BY project, name`
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		logger.Log.Error("failed to query recurring schedules", "error", err)
+		return nil, fmt.Errorf("failed to query recurring schedules: %w", err)
+	}
+	defer rows.Close()
+
+	var all []Recurrence
+	for rows.Next() {
+		var (
+			r                                 Recurrence
+			title, assignee, dueIn, createdBy sql.NullString
+		)
+		if err := rows.Scan(&r.Project, &r.Name, &r.Template, &title, &r.Spec, &r.Cron, &assignee, &dueIn, &createdBy,
+			&r.CreatedAt, &r.LastRunAt); err != nil {
+			logger.Log.Error("failed to scan recurring schedule", "error", err)
+			return nil, fmt.Errorf("failed to scan recurring schedule: %w", err)
+		}
+		r.Title, r.AssignedTo, r.DueIn, r.CreatedBy = title.String, assignee.String, dueIn.String, createdBy.String
+		all = append(all, r)
+	}
+	return all, rows.Err()
+}
+
+// Remove deletes a schedule. The record of the tickets it created is kept.
+func Remove(db *sql.DB, project, name string) error {
+	logger.Log.Debug("removing recurring schedule", "project", project, "name", name)
+
+	result, err := db.Exec(`DELETE FROM recurring_schedules WHERE project = ? AND name = ?`, project, name)
+	if err != nil {
+		logger.Log.Error("failed to remove recurring schedule", "error", err, "project", project, "name", name)
+		return fmt.Errorf("failed to remove recurring schedule: %w", err)
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("no schedule named '%s' in project '%s'", name, project)
+	}
+
+	logger.Log.Info("recurring schedule removed", "project", project, "name", name)
+	return nil
+}
+
+// SpawnedBy returns the schedule run that created a ticket, or nil if no schedule did
+func SpawnedBy(db *sql.DB, ticketID int64) (*Spawned, error) {
+	s := &Spawned{TicketID: ticketID}
+	err := db.QueryRow(`SELECT project, schedule, occurrence FROM schedule_runs WHERE ticket_id = ?`, ticketID).
+		Scan(&s.Project, &s.Schedule, &s.Occurrence)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		logger.Log.Error("failed to look up schedule run", "error", err, "ticket_id", ticketID)
+		return nil, fmt.Errorf("failed to look up schedule run: %w", err)
+	}
+	return s, nil
+}
+
+// Due returns the occurrence a schedule should create a ticket for at now: the
+// latest one after its last run, or after it was added if it has not run yet.
+// Earlier occurrences missed while nothing ran are skipped rather than created
+// all at once. ok is false when nothing is due.
+func (r *Recurrence) Due(now time.Time) (occurrence time.Time, ok bool) {
+	since := r.CreatedAt
+	if r.LastRunAt != nil && r.LastRunAt.After(since) {
+		since = *r.LastRunAt
+	}
+	for t := r.Next(since); !t.IsZero() && !t.After(now); t = r.Next(t) {
+		occurrence, ok = t, true
+	}
+	return occurrence, ok
+}
+
+// Run creates the tickets that are due at now through store and returns what it
+// created. It can be run as often as wanted, for example every few minutes from
+// cron: each occurrence is claimed in schedule_runs before its ticket is created,
+// so it only ever gets one ticket. With dryRun set nothing is written. A schedule
+// that fails is reported in its Spawned entry and the others still run.
+func Run(ctx context.Context, db *sql.DB, store ticket.TicketStore, now time.Time, dryRun bool) ([]Spawned, error) {
+	logger.Log.Debug("running recurring schedules", "now", now, "dry_run", dryRun)
+
+	schedules, err := List(db, "")
+	if err != nil {
+		return nil, err
+	}
+
+	var spawned []Spawned
+	for i := range schedules {
+		r := &schedules[i]
+		occurrence, ok := r.Due(now)
+		if !ok {
+			continue
+		}
+		s := Spawned{Project: r.Project, Schedule: r.Name, Occurrence: occurrence}
+		if !dryRun {
+			created, err := spawn(ctx, db, store, r, occurrence)
+			if err != nil {
+				s.Error = err.Error()
+			} else if created == 0 {
+				continue // another run claimed it first
+			}
+			s.TicketID = created
+		}
+		spawned = append(spawned, s)
+	}
+	return spawned, nil
+}
+
+// spawn claims an occurrence and creates its ticket, returning the ticket's ID, or
+// zero if the occurrence was already claimed
+func spawn(ctx context.Context, db *sql.DB, store ticket.TicketStore, r *Recurrence, occurrence time.Time) (int64, error) {
+	// Occurrences are keyed in UTC so every backend and replica agrees on them
+	key := occurrence.UTC()
+	result, err := db.Exec(`
+		INSERT INTO schedule_runs (project, schedule, occurrence, created_at)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT (project, schedule, occurrence) DO NOTHING`,
+		r.Project, r.Name, key, time.Now(),
+	)
+	if err != nil {
+		logger.Log.Error("failed to claim schedule run", "error", err, "project", r.Project, "name", r.Name)
+		return 0, fmt.Errorf("failed to claim schedule run: %w", err)
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		logger.Log.Debug("schedule run already claimed", "project", r.Project, "name", r.Name, "occurrence", key)
+		return 0, nil
+	}
+	release := func() {
+		if _, err := db.Exec(`DELETE FROM schedule_runs WHERE project = ? AND schedule = ? AND occurrence = ?`,
+			r.Project, r.Name, key); err != nil {
+			logger.Log.Warn("failed to release schedule run", "error", err, "project", r.Project, "name", r.Name)
+		}
+	}
+
+	t, err := r.ticket(db, occurrence)
+	if err != nil {
+		release()
+		return 0, err
+	}
+	if err := store.Create(ctx, r.Project, t); err != nil {
+		logger.Log.Error("failed to create recurring ticket", "error", err, "project", r.Project, "name", r.Name)
+		release()
+		return 0, fmt.Errorf("failed to create ticket: %w", err)
+	}
+
+	if _, err := db.Exec(`UPDATE schedule_runs SET ticket_id = ? WHERE project = ? AND schedule = ? AND occurrence = ?`,
+		t.ID, r.Project, r.Name, key); err != nil {
+		logger.Log.Error("failed to record schedule run", "error", err, "project", r.Project, "name", r.Name)
+		return t.ID, fmt.Errorf("failed to record schedule run: %w", err)
+	}
+	if _, err := db.Exec(`UPDATE recurring_schedules SET last_run_at = ? WHERE project = ? AND name = ?`,
+		key, r.Project, r.Name); err != nil {
+		logger.Log.Error("failed to record last run", "error", err, "project", r.Project, "name", r.Name)
+		return t.ID, fmt.Errorf("failed to record last run: %w", err)
+	}
+
+	logger.Log.Info("recurring ticket created", "id", t.ID, "project", r.Project, "name", r.Name, "occurrence", key)
+	return t.ID, nil
+}
+
+// ticket builds the ticket for an occurrence from the schedule's template
+func (r *Recurrence) ticket(db *sql.DB, occurrence time.Time) (*ticket.Ticket, error) {
+	tmpl, err := templates.Get(db, r.Project, r.Template)
+	if err != nil {
+		return nil, err
+	}
+
+	vars := map[string]string{
+		templates.VarUser:    r.CreatedBy,
+		templates.VarDate:    occurrence.Format("2006-01-02"),
+		templates.VarBranch:  "",
+		templates.VarProject: r.Project,
+	}
+	title := r.Title
+	if title == "" {
+		title = r.Name + " {{date}}"
+	}
+
+	t := &ticket.Ticket{
+		Type:        ticket.TypeTask,
+		Title:       templates.Expand(title, vars),
+		Description: templates.Expand(tmpl.Description, vars),
+		Status:      ticket.StatusOpen,
+		Priority:    ticket.PriorityUndefined,
+		Tags:        append([]string{}, tmpl.Tags...),
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+	}
+	if tmpl.Type != "" {
+		t.Type = ticket.Type(tmpl.Type)
+	}
+	if tmpl.Priority != "" {
+		t.Priority = ticket.Priority(tmpl.Priority)
+	}
+	if r.AssignedTo != "" {
+		assignee := r.AssignedTo
+		t.AssignedTo = &assignee
+	}
+	if r.CreatedBy != "" {
+		creator := r.CreatedBy
+		t.CreatedBy = &creator
+	}
+	if r.DueIn != "" {
+		d, err := dates.ParseDuration(r.DueIn)
+		if err != nil {
+			return nil, fmt.Errorf("invalid due offset %q: %w", r.DueIn, err)
+		}
+		due := occurrence.Add(d)
+		t.DueAt = &due
+	}
+	return t, nil
+}
@@ -0,0 +1,147 @@
+package recur_test
+
+import (
+	"alexandria/internal/recur"
+	"alexandria/internal/templates"
+	"alexandria/internal/testdb"
+	"alexandria/internal/ticket"
+	"context"
+	"testing"
+	"time"
+)
+
+func at(value string) time.Time {
+	t, err := time.ParseInLocation("2006-01-02 15:04", value, time.Local)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestSchedules(t *testing.T) {
+	tests := []struct {
+		name  string
+		parse func() (*recur.Cron, error)
+		cron  string
+		after string
+		next  string
+	}{
+		{"every monday", func() (*recur.Cron, error) { return recur.ParseEvery("monday", "") }, "0 0 * * 1", "2026-10-18 12:00", "2026-10-19 00:00"},
+		{"every weekday at", func() (*recur.Cron, error) { return recur.ParseEvery("weekday", "09:30") }, "30 9 * * 1-5", "2026-10-16 10:00", "2026-10-19 09:30"},
+		{"every month", func() (*recur.Cron, error) { return recur.ParseEvery("month", "") }, "0 0 1 * *", "2026-10-18 12:00", "2026-11-01 00:00"},
+		{"cron step", func() (*recur.Cron, error) { return recur.ParseCron("*/15 * * * *") }, "*/15 * * * *", "2026-10-18 12:07", "2026-10-18 12:15"},
+		{"cron sunday as 7", func() (*recur.Cron, error) { return recur.ParseCron("0 8 * * 7") }, "0 8 * * 7", "2026-10-18 09:00", "2026-10-25 08:00"},
+		{"cron day of month or week", func() (*recur.Cron, error) { return recur.ParseCron("0 0 13 * fri") }, "0 0 13 * fri", "2026-10-18 00:00", "2026-10-23 00:00"},
+		{"rrule weekly", func() (*recur.Cron, error) { return recur.ParseRRule("FREQ=WEEKLY;BYDAY=MO,TH;BYHOUR=9") }, "0 9 * * 1,4", "2026-10-19 09:00", "2026-10-22 09:00"},
+		{"rrule monthly", func() (*recur.Cron, error) { return recur.ParseRRule("RRULE:FREQ=MONTHLY;BYMONTHDAY=31") }, "0 0 31 * *", "2026-10-31 00:00", "2026-12-31 00:00"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := tt.parse()
+			if err != nil {
+				t.Fatalf("failed to parse: %v", err)
+			}
+			if c.String() != tt.cron {
+				t.Errorf("expected cron %q, got %q", tt.cron, c.String())
+			}
+			if got := c.Next(at(tt.after)); !got.Equal(at(tt.next)) {
+				t.Errorf("expected next %s, got %s", tt.next, got.Format("2006-01-02 15:04"))
+			}
+		})
+	}
+
+	for _, bad := range []func() (*recur.Cron, error){
+		func() (*recur.Cron, error) { return recur.ParseCron("0 9 * *") },
+		func() (*recur.Cron, error) { return recur.ParseCron("60 * * * *") },
+		func() (*recur.Cron, error) { return recur.ParseEvery("fortnight", "") },
+		func() (*recur.Cron, error) { return recur.ParseEvery("day", "25:00") },
+		func() (*recur.Cron, error) { return recur.ParseRRule("FREQ=WEEKLY;INTERVAL=2") },
+		func() (*recur.Cron, error) { return recur.ParseRRule("FREQ=YEARLY") },
+	} {
+		if c, err := bad(); err == nil {
+			t.Errorf("expected an error, got %s", c)
+		}
+	}
+}
+
+func TestRunCreatesEachOccurrenceOnce(t *testing.T) {
+	db := testdb.Open(t)
+	store := ticket.NewSQLStore(db)
+	ctx := context.Background()
+
+	tmpl := &templates.Template{Project: "OPS", Name: "weekly-ops", Type: "task", Priority: "high", Tags: []string{"oncall"},
+		Description: "Chores for the week of {{date}}"}
+	if err := templates.Save(db, tmpl); err != nil {
+		t.Fatalf("failed to save template: %v", err)
+	}
+
+	r := &recur.Recurrence{Project: "OPS", Name: "weekly-ops", Template: "weekly-ops", Spec: "every monday", Cron: "0 9 * * 1",
+		AssignedTo: "alice", DueIn: "2d", CreatedBy: "bob"}
+	if err := recur.Add(db, r); err != nil {
+		t.Fatalf("failed to add schedule: %v", err)
+	}
+	if err := recur.Add(db, r); err == nil {
+		t.Error("expected a duplicate schedule name to be rejected")
+	}
+	if err := recur.Add(db, &recur.Recurrence{Project: "OPS", Name: "other", Template: "missing", Cron: "0 9 * * 1"}); err == nil {
+		t.Error("expected a missing template to be rejected")
+	}
+
+	monday := r.Next(time.Now())
+	run := func(now time.Time, dryRun bool) []recur.Spawned {
+		t.Helper()
+		spawned, err := recur.Run(ctx, db, store, now, dryRun)
+		if err != nil {
+			t.Fatalf("run failed: %v", err)
+		}
+		return spawned
+	}
+
+	if got := run(monday.Add(-time.Minute), false); len(got) != 0 {
+		t.Fatalf("expected nothing due before the first occurrence, got %+v", got)
+	}
+	if got := run(monday.Add(time.Hour), true); len(got) != 1 || got[0].TicketID != 0 {
+		t.Fatalf("expected a dry run to report one ticket without creating it, got %+v", got)
+	}
+
+	got := run(monday.Add(time.Hour), false)
+	if len(got) != 1 || got[0].TicketID == 0 || !got[0].Occurrence.Equal(monday) {
+		t.Fatalf("expected one ticket for %s, got %+v", monday, got)
+	}
+	for i := 0; i < 3; i++ {
+		if again := run(monday.Add(2*time.Hour), false); len(again) != 0 {
+			t.Fatalf("expected repeated runs to create nothing, got %+v", again)
+		}
+	}
+
+	created, err := store.Get(ctx, got[0].TicketID)
+	if err != nil {
+		t.Fatalf("failed to load created ticket: %v", err)
+	}
+	date := monday.Format("2006-01-02")
+	if created.Title != "weekly-ops "+date || created.Description != "Chores for the week of "+date ||
+		created.Priority != ticket.PriorityHigh || len(created.Tags) != 1 || created.Tags[0] != "oncall" {
+		t.Errorf("ticket not built from the template: %+v", created)
+	}
+	if created.AssignedTo == nil || *created.AssignedTo != "alice" || created.DueAt == nil || !created.DueAt.Equal(monday.AddDate(0, 0, 2)) {
+		t.Errorf("expected assignee alice due two days later, got %+v", created)
+	}
+
+	by, err := recur.SpawnedBy(db, created.ID)
+	if err != nil || by == nil || by.Schedule != "weekly-ops" || !by.Occurrence.Equal(monday) {
+		t.Errorf("expected the ticket to record its schedule, got %+v (%v)", by, err)
+	}
+
+	// Three missed weeks produce one ticket, for the latest
+	later := monday.AddDate(0, 0, 21)
+	got = run(later.Add(time.Minute), false)
+	if len(got) != 1 || !got[0].Occurrence.Equal(later) {
+		t.Fatalf("expected one ticket for %s, got %+v", later, got)
+	}
+
+	schedules, err := recur.List(db, "OPS")
+	if err != nil || len(schedules) != 1 || schedules[0].LastRunAt == nil || !schedules[0].LastRunAt.Equal(later) {
+		t.Errorf("expected the last run to be recorded, got %+v (%v)", schedules, err)
+	}
+}
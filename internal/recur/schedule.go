@@ -0,0 +1,293 @@
+package recur
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Cron is a parsed five-field cron expression: minute, hour, day of month, month
+// and day of week. As in cron, when both the day of month and the day of week are
+// restricted a day matching either one matches.
+type Cron struct {
+	expr    string
+	minute  uint64 // bit n set when minute n matches
+	hour    uint64
+	dom     uint64
+	month   uint64
+	dow     uint64 // 0 is Sunday
+	domStar bool
+	dowStar bool
+}
+
+// cronFields gives the range of each field, in order
+var cronFields = []struct {
+	name     string
+	min, max int
+}{
+	{"minute", 0, 59},
+	{"hour", 0, 23},
+	{"day of month", 1, 31},
+	{"month", 1, 12},
+	{"day of week", 0, 7},
+}
+
+// dayNames are the names accepted for days of the week, in cron and --every
+var dayNames = map[string]int{
+	"sun": 0, "sunday": 0,
+	"mon": 1, "monday": 1,
+	"tue": 2, "tuesday": 2,
+	"wed": 3, "wednesday": 3,
+	"thu": 4, "thursday": 4,
+	"fri": 5, "friday": 5,
+	"sat": 6, "saturday": 6,
+}
+
+// ParseCron parses a cron expression such as "0 9 * * 1". Fields accept *, numbers,
+// ranges (1-5), lists (1,3), steps (*/15, 1-10/2) and day names (mon-fri).
+func ParseCron(expr string) (*Cron, error) {
+	fields := strings.Fields(expr)
+	if len(fields) != len(cronFields) {
+		return nil, fmt.Errorf("invalid cron expression: %q (expected 5 fields: minute hour day-of-month month day-of-week)", expr)
+	}
+
+	c := &Cron{expr: strings.Join(fields, " ")}
+	sets := []*uint64{&c.minute, &c.hour, &c.dom, &c.month, &c.dow}
+	for i, f := range fields {
+		bits, err := parseCronField(f, cronFields[i].min, cronFields[i].max)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cron %s %q: %w", cronFields[i].name, f, err)
+		}
+		*sets[i] = bits
+	}
+	// 7 is another name for Sunday
+	if c.dow&(1<<7) != 0 {
+		c.dow = c.dow&^(1<<7) | 1
+	}
+	c.domStar = fields[2] == "*"
+	c.dowStar = fields[4] == "*"
+	return c, nil
+}
+
+// parseCronField returns the values a field matches as a bit set
+func parseCronField(field string, min, max int) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		step := 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			n, err := strconv.Atoi(part[i+1:])
+			if err != nil || n < 1 {
+				return 0, fmt.Errorf("invalid step")
+			}
+			step = n
+			part = part[:i]
+		}
+
+		lo, hi := min, max
+		switch {
+		case part == "*":
+		case strings.Contains(part, "-"):
+			bounds := strings.SplitN(part, "-", 2)
+			var err error
+			if lo, err = cronValue(bounds[0], max); err != nil {
+				return 0, err
+			}
+			if hi, err = cronValue(bounds[1], max); err != nil {
+				return 0, err
+			}
+		default:
+			v, err := cronValue(part, max)
+			if err != nil {
+				return 0, err
+			}
+			lo, hi = v, v
+			if step > 1 {
+				hi = max
+			}
+		}
+		if lo < min || hi > max || lo > hi {
+			return 0, fmt.Errorf("out of range %d-%d", min, max)
+		}
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << v
+		}
+	}
+	return bits, nil
+}
+
+// cronValue parses a number, or a day name in the day of week field
+func cronValue(s string, max int) (int, error) {
+	if max == 7 {
+		if d, ok := dayNames[strings.ToLower(s)]; ok {
+			return d, nil
+		}
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value %q", s)
+	}
+	return v, nil
+}
+
+// String returns the expression in its normalized form
+func (c *Cron) String() string {
+	return c.expr
+}
+
+// dayMatches reports whether t's day is in the schedule
+func (c *Cron) dayMatches(t time.Time) bool {
+	dom := c.dom&(1<<t.Day()) != 0
+	dow := c.dow&(1<<int(t.Weekday())) != 0
+	if c.domStar || c.dowStar {
+		return dom && dow
+	}
+	return dom || dow
+}
+
+// Next returns the first time after t, to the minute, that the schedule matches.
+// It returns the zero time if there is none within five years, as for February 30.
+func (c *Cron) Next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+
+	for t.Before(limit) {
+		if c.month&(1<<int(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !c.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if c.hour&(1<<t.Hour()) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			continue
+		}
+		if c.minute&(1<<t.Minute()) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+// ParseEvery turns a plain description of a schedule into a cron expression. every
+// is day, weekday, week, month, hour or a list of weekday names such as
+// "monday,thursday"; at is the time of day as HH:MM.
+func ParseEvery(every, at string) (*Cron, error) {
+	minute, hour := 0, 0
+	if at != "" {
+		t, err := time.Parse("15:04", at)
+		if err != nil {
+			return nil, fmt.Errorf("invalid time of day: %s (use HH:MM)", at)
+		}
+		minute, hour = t.Minute(), t.Hour()
+	}
+
+	s := strings.ToLower(strings.TrimSpace(every))
+	var days string
+	switch s {
+	case "day", "daily":
+		days = "* * *"
+	case "weekday", "weekdays":
+		days = "* * 1-5"
+	case "week", "weekly":
+		days = "* * 1"
+	case "month", "monthly":
+		days = "1 * *"
+	case "hour", "hourly":
+		if at != "" {
+			return nil, fmt.Errorf("--at does not apply to an hourly schedule")
+		}
+		return ParseCron("0 * * * *")
+	default:
+		var dows []string
+		for _, name := range strings.Split(s, ",") {
+			d, ok := dayNames[strings.TrimSpace(name)]
+			if !ok {
+				return nil, fmt.Errorf("invalid schedule: every %s (use day, weekday, week, month, hour or weekday names like monday,thursday)", every)
+			}
+			dows = append(dows, strconv.Itoa(d))
+		}
+		days = "* * " + strings.Join(dows, ",")
+	}
+	return ParseCron(fmt.Sprintf("%d %d %s", minute, hour, days))
+}
+
+// rruleDays maps RRULE day codes to cron days of the week
+var rruleDays = map[string]string{"SU": "0", "MO": "1", "TU": "2", "WE": "3", "TH": "4", "FR": "5", "SA": "6"}
+
+// ParseRRule turns an iCalendar RRULE such as "FREQ=WEEKLY;BYDAY=MO" into a cron
+// expression. FREQ may be HOURLY, DAILY, WEEKLY or MONTHLY, with BYMONTH,
+// BYMONTHDAY, BYDAY (without ordinals), BYHOUR and BYMINUTE. Rules needing a start
+// date, such as INTERVAL above 1, COUNT or UNTIL, are not supported. Missing parts
+// default to midnight, Monday for WEEKLY and the 1st for MONTHLY.
+func ParseRRule(rule string) (*Cron, error) {
+	parts := map[string]string{}
+	for _, p := range strings.Split(strings.TrimPrefix(strings.TrimSpace(rule), "RRULE:"), ";") {
+		if p == "" {
+			continue
+		}
+		kv := strings.SplitN(p, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("invalid RRULE part: %s", p)
+		}
+		parts[strings.ToUpper(kv[0])] = strings.ToUpper(kv[1])
+	}
+
+	minute, hour, dom, month, dow := "0", "0", "*", "*", "*"
+	for key, value := range parts {
+		switch key {
+		case "FREQ":
+		case "INTERVAL":
+			if value != "1" {
+				return nil, fmt.Errorf("unsupported RRULE INTERVAL=%s (only 1 is supported)", value)
+			}
+		case "BYMINUTE":
+			minute = value
+		case "BYHOUR":
+			hour = value
+		case "BYMONTHDAY":
+			dom = value
+		case "BYMONTH":
+			month = value
+		case "BYDAY":
+			var days []string
+			for _, d := range strings.Split(value, ",") {
+				n, ok := rruleDays[d]
+				if !ok {
+					return nil, fmt.Errorf("unsupported RRULE BYDAY value: %s", d)
+				}
+				days = append(days, n)
+			}
+			dow = strings.Join(days, ",")
+		default:
+			return nil, fmt.Errorf("unsupported RRULE part: %s", key)
+		}
+	}
+
+	switch parts["FREQ"] {
+	case "HOURLY":
+		hour = "*"
+		if _, ok := parts["BYHOUR"]; ok {
+			hour = parts["BYHOUR"]
+		}
+	case "DAILY":
+	case "WEEKLY":
+		if dow == "*" {
+			dow = "1"
+		}
+	case "MONTHLY":
+		if dom == "*" && dow == "*" {
+			dom = "1"
+		}
+	case "":
+		return nil, fmt.Errorf("invalid RRULE: FREQ is required")
+	default:
+		return nil, fmt.Errorf("unsupported RRULE FREQ=%s (use HOURLY, DAILY, WEEKLY or MONTHLY)", parts["FREQ"])
+	}
+
+	return ParseCron(strings.Join([]string{minute, hour, dom, month, dow}, " "))
+}
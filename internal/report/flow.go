@@ -0,0 +1,149 @@
+package report
+
+import (
+	"alexandria/internal/dates"
+	"alexandria/internal/ticket"
+	"time"
+)
+
+// Series is a set of values sampled once per day
+type Series struct {
+	Title   string      `json:"title"`
+	Unit    string      `json:"unit"`
+	Columns []string    `json:"columns"`
+	Dates   []time.Time `json:"dates"`
+	Values  [][]float64 `json:"values"` // Values[i][j] is column j on Dates[i]
+	Stacked bool        `json:"stacked"`
+}
+
+// StatusAt reconstructs the status a ticket had at a moment in time, returning
+// an empty status if the ticket did not exist yet. Tickets without recorded history
+// are approximated from their current status, start date and close timestamp.
+func StatusAt(t ticket.Ticket, history []ticket.StatusChange, at time.Time) ticket.Status {
+	if at.Before(t.CreatedAt) {
+		return ""
+	}
+
+	if len(history) == 0 {
+		switch t.Status {
+		case ticket.StatusClosed:
+			if at.Before(ClosedTime(t)) {
+				return ticket.StatusOpen
+			}
+		case ticket.StatusInProgress:
+			if at.Before(StartedTime(t)) {
+				return ticket.StatusOpen
+			}
+		}
+		return t.Status
+	}
+
+	// Tickets created before history was recorded start from the first change's origin
+	status := history[0].From
+	if status == "" {
+		status = ticket.StatusOpen
+	}
+	for _, c := range history {
+		if c.ChangedAt.After(at) {
+			break
+		}
+		status = c.To
+	}
+	return status
+}
+
+// Burndown computes the work remaining at the end of each day between from and to,
+// alongside an ideal line falling linearly to zero. Story points are used when any
+// ticket has them, otherwise tickets are counted.
+func Burndown(title string, tickets []ticket.Ticket, history map[int64][]ticket.StatusChange, from, to time.Time) *Series {
+	usePoints := false
+	for _, t := range tickets {
+		if t.StoryPoints != nil {
+			usePoints = true
+			break
+		}
+	}
+
+	s := &Series{Title: title, Unit: "tickets", Columns: []string{"remaining", "ideal"}}
+	if usePoints {
+		s.Unit = "points"
+	}
+
+	days := sampleDays(from, to)
+	for _, at := range days {
+		var remaining float64
+		for _, t := range tickets {
+			status := StatusAt(t, history[t.ID], at)
+			if status == "" || status == ticket.StatusClosed {
+				continue
+			}
+			if !usePoints {
+				remaining++
+			} else if t.StoryPoints != nil {
+				remaining += *t.StoryPoints
+			}
+		}
+		s.Dates = append(s.Dates, dates.StartOfDay(at))
+		s.Values = append(s.Values, []float64{remaining, 0})
+	}
+
+	// Ideal line runs from the full scope down to zero on the last day. The largest
+	// remaining value is used so tickets added after the first day still count.
+	if n := len(s.Values); n > 0 {
+		start := 0.0
+		for _, v := range s.Values {
+			if v[0] > start {
+				start = v[0]
+			}
+		}
+		for i := range s.Values {
+			if n == 1 {
+				s.Values[i][1] = start
+				continue
+			}
+			s.Values[i][1] = start * float64(n-1-i) / float64(n-1)
+		}
+	}
+
+	return s
+}
+
+// CumulativeFlow counts tickets in each status at the end of each day between from and to
+func CumulativeFlow(title string, tickets []ticket.Ticket, history map[int64][]ticket.StatusChange, from, to time.Time) *Series {
+	s := &Series{
+		Title:   title,
+		Unit:    "tickets",
+		Columns: []string{string(ticket.StatusClosed), string(ticket.StatusInProgress), string(ticket.StatusOpen)},
+		Stacked: true,
+	}
+
+	for _, at := range sampleDays(from, to) {
+		counts := make([]float64, len(s.Columns))
+		for _, t := range tickets {
+			status := StatusAt(t, history[t.ID], at)
+			for j, col := range s.Columns {
+				if string(status) == col {
+					counts[j]++
+				}
+			}
+		}
+		s.Dates = append(s.Dates, dates.StartOfDay(at))
+		s.Values = append(s.Values, counts)
+	}
+
+	return s
+}
+
+// sampleDays returns the end of each day from from to to, with the final
+// sample capped at to so today's values reflect the current state
+func sampleDays(from, to time.Time) []time.Time {
+	var samples []time.Time
+	for day := dates.StartOfDay(from); !day.After(to); day = day.AddDate(0, 0, 1) {
+		end := day.AddDate(0, 0, 1).Add(-time.Nanosecond)
+		if end.After(to) {
+			end = to
+		}
+		samples = append(samples, end)
+	}
+	return samples
+}
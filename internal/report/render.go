@@ -0,0 +1,254 @@
+package report
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Chart output formats
+const (
+	FormatText = "text"
+	FormatCSV  = "csv"
+	FormatSVG  = "svg"
+)
+
+// Render writes a series in the requested format
+func Render(w io.Writer, s *Series, format string, width int) error {
+	switch format {
+	case FormatText:
+		return RenderText(w, s, width)
+	case FormatCSV:
+		return RenderCSV(w, s)
+	case FormatSVG:
+		return RenderSVG(w, s)
+	}
+	return fmt.Errorf("invalid format: %s (must be: %s, %s or %s)", format, FormatText, FormatCSV, FormatSVG)
+}
+
+// eighths are the Unicode blocks used to draw fractional bar lengths
+var eighths = []string{"", "▏", "▎", "▍", "▌", "▋", "▊", "▉"}
+
+// stackBlocks are the fill characters used for each column of a stacked chart
+var stackBlocks = []string{"█", "▓", "▒", "░"}
+
+// RenderText draws one horizontal bar per day using Unicode blocks. Stacked
+// series draw each column with its own shade; other series draw the first column
+// and mark the second (such as an ideal line) with a "│".
+func RenderText(w io.Writer, s *Series, width int) error {
+	if width <= 0 {
+		width = 50
+	}
+
+	fmt.Fprintf(w, "%s (%s)\n\n", s.Title, s.Unit)
+	if len(s.Dates) == 0 {
+		fmt.Fprintln(w, "No data.")
+		return nil
+	}
+
+	max := seriesMax(s)
+	scale := 0.0
+	if max > 0 {
+		scale = float64(width) / max
+	}
+
+	for i, day := range s.Dates {
+		values := s.Values[i]
+		var bar string
+		if s.Stacked {
+			bar = stackedBar(values, scale)
+		} else {
+			bar = fractionalBar(values[0]*scale, width)
+			if len(values) > 1 {
+				bar = markBar(bar, int(math.Round(values[1]*scale)), width)
+			}
+		}
+
+		parts := make([]string, len(values))
+		for j, v := range values {
+			parts[j] = fmt.Sprintf("%s %s", s.Columns[j], formatValue(v))
+		}
+		fmt.Fprintf(w, "%s %s %s\n", day.Format("01-02"), padRunes(bar, width), strings.Join(parts, "  "))
+	}
+
+	if s.Stacked {
+		legend := make([]string, len(s.Columns))
+		for j, col := range s.Columns {
+			legend[j] = stackBlocks[j%len(stackBlocks)] + " " + col
+		}
+		fmt.Fprintf(w, "\n%s\n", strings.Join(legend, "   "))
+	} else if len(s.Columns) > 1 {
+		fmt.Fprintf(w, "\n█ %s   │ %s\n", s.Columns[0], s.Columns[1])
+	}
+	return nil
+}
+
+// RenderCSV writes a header row followed by one row per day
+func RenderCSV(w io.Writer, s *Series) error {
+	fmt.Fprintf(w, "date,%s\n", strings.Join(s.Columns, ","))
+	for i, day := range s.Dates {
+		row := make([]string, len(s.Values[i]))
+		for j, v := range s.Values[i] {
+			row[j] = strconv.FormatFloat(v, 'f', -1, 64)
+		}
+		fmt.Fprintf(w, "%s,%s\n", day.Format("2006-01-02"), strings.Join(row, ","))
+	}
+	return nil
+}
+
+// svgColors are the stroke and fill colours used for each column
+var svgColors = []string{"#2e7d32", "#f9a825", "#1565c0", "#6a1b9a"}
+
+// RenderSVG draws the series as a line chart, or as stacked areas for stacked series
+func RenderSVG(w io.Writer, s *Series) error {
+	const (
+		width  = 800.0
+		height = 400.0
+		left   = 50.0
+		right  = 150.0
+		top    = 40.0
+		bottom = 40.0
+	)
+	plotW := width - left - right
+	plotH := height - top - bottom
+
+	max := seriesMax(s)
+	if max == 0 {
+		max = 1
+	}
+	x := func(i int) float64 {
+		if len(s.Dates) <= 1 {
+			return left
+		}
+		return left + plotW*float64(i)/float64(len(s.Dates)-1)
+	}
+	y := func(v float64) float64 {
+		return top + plotH - plotH*v/max
+	}
+
+	fmt.Fprintf(w, `<svg xmlns="http://www.w3.org/2000/svg" width="%.0f" height="%.0f" viewBox="0 0 %.0f %.0f" font-family="sans-serif" font-size="12">`+"\n", width, height, width, height)
+	fmt.Fprintf(w, `<rect width="100%%" height="100%%" fill="white"/>`+"\n")
+	fmt.Fprintf(w, `<text x="%.0f" y="24" font-size="16">%s (%s)</text>`+"\n", left, html.EscapeString(s.Title), html.EscapeString(s.Unit))
+
+	// Axes
+	fmt.Fprintf(w, `<line x1="%.0f" y1="%.0f" x2="%.0f" y2="%.0f" stroke="#333"/>`+"\n", left, top, left, top+plotH)
+	fmt.Fprintf(w, `<line x1="%.0f" y1="%.0f" x2="%.0f" y2="%.0f" stroke="#333"/>`+"\n", left, top+plotH, left+plotW, top+plotH)
+	fmt.Fprintf(w, `<text x="%.0f" y="%.0f" text-anchor="end">%s</text>`+"\n", left-6, top+4, formatValue(max))
+	fmt.Fprintf(w, `<text x="%.0f" y="%.0f" text-anchor="end">0</text>`+"\n", left-6, top+plotH+4)
+	if len(s.Dates) > 0 {
+		fmt.Fprintf(w, `<text x="%.0f" y="%.0f">%s</text>`+"\n", left, height-14, s.Dates[0].Format("2006-01-02"))
+		fmt.Fprintf(w, `<text x="%.0f" y="%.0f" text-anchor="end">%s</text>`+"\n", left+plotW, height-14, s.Dates[len(s.Dates)-1].Format("2006-01-02"))
+	}
+
+	for j, col := range s.Columns {
+		color := svgColors[j%len(svgColors)]
+		if s.Stacked {
+			// Each band spans from the cumulative total below it to its own cumulative total
+			var upper, lower []string
+			for i := range s.Dates {
+				below := 0.0
+				for k := 0; k < j; k++ {
+					below += s.Values[i][k]
+				}
+				upper = append(upper, fmt.Sprintf("%.1f,%.1f", x(i), y(below+s.Values[i][j])))
+				lower = append([]string{fmt.Sprintf("%.1f,%.1f", x(i), y(below))}, lower...)
+			}
+			fmt.Fprintf(w, `<polygon points="%s" fill="%s" fill-opacity="0.8"/>`+"\n", strings.Join(append(upper, lower...), " "), color)
+		} else {
+			var points []string
+			for i := range s.Dates {
+				points = append(points, fmt.Sprintf("%.1f,%.1f", x(i), y(s.Values[i][j])))
+			}
+			dash := ""
+			if j > 0 {
+				dash = ` stroke-dasharray="6,4"`
+			}
+			fmt.Fprintf(w, `<polyline points="%s" fill="none" stroke="%s" stroke-width="2"%s/>`+"\n", strings.Join(points, " "), color, dash)
+		}
+
+		// Legend
+		ly := top + 20*float64(j)
+		fmt.Fprintf(w, `<rect x="%.0f" y="%.0f" width="12" height="12" fill="%s"/>`+"\n", width-right+20, ly, color)
+		fmt.Fprintf(w, `<text x="%.0f" y="%.0f">%s</text>`+"\n", width-right+38, ly+11, html.EscapeString(col))
+	}
+
+	fmt.Fprintln(w, "</svg>")
+	return nil
+}
+
+// seriesMax returns the largest value to plot: the largest daily total for
+// stacked series, otherwise the largest single value
+func seriesMax(s *Series) float64 {
+	max := 0.0
+	for _, values := range s.Values {
+		if s.Stacked {
+			total := 0.0
+			for _, v := range values {
+				total += v
+			}
+			max = math.Max(max, total)
+			continue
+		}
+		for _, v := range values {
+			max = math.Max(max, v)
+		}
+	}
+	return max
+}
+
+// fractionalBar draws a bar of the given length in character cells, using
+// partial blocks for the final cell
+func fractionalBar(length float64, width int) string {
+	length = math.Min(math.Max(length, 0), float64(width))
+	full := int(length)
+	frac := int((length - float64(full)) * 8)
+	return strings.Repeat("█", full) + eighths[frac]
+}
+
+// stackedBar draws one segment per value, rounding cumulative widths so the
+// segments always add up to the scaled total
+func stackedBar(values []float64, scale float64) string {
+	var b strings.Builder
+	cumulative, drawn := 0.0, 0
+	for j, v := range values {
+		cumulative += v
+		end := int(math.Round(cumulative * scale))
+		if end > drawn {
+			b.WriteString(strings.Repeat(stackBlocks[j%len(stackBlocks)], end-drawn))
+			drawn = end
+		}
+	}
+	return b.String()
+}
+
+// markBar places a "│" marker at a cell position, padding the bar if needed
+func markBar(bar string, pos, width int) string {
+	cells := []rune(padRunes(bar, width))
+	if pos >= width {
+		pos = width - 1
+	}
+	if pos < 0 {
+		pos = 0
+	}
+	cells[pos] = '│'
+	return strings.TrimRight(string(cells), " ")
+}
+
+// padRunes pads a string with spaces to a width measured in runes
+func padRunes(s string, width int) string {
+	if n := len([]rune(s)); n < width {
+		return s + strings.Repeat(" ", width-n)
+	}
+	return s
+}
+
+// formatValue prints whole numbers without decimals and others with one
+func formatValue(v float64) string {
+	if v == math.Trunc(v) {
+		return strconv.FormatFloat(v, 'f', 0, 64)
+	}
+	return strconv.FormatFloat(v, 'f', 1, 64)
+}
@@ -0,0 +1,140 @@
+package report
+
+import (
+	"alexandria/internal/dates"
+	"alexandria/internal/ticket"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// WeekFlow is the number of tickets opened and closed in one week
+type WeekFlow struct {
+	Week   string `json:"week"`
+	Opened int    `json:"opened"`
+	Closed int    `json:"closed"`
+}
+
+// StaleTicket is an open ticket that has not been updated recently
+type StaleTicket struct {
+	ID        int64     `json:"id"`
+	Title     string    `json:"title"`
+	Status    string    `json:"status"`
+	UpdatedAt time.Time `json:"updated_at"`
+	IdleDays  int       `json:"idle_days"`
+}
+
+// Stats is a dashboard of counts and ages for a project
+type Stats struct {
+	Project           string         `json:"project"`
+	Total             int            `json:"total"`
+	ByStatus          map[string]int `json:"by_status"`
+	ByType            map[string]int `json:"by_type"`
+	ByPriority        map[string]int `json:"by_priority"`
+	ByAssignee        map[string]int `json:"by_assignee"`
+	OpenCriticalPath  int            `json:"open_critical_path"`
+	Weekly            []WeekFlow     `json:"weekly"`
+	MedianOpenAgeDays float64        `json:"median_open_age_days"`
+	OldestUntouched   []StaleTicket  `json:"oldest_untouched"`
+}
+
+// Unassigned is the assignee bucket for tickets without an assignee
+const Unassigned = "unassigned"
+
+// BuildStats computes the dashboard for a project's tickets. weeks controls how many
+// weeks of opened/closed history are included and oldest how many untouched tickets are listed.
+func BuildStats(project string, tickets []ticket.Ticket, now time.Time, weeks, oldest int) *Stats {
+	s := &Stats{
+		Project:         project,
+		Total:           len(tickets),
+		ByStatus:        map[string]int{},
+		ByType:          map[string]int{},
+		ByPriority:      map[string]int{},
+		ByAssignee:      map[string]int{},
+		Weekly:          []WeekFlow{},
+		OldestUntouched: []StaleTicket{},
+	}
+
+	// Weekly buckets, oldest first, starting on Mondays
+	thisWeek := dates.StartOfWeek(now)
+	starts := make([]time.Time, weeks)
+	for i := 0; i < weeks; i++ {
+		start := thisWeek.AddDate(0, 0, -7*(weeks-1-i))
+		year, week := start.ISOWeek()
+		starts[i] = start
+		s.Weekly = append(s.Weekly, WeekFlow{Week: fmt.Sprintf("%d-W%02d", year, week)})
+	}
+	weekIndex := func(at time.Time) int {
+		for i := len(starts) - 1; i >= 0; i-- {
+			if !at.Before(starts[i]) {
+				if at.Before(starts[i].AddDate(0, 0, 7)) {
+					return i
+				}
+				return -1
+			}
+		}
+		return -1
+	}
+
+	var openAges []float64
+	var open []ticket.Ticket
+
+	for _, t := range tickets {
+		s.ByStatus[string(t.Status)]++
+		s.ByType[string(t.Type)]++
+		s.ByPriority[string(t.Priority)]++
+		assignee := Unassigned
+		if t.AssignedTo != nil && *t.AssignedTo != "" {
+			assignee = *t.AssignedTo
+		}
+		s.ByAssignee[assignee]++
+
+		if i := weekIndex(t.CreatedAt); i >= 0 {
+			s.Weekly[i].Opened++
+		}
+
+		if t.Status == ticket.StatusClosed {
+			if i := weekIndex(ClosedTime(t)); i >= 0 {
+				s.Weekly[i].Closed++
+			}
+			continue
+		}
+
+		if t.CriticalPath {
+			s.OpenCriticalPath++
+		}
+		openAges = append(openAges, now.Sub(t.CreatedAt).Hours()/24)
+		open = append(open, t)
+	}
+
+	s.MedianOpenAgeDays = math.Round(median(openAges)*10) / 10
+
+	sort.Slice(open, func(i, j int) bool { return open[i].UpdatedAt.Before(open[j].UpdatedAt) })
+	for i := 0; i < len(open) && i < oldest; i++ {
+		t := open[i]
+		s.OldestUntouched = append(s.OldestUntouched, StaleTicket{
+			ID:        t.ID,
+			Title:     t.Title,
+			Status:    string(t.Status),
+			UpdatedAt: t.UpdatedAt,
+			IdleDays:  int(now.Sub(t.UpdatedAt).Hours() / 24),
+		})
+	}
+
+	return s
+}
+
+// median returns the middle value of a set, or 0 for an empty set
+func median(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 1 {
+		return sorted[mid]
+	}
+	return (sorted[mid-1] + sorted[mid]) / 2
+}
@@ -0,0 +1,161 @@
+package report
+
+import (
+	"alexandria/internal/ticket"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Grouping options for velocity reports
+const (
+	GroupByWeek   = "week"
+	GroupBySprint = "sprint"
+)
+
+// VelocityOptions controls how closed tickets are bucketed
+type VelocityOptions struct {
+	GroupBy      string // GroupByWeek or GroupBySprint
+	SprintPrefix string // tag prefix that identifies a sprint, e.g. "sprint-"
+}
+
+// Period is the work completed in one week or sprint
+type Period struct {
+	Label         string    `json:"period"`
+	Tickets       int       `json:"tickets"`
+	Points        float64   `json:"points"`
+	EstimateHours float64   `json:"estimate_hours"`
+	ActualHours   float64   `json:"actual_hours"`
+	EstimateRatio *float64  `json:"estimate_ratio,omitempty"`
+	firstClosed   time.Time // used to order sprints chronologically
+}
+
+// Velocity summarises completed work for a project
+type Velocity struct {
+	Project           string   `json:"project"`
+	GroupBy           string   `json:"group_by"`
+	Periods           []Period `json:"periods"`
+	AveragePoints     float64  `json:"average_points"`
+	AverageCycleHours float64  `json:"average_cycle_hours"`
+	EstimatedTickets  int      `json:"estimated_tickets"`
+	EstimateRatio     *float64 `json:"estimate_ratio,omitempty"`
+	Ungrouped         int      `json:"ungrouped,omitempty"`
+}
+
+// BuildVelocity computes velocity from closed tickets and the time logged against them
+func BuildVelocity(project string, tickets []ticket.Ticket, logged map[int64]time.Duration, opts VelocityOptions) (*Velocity, error) {
+	if opts.GroupBy != GroupByWeek && opts.GroupBy != GroupBySprint {
+		return nil, fmt.Errorf("invalid grouping: %s (must be: %s or %s)", opts.GroupBy, GroupByWeek, GroupBySprint)
+	}
+
+	v := &Velocity{Project: project, GroupBy: opts.GroupBy, Periods: []Period{}}
+	periods := make(map[string]*Period)
+	ratios := make(map[string][2]float64) // label -> {actual, estimate} for tickets with both
+	var cycleTotal time.Duration
+	var cycleCount int
+	var allActual, allEstimate float64
+
+	for _, t := range tickets {
+		if t.Status != ticket.StatusClosed {
+			continue
+		}
+		closed := ClosedTime(t)
+
+		label := ""
+		switch opts.GroupBy {
+		case GroupByWeek:
+			year, week := closed.ISOWeek()
+			label = fmt.Sprintf("%d-W%02d", year, week)
+		case GroupBySprint:
+			label = sprintOf(t, opts.SprintPrefix)
+		}
+		if label == "" {
+			v.Ungrouped++
+			continue
+		}
+
+		p, ok := periods[label]
+		if !ok {
+			p = &Period{Label: label, firstClosed: closed}
+			periods[label] = p
+		}
+		if closed.Before(p.firstClosed) {
+			p.firstClosed = closed
+		}
+
+		p.Tickets++
+		if t.StoryPoints != nil {
+			p.Points += *t.StoryPoints
+		}
+		actual := logged[t.ID].Hours()
+		p.ActualHours += actual
+		if t.EstimateHours != nil {
+			p.EstimateHours += *t.EstimateHours
+			if *t.EstimateHours > 0 && actual > 0 {
+				r := ratios[label]
+				ratios[label] = [2]float64{r[0] + actual, r[1] + *t.EstimateHours}
+				allActual += actual
+				allEstimate += *t.EstimateHours
+				v.EstimatedTickets++
+			}
+		}
+
+		cycleTotal += closed.Sub(StartedTime(t))
+		cycleCount++
+	}
+
+	var pointsTotal float64
+	for label, p := range periods {
+		if r, ok := ratios[label]; ok {
+			ratio := r[0] / r[1]
+			p.EstimateRatio = &ratio
+		}
+		pointsTotal += p.Points
+		v.Periods = append(v.Periods, *p)
+	}
+	sort.Slice(v.Periods, func(i, j int) bool {
+		return v.Periods[i].firstClosed.Before(v.Periods[j].firstClosed)
+	})
+
+	if len(v.Periods) > 0 {
+		v.AveragePoints = pointsTotal / float64(len(v.Periods))
+	}
+	if cycleCount > 0 {
+		v.AverageCycleHours = math.Round((cycleTotal/time.Duration(cycleCount)).Hours()*100) / 100
+	}
+	if allEstimate > 0 {
+		ratio := allActual / allEstimate
+		v.EstimateRatio = &ratio
+	}
+
+	return v, nil
+}
+
+// ClosedTime returns when a closed ticket was closed. Tickets closed before close
+// timestamps were recorded fall back to their last update.
+func ClosedTime(t ticket.Ticket) time.Time {
+	if t.ClosedAt != nil {
+		return *t.ClosedAt
+	}
+	return t.UpdatedAt
+}
+
+// StartedTime returns when work on a ticket began: its start date if set, otherwise its creation
+func StartedTime(t ticket.Ticket) time.Time {
+	if t.StartAt != nil {
+		return *t.StartAt
+	}
+	return t.CreatedAt
+}
+
+// sprintOf returns the first tag carrying the sprint prefix, or "" if there is none
+func sprintOf(t ticket.Ticket, prefix string) string {
+	for _, tag := range t.Tags {
+		if strings.HasPrefix(tag, prefix) {
+			return tag
+		}
+	}
+	return ""
+}
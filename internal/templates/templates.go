@@ -0,0 +1,173 @@
+package templates
+
+import (
+	"alexandria/internal/logger"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Template holds the defaults a project applies to tickets created from it
+type Template struct {
+	Project     string    `json:"project"`
+	Name        string    `json:"name"`
+	Type        string    `json:"type,omitempty"`
+	Priority    string    `json:"priority,omitempty"`
+	Tags        []string  `json:"tags,omitempty"`
+	Description string    `json:"description,omitempty"` // Markdown skeleton, may contain placeholders
+	CreatedBy   string    `json:"created_by,omitempty"`
+	UpdatedAt   time.Time `json:"updated_at,omitempty"`
+}
+
+// Placeholders that Expand replaces, written as {{name}} in a template
+const (
+	VarUser    = "user"
+	VarDate    = "date"
+	VarBranch  = "branch"
+	VarProject = "project"
+)
+
+// Expand replaces {{name}} placeholders in text with their values. Placeholders
+// without a value are left as they are so mistakes are visible in the ticket.
+func Expand(text string, vars map[string]string) string {
+	if !strings.Contains(text, "{{") {
+		return text
+	}
+	pairs := make([]string, 0, len(vars)*2)
+	for name, value := range vars {
+		pairs = append(pairs, "{{"+name+"}}", value)
+	}
+	return strings.NewReplacer(pairs...).Replace(text)
+}
+
+// Save stores a template, replacing any template of the same name in the project
+func Save(db *sql.DB, t *Template) error {
+	logger.Log.Debug("saving template", "project", t.Project, "name", t.Name)
+
+	if t.Project == "" {
+		return fmt.Errorf("template project is required")
+	}
+	if t.Name == "" {
+		return fmt.Errorf("template name is required")
+	}
+
+	tags, err := json.Marshal(t.Tags)
+	if err != nil {
+		logger.Log.Error("failed to marshal template tags", "error", err)
+		return fmt.Errorf("failed to marshal template tags: %w", err)
+	}
+
+	now := time.Now()
+	_, err = db.Exec(`
+		INSERT INTO ticket_templates (project, name, type, priority, tags, description, created_by, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(project, name) DO UPDATE SET
+			type = excluded.type,
+			priority = excluded.priority,
+			tags = excluded.tags,
+			description = excluded.description,
+			updated_at = excluded.updated_at`,
+		t.Project, t.Name, t.Type, t.Priority, string(tags), t.Description, t.CreatedBy, now, now,
+	)
+	if err != nil {
+		logger.Log.Error("failed to save template", "error", err, "project", t.Project, "name", t.Name)
+		return fmt.Errorf("failed to save template: %w", err)
+	}
+
+	logger.Log.Info("template saved", "project", t.Project, "name", t.Name)
+	return nil
+}
+
+// Get finds a project's template by name
+func Get(db *sql.DB, project, name string) (*Template, error) {
+	logger.Log.Debug("loading template", "project", project, "name", name)
+
+	row := db.QueryRow(`
+		SELECT project, name, type, priority, tags, description, created_by, updated_at
+		FROM ticket_templates WHERE project = ? AND name = ?`, project, name)
+	t, err := scanTemplate(row)
+	if err == sql.ErrNoRows {
+		logger.Log.Error("template not found", "project", project, "name", name)
+		return nil, fmt.Errorf("no template named '%s' in project '%s'", name, project)
+	}
+	if err != nil {
+		logger.Log.Error("failed to load template", "error", err, "project", project, "name", name)
+		return nil, fmt.Errorf("failed to load template: %w", err)
+	}
+	return t, nil
+}
+
+// List returns a project's templates sorted by name
+func List(db *sql.DB, project string) ([]Template, error) {
+	logger.Log.Debug("listing templates", "project", project)
+
+	rows, err := db.Query(`
+		SELECT project, name, type, priority, tags, description, created_by, updated_at
+		FROM ticket_templates WHERE project = ? ORDER BY name`, project)
+	if err != nil {
+		logger.Log.Error("failed to query templates", "error", err)
+		return nil, fmt.Errorf("failed to query templates: %w", err)
+	}
+	defer rows.Close()
+
+	var all []Template
+	for rows.Next() {
+		t, err := scanTemplate(rows)
+		if err != nil {
+			logger.Log.Error("failed to scan template", "error", err)
+			return nil, fmt.Errorf("failed to scan template: %w", err)
+		}
+		all = append(all, *t)
+	}
+
+	if err := rows.Err(); err != nil {
+		logger.Log.Error("error iterating templates", "error", err)
+		return nil, fmt.Errorf("error iterating templates: %w", err)
+	}
+	return all, nil
+}
+
+// Delete removes a project's template
+func Delete(db *sql.DB, project, name string) error {
+	logger.Log.Debug("deleting template", "project", project, "name", name)
+
+	result, err := db.Exec(`DELETE FROM ticket_templates WHERE project = ? AND name = ?`, project, name)
+	if err != nil {
+		logger.Log.Error("failed to delete template", "error", err, "project", project, "name", name)
+		return fmt.Errorf("failed to delete template: %w", err)
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("no template named '%s' in project '%s'", name, project)
+	}
+
+	logger.Log.Info("template deleted", "project", project, "name", name)
+	return nil
+}
+
+// rowScanner is satisfied by *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// scanTemplate reads a template row selected in the column order used above
+func scanTemplate(row rowScanner) (*Template, error) {
+	var (
+		t                                       Template
+		tType, tPriority, tags, desc, createdBy sql.NullString
+	)
+	if err := row.Scan(&t.Project, &t.Name, &tType, &tPriority, &tags, &desc, &createdBy, &t.UpdatedAt); err != nil {
+		return nil, err
+	}
+	t.Type = tType.String
+	t.Priority = tPriority.String
+	t.Description = desc.String
+	t.CreatedBy = createdBy.String
+	if tags.String != "" {
+		if err := json.Unmarshal([]byte(tags.String), &t.Tags); err != nil {
+			return nil, fmt.Errorf("failed to parse template tags: %w", err)
+		}
+	}
+	return &t, nil
+}
@@ -0,0 +1,28 @@
+package testdb
+
+import (
+	"alexandria/internal/database"
+	"alexandria/internal/logger"
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// Open opens a fresh SQLite database with the current schema for a test and
+// closes it when the test ends
+func Open(t *testing.T) *sql.DB {
+	t.Helper()
+	logger.Init(false)
+
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "tickets.db")+"?_foreign_keys=on")
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err := database.InitSchema(db); err != nil {
+		t.Fatalf("failed to create schema: %v", err)
+	}
+	return db
+}
@@ -0,0 +1,221 @@
+package ticket
+
+import (
+	"alexandria/internal/logger"
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// archivedTables pairs each table holding records attached to a ticket with the
+// archive table they move to, and the columns moved
+var archivedTables = []struct {
+	live, archive, columns string
+}{
+	{"ticket_tags", "archived_ticket_tags", "ticket_id, tag"},
+	{"ticket_files", "archived_ticket_files", "id, ticket_id, file_path"},
+	{"ticket_comments", "archived_ticket_comments", "id, ticket_id, comment_text, created_at"},
+	{"ticket_worklogs", "archived_ticket_worklogs", "id, ticket_id, username, started_at, ended_at, note"},
+	{"ticket_status_history", "archived_ticket_status_history", "id, ticket_id, from_status, to_status, changed_at"},
+	{"ticket_commits", "archived_ticket_commits", "ticket_id, sha, summary, author, committed_at"},
+	{"ticket_checklist_items", "archived_ticket_checklist_items", "id, ticket_id, position, item_text, done_at, created_at"},
+}
+
+// archivedColumns selects an archived ticket, scanned with scanArchived
+var archivedColumns = selectColumns("t.") + ", t.archived_at"
+
+// scanArchived reads a row selected with archivedColumns into t
+func scanArchived(row rowScanner, t *Ticket) error {
+	return row.Scan(append(ticketFields(t), &t.ArchivedAt)...)
+}
+
+// Archive moves a project's tickets closed before the cutoff, with everything
+// attached to them, from the ticket tables to the archive tables and returns them
+func (s *SQLStore) Archive(ctx context.Context, project string, closedBefore time.Time) ([]Ticket, error) {
+	logger.Log.Debug("archiving tickets", "project", project, "closed_before", closedBefore)
+
+	ids, err := s.idsBefore(ctx, closedBefore, `
+		SELECT id, closed_at FROM tickets
+		WHERE project = ? AND status = 'closed' AND deleted_at IS NULL AND closed_at IS NOT NULL
+		ORDER BY created_at DESC`, project)
+	if err != nil {
+		return nil, err
+	}
+	candidates, err := s.loadTickets(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.Log.Error("failed to begin transaction", "error", err)
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Tickets reopened or deleted since they were picked are skipped
+	now := time.Now()
+	var archived []Ticket
+	for _, t := range candidates {
+		ok, err := archiveTicket(ctx, tx, t.ID, closedBefore, now)
+		if err != nil {
+			return nil, fmt.Errorf("ticket %d: %w", t.ID, err)
+		}
+		if ok {
+			t.ArchivedAt = &now
+			archived = append(archived, t)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		logger.Log.Error("failed to commit archive transaction", "error", err)
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	logger.Log.Info("tickets archived", "project", project, "count", len(archived))
+	return archived, nil
+}
+
+// archiveTicket moves a ticket closed before the cutoff and the records attached to
+// it to the archive tables within tx. Its undo journal, watchers and any running
+// timer are dropped. It reports false and leaves the ticket alone when it is no
+// longer closed before the cutoff or has been deleted.
+func archiveTicket(ctx context.Context, tx *sql.Tx, ticketID int64, closedBefore, at time.Time) (bool, error) {
+	var closedAt time.Time
+	err := tx.QueryRowContext(ctx,
+		"SELECT closed_at FROM tickets WHERE id = ? AND status = 'closed' AND deleted_at IS NULL AND closed_at IS NOT NULL", ticketID,
+	).Scan(&closedAt)
+	if err == sql.ErrNoRows || err == nil && !closedAt.Before(closedBefore) {
+		logger.Log.Debug("ticket no longer due for archiving", "ticket_id", ticketID)
+		return false, nil
+	}
+	if err != nil {
+		logger.Log.Error("failed to find ticket", "error", err, "ticket_id", ticketID)
+		return false, fmt.Errorf("failed to find ticket: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx,
+		`INSERT INTO archived_tickets (`+ticketColumns+`, archived_at) SELECT `+ticketColumns+`, ? FROM tickets WHERE id = ?`,
+		at, ticketID,
+	); err != nil {
+		logger.Log.Error("failed to archive ticket", "error", err, "ticket_id", ticketID)
+		return false, fmt.Errorf("failed to archive ticket: %w", err)
+	}
+
+	for _, t := range archivedTables {
+		move := fmt.Sprintf("INSERT INTO %s (%s) SELECT %s FROM %s WHERE ticket_id = ?", t.archive, t.columns, t.columns, t.live)
+		if _, err := tx.ExecContext(ctx, move, ticketID); err != nil {
+			logger.Log.Error("failed to archive records", "error", err, "table", t.live, "ticket_id", ticketID)
+			return false, fmt.Errorf("failed to archive %s: %w", t.live, err)
+		}
+		if _, err := tx.ExecContext(ctx, fmt.Sprintf("DELETE FROM %s WHERE ticket_id = ?", t.live), ticketID); err != nil {
+			logger.Log.Error("failed to remove archived records", "error", err, "table", t.live, "ticket_id", ticketID)
+			return false, fmt.Errorf("failed to remove archived %s: %w", t.live, err)
+		}
+	}
+
+	for _, table := range []string{"ticket_journal", "ticket_watchers", "active_timers"} {
+		if _, err := tx.ExecContext(ctx, fmt.Sprintf("DELETE FROM %s WHERE ticket_id = ?", table), ticketID); err != nil {
+			logger.Log.Error("failed to clear records", "error", err, "table", table, "ticket_id", ticketID)
+			return false, fmt.Errorf("failed to clear %s: %w", table, err)
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM tickets WHERE id = ?", ticketID); err != nil {
+		logger.Log.Error("failed to remove archived ticket", "error", err, "ticket_id", ticketID)
+		return false, fmt.Errorf("failed to remove archived ticket: %w", err)
+	}
+	return true, nil
+}
+
+// ListArchived returns archived tickets matching the filters, newest first
+func (s *SQLStore) ListArchived(ctx context.Context, filters Filters) ([]Ticket, error) {
+	logger.Log.Debug("listing archived tickets", "filters", fmt.Sprintf("%+v", filters))
+
+	// Archived tickets are closed, so they are never overdue or due soon
+	if filters.Overdue || filters.DueWithin > 0 {
+		return nil, nil
+	}
+
+	clause, args := filterClause(filters, "archived_ticket_checklist_items")
+	query := `
+		SELECT DISTINCT ` + archivedColumns + `
+		FROM archived_tickets t
+		LEFT JOIN archived_ticket_tags tt ON t.id = tt.ticket_id
+		WHERE 1 = 1` + clause + ` ORDER BY t.created_at DESC`
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		logger.Log.Error("failed to query archived tickets", "error", err)
+		return nil, fmt.Errorf("failed to query archived tickets: %w", err)
+	}
+	defer rows.Close()
+
+	var tickets []Ticket
+	var ids []int64
+	for rows.Next() {
+		var t Ticket
+		if err := scanArchived(rows, &t); err != nil {
+			logger.Log.Error("failed to scan archived ticket", "error", err)
+			return nil, fmt.Errorf("failed to scan archived ticket: %w", err)
+		}
+		tickets = append(tickets, t)
+		ids = append(ids, t.ID)
+	}
+	if err := rows.Err(); err != nil {
+		logger.Log.Error("error iterating archived tickets", "error", err)
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	checklists, err := loadChecklists(ctx, s.db, "archived_ticket_checklist_items", ids)
+	if err != nil {
+		return nil, err
+	}
+	for i := range tickets {
+		if err := s.loadArchivedRelated(ctx, &tickets[i]); err != nil {
+			return nil, err
+		}
+		tickets[i].Checklist = checklists[tickets[i].ID]
+	}
+
+	logger.Log.Debug("archived tickets listed", "count", len(tickets))
+	return tickets, nil
+}
+
+// GetArchived loads an archived ticket by ID
+func (s *SQLStore) GetArchived(ctx context.Context, id int64) (*Ticket, error) {
+	logger.Log.Debug("getting archived ticket", "id", id)
+
+	t := &Ticket{}
+	err := scanArchived(s.db.QueryRowContext(ctx, `SELECT `+archivedColumns+` FROM archived_tickets t WHERE t.id = ?`, id), t)
+	if err == sql.ErrNoRows {
+		logger.Log.Debug("ticket not archived", "id", id)
+		return nil, fmt.Errorf("ticket %d not found in the archive", id)
+	}
+	if err != nil {
+		logger.Log.Error("failed to fetch archived ticket", "error", err, "id", id)
+		return nil, fmt.Errorf("failed to fetch archived ticket: %w", err)
+	}
+
+	if err := s.loadArchivedRelated(ctx, t); err != nil {
+		return nil, err
+	}
+	if t.Checklist, err = loadChecklist(ctx, s.db, "archived_ticket_checklist_items", t.ID); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// loadArchivedRelated populates the tags, files and comments of an archived ticket
+func (s *SQLStore) loadArchivedRelated(ctx context.Context, t *Ticket) error {
+	var err error
+	if t.Tags, err = queryStrings(ctx, s.db, "SELECT tag FROM archived_ticket_tags WHERE ticket_id = ?", t.ID); err != nil {
+		return err
+	}
+	if t.Files, err = queryStrings(ctx, s.db, "SELECT file_path FROM archived_ticket_files WHERE ticket_id = ?", t.ID); err != nil {
+		return err
+	}
+	t.Comments, err = queryStrings(ctx, s.db, "SELECT comment_text FROM archived_ticket_comments WHERE ticket_id = ? ORDER BY created_at", t.ID)
+	return err
+}
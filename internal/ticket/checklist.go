@@ -0,0 +1,251 @@
+package ticket
+
+import (
+	"alexandria/internal/logger"
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// CheckItem is a step on a ticket's checklist
+type CheckItem struct {
+	ID        int64      `json:"id"`
+	Text      string     `json:"text"`
+	DoneAt    *time.Time `json:"done_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// Done reports whether the item has been checked off
+func (c CheckItem) Done() bool {
+	return c.DoneAt != nil
+}
+
+// ChecklistProgress counts the ticket's checklist items and how many are done
+func (t *Ticket) ChecklistProgress() (done, total int) {
+	for _, c := range t.Checklist {
+		if c.Done() {
+			done++
+		}
+	}
+	return done, len(t.Checklist)
+}
+
+// HasIncompleteChecklist reports whether any of the ticket's checklist items is still to do
+func (t *Ticket) HasIncompleteChecklist() bool {
+	done, total := t.ChecklistProgress()
+	return done < total
+}
+
+// checklistItem picks item number n (counting from 1) out of a checklist
+func checklistItem(items []CheckItem, ticketID int64, n int) (*CheckItem, error) {
+	if n < 1 || n > len(items) {
+		return nil, fmt.Errorf("ticket %d has no checklist item %d (it has %d)", ticketID, n, len(items))
+	}
+	item := items[n-1]
+	return &item, nil
+}
+
+// loadChecklist loads the checklist of a ticket from table, in order
+func loadChecklist(ctx context.Context, q querier, table string, ticketID int64) ([]CheckItem, error) {
+	checklists, err := loadChecklists(ctx, q, table, []int64{ticketID})
+	if err != nil {
+		return nil, err
+	}
+	return checklists[ticketID], nil
+}
+
+// loadChecklists loads the checklists of several tickets from table with a single
+// query, keyed by ticket ID and each in order
+func loadChecklists(ctx context.Context, q querier, table string, ticketIDs []int64) (map[int64][]CheckItem, error) {
+	logger.Log.Debug("loading checklists", "tickets", len(ticketIDs))
+	checklists := make(map[int64][]CheckItem)
+	if len(ticketIDs) == 0 {
+		return checklists, nil
+	}
+
+	args := make([]interface{}, len(ticketIDs))
+	for i, id := range ticketIDs {
+		args[i] = id
+	}
+	rows, err := q.QueryContext(ctx,
+		`SELECT ticket_id, id, item_text, done_at, created_at FROM `+table+`
+		WHERE ticket_id IN (?`+strings.Repeat(", ?", len(ticketIDs)-1)+`) ORDER BY ticket_id, position, id`, args...)
+	if err != nil {
+		logger.Log.Error("failed to query checklists", "error", err)
+		return nil, fmt.Errorf("failed to load checklists: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var ticketID int64
+		var c CheckItem
+		var doneAt sql.NullTime
+		if err := rows.Scan(&ticketID, &c.ID, &c.Text, &doneAt, &c.CreatedAt); err != nil {
+			logger.Log.Error("failed to scan checklist item", "error", err)
+			return nil, fmt.Errorf("failed to scan checklist item: %w", err)
+		}
+		if doneAt.Valid {
+			c.DoneAt = &doneAt.Time
+		}
+		checklists[ticketID] = append(checklists[ticketID], c)
+	}
+
+	if err := rows.Err(); err != nil {
+		logger.Log.Error("error iterating checklists", "error", err)
+		return nil, fmt.Errorf("error iterating checklists: %w", err)
+	}
+	return checklists, nil
+}
+
+// AddCheckItem appends an item to the end of a ticket's checklist and marks the
+// ticket as updated
+func (s *SQLStore) AddCheckItem(ctx context.Context, ticketID int64, text string) (*CheckItem, error) {
+	logger.Log.Debug("adding checklist item", "ticket_id", ticketID)
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.Log.Error("failed to begin transaction", "error", err)
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	if err := touchTicket(ctx, tx, ticketID, now); err != nil {
+		return nil, err
+	}
+	if err := journal(ctx, tx, OpChecklist, ticketID, nil); err != nil {
+		return nil, err
+	}
+
+	var last int
+	if err := tx.QueryRowContext(ctx,
+		`SELECT COALESCE(MAX(position), 0) FROM ticket_checklist_items WHERE ticket_id = ?`, ticketID,
+	).Scan(&last); err != nil {
+		logger.Log.Error("failed to find end of checklist", "error", err, "ticket_id", ticketID)
+		return nil, fmt.Errorf("failed to find end of checklist: %w", err)
+	}
+
+	item := &CheckItem{Text: text, CreatedAt: now}
+	err = tx.QueryRowContext(ctx,
+		`INSERT INTO ticket_checklist_items (ticket_id, position, item_text, created_at) VALUES (?, ?, ?, ?) RETURNING id`,
+		ticketID, last+1, text, now,
+	).Scan(&item.ID)
+	if err != nil {
+		logger.Log.Error("failed to insert checklist item", "error", err, "ticket_id", ticketID)
+		return nil, fmt.Errorf("failed to insert checklist item: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		logger.Log.Error("failed to commit transaction", "error", err)
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	logger.Log.Info("checklist item added", "id", item.ID, "ticket_id", ticketID)
+	return item, nil
+}
+
+// SetCheckItemDone checks off item number n (counting from 1) of a ticket's
+// checklist, or unchecks it when done is false, and marks the ticket as updated
+func (s *SQLStore) SetCheckItemDone(ctx context.Context, ticketID int64, n int, done bool) (*CheckItem, error) {
+	logger.Log.Debug("checking off checklist item", "ticket_id", ticketID, "item", n, "done", done)
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.Log.Error("failed to begin transaction", "error", err)
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	if err := touchTicket(ctx, tx, ticketID, now); err != nil {
+		return nil, err
+	}
+	if err := journal(ctx, tx, OpChecklist, ticketID, nil); err != nil {
+		return nil, err
+	}
+	items, err := loadChecklist(ctx, tx, "ticket_checklist_items", ticketID)
+	if err != nil {
+		return nil, err
+	}
+	item, err := checklistItem(items, ticketID, n)
+	if err != nil {
+		return nil, err
+	}
+
+	// Checking off an item twice keeps when it was first done
+	switch {
+	case done && item.DoneAt == nil:
+		item.DoneAt = &now
+	case !done:
+		item.DoneAt = nil
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE ticket_checklist_items SET done_at = ? WHERE id = ?`, item.DoneAt, item.ID); err != nil {
+		logger.Log.Error("failed to update checklist item", "error", err, "id", item.ID)
+		return nil, fmt.Errorf("failed to update checklist item: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		logger.Log.Error("failed to commit transaction", "error", err)
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	logger.Log.Info("checklist item updated", "id", item.ID, "ticket_id", ticketID, "done", done)
+	return item, nil
+}
+
+// RemoveCheckItem removes item number n (counting from 1) from a ticket's
+// checklist, returning it, and marks the ticket as updated
+func (s *SQLStore) RemoveCheckItem(ctx context.Context, ticketID int64, n int) (*CheckItem, error) {
+	logger.Log.Debug("removing checklist item", "ticket_id", ticketID, "item", n)
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.Log.Error("failed to begin transaction", "error", err)
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := touchTicket(ctx, tx, ticketID, time.Now()); err != nil {
+		return nil, err
+	}
+	if err := journal(ctx, tx, OpChecklist, ticketID, nil); err != nil {
+		return nil, err
+	}
+	items, err := loadChecklist(ctx, tx, "ticket_checklist_items", ticketID)
+	if err != nil {
+		return nil, err
+	}
+	item, err := checklistItem(items, ticketID, n)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM ticket_checklist_items WHERE id = ?`, item.ID); err != nil {
+		logger.Log.Error("failed to delete checklist item", "error", err, "id", item.ID)
+		return nil, fmt.Errorf("failed to delete checklist item: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		logger.Log.Error("failed to commit transaction", "error", err)
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	logger.Log.Info("checklist item removed", "id", item.ID, "ticket_id", ticketID)
+	return item, nil
+}
+
+// touchTicket marks a ticket outside the trash as updated within tx
+func touchTicket(ctx context.Context, tx *sql.Tx, ticketID int64, at time.Time) error {
+	result, err := tx.ExecContext(ctx, "UPDATE tickets SET updated_at = ? WHERE id = ? AND deleted_at IS NULL", at, ticketID)
+	if err != nil {
+		logger.Log.Error("failed to update ticket", "error", err, "ticket_id", ticketID)
+		return fmt.Errorf("failed to update ticket: %w", err)
+	}
+	if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+		logger.Log.Error("ticket not found", "ticket_id", ticketID)
+		return fmt.Errorf("ticket %d not found", ticketID)
+	}
+	return nil
+}
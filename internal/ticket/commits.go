@@ -0,0 +1,86 @@
+package ticket
+
+import (
+	"alexandria/internal/logger"
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Commit is a git commit linked to a ticket
+type Commit struct {
+	SHA         string    `json:"sha"`
+	Summary     string    `json:"summary"`
+	Author      string    `json:"author,omitempty"`
+	CommittedAt time.Time `json:"committed_at"`
+}
+
+// ShortSHA returns the abbreviated commit hash
+func (c Commit) ShortSHA() string {
+	if len(c.SHA) > 7 {
+		return c.SHA[:7]
+	}
+	return c.SHA
+}
+
+// LinkCommit records a commit against a ticket. It reports whether the link is new,
+// so scanning the same history twice leaves existing links untouched.
+func (s *SQLStore) LinkCommit(ctx context.Context, ticketID int64, c Commit) (bool, error) {
+	logger.Log.Debug("linking commit", "ticket_id", ticketID, "sha", c.SHA)
+
+	result, err := s.db.ExecContext(ctx,
+		`INSERT INTO ticket_commits (ticket_id, sha, summary, author, committed_at) VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT (ticket_id, sha) DO NOTHING`,
+		ticketID, c.SHA, c.Summary, c.Author, c.CommittedAt,
+	)
+	if err != nil {
+		logger.Log.Error("failed to link commit", "error", err, "ticket_id", ticketID, "sha", c.SHA)
+		return false, fmt.Errorf("failed to link commit: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		logger.Log.Error("failed to check rows affected", "error", err)
+		return false, fmt.Errorf("failed to check rows affected: %w", err)
+	}
+
+	if rows > 0 {
+		logger.Log.Info("commit linked", "ticket_id", ticketID, "sha", c.ShortSHA())
+	}
+	return rows > 0, nil
+}
+
+// ListCommits returns the commits linked to a ticket, oldest first
+func (s *SQLStore) ListCommits(ctx context.Context, ticketID int64) ([]Commit, error) {
+	logger.Log.Debug("listing commits", "ticket_id", ticketID)
+
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT sha, summary, author, committed_at FROM ticket_commits WHERE ticket_id = ? ORDER BY committed_at`,
+		ticketID,
+	)
+	if err != nil {
+		logger.Log.Error("failed to query commits", "error", err, "ticket_id", ticketID)
+		return nil, fmt.Errorf("failed to query commits: %w", err)
+	}
+	defer rows.Close()
+
+	var commits []Commit
+	for rows.Next() {
+		var c Commit
+		var author sql.NullString
+		if err := rows.Scan(&c.SHA, &c.Summary, &author, &c.CommittedAt); err != nil {
+			logger.Log.Error("failed to scan commit", "error", err)
+			return nil, fmt.Errorf("failed to scan commit: %w", err)
+		}
+		c.Author = author.String
+		commits = append(commits, c)
+	}
+
+	if err := rows.Err(); err != nil {
+		logger.Log.Error("error iterating commits", "error", err)
+		return nil, fmt.Errorf("error iterating commits: %w", err)
+	}
+
+	return commits, nil
+}
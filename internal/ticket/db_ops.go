@@ -2,31 +2,110 @@ package ticket
 
 import (
 	"alexandria/internal/logger"
+	"context"
 	"database/sql"
 	"fmt"
+	"strings"
 	"time"
 )
+
+// SQLStore is the TicketStore kept in a SQL database (SQLite, Turso or PostgreSQL)
+type SQLStore struct {
+	db *sql.DB
+}
+
+// NewSQLStore returns a TicketStore backed by db
+func NewSQLStore(db *sql.DB) *SQLStore {
+	return &SQLStore{db: db}
+}
+
+// ticketColumnNames is the column list shared by every query that loads a full ticket row
+var ticketColumnNames = []string{
+	"id", "project", "type", "title", "description", "critical_path",
+	"status", "priority", "created_by", "assigned_to", "created_at", "updated_at",
+	"due_at", "start_at", "story_points", "estimate_hours", "closed_at", "deleted_at",
+	"parent_id",
+}
+
+// ticketColumns is ticketColumnNames ready for use in a SELECT
+var ticketColumns = selectColumns("")
+
+// selectColumns returns ticketColumnNames qualified with a table alias such as "t."
+func selectColumns(alias string) string {
+	cols := make([]string, len(ticketColumnNames))
+	for i, name := range ticketColumnNames {
+		cols[i] = alias + name
+	}
+	return strings.Join(cols, ", ")
+}
+
+// rowScanner is satisfied by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// querier is satisfied by both *sql.DB and *sql.Tx
+type querier interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// scanTicket reads a row selected with ticketColumns into t
+func scanTicket(row rowScanner, t *Ticket) error {
+	return row.Scan(ticketFields(t)...)
+}
+
+// ticketFields returns pointers to the fields of t in ticketColumnNames order
+func ticketFields(t *Ticket) []interface{} {
+	return []interface{}{
+		&t.ID,
+		&t.Project,
+		&t.Type,
+		&t.Title,
+		&t.Description,
+		&t.CriticalPath,
+		&t.Status,
+		&t.Priority,
+		&t.CreatedBy,
+		&t.AssignedTo,
+		&t.CreatedAt,
+		&t.UpdatedAt,
+		&t.DueAt,
+		&t.StartAt,
+		&t.StoryPoints,
+		&t.EstimateHours,
+		&t.ClosedAt,
+		&t.DeletedAt,
+		&t.ParentID,
+	}
+}
+
 // Create inserts a new ticket into the database
-func (t *Ticket) Create(db *sql.DB, project string) error {
+func (s *SQLStore) Create(ctx context.Context, project string, t *Ticket) error {
 	logger.Log.Debug("creating ticket in database", "project", project, "title", t.Title)
 
 	// Start a transaction
-	tx, err := db.Begin()
+	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
 		logger.Log.Error("failed to begin transaction", "error", err)
 		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
 	defer tx.Rollback()
 
+	// A ticket created closed was closed when it was created
+	closeOnCreate(t)
+
 	// Insert the main ticket record (ID is auto-generated)
 	insertTicketQuery := `
 		INSERT INTO tickets (
 			project, type, title, description, critical_path,
-			status, priority, created_by, assigned_to, created_at, updated_at
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+			status, priority, created_by, assigned_to, created_at, updated_at,
+			due_at, start_at, story_points, estimate_hours, parent_id, closed_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		RETURNING id`
 
+	// RETURNING works on every backend, unlike LastInsertId which PostgreSQL lacks
 	logger.Log.Debug("inserting ticket record")
-	result, err := tx.Exec(
+	err = tx.QueryRowContext(ctx,
 		insertTicketQuery,
 		project,
 		t.Type,
@@ -39,27 +118,33 @@ func (t *Ticket) Create(db *sql.DB, project string) error {
 		t.AssignedTo,
 		t.CreatedAt,
 		t.UpdatedAt,
-	)
+		t.DueAt,
+		t.StartAt,
+		t.StoryPoints,
+		t.EstimateHours,
+		t.ParentID,
+		t.ClosedAt,
+	).Scan(&t.ID)
 	if err != nil {
 		logger.Log.Error("failed to insert ticket", "error", err, "title", t.Title)
 		return fmt.Errorf("failed to insert ticket: %w", err)
 	}
+	t.Project = project
+	logger.Log.Debug("ticket record inserted", "id", t.ID)
 
-	// Get the auto-generated ID
-	id, err := result.LastInsertId()
-	if err != nil {
-		logger.Log.Error("failed to get inserted ID", "error", err)
-		return fmt.Errorf("failed to get inserted ID: %w", err)
+	if err := recordStatusChange(ctx, tx, t.ID, "", t.Status, t.CreatedAt); err != nil {
+		return err
+	}
+	if err := journal(ctx, tx, OpCreate, t.ID, nil); err != nil {
+		return err
 	}
-	t.ID = id
-	logger.Log.Debug("ticket record inserted", "id", t.ID)
 
 	// Insert tags
 	if len(t.Tags) > 0 {
 		logger.Log.Debug("inserting tags", "count", len(t.Tags))
 		insertTagQuery := `INSERT INTO ticket_tags (ticket_id, tag) VALUES (?, ?)`
 		for _, tag := range t.Tags {
-			if _, err := tx.Exec(insertTagQuery, t.ID, tag); err != nil {
+			if _, err := tx.ExecContext(ctx, insertTagQuery, t.ID, tag); err != nil {
 				logger.Log.Error("failed to insert tag", "error", err, "tag", tag)
 				return fmt.Errorf("failed to insert tag: %w", err)
 			}
@@ -71,7 +156,7 @@ func (t *Ticket) Create(db *sql.DB, project string) error {
 		logger.Log.Debug("inserting files", "count", len(t.Files))
 		insertFileQuery := `INSERT INTO ticket_files (ticket_id, file_path) VALUES (?, ?)`
 		for _, file := range t.Files {
-			if _, err := tx.Exec(insertFileQuery, t.ID, file); err != nil {
+			if _, err := tx.ExecContext(ctx, insertFileQuery, t.ID, file); err != nil {
 				logger.Log.Error("failed to insert file", "error", err, "file", file)
 				return fmt.Errorf("failed to insert file: %w", err)
 			}
@@ -83,7 +168,7 @@ func (t *Ticket) Create(db *sql.DB, project string) error {
 		logger.Log.Debug("inserting comments", "count", len(t.Comments))
 		insertCommentQuery := `INSERT INTO ticket_comments (ticket_id, comment_text, created_at) VALUES (?, ?, ?)`
 		for _, comment := range t.Comments {
-			if _, err := tx.Exec(insertCommentQuery, t.ID, comment, time.Now()); err != nil {
+			if _, err := tx.ExecContext(ctx, insertCommentQuery, t.ID, comment, time.Now()); err != nil {
 				logger.Log.Error("failed to insert comment", "error", err)
 				return fmt.Errorf("failed to insert comment: %w", err)
 			}
@@ -101,50 +186,111 @@ func (t *Ticket) Create(db *sql.DB, project string) error {
 	return nil
 }
 
+// closeOnCreate sets when a ticket created with status closed was closed, and
+// clears it for any other status
+func closeOnCreate(t *Ticket) {
+	if t.Status != StatusClosed {
+		t.ClosedAt = nil
+		return
+	}
+	if t.ClosedAt == nil {
+		closedAt := t.CreatedAt
+		if closedAt.IsZero() {
+			closedAt = time.Now()
+		}
+		t.ClosedAt = &closedAt
+	}
+}
+
 // Update modifies an existing ticket in the database
-func (t *Ticket) Update(db *sql.DB, project string, id int64, title string) error {
-	logger.Log.Debug("updating ticket", "project", project, "id", id, "title", title)
+func (s *SQLStore) Update(ctx context.Context, project string, t *Ticket) error {
+	logger.Log.Debug("updating ticket", "project", project, "id", t.ID)
 
 	// Start a transaction
-	tx, err := db.Begin()
+	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
 		logger.Log.Error("failed to begin transaction", "error", err)
 		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
 	defer tx.Rollback()
 
-	var ticketID int64
+	if err := updateTicket(ctx, tx, project, t); err != nil {
+		return err
+	}
 
-	// Determine which identifier to use
-	if id != 0 {
-		ticketID = id
-		logger.Log.Debug("using ID to find ticket", "id", ticketID)
-	} else if title != "" {
-		logger.Log.Debug("looking up ticket by title", "title", title)
-		err = tx.QueryRow("SELECT id FROM tickets WHERE title = ? AND project = ?", title, project).Scan(&ticketID)
-		if err == sql.ErrNoRows {
-			logger.Log.Error("ticket not found", "title", title, "project", project)
-			return fmt.Errorf("no ticket found with title '%s'", title)
-		}
-		if err != nil {
-			logger.Log.Error("failed to find ticket", "error", err)
-			return fmt.Errorf("failed to find ticket: %w", err)
+	// Commit the transaction
+	logger.Log.Debug("committing update transaction", "ticket_id", t.ID)
+	if err := tx.Commit(); err != nil {
+		logger.Log.Error("failed to commit transaction", "error", err)
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	logger.Log.Info("ticket updated", "ticket_id", t.ID, "project", project)
+	return nil
+}
+
+// UpdateMany saves several tickets, each in its own project, in one transaction
+func (s *SQLStore) UpdateMany(ctx context.Context, tickets []Ticket) error {
+	logger.Log.Debug("updating tickets", "count", len(tickets))
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.Log.Error("failed to begin transaction", "error", err)
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for i := range tickets {
+		if err := updateTicket(ctx, tx, tickets[i].Project, &tickets[i]); err != nil {
+			return fmt.Errorf("ticket %d: %w", tickets[i].ID, err)
 		}
-		logger.Log.Debug("found ticket", "ticket_id", ticketID)
-	} else {
-		logger.Log.Error("no identifier provided for update")
-		return fmt.Errorf("either id or title must be provided")
+	}
+
+	if err := tx.Commit(); err != nil {
+		logger.Log.Error("failed to commit transaction", "error", err)
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	logger.Log.Info("tickets updated", "count", len(tickets))
+	return nil
+}
+
+// updateTicket writes every field of t within tx, recording any status change
+func updateTicket(ctx context.Context, tx *sql.Tx, project string, t *Ticket) error {
+	ticketID := t.ID
+
+	// Remember the current status so a change can be recorded in the history
+	var previousStatus Status
+	err := tx.QueryRowContext(ctx, "SELECT status FROM tickets WHERE id = ? AND project = ? AND deleted_at IS NULL", ticketID, project).Scan(&previousStatus)
+	if err == sql.ErrNoRows {
+		logger.Log.Error("ticket not found", "ticket_id", ticketID, "project", project)
+		return fmt.Errorf("no ticket found with the provided identifier")
+	}
+	if err != nil {
+		logger.Log.Error("failed to load current status", "error", err)
+		return fmt.Errorf("failed to load current status: %w", err)
+	}
+
+	// Journal the ticket as it was so the update can be undone
+	before, err := snapshotTicket(ctx, tx, ticketID)
+	if err != nil {
+		return err
+	}
+	if err := journal(ctx, tx, OpUpdate, ticketID, before); err != nil {
+		return err
 	}
 
 	// Update the main ticket record
 	updateTicketQuery := `
 		UPDATE tickets SET
 			type = ?, title = ?, description = ?, critical_path = ?,
-			status = ?, priority = ?, assigned_to = ?, updated_at = ?
+			status = ?, priority = ?, assigned_to = ?, updated_at = ?,
+			due_at = ?, start_at = ?, story_points = ?, estimate_hours = ?, parent_id = ?,
+			closed_at = CASE WHEN ? = 'closed' THEN COALESCE(closed_at, ?) ELSE NULL END
 		WHERE id = ? AND project = ?`
 
 	logger.Log.Debug("executing update query", "ticket_id", ticketID)
-	result, err := tx.Exec(
+	result, err := tx.ExecContext(ctx,
 		updateTicketQuery,
 		t.Type,
 		t.Title,
@@ -154,6 +300,13 @@ func (t *Ticket) Update(db *sql.DB, project string, id int64, title string) erro
 		t.Priority,
 		t.AssignedTo,
 		time.Now(),
+		t.DueAt,
+		t.StartAt,
+		t.StoryPoints,
+		t.EstimateHours,
+		t.ParentID,
+		t.Status,
+		time.Now(),
 		ticketID,
 		project,
 	)
@@ -175,9 +328,15 @@ func (t *Ticket) Update(db *sql.DB, project string, id int64, title string) erro
 
 	logger.Log.Debug("ticket record updated", "rows_affected", rowsAffected)
 
+	if previousStatus != t.Status {
+		if err := recordStatusChange(ctx, tx, ticketID, previousStatus, t.Status, time.Now()); err != nil {
+			return err
+		}
+	}
+
 	// Update tags - delete existing and insert new ones
 	logger.Log.Debug("updating tags", "ticket_id", ticketID)
-	if _, err := tx.Exec("DELETE FROM ticket_tags WHERE ticket_id = ?", ticketID); err != nil {
+	if _, err := tx.ExecContext(ctx, "DELETE FROM ticket_tags WHERE ticket_id = ?", ticketID); err != nil {
 		logger.Log.Error("failed to delete existing tags", "error", err)
 		return fmt.Errorf("failed to delete existing tags: %w", err)
 	}
@@ -186,7 +345,7 @@ func (t *Ticket) Update(db *sql.DB, project string, id int64, title string) erro
 		logger.Log.Debug("inserting new tags", "count", len(t.Tags))
 		insertTagQuery := `INSERT INTO ticket_tags (ticket_id, tag) VALUES (?, ?)`
 		for _, tag := range t.Tags {
-			if _, err := tx.Exec(insertTagQuery, ticketID, tag); err != nil {
+			if _, err := tx.ExecContext(ctx, insertTagQuery, ticketID, tag); err != nil {
 				logger.Log.Error("failed to insert tag", "error", err, "tag", tag)
 				return fmt.Errorf("failed to insert tag: %w", err)
 			}
@@ -195,7 +354,7 @@ func (t *Ticket) Update(db *sql.DB, project string, id int64, title string) erro
 
 	// Update files - delete existing and insert new ones
 	logger.Log.Debug("updating files", "ticket_id", ticketID)
-	if _, err := tx.Exec("DELETE FROM ticket_files WHERE ticket_id = ?", ticketID); err != nil {
+	if _, err := tx.ExecContext(ctx, "DELETE FROM ticket_files WHERE ticket_id = ?", ticketID); err != nil {
 		logger.Log.Error("failed to delete existing files", "error", err)
 		return fmt.Errorf("failed to delete existing files: %w", err)
 	}
@@ -204,93 +363,32 @@ func (t *Ticket) Update(db *sql.DB, project string, id int64, title string) erro
 		logger.Log.Debug("inserting new files", "count", len(t.Files))
 		insertFileQuery := `INSERT INTO ticket_files (ticket_id, file_path) VALUES (?, ?)`
 		for _, file := range t.Files {
-			if _, err := tx.Exec(insertFileQuery, ticketID, file); err != nil {
+			if _, err := tx.ExecContext(ctx, insertFileQuery, ticketID, file); err != nil {
 				logger.Log.Error("failed to insert file", "error", err, "file", file)
 				return fmt.Errorf("failed to insert file: %w", err)
 			}
 		}
 	}
 
-	// Add new comments (don't delete existing ones)
-	if len(t.Comments) > 0 {
-		logger.Log.Debug("adding new comments", "count", len(t.Comments))
-		insertCommentQuery := `INSERT INTO ticket_comments (ticket_id, comment_text, created_at) VALUES (?, ?, ?)`
-		for _, comment := range t.Comments {
-			if _, err := tx.Exec(insertCommentQuery, ticketID, comment, time.Now()); err != nil {
-				logger.Log.Error("failed to insert comment", "error", err)
-				return fmt.Errorf("failed to insert comment: %w", err)
-			}
-		}
-	}
-
-	// Commit the transaction
-	logger.Log.Debug("committing update transaction", "ticket_id", ticketID)
-	if err := tx.Commit(); err != nil {
-		logger.Log.Error("failed to commit transaction", "error", err)
-		return fmt.Errorf("failed to commit transaction: %w", err)
-	}
-
-	logger.Log.Info("ticket updated", "ticket_id", ticketID, "project", project)
 	return nil
 }
 
 // List retrieves tickets from the database based on the provided filters
-func List(db *sql.DB, filters Filters) ([]Ticket, error) {
+func (s *SQLStore) List(ctx context.Context, filters Filters) ([]Ticket, error) {
 	logger.Log.Debug("listing tickets", "filters", fmt.Sprintf("%+v", filters))
 
 	query := `
-		SELECT DISTINCT t.id, t.project, t.type, t.title, t.description,
-		       t.critical_path, t.status, t.priority, t.created_by,
-		       t.assigned_to, t.created_at, t.updated_at
+		SELECT DISTINCT ` + selectColumns("t.") + `
 		FROM tickets t
 		LEFT JOIN ticket_tags tt ON t.id = tt.ticket_id
-		WHERE 1=1`
-
-	var args []interface{}
+		WHERE t.deleted_at IS NULL`
 
 	// Build dynamic query based on filters
-	if filters.Status != nil {
-		query += " AND t.status = ?"
-		args = append(args, *filters.Status)
-	}
-
-	if filters.Type != nil {
-		query += " AND t.type = ?"
-		args = append(args, *filters.Type)
-	}
-
-	if filters.Priority != nil {
-		query += " AND t.priority = ?"
-		args = append(args, *filters.Priority)
-	}
-
-	if filters.AssignedTo != nil {
-		query += " AND t.assigned_to = ?"
-		args = append(args, *filters.AssignedTo)
-	}
-
-	if filters.Project != nil {
-		query += " AND t.project = ?"
-		args = append(args, *filters.Project)
-	}
-
-	// Filter by tags if provided
-	if len(filters.Tags) > 0 {
-		query += " AND tt.tag IN ("
-		for i := range filters.Tags {
-			if i > 0 {
-				query += ","
-			}
-			query += "?"
-			args = append(args, filters.Tags[i])
-		}
-		query += ")"
-	}
-
-	query += " ORDER BY t.created_at DESC"
+	clause, args := filterClause(filters, "ticket_checklist_items")
+	query += clause + " ORDER BY t.created_at DESC"
 
 	logger.Log.Debug("executing list query")
-	rows, err := db.Query(query, args...)
+	rows, err := s.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		logger.Log.Error("failed to query tickets", "error", err)
 		return nil, fmt.Errorf("failed to query tickets: %w", err)
@@ -299,31 +397,28 @@ func List(db *sql.DB, filters Filters) ([]Ticket, error) {
 
 	var tickets []Ticket
 	ticketMap := make(map[int64]*Ticket)
+	var order []int64
+	now := time.Now()
 
 	for rows.Next() {
 		var t Ticket
 
-		err := rows.Scan(
-			&t.ID,
-			&t.Project,
-			&t.Type,
-			&t.Title,
-			&t.Description,
-			&t.CriticalPath,
-			&t.Status,
-			&t.Priority,
-			&t.CreatedBy,
-			&t.AssignedTo,
-			&t.CreatedAt,
-			&t.UpdatedAt,
-		)
-		if err != nil {
+		if err := scanTicket(rows, &t); err != nil {
 			return nil, fmt.Errorf("failed to scan ticket: %w", err)
 		}
 
+		// Due date filters are evaluated here so they behave the same on every backend
+		if filters.Overdue && !t.IsOverdue(now) {
+			continue
+		}
+		if filters.DueWithin > 0 && (t.DueAt == nil || t.Status == StatusClosed || t.DueAt.After(now.Add(filters.DueWithin))) {
+			continue
+		}
+
 		// Avoid duplicates from JOIN
 		if _, exists := ticketMap[t.ID]; !exists {
 			ticketMap[t.ID] = &t
+			order = append(order, t.ID)
 		}
 	}
 
@@ -334,24 +429,25 @@ func List(db *sql.DB, filters Filters) ([]Ticket, error) {
 
 	logger.Log.Debug("found tickets", "count", len(ticketMap))
 
-	// Convert map to slice and load related data
-	for _, ticket := range ticketMap {
+	// Convert map to slice (keeping the query order) and load related data
+	for _, id := range order {
+		ticket := ticketMap[id]
 		// Load tags
-		tags, err := loadTags(db, ticket.ID)
+		tags, err := s.loadTags(ctx, ticket.ID)
 		if err != nil {
 			return nil, err
 		}
 		ticket.Tags = tags
 
 		// Load files
-		files, err := loadFiles(db, ticket.ID)
+		files, err := s.loadFiles(ctx, ticket.ID)
 		if err != nil {
 			return nil, err
 		}
 		ticket.Files = files
 
 		// Load comments
-		comments, err := loadComments(db, ticket.ID)
+		comments, err := s.loadComments(ctx, ticket.ID)
 		if err != nil {
 			return nil, err
 		}
@@ -360,14 +456,80 @@ func List(db *sql.DB, filters Filters) ([]Ticket, error) {
 		tickets = append(tickets, *ticket)
 	}
 
+	// Checklists are loaded for every listed ticket at once
+	checklists, err := loadChecklists(ctx, s.db, "ticket_checklist_items", order)
+	if err != nil {
+		return nil, err
+	}
+	for i := range tickets {
+		tickets[i].Checklist = checklists[tickets[i].ID]
+	}
+
 	logger.Log.Info("tickets listed", "count", len(tickets))
 	return tickets, nil
 }
 
+// filterClause returns the conditions and arguments that apply filters to a query
+// over tickets aliased t joined to their tags aliased tt, whose checklist items are
+// in checklistTable. The due date filters are left to the caller.
+func filterClause(filters Filters, checklistTable string) (string, []interface{}) {
+	var query string
+	var args []interface{}
+
+	if filters.Status != nil {
+		query += " AND t.status = ?"
+		args = append(args, *filters.Status)
+	}
+
+	if filters.Type != nil {
+		query += " AND t.type = ?"
+		args = append(args, *filters.Type)
+	}
+
+	if filters.Priority != nil {
+		query += " AND t.priority = ?"
+		args = append(args, *filters.Priority)
+	}
+
+	if filters.AssignedTo != nil {
+		query += " AND t.assigned_to = ?"
+		args = append(args, *filters.AssignedTo)
+	}
+
+	if filters.Project != nil {
+		query += " AND t.project = ?"
+		args = append(args, *filters.Project)
+	}
+
+	if filters.Parent != nil {
+		query += " AND t.parent_id = ?"
+		args = append(args, *filters.Parent)
+	}
+
+	// Filter by tags if provided
+	if len(filters.Tags) > 0 {
+		query += " AND tt.tag IN ("
+		for i := range filters.Tags {
+			if i > 0 {
+				query += ","
+			}
+			query += "?"
+			args = append(args, filters.Tags[i])
+		}
+		query += ")"
+	}
+
+	if filters.IncompleteChecklist {
+		query += " AND EXISTS (SELECT 1 FROM " + checklistTable + " c WHERE c.ticket_id = t.id AND c.done_at IS NULL)"
+	}
+
+	return query, args
+}
+
 // loadTags loads tags for a specific ticket
-func loadTags(db *sql.DB, ticketID int64) ([]string, error) {
+func (s *SQLStore) loadTags(ctx context.Context, ticketID int64) ([]string, error) {
 	logger.Log.Debug("loading tags", "ticket_id", ticketID)
-	rows, err := db.Query("SELECT tag FROM ticket_tags WHERE ticket_id = ?", ticketID)
+	rows, err := s.db.QueryContext(ctx, "SELECT tag FROM ticket_tags WHERE ticket_id = ?", ticketID)
 	if err != nil {
 		logger.Log.Error("failed to query tags", "error", err, "ticket_id", ticketID)
 		return nil, fmt.Errorf("failed to load tags: %w", err)
@@ -394,9 +556,9 @@ func loadTags(db *sql.DB, ticketID int64) ([]string, error) {
 }
 
 // loadFiles loads files for a specific ticket
-func loadFiles(db *sql.DB, ticketID int64) ([]string, error) {
+func (s *SQLStore) loadFiles(ctx context.Context, ticketID int64) ([]string, error) {
 	logger.Log.Debug("loading files", "ticket_id", ticketID)
-	rows, err := db.Query("SELECT file_path FROM ticket_files WHERE ticket_id = ?", ticketID)
+	rows, err := s.db.QueryContext(ctx, "SELECT file_path FROM ticket_files WHERE ticket_id = ?", ticketID)
 	if err != nil {
 		logger.Log.Error("failed to query files", "error", err, "ticket_id", ticketID)
 		return nil, fmt.Errorf("failed to load files: %w", err)
@@ -423,9 +585,9 @@ func loadFiles(db *sql.DB, ticketID int64) ([]string, error) {
 }
 
 // loadComments loads comments for a specific ticket
-func loadComments(db *sql.DB, ticketID int64) ([]string, error) {
+func (s *SQLStore) loadComments(ctx context.Context, ticketID int64) ([]string, error) {
 	logger.Log.Debug("loading comments", "ticket_id", ticketID)
-	rows, err := db.Query("SELECT comment_text FROM ticket_comments WHERE ticket_id = ? ORDER BY created_at", ticketID)
+	rows, err := s.db.QueryContext(ctx, "SELECT comment_text FROM ticket_comments WHERE ticket_id = ? ORDER BY created_at", ticketID)
 	if err != nil {
 		logger.Log.Error("failed to query comments", "error", err, "ticket_id", ticketID)
 		return nil, fmt.Errorf("failed to load comments: %w", err)
@@ -450,59 +612,61 @@ func loadComments(db *sql.DB, ticketID int64) ([]string, error) {
 	logger.Log.Debug("comments loaded", "count", len(comments))
 	return comments, nil
 }
-  // Delete removes a ticket from the database
-func (t *Ticket) Delete(db *sql.DB, project string, id int64, title string) error {
-	logger.Log.Debug("deleting ticket", "project", project, "id", id, "title", title)
 
-	// Start a transaction
-	tx, err := db.Begin()
+// AddComment appends a comment to a ticket and marks the ticket as updated
+func (s *SQLStore) AddComment(ctx context.Context, ticketID int64, text string) error {
+	logger.Log.Debug("adding comment", "ticket_id", ticketID)
+
+	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
 		logger.Log.Error("failed to begin transaction", "error", err)
 		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
 	defer tx.Rollback()
 
-	var ticketID int64
-
-	// Determine which identifier to use
-	if id != 0 {
-		ticketID = id
-	} else if title != "" {
-		err = tx.QueryRow("SELECT id FROM tickets WHERE title = ? AND project = ?", title, project).Scan(&ticketID)
-		if err == sql.ErrNoRows {
-			logger.Log.Error("ticket not found", "title", title, "project", project)
-			return fmt.Errorf("no ticket found with title '%s'", title)
-		}
-		if err != nil {
-			logger.Log.Error("failed to find ticket", "error", err)
-			return fmt.Errorf("failed to find ticket: %w", err)
-		}
-	} else {
-		logger.Log.Error("no identifier provided for delete")
-		return fmt.Errorf("either id or title must be provided")
+	now := time.Now()
+	result, err := tx.ExecContext(ctx, "UPDATE tickets SET updated_at = ? WHERE id = ?", now, ticketID)
+	if err != nil {
+		logger.Log.Error("failed to update ticket", "error", err, "ticket_id", ticketID)
+		return fmt.Errorf("failed to update ticket: %w", err)
+	}
+	if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+		logger.Log.Error("ticket not found", "ticket_id", ticketID)
+		return fmt.Errorf("ticket %d not found", ticketID)
 	}
 
-	logger.Log.Debug("deleting ticket data", "ticket_id", ticketID)
-
-	// Delete from all tables using ticketID
-	if _, err := tx.Exec("DELETE FROM ticket_tags WHERE ticket_id = ?", ticketID); err != nil {
-		logger.Log.Error("failed to delete tags", "error", err)
-		return fmt.Errorf("failed to delete tags: %w", err)
+	if _, err := tx.ExecContext(ctx, `INSERT INTO ticket_comments (ticket_id, comment_text, created_at) VALUES (?, ?, ?)`, ticketID, text, now); err != nil {
+		logger.Log.Error("failed to insert comment", "error", err)
+		return fmt.Errorf("failed to insert comment: %w", err)
+	}
+	if err := journal(ctx, tx, OpComment, ticketID, nil); err != nil {
+		return err
 	}
 
-	if _, err := tx.Exec("DELETE FROM ticket_files WHERE ticket_id = ?", ticketID); err != nil {
-		logger.Log.Error("failed to delete files", "error", err)
-		return fmt.Errorf("failed to delete files: %w", err)
+	if err := tx.Commit(); err != nil {
+		logger.Log.Error("failed to commit transaction", "error", err)
+		return fmt.Errorf("failed to commit transaction: %w", err)
 	}
 
-	if _, err := tx.Exec("DELETE FROM ticket_comments WHERE ticket_id = ?", ticketID); err != nil {
-		logger.Log.Error("failed to delete comments", "error", err)
-		return fmt.Errorf("failed to delete comments: %w", err)
+	logger.Log.Info("comment added", "ticket_id", ticketID)
+	return nil
+}
+
+// Delete moves a ticket to the trash. It stays in the database, with everything
+// attached to it, until it is restored or purged.
+func (s *SQLStore) Delete(ctx context.Context, project string, ticketID int64) error {
+	logger.Log.Debug("deleting ticket", "project", project, "id", ticketID)
+
+	// Start a transaction
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.Log.Error("failed to begin transaction", "error", err)
+		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
+	defer tx.Rollback()
 
-	if _, err := tx.Exec("DELETE FROM tickets WHERE id = ? AND project = ?", ticketID, project); err != nil {
-		logger.Log.Error("failed to delete ticket record", "error", err)
-		return fmt.Errorf("failed to delete ticket: %w", err)
+	if err := trashTicket(ctx, tx, project, ticketID, time.Now()); err != nil {
+		return err
 	}
 
 	if err := tx.Commit(); err != nil {
@@ -510,102 +674,233 @@ func (t *Ticket) Delete(db *sql.DB, project string, id int64, title string) erro
 		return fmt.Errorf("failed to commit transaction: %w", err)
 	}
 
-	logger.Log.Info("ticket deleted", "ticket_id", ticketID, "project", project)
+	logger.Log.Info("ticket moved to trash", "ticket_id", ticketID, "project", project)
 	return nil
 }
 
-func (t *Ticket) View(db *sql.DB, project string, id int64, title string) error {
-	logger.Log.Debug("viewing ticket", "project", project, "id", id, "title", title)
+// DeleteMany moves several tickets, each in its own project, to the trash in one transaction
+func (s *SQLStore) DeleteMany(ctx context.Context, tickets []Ticket) error {
+	logger.Log.Debug("deleting tickets", "count", len(tickets))
 
-	// Start a transaction
-	tx, err := db.Begin()
+	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
 		logger.Log.Error("failed to begin transaction", "error", err)
 		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
 	defer tx.Rollback()
 
-	var ticketID int64
+	now := time.Now()
+	for _, t := range tickets {
+		if err := trashTicket(ctx, tx, t.Project, t.ID, now); err != nil {
+			return fmt.Errorf("ticket %d: %w", t.ID, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		logger.Log.Error("failed to commit delete transaction", "error", err)
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	logger.Log.Info("tickets moved to trash", "count", len(tickets))
+	return nil
+}
+
+// trashTicket sets the deleted_at tombstone on a ticket within tx
+func trashTicket(ctx context.Context, tx *sql.Tx, project string, ticketID int64, at time.Time) error {
+	result, err := tx.ExecContext(ctx, "UPDATE tickets SET deleted_at = ? WHERE id = ? AND project = ? AND deleted_at IS NULL", at, ticketID, project)
+	if err != nil {
+		logger.Log.Error("failed to move ticket to trash", "error", err, "ticket_id", ticketID)
+		return fmt.Errorf("failed to move ticket to trash: %w", err)
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		logger.Log.Error("ticket not found", "ticket_id", ticketID, "project", project)
+		return fmt.Errorf("no ticket found with the provided identifier")
+	}
+
+	before, err := snapshotTicket(ctx, tx, ticketID)
+	if err != nil {
+		return err
+	}
+	return journal(ctx, tx, OpDelete, ticketID, before)
+}
+
+// purgeTicket removes a ticket deleted before the cutoff, and everything attached to
+// it, within tx. It reports false and leaves the ticket alone when that no longer
+// holds, as when it was restored after being picked for purging.
+func purgeTicket(ctx context.Context, tx *sql.Tx, project string, ticketID int64, before time.Time) (bool, error) {
+	var deletedAt time.Time
+	err := tx.QueryRowContext(ctx,
+		"SELECT deleted_at FROM tickets WHERE id = ? AND project = ? AND deleted_at IS NOT NULL", ticketID, project,
+	).Scan(&deletedAt)
+	if err == sql.ErrNoRows || err == nil && !deletedAt.Before(before) {
+		logger.Log.Debug("ticket no longer due for purging", "ticket_id", ticketID)
+		return false, nil
+	}
+	if err != nil {
+		logger.Log.Error("failed to find ticket", "error", err)
+		return false, fmt.Errorf("failed to find ticket: %w", err)
+	}
+
+	logger.Log.Debug("deleting ticket data", "ticket_id", ticketID)
+
+	// Delete from all tables using ticketID
+	if _, err := tx.ExecContext(ctx, "DELETE FROM ticket_tags WHERE ticket_id = ?", ticketID); err != nil {
+		logger.Log.Error("failed to delete tags", "error", err)
+		return false, fmt.Errorf("failed to delete tags: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM ticket_files WHERE ticket_id = ?", ticketID); err != nil {
+		logger.Log.Error("failed to delete files", "error", err)
+		return false, fmt.Errorf("failed to delete files: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM ticket_comments WHERE ticket_id = ?", ticketID); err != nil {
+		logger.Log.Error("failed to delete comments", "error", err)
+		return false, fmt.Errorf("failed to delete comments: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM ticket_worklogs WHERE ticket_id = ?", ticketID); err != nil {
+		logger.Log.Error("failed to delete worklogs", "error", err)
+		return false, fmt.Errorf("failed to delete worklogs: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM ticket_status_history WHERE ticket_id = ?", ticketID); err != nil {
+		logger.Log.Error("failed to delete status history", "error", err)
+		return false, fmt.Errorf("failed to delete status history: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM ticket_commits WHERE ticket_id = ?", ticketID); err != nil {
+		logger.Log.Error("failed to delete commit links", "error", err, "ticket_id", ticketID)
+		return false, fmt.Errorf("failed to delete commit links: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM ticket_checklist_items WHERE ticket_id = ?", ticketID); err != nil {
+		logger.Log.Error("failed to delete checklist", "error", err, "ticket_id", ticketID)
+		return false, fmt.Errorf("failed to delete checklist: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM active_timers WHERE ticket_id = ?", ticketID); err != nil {
+		logger.Log.Error("failed to delete timers", "error", err)
+		return false, fmt.Errorf("failed to delete timers: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM ticket_journal WHERE ticket_id = ?", ticketID); err != nil {
+		logger.Log.Error("failed to delete journal", "error", err)
+		return false, fmt.Errorf("failed to delete journal: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM ticket_watchers WHERE ticket_id = ?", ticketID); err != nil {
+		logger.Log.Error("failed to delete watchers", "error", err)
+		return false, fmt.Errorf("failed to delete watchers: %w", err)
+	}
+
+	// Children outlive their parent as top-level tickets
+	if _, err := tx.ExecContext(ctx, "UPDATE tickets SET parent_id = NULL WHERE parent_id = ?", ticketID); err != nil {
+		logger.Log.Error("failed to detach children", "error", err, "ticket_id", ticketID)
+		return false, fmt.Errorf("failed to detach children: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM tickets WHERE id = ? AND project = ? AND deleted_at IS NOT NULL", ticketID, project); err != nil {
+		logger.Log.Error("failed to delete ticket record", "error", err)
+		return false, fmt.Errorf("failed to delete ticket: %w", err)
+	}
+
+	return true, nil
+}
+
+// Find loads a ticket in a project by ID, or by title when id is 0
+func (s *SQLStore) Find(ctx context.Context, project string, id int64, title string) (*Ticket, error) {
+	logger.Log.Debug("finding ticket", "project", project, "id", id, "title", title)
+
+	ticketID := id
+	if ticketID == 0 {
+		if title == "" {
+			logger.Log.Error("validation failed", "error", "neither id nor title provided")
+			return nil, fmt.Errorf("either id or title must be provided")
+		}
 
-	if id != 0 {
-		ticketID = id
-		logger.Log.Debug("using provided ID", "id", ticketID)
-	} else if title != "" {
 		logger.Log.Debug("resolving ticket by title", "title", title, "project", project)
-		err = tx.QueryRow("SELECT id FROM tickets WHERE title = ? AND project = ?", title, project).Scan(&ticketID)
+		err := s.db.QueryRowContext(ctx, "SELECT id FROM tickets WHERE title = ? AND project = ? AND deleted_at IS NULL", title, project).Scan(&ticketID)
 		if err == sql.ErrNoRows {
 			logger.Log.Error("ticket not found by title", "title", title, "project", project)
-			return fmt.Errorf("no ticket found with title '%s'", title)
+			return nil, fmt.Errorf("no ticket found with title '%s'", title)
 		}
 		if err != nil {
 			logger.Log.Error("failed to find ticket by title", "error", err, "title", title)
-			return fmt.Errorf("failed to find ticket: %w", err)
+			return nil, fmt.Errorf("failed to find ticket: %w", err)
 		}
 		logger.Log.Debug("resolved ticket ID", "id", ticketID, "title", title)
-	} else {
-		logger.Log.Error("validation failed", "error", "neither id nor title provided")
-		return fmt.Errorf("either id or title must be provided")
 	}
 
-	logger.Log.Debug("fetching ticket from database", "id", ticketID, "project", project)
-	query := `SELECT id, project, type, title, description, critical_path,
-                status, priority, created_by, assigned_to, created_at, updated_at
-                FROM tickets WHERE id = ? AND project = ?`
-
-	err = tx.QueryRow(query, ticketID, project).Scan(
-		&t.ID,
-		&t.Project,
-		&t.Type,
-		&t.Title,
-		&t.Description,
-		&t.CriticalPath,
-		&t.Status,
-		&t.Priority,
-		&t.CreatedBy,
-		&t.AssignedTo,
-		&t.CreatedAt,
-		&t.UpdatedAt,
-	)
+	t := &Ticket{}
+	query := `SELECT ` + ticketColumns + ` FROM tickets WHERE id = ? AND project = ? AND deleted_at IS NULL`
+	err := scanTicket(s.db.QueryRowContext(ctx, query, ticketID, project), t)
 	if err == sql.ErrNoRows {
 		logger.Log.Error("ticket not found", "id", ticketID, "project", project)
-		return fmt.Errorf("ticket not found")
+		return nil, fmt.Errorf("ticket not found")
 	}
 	if err != nil {
 		logger.Log.Error("failed to fetch ticket", "error", err, "id", ticketID)
-		return fmt.Errorf("failed to fetch ticket: %w", err)
+		return nil, fmt.Errorf("failed to fetch ticket: %w", err)
+	}
+
+	if err := s.loadRelated(ctx, t); err != nil {
+		return nil, err
+	}
+
+	logger.Log.Debug("ticket found", "id", t.ID, "title", t.Title, "project", project)
+	return t, nil
+}
+
+// Get loads a single ticket by ID, regardless of which project it belongs to
+func (s *SQLStore) Get(ctx context.Context, id int64) (*Ticket, error) {
+	logger.Log.Debug("getting ticket", "id", id)
+
+	t := &Ticket{}
+	query := `SELECT ` + ticketColumns + ` FROM tickets WHERE id = ? AND deleted_at IS NULL`
+	err := scanTicket(s.db.QueryRowContext(ctx, query, id), t)
+	if err == sql.ErrNoRows {
+		logger.Log.Error("ticket not found", "id", id)
+		return nil, fmt.Errorf("ticket %d not found", id)
+	}
+	if err != nil {
+		logger.Log.Error("failed to fetch ticket", "error", err, "id", id)
+		return nil, fmt.Errorf("failed to fetch ticket: %w", err)
+	}
+
+	if err := s.loadRelated(ctx, t); err != nil {
+		return nil, err
 	}
 
-	logger.Log.Debug("ticket fetched successfully", "id", t.ID, "title", t.Title)
+	logger.Log.Debug("ticket loaded", "id", t.ID, "project", t.Project)
+	return t, nil
+}
 
-	// Load related data
-	logger.Log.Debug("loading related data", "ticket_id", ticketID)
-	tags, err := loadTags(db, ticketID)
+// loadRelated populates the tags, files, comments and checklist of a ticket
+func (s *SQLStore) loadRelated(ctx context.Context, t *Ticket) error {
+	tags, err := s.loadTags(ctx, t.ID)
 	if err != nil {
 		return err
 	}
 	t.Tags = tags
-	logger.Log.Debug("loaded tags", "count", len(tags))
 
-	files, err := loadFiles(db, ticketID)
+	files, err := s.loadFiles(ctx, t.ID)
 	if err != nil {
 		return err
 	}
 	t.Files = files
-	logger.Log.Debug("loaded files", "count", len(files))
 
-	comments, err := loadComments(db, ticketID)
+	comments, err := s.loadComments(ctx, t.ID)
 	if err != nil {
 		return err
 	}
 	t.Comments = comments
-	logger.Log.Debug("loaded comments", "count", len(comments))
 
-	logger.Log.Debug("committing view transaction")
-	if err := tx.Commit(); err != nil {
-		logger.Log.Error("failed to commit view transaction", "error", err)
-		return fmt.Errorf("failed to commit transaction: %w", err)
+	checklist, err := loadChecklist(ctx, s.db, "ticket_checklist_items", t.ID)
+	if err != nil {
+		return err
 	}
+	t.Checklist = checklist
 
-	logger.Log.Info("ticket viewed successfully", "id", t.ID, "title", t.Title, "project", project)
 	return nil
 }
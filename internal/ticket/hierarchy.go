@@ -0,0 +1,137 @@
+package ticket
+
+import (
+	"context"
+	"fmt"
+)
+
+// Node is a ticket in a tree of parents and children
+type Node struct {
+	Ticket   Ticket
+	Children []*Node
+}
+
+// Rollup summarizes the progress of a ticket's descendants at every depth
+type Rollup struct {
+	Done            int     `json:"done"`
+	Total           int     `json:"total"`
+	RemainingPoints float64 `json:"remaining_points"` // story points of descendants not yet closed
+}
+
+// Rollup counts the node's descendants and how many of them are closed
+func (n *Node) Rollup() Rollup {
+	var r Rollup
+	for _, c := range n.Children {
+		r.Total++
+		if c.Ticket.Status == StatusClosed {
+			r.Done++
+		} else if c.Ticket.StoryPoints != nil {
+			r.RemainingPoints += *c.Ticket.StoryPoints
+		}
+		sub := c.Rollup()
+		r.Done += sub.Done
+		r.Total += sub.Total
+		r.RemainingPoints += sub.RemainingPoints
+	}
+	return r
+}
+
+// Walk calls fn for each node below n, depth first, with its depth (1 for children)
+func (n *Node) Walk(fn func(node *Node, depth int)) {
+	var walk func(nodes []*Node, depth int)
+	walk = func(nodes []*Node, depth int) {
+		for _, c := range nodes {
+			fn(c, depth)
+			walk(c.Children, depth+1)
+		}
+	}
+	walk(n.Children, 1)
+}
+
+// BuildTree arranges tickets under their parents, keeping the order they are in.
+// Tickets whose parent is not among them are roots.
+func BuildTree(tickets []Ticket) []*Node {
+	nodes := make(map[int64]*Node, len(tickets))
+	for i := range tickets {
+		nodes[tickets[i].ID] = &Node{Ticket: tickets[i]}
+	}
+
+	var roots []*Node
+	for i := range tickets {
+		n := nodes[tickets[i].ID]
+		if p := tickets[i].ParentID; p != nil && nodes[*p] != nil && *p != n.Ticket.ID {
+			nodes[*p].Children = append(nodes[*p].Children, n)
+			continue
+		}
+		roots = append(roots, n)
+	}
+
+	// Tickets caught in a cycle have no root above them; list them at the top
+	reached := make(map[int64]bool, len(tickets))
+	for _, r := range roots {
+		reached[r.Ticket.ID] = true
+		r.Walk(func(n *Node, _ int) { reached[n.Ticket.ID] = true })
+	}
+	for i := range tickets {
+		if !reached[tickets[i].ID] {
+			n := nodes[tickets[i].ID]
+			n.Children = nil
+			roots = append(roots, n)
+		}
+	}
+	return roots
+}
+
+// Subtree loads t's descendants from store, newest first at each level
+func Subtree(ctx context.Context, store TicketStore, t *Ticket) (*Node, error) {
+	root := &Node{Ticket: *t}
+	seen := map[int64]bool{t.ID: true}
+	queue := []*Node{root}
+	for len(queue) > 0 {
+		n := queue[0]
+		queue = queue[1:]
+
+		id := n.Ticket.ID
+		children, err := store.List(ctx, Filters{Parent: &id})
+		if err != nil {
+			return nil, fmt.Errorf("failed to load children of ticket %d: %w", id, err)
+		}
+		for _, c := range children {
+			if seen[c.ID] {
+				continue
+			}
+			seen[c.ID] = true
+			child := &Node{Ticket: c}
+			n.Children = append(n.Children, child)
+			queue = append(queue, child)
+		}
+	}
+	return root, nil
+}
+
+// CheckParent loads the ticket that would become the parent of ticket id (0 for a
+// new ticket), refusing a parent that is the ticket itself or one of its descendants
+func CheckParent(ctx context.Context, store TicketStore, id, parentID int64) (*Ticket, error) {
+	parent, err := store.Get(ctx, parentID)
+	if err != nil {
+		return nil, fmt.Errorf("parent ticket %d not found", parentID)
+	}
+	if id == 0 {
+		return parent, nil
+	}
+
+	seen := make(map[int64]bool)
+	for t := parent; t != nil && !seen[t.ID]; {
+		if t.ID == id {
+			return nil, fmt.Errorf("ticket %d cannot be its own ancestor", id)
+		}
+		seen[t.ID] = true
+		if t.ParentID == nil {
+			break
+		}
+		if t, err = store.Get(ctx, *t.ParentID); err != nil {
+			break // a parent in the trash or archive ends the chain
+		}
+	}
+	return parent, nil
+}
@@ -0,0 +1,69 @@
+package ticket
+
+import (
+	"alexandria/internal/logger"
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// StatusChange records a ticket moving from one status to another.
+// From is empty for the status a ticket was created with.
+type StatusChange struct {
+	TicketID  int64     `json:"ticket_id"`
+	From      Status    `json:"from,omitempty"`
+	To        Status    `json:"to"`
+	ChangedAt time.Time `json:"changed_at"`
+}
+
+// recordStatusChange appends an entry to the status history within a transaction
+func recordStatusChange(ctx context.Context, tx *sql.Tx, ticketID int64, from, to Status, at time.Time) error {
+	logger.Log.Debug("recording status change", "ticket_id", ticketID, "from", from, "to", to)
+
+	var fromValue interface{}
+	if from != "" {
+		fromValue = from
+	}
+
+	if _, err := tx.ExecContext(ctx,
+		`INSERT INTO ticket_status_history (ticket_id, from_status, to_status, changed_at) VALUES (?, ?, ?, ?)`,
+		ticketID, fromValue, to, at,
+	); err != nil {
+		logger.Log.Error("failed to record status change", "error", err, "ticket_id", ticketID)
+		return fmt.Errorf("failed to record status change: %w", err)
+	}
+	return nil
+}
+
+// StatusHistory loads the status changes of every ticket, oldest first, keyed by ticket ID
+func (s *SQLStore) StatusHistory(ctx context.Context) (map[int64][]StatusChange, error) {
+	logger.Log.Debug("loading status history")
+
+	rows, err := s.db.QueryContext(ctx, `SELECT ticket_id, from_status, to_status, changed_at FROM ticket_status_history ORDER BY changed_at, id`)
+	if err != nil {
+		logger.Log.Error("failed to query status history", "error", err)
+		return nil, fmt.Errorf("failed to query status history: %w", err)
+	}
+	defer rows.Close()
+
+	history := make(map[int64][]StatusChange)
+	for rows.Next() {
+		var c StatusChange
+		var from sql.NullString
+		if err := rows.Scan(&c.TicketID, &from, &c.To, &c.ChangedAt); err != nil {
+			logger.Log.Error("failed to scan status change", "error", err)
+			return nil, fmt.Errorf("failed to scan status change: %w", err)
+		}
+		c.From = Status(from.String)
+		history[c.TicketID] = append(history[c.TicketID], c)
+	}
+
+	if err := rows.Err(); err != nil {
+		logger.Log.Error("error iterating status history", "error", err)
+		return nil, fmt.Errorf("error iterating status history: %w", err)
+	}
+
+	logger.Log.Debug("status history loaded", "tickets", len(history))
+	return history, nil
+}
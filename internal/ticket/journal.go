@@ -0,0 +1,329 @@
+package ticket
+
+import (
+	"alexandria/internal/logger"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Journal operations, one per kind of change that can be undone
+const (
+	OpCreate = "create"
+	OpUpdate = "update"
+	OpDelete = "delete"
+)
+
+// Journal operations that are recorded so that another user's undo sees the
+// ticket has changed since, but that are not undone themselves
+const (
+	OpComment   = "comment"
+	OpChecklist = "checklist"
+)
+
+// undoableOps restricts a journal query to the operations Undo reverts
+const undoableOps = " AND op IN ('" + OpCreate + "', '" + OpUpdate + "', '" + OpDelete + "')"
+
+// undoable reports whether Undo reverts journal operation op
+func undoable(op string) bool {
+	return op == OpCreate || op == OpUpdate || op == OpDelete
+}
+
+// JournalEntry records a change to a ticket along with the ticket as it was before,
+// so the change can be undone
+type JournalEntry struct {
+	ID       int64      `json:"id"`
+	TicketID int64      `json:"ticket_id"`
+	User     string     `json:"user"`
+	Op       string     `json:"op"`
+	Before   *Ticket    `json:"before,omitempty"` // nil for a create
+	At       time.Time  `json:"at"`
+	UndoneAt *time.Time `json:"undone_at,omitempty"`
+}
+
+// ErrNothingToUndo is returned by Undo when the user has no changes left to undo
+var ErrNothingToUndo = errors.New("nothing to undo")
+
+type actorKey struct{}
+
+// WithActor returns a context that attributes the changes made with it to user
+func WithActor(ctx context.Context, user string) context.Context {
+	return context.WithValue(ctx, actorKey{}, user)
+}
+
+// ActorFrom returns the user changes made with ctx are attributed to, or ""
+func ActorFrom(ctx context.Context) string {
+	user, _ := ctx.Value(actorKey{}).(string)
+	return user
+}
+
+// journal records a change within the transaction that makes it. before is the
+// ticket as it was, and nil for a create, comment or checklist change.
+func journal(ctx context.Context, tx *sql.Tx, op string, ticketID int64, before *Ticket) error {
+	var image interface{}
+	if before != nil {
+		data, err := json.Marshal(before)
+		if err != nil {
+			logger.Log.Error("failed to marshal before-image", "error", err, "ticket_id", ticketID)
+			return fmt.Errorf("failed to marshal before-image: %w", err)
+		}
+		image = string(data)
+	}
+
+	if _, err := tx.ExecContext(ctx,
+		`INSERT INTO ticket_journal (ticket_id, username, op, before_image, created_at) VALUES (?, ?, ?, ?, ?)`,
+		ticketID, ActorFrom(ctx), op, image, time.Now(),
+	); err != nil {
+		logger.Log.Error("failed to write journal", "error", err, "ticket_id", ticketID, "op", op)
+		return fmt.Errorf("failed to write journal: %w", err)
+	}
+	return nil
+}
+
+// snapshotTicket loads a ticket with its tags, files and comments within tx
+func snapshotTicket(ctx context.Context, tx *sql.Tx, ticketID int64) (*Ticket, error) {
+	t := &Ticket{}
+	if err := scanTicket(tx.QueryRowContext(ctx, `SELECT `+ticketColumns+` FROM tickets WHERE id = ?`, ticketID), t); err != nil {
+		logger.Log.Error("failed to load before-image", "error", err, "ticket_id", ticketID)
+		return nil, fmt.Errorf("failed to load before-image: %w", err)
+	}
+
+	var err error
+	if t.Tags, err = queryStrings(ctx, tx, "SELECT tag FROM ticket_tags WHERE ticket_id = ?", ticketID); err != nil {
+		return nil, err
+	}
+	if t.Files, err = queryStrings(ctx, tx, "SELECT file_path FROM ticket_files WHERE ticket_id = ?", ticketID); err != nil {
+		return nil, err
+	}
+	if t.Comments, err = queryStrings(ctx, tx, "SELECT comment_text FROM ticket_comments WHERE ticket_id = ? ORDER BY created_at", ticketID); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// queryStrings runs a query returning a single text column
+func queryStrings(ctx context.Context, q querier, query string, args ...interface{}) ([]string, error) {
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		logger.Log.Error("failed to query related records", "error", err)
+		return nil, fmt.Errorf("failed to load related records: %w", err)
+	}
+	defer rows.Close()
+
+	values := []string{}
+	for rows.Next() {
+		var v string
+		if err := rows.Scan(&v); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		values = append(values, v)
+	}
+	return values, rows.Err()
+}
+
+// ListJournal returns the user's changes that can still be undone, newest first
+func (s *SQLStore) ListJournal(ctx context.Context, user string, limit int) ([]JournalEntry, error) {
+	logger.Log.Debug("listing journal", "user", user, "limit", limit)
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, ticket_id, username, op, before_image, created_at, undone_at
+		FROM ticket_journal WHERE username = ? AND undone_at IS NULL`+undoableOps+`
+		ORDER BY id DESC LIMIT ?`, user, limit)
+	if err != nil {
+		logger.Log.Error("failed to query journal", "error", err)
+		return nil, fmt.Errorf("failed to query journal: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []JournalEntry
+	for rows.Next() {
+		e, err := scanJournalEntry(rows)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, *e)
+	}
+	if err := rows.Err(); err != nil {
+		logger.Log.Error("error iterating journal", "error", err)
+		return nil, fmt.Errorf("error iterating journal: %w", err)
+	}
+	return entries, nil
+}
+
+// scanJournalEntry reads a journal row selected in the column order used by ListJournal
+func scanJournalEntry(row rowScanner) (*JournalEntry, error) {
+	var (
+		e       JournalEntry
+		image   sql.NullString
+		undone  *time.Time
+		created time.Time
+	)
+	if err := row.Scan(&e.ID, &e.TicketID, &e.User, &e.Op, &image, &created, &undone); err != nil {
+		return nil, err
+	}
+	e.At, e.UndoneAt = created, undone
+	if image.Valid {
+		e.Before = &Ticket{}
+		if err := json.Unmarshal([]byte(image.String), e.Before); err != nil {
+			logger.Log.Error("failed to parse before-image", "error", err, "entry", e.ID)
+			return nil, fmt.Errorf("failed to parse before-image: %w", err)
+		}
+	}
+	return &e, nil
+}
+
+// Undo reverts the user's most recent change that has not been undone yet. It
+// refuses when someone else has changed the ticket since.
+func (s *SQLStore) Undo(ctx context.Context, user string) (*JournalEntry, error) {
+	logger.Log.Debug("undoing last change", "user", user)
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.Log.Error("failed to begin transaction", "error", err)
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	e, err := scanJournalEntry(tx.QueryRowContext(ctx, `
+		SELECT id, ticket_id, username, op, before_image, created_at, undone_at
+		FROM ticket_journal WHERE username = ? AND undone_at IS NULL`+undoableOps+`
+		ORDER BY id DESC LIMIT 1`, user))
+	if err == sql.ErrNoRows {
+		return nil, ErrNothingToUndo
+	}
+	if err != nil {
+		logger.Log.Error("failed to load journal entry", "error", err)
+		return nil, fmt.Errorf("failed to load journal entry: %w", err)
+	}
+
+	// Comments and checklist changes by others count too, though they are never undone
+	var other string
+	err = tx.QueryRowContext(ctx, `
+		SELECT username FROM ticket_journal
+		WHERE ticket_id = ? AND id > ? AND undone_at IS NULL AND username <> ?
+		ORDER BY id LIMIT 1`, e.TicketID, e.ID, user).Scan(&other)
+	if err == nil {
+		logger.Log.Error("ticket changed by another user", "ticket_id", e.TicketID, "user", other)
+		return nil, fmt.Errorf("cannot undo %s of ticket %d: %s has changed it since", e.Op, e.TicketID, other)
+	}
+	if err != sql.ErrNoRows {
+		logger.Log.Error("failed to check journal", "error", err)
+		return nil, fmt.Errorf("failed to check journal: %w", err)
+	}
+
+	if err := revert(ctx, tx, e); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if _, err := tx.ExecContext(ctx, "UPDATE ticket_journal SET undone_at = ? WHERE id = ?", now, e.ID); err != nil {
+		logger.Log.Error("failed to mark journal entry undone", "error", err, "entry", e.ID)
+		return nil, fmt.Errorf("failed to mark journal entry undone: %w", err)
+	}
+	e.UndoneAt = &now
+
+	if err := tx.Commit(); err != nil {
+		logger.Log.Error("failed to commit undo transaction", "error", err)
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	logger.Log.Info("change undone", "op", e.Op, "ticket_id", e.TicketID, "user", user)
+	return e, nil
+}
+
+// revert puts a ticket back the way it was before a journaled change
+func revert(ctx context.Context, tx *sql.Tx, e *JournalEntry) error {
+	var exists int
+	if err := tx.QueryRowContext(ctx, "SELECT COUNT(*) FROM tickets WHERE id = ?", e.TicketID).Scan(&exists); err != nil {
+		logger.Log.Error("failed to find ticket", "error", err)
+		return fmt.Errorf("failed to find ticket: %w", err)
+	}
+	if exists == 0 {
+		return fmt.Errorf("cannot undo %s of ticket %d: it has been purged", e.Op, e.TicketID)
+	}
+
+	switch e.Op {
+	case OpCreate:
+		// Undoing a create sends the ticket to the trash rather than destroying it
+		if _, err := tx.ExecContext(ctx, "UPDATE tickets SET deleted_at = ? WHERE id = ?", time.Now(), e.TicketID); err != nil {
+			logger.Log.Error("failed to undo create", "error", err, "ticket_id", e.TicketID)
+			return fmt.Errorf("failed to undo create: %w", err)
+		}
+
+	case OpDelete:
+		if _, err := tx.ExecContext(ctx, "UPDATE tickets SET deleted_at = NULL WHERE id = ?", e.TicketID); err != nil {
+			logger.Log.Error("failed to undo delete", "error", err, "ticket_id", e.TicketID)
+			return fmt.Errorf("failed to undo delete: %w", err)
+		}
+
+	case OpUpdate:
+		return restoreImage(ctx, tx, e)
+
+	default:
+		return fmt.Errorf("unknown journal operation: %s", e.Op)
+	}
+	return nil
+}
+
+// restoreImage writes a before-image back over a ticket. Comments are kept, as
+// they are added on their own rather than by updates.
+func restoreImage(ctx context.Context, tx *sql.Tx, e *JournalEntry) error {
+	b := e.Before
+	if b == nil {
+		return fmt.Errorf("journal entry %d has no before-image", e.ID)
+	}
+
+	var current Status
+	if err := tx.QueryRowContext(ctx, "SELECT status FROM tickets WHERE id = ?", e.TicketID).Scan(&current); err != nil {
+		logger.Log.Error("failed to load current status", "error", err)
+		return fmt.Errorf("failed to load current status: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE tickets SET
+			type = ?, title = ?, description = ?, critical_path = ?,
+			status = ?, priority = ?, assigned_to = ?, updated_at = ?,
+			due_at = ?, start_at = ?, story_points = ?, estimate_hours = ?, closed_at = ?, parent_id = ?
+		WHERE id = ?`,
+		b.Type, b.Title, b.Description, b.CriticalPath,
+		b.Status, b.Priority, b.AssignedTo, b.UpdatedAt,
+		b.DueAt, b.StartAt, b.StoryPoints, b.EstimateHours, b.ClosedAt, b.ParentID,
+		e.TicketID,
+	); err != nil {
+		logger.Log.Error("failed to restore ticket", "error", err, "ticket_id", e.TicketID)
+		return fmt.Errorf("failed to restore ticket: %w", err)
+	}
+
+	if current != b.Status {
+		if err := recordStatusChange(ctx, tx, e.TicketID, current, b.Status, time.Now()); err != nil {
+			return err
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM ticket_tags WHERE ticket_id = ?", e.TicketID); err != nil {
+		logger.Log.Error("failed to restore tags", "error", err)
+		return fmt.Errorf("failed to restore tags: %w", err)
+	}
+	for _, tag := range b.Tags {
+		if _, err := tx.ExecContext(ctx, "INSERT INTO ticket_tags (ticket_id, tag) VALUES (?, ?)", e.TicketID, tag); err != nil {
+			logger.Log.Error("failed to restore tag", "error", err, "tag", tag)
+			return fmt.Errorf("failed to restore tags: %w", err)
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM ticket_files WHERE ticket_id = ?", e.TicketID); err != nil {
+		logger.Log.Error("failed to restore files", "error", err)
+		return fmt.Errorf("failed to restore files: %w", err)
+	}
+	for _, file := range b.Files {
+		if _, err := tx.ExecContext(ctx, "INSERT INTO ticket_files (ticket_id, file_path) VALUES (?, ?)", e.TicketID, file); err != nil {
+			logger.Log.Error("failed to restore file", "error", err, "file", file)
+			return fmt.Errorf("failed to restore files: %w", err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,716 @@
+package ticket
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MemoryStore is a TicketStore that keeps everything in memory. It is meant for tests
+// and behaves like SQLStore, but nothing survives the process.
+type MemoryStore struct {
+	mu       sync.Mutex
+	nextID   int64
+	nextLog  int64
+	nextStep int64
+	nextItem int64
+	tickets  map[int64]*Ticket
+	history  map[int64][]StatusChange
+	commits  map[int64][]Commit
+	worklogs []Worklog
+	timers   map[string]Timer
+	journal  []JournalEntry
+	archived map[int64]*Ticket
+}
+
+// NewMemoryStore returns an empty in-memory TicketStore
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		tickets:  make(map[int64]*Ticket),
+		history:  make(map[int64][]StatusChange),
+		commits:  make(map[int64][]Commit),
+		timers:   make(map[string]Timer),
+		archived: make(map[int64]*Ticket),
+	}
+}
+
+// copyTicket returns a deep copy so callers never share slices with the store
+func copyTicket(t *Ticket) *Ticket {
+	c := *t
+	c.Tags = append([]string{}, t.Tags...)
+	c.Files = append([]string{}, t.Files...)
+	c.Comments = append([]string{}, t.Comments...)
+	c.Checklist = append([]CheckItem(nil), t.Checklist...)
+	return &c
+}
+
+// Create saves a new ticket in a project and sets its ID
+func (s *MemoryStore) Create(ctx context.Context, project string, t *Ticket) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.nextID++
+	t.ID = s.nextID
+	t.Project = project
+	closeOnCreate(t)
+	s.tickets[t.ID] = copyTicket(t)
+	s.history[t.ID] = append(s.history[t.ID], StatusChange{TicketID: t.ID, To: t.Status, ChangedAt: t.CreatedAt})
+	s.record(ctx, OpCreate, t.ID)
+	return nil
+}
+
+// Get loads a ticket by ID, regardless of which project it belongs to
+func (s *MemoryStore) Get(ctx context.Context, id int64) (*Ticket, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, ok := s.tickets[id]
+	if !ok || t.DeletedAt != nil {
+		return nil, fmt.Errorf("ticket %d not found", id)
+	}
+	return copyTicket(t), nil
+}
+
+// Find loads a ticket in a project by ID, or by title when id is 0
+func (s *MemoryStore) Find(ctx context.Context, project string, id int64, title string) (*Ticket, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if id == 0 {
+		if title == "" {
+			return nil, fmt.Errorf("either id or title must be provided")
+		}
+		for _, t := range s.tickets {
+			if t.Title == title && t.Project == project && t.DeletedAt == nil && (id == 0 || t.ID < id) {
+				id = t.ID
+			}
+		}
+		if id == 0 {
+			return nil, fmt.Errorf("no ticket found with title '%s'", title)
+		}
+	}
+
+	if !s.inProject(id, project) {
+		return nil, fmt.Errorf("ticket not found")
+	}
+	t := s.tickets[id]
+	return copyTicket(t), nil
+}
+
+// List returns the tickets matching the filters, newest first
+func (s *MemoryStore) List(ctx context.Context, filters Filters) ([]Ticket, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	var tickets []Ticket
+	for _, t := range s.tickets {
+		if t.DeletedAt != nil || !matches(t, filters, now) {
+			continue
+		}
+		tickets = append(tickets, *copyTicket(t))
+	}
+
+	sortNewestFirst(tickets)
+	return tickets, nil
+}
+
+// sortNewestFirst orders tickets by creation time, newest first, as SQLStore lists them
+func sortNewestFirst(tickets []Ticket) {
+	sort.SliceStable(tickets, func(i, j int) bool {
+		if !tickets[i].CreatedAt.Equal(tickets[j].CreatedAt) {
+			return tickets[i].CreatedAt.After(tickets[j].CreatedAt)
+		}
+		return tickets[i].ID > tickets[j].ID
+	})
+}
+
+// matches reports whether a ticket passes the filters
+func matches(t *Ticket, filters Filters, now time.Time) bool {
+	if filters.Status != nil && t.Status != *filters.Status {
+		return false
+	}
+	if filters.Type != nil && t.Type != *filters.Type {
+		return false
+	}
+	if filters.Priority != nil && t.Priority != *filters.Priority {
+		return false
+	}
+	if filters.AssignedTo != nil && (t.AssignedTo == nil || *t.AssignedTo != *filters.AssignedTo) {
+		return false
+	}
+	if filters.Project != nil && t.Project != *filters.Project {
+		return false
+	}
+	if filters.Parent != nil && (t.ParentID == nil || *t.ParentID != *filters.Parent) {
+		return false
+	}
+	if len(filters.Tags) > 0 && !hasAnyTag(t.Tags, filters.Tags) {
+		return false
+	}
+	if filters.Overdue && !t.IsOverdue(now) {
+		return false
+	}
+	if filters.DueWithin > 0 && (t.DueAt == nil || t.Status == StatusClosed || t.DueAt.After(now.Add(filters.DueWithin))) {
+		return false
+	}
+	if filters.IncompleteChecklist && !t.HasIncompleteChecklist() {
+		return false
+	}
+	return true
+}
+
+// hasAnyTag reports whether tags contains at least one of wanted
+func hasAnyTag(tags, wanted []string) bool {
+	for _, tag := range tags {
+		for _, w := range wanted {
+			if tag == w {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Update saves every field of t, which must belong to project
+func (s *MemoryStore) Update(ctx context.Context, project string, t *Ticket) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.inProject(t.ID, project) {
+		return fmt.Errorf("no ticket found with the provided identifier")
+	}
+	s.record(ctx, OpUpdate, t.ID)
+	s.update(project, t)
+	return nil
+}
+
+// UpdateMany saves several tickets, each in its own project, or none if any is missing
+func (s *MemoryStore) UpdateMany(ctx context.Context, tickets []Ticket) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, t := range tickets {
+		if !s.inProject(t.ID, t.Project) {
+			return fmt.Errorf("ticket %d: no ticket found with the provided identifier", t.ID)
+		}
+	}
+	for i := range tickets {
+		s.record(ctx, OpUpdate, tickets[i].ID)
+		s.update(tickets[i].Project, &tickets[i])
+	}
+	return nil
+}
+
+// inProject reports whether the ticket exists outside the trash and belongs to project
+func (s *MemoryStore) inProject(id int64, project string) bool {
+	t, ok := s.tickets[id]
+	return ok && t.Project == project && t.DeletedAt == nil
+}
+
+// update replaces an existing ticket; the caller holds the lock
+func (s *MemoryStore) update(project string, t *Ticket) {
+	existing := s.tickets[t.ID]
+	now := time.Now()
+	updated := copyTicket(t)
+	updated.Project = project
+	updated.CreatedBy = existing.CreatedBy
+	updated.CreatedAt = existing.CreatedAt
+	updated.UpdatedAt = now
+	updated.Comments = existing.Comments
+	updated.Checklist = existing.Checklist
+	updated.DeletedAt = existing.DeletedAt
+	updated.ClosedAt = nil
+	if t.Status == StatusClosed {
+		updated.ClosedAt = existing.ClosedAt
+		if updated.ClosedAt == nil {
+			updated.ClosedAt = &now
+		}
+	}
+
+	if existing.Status != t.Status {
+		s.history[t.ID] = append(s.history[t.ID], StatusChange{TicketID: t.ID, From: existing.Status, To: t.Status, ChangedAt: now})
+	}
+	s.tickets[t.ID] = updated
+}
+
+// Delete moves a ticket in a project to the trash
+func (s *MemoryStore) Delete(ctx context.Context, project string, id int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.inProject(id, project) {
+		return fmt.Errorf("no ticket found with the provided identifier")
+	}
+	s.record(ctx, OpDelete, id)
+	now := time.Now()
+	s.tickets[id].DeletedAt = &now
+	return nil
+}
+
+// DeleteMany moves several tickets, each in its own project, to the trash, or none if any is missing
+func (s *MemoryStore) DeleteMany(ctx context.Context, tickets []Ticket) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, t := range tickets {
+		if !s.inProject(t.ID, t.Project) {
+			return fmt.Errorf("ticket %d: no ticket found with the provided identifier", t.ID)
+		}
+	}
+	now := time.Now()
+	for _, t := range tickets {
+		s.record(ctx, OpDelete, t.ID)
+		s.tickets[t.ID].DeletedAt = &now
+	}
+	return nil
+}
+
+// ListTrash returns deleted tickets, optionally only those in project, most recently deleted first
+func (s *MemoryStore) ListTrash(ctx context.Context, project string) ([]Ticket, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var tickets []Ticket
+	for _, t := range s.tickets {
+		if t.DeletedAt != nil && (project == "" || t.Project == project) {
+			tickets = append(tickets, *copyTicket(t))
+		}
+	}
+	sort.SliceStable(tickets, func(i, j int) bool {
+		return tickets[i].DeletedAt.After(*tickets[j].DeletedAt)
+	})
+	return tickets, nil
+}
+
+// Restore takes a ticket out of the trash
+func (s *MemoryStore) Restore(ctx context.Context, id int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, ok := s.tickets[id]
+	if !ok || t.DeletedAt == nil {
+		return fmt.Errorf("ticket %d is not in the trash", id)
+	}
+	t.DeletedAt = nil
+	return nil
+}
+
+// Purge permanently removes tickets deleted before the cutoff and returns them
+func (s *MemoryStore) Purge(ctx context.Context, before time.Time) ([]Ticket, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var purged []Ticket
+	for id, t := range s.tickets {
+		if t.DeletedAt != nil && t.DeletedAt.Before(before) {
+			purged = append(purged, *copyTicket(t))
+			s.delete(id)
+		}
+	}
+	return purged, nil
+}
+
+// delete permanently removes a ticket and everything attached to it; the caller holds the lock
+func (s *MemoryStore) delete(id int64) {
+	delete(s.tickets, id)
+	delete(s.history, id)
+	delete(s.commits, id)
+
+	for _, t := range s.tickets {
+		if t.ParentID != nil && *t.ParentID == id {
+			t.ParentID = nil
+		}
+	}
+
+	entries := s.journal[:0]
+	for _, e := range s.journal {
+		if e.TicketID != id {
+			entries = append(entries, e)
+		}
+	}
+	s.journal = entries
+
+	logs := s.worklogs[:0]
+	for _, w := range s.worklogs {
+		if w.TicketID != id {
+			logs = append(logs, w)
+		}
+	}
+	s.worklogs = logs
+
+	for user, timer := range s.timers {
+		if timer.TicketID == id {
+			delete(s.timers, user)
+		}
+	}
+}
+
+// AddComment appends a comment to a ticket and marks the ticket as updated
+func (s *MemoryStore) AddComment(ctx context.Context, ticketID int64, text string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, ok := s.tickets[ticketID]
+	if !ok {
+		return fmt.Errorf("ticket %d not found", ticketID)
+	}
+	s.record(ctx, OpComment, ticketID)
+	t.Comments = append(t.Comments, text)
+	t.UpdatedAt = time.Now()
+	return nil
+}
+
+// AddCheckItem appends an item to a ticket's checklist and marks the ticket as updated
+func (s *MemoryStore) AddCheckItem(ctx context.Context, ticketID int64, text string) (*CheckItem, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, ok := s.tickets[ticketID]
+	if !ok || t.DeletedAt != nil {
+		return nil, fmt.Errorf("ticket %d not found", ticketID)
+	}
+	s.record(ctx, OpChecklist, ticketID)
+	s.nextItem++
+	now := time.Now()
+	item := CheckItem{ID: s.nextItem, Text: text, CreatedAt: now}
+	t.Checklist = append(t.Checklist, item)
+	t.UpdatedAt = now
+	return &item, nil
+}
+
+// SetCheckItemDone checks off item number n of a ticket's checklist, or unchecks it
+func (s *MemoryStore) SetCheckItemDone(ctx context.Context, ticketID int64, n int, done bool) (*CheckItem, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, ok := s.tickets[ticketID]
+	if !ok || t.DeletedAt != nil {
+		return nil, fmt.Errorf("ticket %d not found", ticketID)
+	}
+	if _, err := checklistItem(t.Checklist, ticketID, n); err != nil {
+		return nil, err
+	}
+	s.record(ctx, OpChecklist, ticketID)
+	now := time.Now()
+	item := &t.Checklist[n-1]
+	switch {
+	case done && item.DoneAt == nil:
+		item.DoneAt = &now
+	case !done:
+		item.DoneAt = nil
+	}
+	t.UpdatedAt = now
+	updated := *item
+	return &updated, nil
+}
+
+// RemoveCheckItem removes item number n from a ticket's checklist
+func (s *MemoryStore) RemoveCheckItem(ctx context.Context, ticketID int64, n int) (*CheckItem, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, ok := s.tickets[ticketID]
+	if !ok || t.DeletedAt != nil {
+		return nil, fmt.Errorf("ticket %d not found", ticketID)
+	}
+	item, err := checklistItem(t.Checklist, ticketID, n)
+	if err != nil {
+		return nil, err
+	}
+	s.record(ctx, OpChecklist, ticketID)
+	t.Checklist = append(append([]CheckItem(nil), t.Checklist[:n-1]...), t.Checklist[n:]...)
+	t.UpdatedAt = time.Now()
+	return item, nil
+}
+
+// StatusHistory returns every recorded status change grouped by ticket, oldest first
+func (s *MemoryStore) StatusHistory(ctx context.Context) (map[int64][]StatusChange, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	history := make(map[int64][]StatusChange, len(s.history))
+	for id, changes := range s.history {
+		history[id] = append([]StatusChange{}, changes...)
+	}
+	return history, nil
+}
+
+// LinkCommit records a commit against a ticket, reporting whether it was new
+func (s *MemoryStore) LinkCommit(ctx context.Context, ticketID int64, c Commit) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.tickets[ticketID]; !ok {
+		return false, fmt.Errorf("failed to link commit: ticket %d not found", ticketID)
+	}
+	for _, existing := range s.commits[ticketID] {
+		if existing.SHA == c.SHA {
+			return false, nil
+		}
+	}
+	s.commits[ticketID] = append(s.commits[ticketID], c)
+	return true, nil
+}
+
+// ListCommits returns the commits linked to a ticket, oldest first
+func (s *MemoryStore) ListCommits(ctx context.Context, ticketID int64) ([]Commit, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	commits := append([]Commit(nil), s.commits[ticketID]...)
+	sort.SliceStable(commits, func(i, j int) bool {
+		return commits[i].CommittedAt.Before(commits[j].CommittedAt)
+	})
+	return commits, nil
+}
+
+// AddWorklog records time spent on a ticket and sets the worklog's ID
+func (s *MemoryStore) AddWorklog(ctx context.Context, w *Worklog) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !w.EndedAt.After(w.StartedAt) {
+		return fmt.Errorf("worklog must end after it starts")
+	}
+	s.addWorklog(w)
+	return nil
+}
+
+// addWorklog assigns an ID and stores a worklog; the caller holds the lock
+func (s *MemoryStore) addWorklog(w *Worklog) {
+	s.nextLog++
+	w.ID = s.nextLog
+	s.worklogs = append(s.worklogs, *w)
+}
+
+// ListWorklogs returns worklogs, optionally limited to a ticket, a user and a start time
+func (s *MemoryStore) ListWorklogs(ctx context.Context, ticketID int64, user string, since time.Time) ([]Worklog, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var logs []Worklog
+	for _, w := range s.worklogs {
+		if w.StartedAt.Before(since) {
+			continue
+		}
+		if ticketID != 0 && w.TicketID != ticketID {
+			continue
+		}
+		if user != "" && w.User != user {
+			continue
+		}
+		logs = append(logs, w)
+	}
+
+	sort.SliceStable(logs, func(i, j int) bool {
+		return logs[i].StartedAt.Before(logs[j].StartedAt)
+	})
+	return logs, nil
+}
+
+// TotalLogged sums the time logged against a ticket and counts its worklogs
+func (s *MemoryStore) TotalLogged(ctx context.Context, ticketID int64) (time.Duration, int, error) {
+	logs, err := s.ListWorklogs(ctx, ticketID, "", time.Time{})
+	if err != nil {
+		return 0, 0, err
+	}
+	return totalDuration(logs), len(logs), nil
+}
+
+// LoggedByTicket sums the time logged against every ticket
+func (s *MemoryStore) LoggedByTicket(ctx context.Context) (map[int64]time.Duration, error) {
+	logs, err := s.ListWorklogs(ctx, 0, "", time.Time{})
+	if err != nil {
+		return nil, err
+	}
+	return durationByTicket(logs), nil
+}
+
+// GetTimer returns the user's running timer, or nil if none is running
+func (s *MemoryStore) GetTimer(ctx context.Context, user string) (*Timer, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	timer, ok := s.timers[user]
+	if !ok {
+		return nil, nil
+	}
+	return &timer, nil
+}
+
+// StartTimer starts a timer, stopping and returning any timer the user had running
+func (s *MemoryStore) StartTimer(ctx context.Context, timer *Timer) (*Worklog, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var stopped *Worklog
+	if _, ok := s.timers[timer.User]; ok {
+		w, err := s.stopTimer(timer.User, timer.StartedAt, "")
+		if err != nil {
+			return nil, err
+		}
+		stopped = w
+	}
+	s.timers[timer.User] = *timer
+	return stopped, nil
+}
+
+// StopTimer stops the user's running timer and records it as a worklog
+func (s *MemoryStore) StopTimer(ctx context.Context, user string, endedAt time.Time, note string) (*Worklog, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.stopTimer(user, endedAt, note)
+}
+
+// stopTimer does the work of StopTimer; the caller holds the lock
+func (s *MemoryStore) stopTimer(user string, endedAt time.Time, note string) (*Worklog, error) {
+	timer, ok := s.timers[user]
+	if !ok {
+		return nil, fmt.Errorf("no timer is running for %s", user)
+	}
+
+	w := &Worklog{TicketID: timer.TicketID, User: user, StartedAt: timer.StartedAt, EndedAt: endedAt, Note: timer.Note}
+	if note != "" {
+		w.Note = note
+	}
+	// A timer stopped within the same second still records a minimal entry
+	if !w.EndedAt.After(w.StartedAt) {
+		w.EndedAt = w.StartedAt.Add(time.Second)
+	}
+
+	s.addWorklog(w)
+	delete(s.timers, user)
+	return w, nil
+}
+
+// record journals a change to a ticket before it is made; the caller holds the lock
+func (s *MemoryStore) record(ctx context.Context, op string, id int64) {
+	s.nextStep++
+	e := JournalEntry{ID: s.nextStep, TicketID: id, User: ActorFrom(ctx), Op: op, At: time.Now()}
+	if op == OpUpdate || op == OpDelete {
+		e.Before = copyTicket(s.tickets[id])
+	}
+	s.journal = append(s.journal, e)
+}
+
+// ListJournal returns the user's changes that can still be undone, newest first
+func (s *MemoryStore) ListJournal(ctx context.Context, user string, limit int) ([]JournalEntry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var entries []JournalEntry
+	for i := len(s.journal) - 1; i >= 0 && len(entries) < limit; i-- {
+		if e := s.journal[i]; e.User == user && e.UndoneAt == nil && undoable(e.Op) {
+			entries = append(entries, e)
+		}
+	}
+	return entries, nil
+}
+
+// Undo reverts the user's most recent change that has not been undone yet
+func (s *MemoryStore) Undo(ctx context.Context, user string) (*JournalEntry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := len(s.journal) - 1
+	for ; i >= 0; i-- {
+		if e := s.journal[i]; e.User == user && e.UndoneAt == nil && undoable(e.Op) {
+			break
+		}
+	}
+	if i < 0 {
+		return nil, ErrNothingToUndo
+	}
+	e := &s.journal[i]
+
+	for _, later := range s.journal[i+1:] {
+		if later.TicketID == e.TicketID && later.UndoneAt == nil && later.User != user {
+			return nil, fmt.Errorf("cannot undo %s of ticket %d: %s has changed it since", e.Op, e.TicketID, later.User)
+		}
+	}
+
+	t, ok := s.tickets[e.TicketID]
+	if !ok {
+		return nil, fmt.Errorf("cannot undo %s of ticket %d: it has been purged", e.Op, e.TicketID)
+	}
+
+	now := time.Now()
+	switch e.Op {
+	case OpCreate:
+		t.DeletedAt = &now
+	case OpDelete:
+		t.DeletedAt = nil
+	case OpUpdate:
+		restored := copyTicket(e.Before)
+		restored.Project = t.Project
+		restored.CreatedBy = t.CreatedBy
+		restored.CreatedAt = t.CreatedAt
+		restored.DeletedAt = t.DeletedAt
+		restored.Comments = t.Comments
+		restored.Checklist = t.Checklist
+		if t.Status != restored.Status {
+			s.history[t.ID] = append(s.history[t.ID], StatusChange{TicketID: t.ID, From: t.Status, To: restored.Status, ChangedAt: now})
+		}
+		s.tickets[t.ID] = restored
+	default:
+		return nil, fmt.Errorf("unknown journal operation: %s", e.Op)
+	}
+
+	e.UndoneAt = &now
+	undone := *e
+	return &undone, nil
+}
+
+// Archive moves a project's tickets closed before the cutoff to the archive and
+// returns them. What is attached to them leaves the live store with them.
+func (s *MemoryStore) Archive(ctx context.Context, project string, closedBefore time.Time) ([]Ticket, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	var archived []Ticket
+	for id, t := range s.tickets {
+		if t.Project != project || t.DeletedAt != nil || t.Status != StatusClosed || t.ClosedAt == nil || !t.ClosedAt.Before(closedBefore) {
+			continue
+		}
+		a := copyTicket(t)
+		a.ArchivedAt = &now
+		s.archived[id] = a
+		s.delete(id)
+		archived = append(archived, *copyTicket(a))
+	}
+	sortNewestFirst(archived)
+	return archived, nil
+}
+
+// ListArchived returns archived tickets matching the filters, newest first
+func (s *MemoryStore) ListArchived(ctx context.Context, filters Filters) ([]Ticket, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	var tickets []Ticket
+	for _, t := range s.archived {
+		if matches(t, filters, now) {
+			tickets = append(tickets, *copyTicket(t))
+		}
+	}
+	sortNewestFirst(tickets)
+	return tickets, nil
+}
+
+// GetArchived loads an archived ticket by ID
+func (s *MemoryStore) GetArchived(ctx context.Context, id int64) (*Ticket, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, ok := s.archived[id]
+	if !ok {
+		return nil, fmt.Errorf("ticket %d not found in the archive", id)
+	}
+	return copyTicket(t), nil
+}
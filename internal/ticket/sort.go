@@ -0,0 +1,93 @@
+package ticket
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// SortFields lists the fields tickets can be sorted by
+var SortFields = []string{"id", "created", "updated", "due", "priority", "status", "title"}
+
+// priorityRank orders priorities from least to most urgent
+var priorityRank = map[Priority]int{
+	PriorityUndefined: 0,
+	PriorityLow:       1,
+	PriorityMedium:    2,
+	PriorityHigh:      3,
+}
+
+// ValidateSort checks a sort specification such as "due", "priority:desc" or "created:asc"
+func ValidateSort(spec string) error {
+	_, _, err := parseSort(spec)
+	return err
+}
+
+// SortTickets orders tickets in place by a sort specification of the form field[:asc|desc].
+// Tickets without a due date sort after those with one.
+func SortTickets(tickets []Ticket, spec string) error {
+	field, desc, err := parseSort(spec)
+	if err != nil {
+		return err
+	}
+
+	less := func(a, b *Ticket) bool {
+		switch field {
+		case "id":
+			return a.ID < b.ID
+		case "created":
+			return a.CreatedAt.Before(b.CreatedAt)
+		case "updated":
+			return a.UpdatedAt.Before(b.UpdatedAt)
+		case "priority":
+			return priorityRank[a.Priority] < priorityRank[b.Priority]
+		case "status":
+			return a.Status < b.Status
+		case "title":
+			return strings.ToLower(a.Title) < strings.ToLower(b.Title)
+		}
+		return false
+	}
+
+	sort.SliceStable(tickets, func(i, j int) bool {
+		a, b := &tickets[i], &tickets[j]
+		if field == "due" {
+			if a.DueAt == nil || b.DueAt == nil {
+				return a.DueAt != nil && b.DueAt == nil
+			}
+			if desc {
+				return a.DueAt.After(*b.DueAt)
+			}
+			return a.DueAt.Before(*b.DueAt)
+		}
+		if desc {
+			return less(b, a)
+		}
+		return less(a, b)
+	})
+	return nil
+}
+
+// parseSort splits a sort specification into its field and direction
+func parseSort(spec string) (string, bool, error) {
+	field, dir, _ := strings.Cut(strings.ToLower(strings.TrimSpace(spec)), ":")
+
+	valid := false
+	for _, f := range SortFields {
+		if f == field {
+			valid = true
+			break
+		}
+	}
+	if !valid {
+		return "", false, fmt.Errorf("invalid sort field: %s (must be one of: %s)", field, strings.Join(SortFields, ", "))
+	}
+
+	switch dir {
+	case "", "asc":
+		return field, false, nil
+	case "desc":
+		return field, true, nil
+	}
+	return "", false, fmt.Errorf("invalid sort direction: %s (must be asc or desc)", dir)
+}
@@ -0,0 +1,97 @@
+package ticket
+
+import (
+	"context"
+	"time"
+)
+
+// TicketStore reads and writes tickets and the records attached to them. SQLStore
+// keeps them in a database; MemoryStore keeps them in memory for tests.
+type TicketStore interface {
+	// Create saves a new ticket in a project and sets its ID
+	Create(ctx context.Context, project string, t *Ticket) error
+	// Get loads a ticket by ID, regardless of which project it belongs to
+	Get(ctx context.Context, id int64) (*Ticket, error)
+	// Find loads a ticket in a project by ID, or by title when id is 0
+	Find(ctx context.Context, project string, id int64, title string) (*Ticket, error)
+	// List returns the tickets matching the filters, newest first
+	List(ctx context.Context, filters Filters) ([]Ticket, error)
+	// Update saves every field of t, which must belong to project. Tags and files are
+	// replaced and a status change is recorded in the history. Comments and the
+	// checklist are left as they are; comments are added with AddComment.
+	Update(ctx context.Context, project string, t *Ticket) error
+	// Delete moves a ticket in a project to the trash. Tickets in the trash are left
+	// out of Get, Find and List but keep everything attached to them until purged.
+	Delete(ctx context.Context, project string, id int64) error
+	// UpdateMany saves several tickets as Update does, each in its own project. Either
+	// every ticket is saved or, if any fails, none is.
+	UpdateMany(ctx context.Context, tickets []Ticket) error
+	// DeleteMany moves several tickets to the trash as Delete does, all or none
+	DeleteMany(ctx context.Context, tickets []Ticket) error
+
+	// ListTrash returns deleted tickets, optionally only those in project, most recently deleted first
+	ListTrash(ctx context.Context, project string) ([]Ticket, error)
+	// Restore takes a ticket out of the trash
+	Restore(ctx context.Context, id int64) error
+	// Purge permanently removes tickets deleted before the cutoff, with everything
+	// attached to them, and returns them
+	Purge(ctx context.Context, before time.Time) ([]Ticket, error)
+
+	// Archive moves a project's tickets closed before the cutoff, with everything
+	// attached to them, out of the live tickets and returns them. Archived tickets are
+	// only reachable through ListArchived and GetArchived.
+	Archive(ctx context.Context, project string, closedBefore time.Time) ([]Ticket, error)
+	// ListArchived returns archived tickets matching the filters, newest first
+	ListArchived(ctx context.Context, filters Filters) ([]Ticket, error)
+	// GetArchived loads an archived ticket by ID
+	GetArchived(ctx context.Context, id int64) (*Ticket, error)
+
+	// Undo reverts the user's most recent create, update or delete that has not been
+	// undone yet, returning ErrNothingToUndo when there is none. It refuses when
+	// another user has changed the ticket since. Changes are attributed to the user
+	// set on their context with WithActor.
+	Undo(ctx context.Context, user string) (*JournalEntry, error)
+	// ListJournal returns up to limit of the user's changes that can still be undone, newest first
+	ListJournal(ctx context.Context, user string, limit int) ([]JournalEntry, error)
+
+	// AddComment appends a comment to a ticket and marks the ticket as updated
+	AddComment(ctx context.Context, ticketID int64, text string) error
+
+	// AddCheckItem appends an item to a ticket's checklist and marks the ticket as updated
+	AddCheckItem(ctx context.Context, ticketID int64, text string) (*CheckItem, error)
+	// SetCheckItemDone checks off item number n (counting from 1) of a ticket's
+	// checklist, or unchecks it when done is false
+	SetCheckItemDone(ctx context.Context, ticketID int64, n int, done bool) (*CheckItem, error)
+	// RemoveCheckItem removes item number n (counting from 1) from a ticket's checklist
+	RemoveCheckItem(ctx context.Context, ticketID int64, n int) (*CheckItem, error)
+
+	// StatusHistory returns every recorded status change grouped by ticket, oldest first
+	StatusHistory(ctx context.Context) (map[int64][]StatusChange, error)
+
+	// LinkCommit records a commit against a ticket, reporting whether it was new
+	LinkCommit(ctx context.Context, ticketID int64, c Commit) (bool, error)
+	// ListCommits returns the commits linked to a ticket, oldest first
+	ListCommits(ctx context.Context, ticketID int64) ([]Commit, error)
+
+	// AddWorklog records time spent on a ticket and sets the worklog's ID
+	AddWorklog(ctx context.Context, w *Worklog) error
+	// ListWorklogs returns worklogs, optionally limited to a ticket (non-zero), a user
+	// (non-empty) and entries started at or after since (non-zero)
+	ListWorklogs(ctx context.Context, ticketID int64, user string, since time.Time) ([]Worklog, error)
+	// TotalLogged sums the time logged against a ticket and counts its worklogs
+	TotalLogged(ctx context.Context, ticketID int64) (time.Duration, int, error)
+	// LoggedByTicket sums the time logged against every ticket
+	LoggedByTicket(ctx context.Context) (map[int64]time.Duration, error)
+	// GetTimer returns the user's running timer, or nil if none is running
+	GetTimer(ctx context.Context, user string) (*Timer, error)
+	// StartTimer starts a timer, stopping and returning any timer the user had running
+	StartTimer(ctx context.Context, timer *Timer) (*Worklog, error)
+	// StopTimer stops the user's running timer and records it as a worklog
+	StopTimer(ctx context.Context, user string, endedAt time.Time, note string) (*Worklog, error)
+}
+
+// Both implementations must satisfy the interface
+var (
+	_ TicketStore = (*SQLStore)(nil)
+	_ TicketStore = (*MemoryStore)(nil)
+)
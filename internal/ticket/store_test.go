@@ -0,0 +1,663 @@
+package ticket_test
+
+import (
+	"alexandria/internal/database"
+	"alexandria/internal/testdb"
+	"alexandria/internal/ticket"
+	"context"
+	"testing"
+	"time"
+)
+
+// stores returns a fresh instance of every TicketStore implementation, so each test
+// checks that they behave the same
+func stores(t *testing.T) map[string]ticket.TicketStore {
+	return map[string]ticket.TicketStore{
+		"sql":    ticket.NewSQLStore(testdb.Open(t)),
+		"memory": ticket.NewMemoryStore(),
+	}
+}
+
+func newTicket(title string) *ticket.Ticket {
+	now := time.Now()
+	return &ticket.Ticket{
+		Title:     title,
+		Type:      ticket.TypeTask,
+		Status:    ticket.StatusOpen,
+		Priority:  ticket.PriorityMedium,
+		Tags:      []string{"backend"},
+		Comments:  []string{"first"},
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
+
+func mustCreate(t *testing.T, store ticket.TicketStore, project string, tk *ticket.Ticket) {
+	t.Helper()
+	if err := store.Create(context.Background(), project, tk); err != nil {
+		t.Fatalf("failed to create ticket: %v", err)
+	}
+}
+
+func TestCreateAndFind(t *testing.T) {
+	for name, store := range stores(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			tk := newTicket("Write docs")
+			mustCreate(t, store, "alx", tk)
+			if tk.ID == 0 || tk.Project != "alx" {
+				t.Fatalf("create did not set ID and project: %+v", tk)
+			}
+
+			byTitle, err := store.Find(ctx, "alx", 0, "Write docs")
+			if err != nil {
+				t.Fatalf("find by title: %v", err)
+			}
+			if byTitle.ID != tk.ID || len(byTitle.Tags) != 1 || len(byTitle.Comments) != 1 {
+				t.Errorf("find by title returned %+v", byTitle)
+			}
+
+			if _, err := store.Find(ctx, "other", tk.ID, ""); err == nil {
+				t.Error("expected find in another project to fail")
+			}
+			if _, err := store.Get(ctx, tk.ID+100); err == nil {
+				t.Error("expected get of a missing ticket to fail")
+			}
+
+			closed := newTicket("Already done")
+			closed.Status = ticket.StatusClosed
+			mustCreate(t, store, "alx", closed)
+			if got, err := store.Get(ctx, closed.ID); err != nil || got.ClosedAt == nil || !got.ClosedAt.Equal(closed.CreatedAt) {
+				t.Errorf("expected a ticket created closed to be closed when created, got %+v (%v)", got, err)
+			}
+		})
+	}
+}
+
+func TestUpdateRecordsHistory(t *testing.T) {
+	for name, store := range stores(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			tk := newTicket("Fix login")
+			mustCreate(t, store, "alx", tk)
+
+			tk.Status = ticket.StatusClosed
+			tk.Tags = []string{"auth", "urgent"}
+			tk.Comments = []string{"first", "not added by update"}
+			if err := store.Update(ctx, "alx", tk); err != nil {
+				t.Fatalf("update: %v", err)
+			}
+
+			got, err := store.Get(ctx, tk.ID)
+			if err != nil {
+				t.Fatalf("get: %v", err)
+			}
+			if got.Status != ticket.StatusClosed || got.ClosedAt == nil {
+				t.Errorf("status not closed: %+v", got)
+			}
+			if len(got.Tags) != 2 {
+				t.Errorf("tags not replaced: %v", got.Tags)
+			}
+			if len(got.Comments) != 1 {
+				t.Errorf("expected update to leave comments alone: %v", got.Comments)
+			}
+
+			history, err := store.StatusHistory(ctx)
+			if err != nil {
+				t.Fatalf("status history: %v", err)
+			}
+			if changes := history[tk.ID]; len(changes) != 2 || changes[1].From != ticket.StatusOpen {
+				t.Errorf("unexpected history: %+v", changes)
+			}
+
+			if err := store.Update(ctx, "other", tk); err == nil {
+				t.Error("expected update in another project to fail")
+			}
+		})
+	}
+}
+
+func TestListFilters(t *testing.T) {
+	for name, store := range stores(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			first := newTicket("First")
+			first.CreatedAt = time.Now().Add(-time.Hour)
+			mustCreate(t, store, "alx", first)
+			second := newTicket("Second")
+			second.Priority = ticket.PriorityHigh
+			mustCreate(t, store, "alx", second)
+			mustCreate(t, store, "other", newTicket("Elsewhere"))
+
+			project := "alx"
+			all, err := store.List(ctx, ticket.Filters{Project: &project})
+			if err != nil {
+				t.Fatalf("list: %v", err)
+			}
+			if len(all) != 2 || all[0].ID != second.ID {
+				t.Errorf("expected newest first in project, got %+v", all)
+			}
+
+			high := ticket.PriorityHigh
+			filtered, err := store.List(ctx, ticket.Filters{Priority: &high})
+			if err != nil {
+				t.Fatalf("list: %v", err)
+			}
+			if len(filtered) != 1 || filtered[0].ID != second.ID {
+				t.Errorf("priority filter returned %+v", filtered)
+			}
+		})
+	}
+}
+
+func TestDeleteMovesToTrashUntilPurged(t *testing.T) {
+	for name, store := range stores(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			tk := newTicket("Remove me")
+			mustCreate(t, store, "alx", tk)
+			start := time.Now().Add(-time.Hour)
+			if err := store.AddWorklog(ctx, &ticket.Worklog{TicketID: tk.ID, User: "sam", StartedAt: start, EndedAt: start.Add(30 * time.Minute)}); err != nil {
+				t.Fatalf("add worklog: %v", err)
+			}
+
+			if err := store.Delete(ctx, "other", tk.ID); err == nil {
+				t.Error("expected delete in another project to fail")
+			}
+			if err := store.Delete(ctx, "alx", tk.ID); err != nil {
+				t.Fatalf("delete: %v", err)
+			}
+			if _, err := store.Get(ctx, tk.ID); err == nil {
+				t.Error("deleted ticket still visible to get")
+			}
+			if _, err := store.Find(ctx, "alx", 0, "Remove me"); err == nil {
+				t.Error("deleted ticket still visible to find")
+			}
+			if all, _ := store.List(ctx, ticket.Filters{}); len(all) != 0 {
+				t.Errorf("deleted ticket still listed: %+v", all)
+			}
+			if err := store.Delete(ctx, "alx", tk.ID); err == nil {
+				t.Error("expected deleting a ticket twice to fail")
+			}
+
+			trash, err := store.ListTrash(ctx, "alx")
+			if err != nil || len(trash) != 1 || trash[0].DeletedAt == nil || len(trash[0].Tags) != 1 {
+				t.Fatalf("trash = %+v (%v)", trash, err)
+			}
+
+			if err := store.Restore(ctx, tk.ID); err != nil {
+				t.Fatalf("restore: %v", err)
+			}
+			if got, err := store.Get(ctx, tk.ID); err != nil || got.DeletedAt != nil || len(got.Comments) != 1 {
+				t.Errorf("restored ticket = %+v (%v)", got, err)
+			}
+			if err := store.Restore(ctx, tk.ID); err == nil {
+				t.Error("expected restoring a ticket not in the trash to fail")
+			}
+
+			if err := store.Delete(ctx, "alx", tk.ID); err != nil {
+				t.Fatalf("delete: %v", err)
+			}
+			if purged, err := store.Purge(ctx, time.Now().Add(-time.Hour)); err != nil || len(purged) != 0 {
+				t.Errorf("purge before the deletion removed %+v (%v)", purged, err)
+			}
+			if purged, err := store.Purge(ctx, time.Now().Add(time.Second)); err != nil || len(purged) != 1 {
+				t.Fatalf("purge = %+v (%v)", purged, err)
+			}
+			if trash, _ := store.ListTrash(ctx, ""); len(trash) != 0 {
+				t.Errorf("purged ticket still in trash: %+v", trash)
+			}
+			if logs, _ := store.ListWorklogs(ctx, tk.ID, "", time.Time{}); len(logs) != 0 {
+				t.Errorf("worklogs not purged: %+v", logs)
+			}
+		})
+	}
+}
+
+func TestBulkChangesAreAllOrNothing(t *testing.T) {
+	for name, store := range stores(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			a, b := newTicket("A"), newTicket("B")
+			mustCreate(t, store, "alx", a)
+			mustCreate(t, store, "alx", b)
+
+			// A ticket claimed for the wrong project fails the whole batch
+			first, second := *a, *b
+			first.Status, second.Status = ticket.StatusClosed, ticket.StatusClosed
+			second.Project = "other"
+			if err := store.UpdateMany(ctx, []ticket.Ticket{first, second}); err == nil {
+				t.Fatal("expected update of a ticket in the wrong project to fail")
+			}
+			if got, _ := store.Get(ctx, a.ID); got.Status != ticket.StatusOpen {
+				t.Errorf("first ticket was updated despite the failure: %s", got.Status)
+			}
+
+			second.Project = "alx"
+			if err := store.UpdateMany(ctx, []ticket.Ticket{first, second}); err != nil {
+				t.Fatalf("update many: %v", err)
+			}
+			for _, id := range []int64{a.ID, b.ID} {
+				got, _ := store.Get(ctx, id)
+				if got.Status != ticket.StatusClosed || len(got.Comments) != 1 {
+					t.Errorf("ticket %d after update many: %+v", id, got)
+				}
+			}
+
+			if err := store.DeleteMany(ctx, []ticket.Ticket{{ID: a.ID, Project: "alx"}, {ID: b.ID + 100, Project: "alx"}}); err == nil {
+				t.Fatal("expected delete of a missing ticket to fail")
+			}
+			if _, err := store.Get(ctx, a.ID); err != nil {
+				t.Errorf("first ticket was deleted despite the failure: %v", err)
+			}
+			if err := store.DeleteMany(ctx, []ticket.Ticket{*a, *b}); err != nil {
+				t.Fatalf("delete many: %v", err)
+			}
+			if _, err := store.Get(ctx, b.ID); err == nil {
+				t.Error("ticket still exists after delete many")
+			}
+		})
+	}
+}
+
+func TestUndoRevertsChangesInOrder(t *testing.T) {
+	for name, store := range stores(t) {
+		t.Run(name, func(t *testing.T) {
+			alice := ticket.WithActor(context.Background(), "alice")
+			bob := ticket.WithActor(context.Background(), "bob")
+
+			tk := newTicket("Original")
+			if err := store.Create(alice, "alx", tk); err != nil {
+				t.Fatalf("create: %v", err)
+			}
+
+			edit := *tk
+			edit.Title = "Edited"
+			edit.Status = ticket.StatusClosed
+			edit.Tags = []string{"frontend"}
+			if err := store.Update(alice, "alx", &edit); err != nil {
+				t.Fatalf("update: %v", err)
+			}
+			if err := store.Delete(alice, "alx", tk.ID); err != nil {
+				t.Fatalf("delete: %v", err)
+			}
+
+			if e, err := store.Undo(alice, "alice"); err != nil || e.Op != ticket.OpDelete {
+				t.Fatalf("undo delete: %+v, %v", e, err)
+			}
+			if e, err := store.Undo(alice, "alice"); err != nil || e.Op != ticket.OpUpdate {
+				t.Fatalf("undo update: %+v, %v", e, err)
+			}
+			got, err := store.Get(alice, tk.ID)
+			if err != nil {
+				t.Fatalf("get after undo: %v", err)
+			}
+			if got.Title != "Original" || got.Status != ticket.StatusOpen || got.ClosedAt != nil {
+				t.Errorf("fields not restored: %+v", got)
+			}
+			if len(got.Tags) != 1 || got.Tags[0] != "backend" || len(got.Comments) != 1 || got.Comments[0] != "first" {
+				t.Errorf("tags or comments not restored: %v %v", got.Tags, got.Comments)
+			}
+
+			// Bob's change blocks Alice from undoing her create
+			edit = *got
+			edit.Priority = ticket.PriorityHigh
+			if err := store.Update(bob, "alx", &edit); err != nil {
+				t.Fatalf("update by bob: %v", err)
+			}
+			if _, err := store.Undo(alice, "alice"); err == nil {
+				t.Fatal("expected undo to refuse after another user's change")
+			}
+
+			if _, err := store.Undo(bob, "bob"); err != nil {
+				t.Fatalf("undo by bob: %v", err)
+			}
+			if e, err := store.Undo(alice, "alice"); err != nil || e.Op != ticket.OpCreate {
+				t.Fatalf("undo create: %+v, %v", e, err)
+			}
+			if _, err := store.Get(alice, tk.ID); err == nil {
+				t.Error("ticket still visible after undoing its create")
+			}
+			if _, err := store.Undo(alice, "alice"); err != ticket.ErrNothingToUndo {
+				t.Errorf("expected nothing to undo, got %v", err)
+			}
+		})
+	}
+}
+
+func TestUndoKeepsComments(t *testing.T) {
+	for name, store := range stores(t) {
+		t.Run(name, func(t *testing.T) {
+			alice := ticket.WithActor(context.Background(), "alice")
+			bob := ticket.WithActor(context.Background(), "bob")
+
+			tk := newTicket("Commented")
+			if err := store.Create(alice, "alx", tk); err != nil {
+				t.Fatalf("create: %v", err)
+			}
+			edit := *tk
+			edit.Title = "Edited"
+			if err := store.Update(alice, "alx", &edit); err != nil {
+				t.Fatalf("update: %v", err)
+			}
+
+			// Bob's comment blocks Alice from undoing her update, and survives
+			if err := store.AddComment(bob, tk.ID, "from bob"); err != nil {
+				t.Fatalf("comment by bob: %v", err)
+			}
+			if _, err := store.Undo(alice, "alice"); err == nil {
+				t.Fatal("expected undo to refuse after another user's comment")
+			}
+			got, _ := store.Get(alice, tk.ID)
+			if got.Title != "Edited" || len(got.Comments) != 2 || got.Comments[1] != "from bob" {
+				t.Fatalf("refused undo changed the ticket: %q %v", got.Title, got.Comments)
+			}
+
+			// Her own comment is kept when she undoes the update
+			other := newTicket("Other")
+			if err := store.Create(alice, "alx", other); err != nil {
+				t.Fatalf("create: %v", err)
+			}
+			edit = *other
+			edit.Title = "Edited"
+			if err := store.Update(alice, "alx", &edit); err != nil {
+				t.Fatalf("update: %v", err)
+			}
+			if err := store.AddComment(alice, other.ID, "afterwards"); err != nil {
+				t.Fatalf("comment: %v", err)
+			}
+			if e, err := store.Undo(alice, "alice"); err != nil || e.Op != ticket.OpUpdate {
+				t.Fatalf("undo update: %+v, %v", e, err)
+			}
+			got, _ = store.Get(alice, other.ID)
+			if got.Title != "Other" || len(got.Comments) != 2 || got.Comments[0] != "first" || got.Comments[1] != "afterwards" {
+				t.Errorf("expected the fields restored and the comments kept: %q %v", got.Title, got.Comments)
+			}
+		})
+	}
+}
+
+func TestArchiveDatesTicketsClosedBeforeClosedAt(t *testing.T) {
+	ctx := context.Background()
+	db := testdb.Open(t)
+	store := ticket.NewSQLStore(db)
+
+	closed := newTicket("Closed long ago")
+	mustCreate(t, store, "alx", closed)
+	done := *closed
+	done.Status = ticket.StatusClosed
+	if err := store.Update(ctx, "alx", &done); err != nil {
+		t.Fatalf("close ticket: %v", err)
+	}
+
+	// A database from before closed_at gets it filled in from the status history
+	if _, err := db.Exec("ALTER TABLE tickets DROP COLUMN closed_at"); err != nil {
+		t.Fatalf("drop closed_at: %v", err)
+	}
+	if err := database.InitSchema(db); err != nil {
+		t.Fatalf("migrate schema: %v", err)
+	}
+
+	archived, err := store.Archive(ctx, "alx", time.Now().Add(time.Second))
+	if err != nil {
+		t.Fatalf("archive: %v", err)
+	}
+	if len(archived) != 1 || archived[0].ID != closed.ID || archived[0].ClosedAt == nil {
+		t.Fatalf("expected the ticket closed before closed_at archived, got %+v", archived)
+	}
+}
+
+func TestArchiveMovesClosedTickets(t *testing.T) {
+	for name, store := range stores(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			open, closed, elsewhere := newTicket("Open"), newTicket("Closed"), newTicket("Elsewhere")
+			mustCreate(t, store, "alx", open)
+			mustCreate(t, store, "alx", closed)
+			mustCreate(t, store, "other", elsewhere)
+			for _, tk := range []*ticket.Ticket{closed, elsewhere} {
+				done := *tk
+				done.Status = ticket.StatusClosed
+				if err := store.Update(ctx, done.Project, &done); err != nil {
+					t.Fatalf("close ticket: %v", err)
+				}
+			}
+
+			archived, err := store.Archive(ctx, "alx", time.Now().Add(-time.Hour))
+			if err != nil || len(archived) != 0 {
+				t.Fatalf("expected nothing closed before the cutoff: %v, %v", archived, err)
+			}
+			archived, err = store.Archive(ctx, "alx", time.Now().Add(time.Second))
+			if err != nil {
+				t.Fatalf("archive: %v", err)
+			}
+			if len(archived) != 1 || archived[0].ID != closed.ID || archived[0].ArchivedAt == nil {
+				t.Fatalf("expected only the closed ticket archived, got %+v", archived)
+			}
+
+			if _, err := store.Get(ctx, closed.ID); err == nil {
+				t.Error("archived ticket is still live")
+			}
+			live, _ := store.List(ctx, ticket.Filters{})
+			if len(live) != 2 {
+				t.Errorf("expected the open ticket and the other project's left, got %d", len(live))
+			}
+
+			project := "alx"
+			list, err := store.ListArchived(ctx, ticket.Filters{Project: &project, Tags: []string{"backend"}})
+			if err != nil || len(list) != 1 {
+				t.Fatalf("list archived: %v, %v", list, err)
+			}
+			got, err := store.GetArchived(ctx, closed.ID)
+			if err != nil {
+				t.Fatalf("get archived: %v", err)
+			}
+			if got.Title != "Closed" || len(got.Tags) != 1 || len(got.Comments) != 1 || got.ArchivedAt == nil {
+				t.Errorf("archived ticket lost its data: %+v", got)
+			}
+			if _, err := store.GetArchived(ctx, open.ID); err == nil {
+				t.Error("expected a live ticket not to be found in the archive")
+			}
+		})
+	}
+}
+
+func TestTimers(t *testing.T) {
+	for name, store := range stores(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			a := newTicket("A")
+			b := newTicket("B")
+			mustCreate(t, store, "alx", a)
+			mustCreate(t, store, "alx", b)
+
+			start := time.Now().Add(-2 * time.Hour)
+			if _, err := store.StartTimer(ctx, &ticket.Timer{User: "sam", TicketID: a.ID, StartedAt: start}); err != nil {
+				t.Fatalf("start timer: %v", err)
+			}
+			stopped, err := store.StartTimer(ctx, &ticket.Timer{User: "sam", TicketID: b.ID, StartedAt: start.Add(time.Hour)})
+			if err != nil {
+				t.Fatalf("switch timer: %v", err)
+			}
+			if stopped == nil || stopped.TicketID != a.ID || stopped.Duration() != time.Hour {
+				t.Errorf("switching should stop the first timer, got %+v", stopped)
+			}
+
+			if _, err := store.StopTimer(ctx, "sam", start.Add(90*time.Minute), "done"); err != nil {
+				t.Fatalf("stop timer: %v", err)
+			}
+			if timer, err := store.GetTimer(ctx, "sam"); err != nil || timer != nil {
+				t.Errorf("timer still running: %+v, %v", timer, err)
+			}
+
+			totals, err := store.LoggedByTicket(ctx)
+			if err != nil {
+				t.Fatalf("logged by ticket: %v", err)
+			}
+			if totals[a.ID] != time.Hour || totals[b.ID] != 30*time.Minute {
+				t.Errorf("unexpected totals: %v", totals)
+			}
+		})
+	}
+}
+
+func TestLinkCommitIsIdempotent(t *testing.T) {
+	for name, store := range stores(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			tk := newTicket("Link")
+			mustCreate(t, store, "alx", tk)
+
+			c := ticket.Commit{SHA: "abc1234def", Summary: "ALX-1 fix", CommittedAt: time.Now()}
+			for i, want := range []bool{true, false} {
+				isNew, err := store.LinkCommit(ctx, tk.ID, c)
+				if err != nil {
+					t.Fatalf("link commit: %v", err)
+				}
+				if isNew != want {
+					t.Errorf("link %d: got new=%v, want %v", i, isNew, want)
+				}
+			}
+			if commits, _ := store.ListCommits(ctx, tk.ID); len(commits) != 1 {
+				t.Errorf("expected one linked commit, got %+v", commits)
+			}
+		})
+	}
+}
+
+func TestParentsAndChildren(t *testing.T) {
+	for name, store := range stores(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			points := func(p float64) *float64 { return &p }
+
+			epic := newTicket("Billing")
+			mustCreate(t, store, "alx", epic)
+			story := newTicket("Invoices")
+			story.ParentID, story.StoryPoints = &epic.ID, points(5)
+			mustCreate(t, store, "alx", story)
+			task := newTicket("Invoice PDF")
+			task.ParentID, task.StoryPoints = &story.ID, points(3)
+			mustCreate(t, store, "alx", task)
+			done := newTicket("Pricing page")
+			done.ParentID, done.StoryPoints, done.Status = &epic.ID, points(2), ticket.StatusClosed
+			mustCreate(t, store, "alx", done)
+
+			children, err := store.List(ctx, ticket.Filters{Parent: &epic.ID})
+			if err != nil || len(children) != 2 {
+				t.Fatalf("expected the epic's two children, got %d (%v)", len(children), err)
+			}
+
+			tree, err := ticket.Subtree(ctx, store, epic)
+			if err != nil {
+				t.Fatalf("subtree: %v", err)
+			}
+			if r := tree.Rollup(); r.Done != 1 || r.Total != 3 || r.RemainingPoints != 8 {
+				t.Errorf("expected 1/3 done with 8 points remaining, got %+v", r)
+			}
+
+			if _, err := ticket.CheckParent(ctx, store, epic.ID, task.ID); err == nil {
+				t.Error("expected a cycle to be refused")
+			}
+			if _, err := ticket.CheckParent(ctx, store, task.ID, epic.ID); err != nil {
+				t.Errorf("expected moving a task up to be allowed: %v", err)
+			}
+
+			all, err := store.List(ctx, ticket.Filters{})
+			if err != nil {
+				t.Fatalf("list: %v", err)
+			}
+			roots := ticket.BuildTree(all)
+			if len(roots) != 1 || roots[0].Ticket.ID != epic.ID || len(roots[0].Children) != 2 {
+				t.Errorf("expected one root with two children, got %d roots", len(roots))
+			}
+
+			// Purging a parent leaves its children at the top level
+			if err := store.Delete(ctx, "alx", story.ID); err != nil {
+				t.Fatalf("delete: %v", err)
+			}
+			if _, err := store.Purge(ctx, time.Now().Add(time.Minute)); err != nil {
+				t.Fatalf("purge: %v", err)
+			}
+			got, err := store.Get(ctx, task.ID)
+			if err != nil || got.ParentID != nil {
+				t.Errorf("expected the task to lose its purged parent, got %+v (%v)", got, err)
+			}
+		})
+	}
+}
+
+func TestChecklist(t *testing.T) {
+	for name, store := range stores(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			incomplete := ticket.Filters{IncompleteChecklist: true}
+
+			tk := newTicket("Schema change")
+			mustCreate(t, store, "alx", tk)
+			other := newTicket("No checklist")
+			mustCreate(t, store, "alx", other)
+
+			for _, text := range []string{"write migration", "backfill", "drop old column"} {
+				if _, err := store.AddCheckItem(ctx, tk.ID, text); err != nil {
+					t.Fatalf("add item: %v", err)
+				}
+			}
+			if _, err := store.SetCheckItemDone(ctx, tk.ID, 1, true); err != nil {
+				t.Fatalf("check off: %v", err)
+			}
+			if _, err := store.SetCheckItemDone(ctx, tk.ID, 4, true); err == nil {
+				t.Error("expected a missing item to be refused")
+			}
+			removed, err := store.RemoveCheckItem(ctx, tk.ID, 2)
+			if err != nil || removed.Text != "backfill" {
+				t.Fatalf("expected to remove the second item, got %+v (%v)", removed, err)
+			}
+
+			got, err := store.Get(ctx, tk.ID)
+			if err != nil {
+				t.Fatalf("get: %v", err)
+			}
+			if done, total := got.ChecklistProgress(); done != 1 || total != 2 || got.Checklist[1].Text != "drop old column" {
+				t.Errorf("expected 1/2 done in order, got %+v", got.Checklist)
+			}
+
+			// Updating the ticket leaves its checklist alone
+			got.Title = "Schema change v2"
+			got.Checklist = nil
+			if err := store.Update(ctx, "alx", got); err != nil {
+				t.Fatalf("update: %v", err)
+			}
+
+			list, err := store.List(ctx, incomplete)
+			if err != nil || len(list) != 1 || list[0].ID != tk.ID || len(list[0].Checklist) != 2 {
+				t.Fatalf("expected only the ticket with open items, got %+v (%v)", list, err)
+			}
+
+			if _, err := store.SetCheckItemDone(ctx, tk.ID, 2, true); err != nil {
+				t.Fatalf("check off: %v", err)
+			}
+			if list, _ := store.List(ctx, incomplete); len(list) != 0 {
+				t.Errorf("expected no tickets once every item is done, got %d", len(list))
+			}
+			item, err := store.SetCheckItemDone(ctx, tk.ID, 2, false)
+			if err != nil || item.Done() {
+				t.Fatalf("expected the item unchecked, got %+v (%v)", item, err)
+			}
+
+			// The checklist moves to the archive with its ticket
+			got, _ = store.Get(ctx, tk.ID)
+			got.Status = ticket.StatusClosed
+			if err := store.Update(ctx, "alx", got); err != nil {
+				t.Fatalf("close: %v", err)
+			}
+			if _, err := store.Archive(ctx, "alx", time.Now().Add(time.Second)); err != nil {
+				t.Fatalf("archive: %v", err)
+			}
+			archived, err := store.ListArchived(ctx, incomplete)
+			if err != nil || len(archived) != 1 || len(archived[0].Checklist) != 2 {
+				t.Errorf("expected the archived checklist, got %+v (%v)", archived, err)
+			}
+		})
+	}
+}
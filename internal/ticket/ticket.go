@@ -1,25 +1,47 @@
 package ticket
 
 import (
+	"alexandria/internal/dates"
+	"fmt"
+	"strconv"
+	"strings"
 	"time"
+	"unicode"
 )
 
 type Ticket struct {
-	ID          int64     `json:"id"`
-	Project     string    `json:"project"`
-	Type        Type      `json:"type"`
-	Title       string    `json:"title"`
-	Description string    `json:"description"`
-	CriticalPath bool     `json:"criticalpath"`
-	Status      Status    `json:"status"`
-	Priority    Priority  `json:"priority"`
-	CreatedBy   *string   `json:"created_by,omitempty"`
-	AssignedTo  *string   `json:"assigned_to,omitempty"`
-	Tags        []string  `json:"tags"`
-	Files       []string  `json:"files"`
-	Comments    []string  `json:"comments"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID            int64       `json:"id"`
+	Project       string      `json:"project"`
+	Type          Type        `json:"type"`
+	Title         string      `json:"title"`
+	Description   string      `json:"description"`
+	CriticalPath  bool        `json:"criticalpath"`
+	Status        Status      `json:"status"`
+	Priority      Priority    `json:"priority"`
+	CreatedBy     *string     `json:"created_by,omitempty"`
+	AssignedTo    *string     `json:"assigned_to,omitempty"`
+	Tags          []string    `json:"tags"`
+	Files         []string    `json:"files"`
+	Comments      []string    `json:"comments"`
+	CreatedAt     time.Time   `json:"created_at"`
+	UpdatedAt     time.Time   `json:"updated_at"`
+	DueAt         *time.Time  `json:"due_at,omitempty"`
+	StartAt       *time.Time  `json:"start_at,omitempty"`
+	StoryPoints   *float64    `json:"story_points,omitempty"`
+	EstimateHours *float64    `json:"estimate_hours,omitempty"`
+	ClosedAt      *time.Time  `json:"closed_at,omitempty"`
+	DeletedAt     *time.Time  `json:"deleted_at,omitempty"`  // set while the ticket is in the trash
+	ArchivedAt    *time.Time  `json:"archived_at,omitempty"` // set on tickets loaded from the archive
+	ParentID      *int64      `json:"parent_id,omitempty"`   // the epic or story the ticket belongs to
+	Checklist     []CheckItem `json:"checklist,omitempty"`   // small steps, in order; not saved by Update
+}
+
+// IsOverdue returns true if the ticket is still open after its due date
+func (t *Ticket) IsOverdue(now time.Time) bool {
+	if t.DueAt == nil || t.Status == StatusClosed {
+		return false
+	}
+	return t.DueAt.Before(dates.StartOfDay(now))
 }
 
 type Type string
@@ -60,9 +82,9 @@ type Priority string
 
 const (
 	PriorityUndefined Priority = "undefined"
-	PriorityLow    Priority = "low"
-	PriorityMedium Priority = "medium"
-	PriorityHigh   Priority = "high"
+	PriorityLow       Priority = "low"
+	PriorityMedium    Priority = "medium"
+	PriorityHigh      Priority = "high"
 )
 
 // Valid returns true if the priority is valid
@@ -83,11 +105,35 @@ type Link struct {
 
 // Filters for querying tickets
 type Filters struct {
-	Status     *Status
-	Type       *Type
-	Priority   *Priority
-	AssignedTo *string
-	Project    *string
-	Tags       []string
+	Status              *Status
+	Type                *Type
+	Priority            *Priority
+	AssignedTo          *string
+	Project             *string
+	Tags                []string
+	Overdue             bool          // only tickets past their due date
+	DueWithin           time.Duration // only tickets due before now + DueWithin (0 disables)
+	Parent              *int64        // only the direct children of this ticket
+	IncompleteChecklist bool          // only tickets with checklist items still to do
 }
 
+// ParseRef extracts the ticket ID from a reference such as "42", "#42" or "ALX-42".
+// Any alphabetic prefix before the dash is accepted since IDs are unique across projects.
+func ParseRef(ref string) (int64, error) {
+	s := strings.TrimPrefix(strings.TrimSpace(ref), "#")
+	if idx := strings.LastIndex(s, "-"); idx > 0 {
+		prefix := s[:idx]
+		for _, r := range prefix {
+			if !unicode.IsLetter(r) {
+				return 0, fmt.Errorf("invalid ticket reference: %s", ref)
+			}
+		}
+		s = s[idx+1:]
+	}
+
+	id, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || id <= 0 {
+		return 0, fmt.Errorf("invalid ticket reference: %s (expected an ID like 42 or ALX-42)", ref)
+	}
+	return id, nil
+}
@@ -0,0 +1,161 @@
+package ticket
+
+import (
+	"alexandria/internal/logger"
+	"context"
+	"fmt"
+	"time"
+)
+
+// ListTrash returns deleted tickets, optionally only those in project, most recently deleted first
+func (s *SQLStore) ListTrash(ctx context.Context, project string) ([]Ticket, error) {
+	logger.Log.Debug("listing trash", "project", project)
+
+	query := `SELECT ` + ticketColumns + ` FROM tickets WHERE deleted_at IS NOT NULL`
+	var args []interface{}
+	if project != "" {
+		query += " AND project = ?"
+		args = append(args, project)
+	}
+	query += " ORDER BY deleted_at DESC"
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		logger.Log.Error("failed to query trash", "error", err)
+		return nil, fmt.Errorf("failed to query trash: %w", err)
+	}
+	defer rows.Close()
+
+	var tickets []Ticket
+	for rows.Next() {
+		var t Ticket
+		if err := scanTicket(rows, &t); err != nil {
+			logger.Log.Error("failed to scan ticket", "error", err)
+			return nil, fmt.Errorf("failed to scan ticket: %w", err)
+		}
+		tickets = append(tickets, t)
+	}
+	if err := rows.Err(); err != nil {
+		logger.Log.Error("error iterating trash", "error", err)
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	for i := range tickets {
+		if err := s.loadRelated(ctx, &tickets[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	logger.Log.Debug("trash listed", "count", len(tickets))
+	return tickets, nil
+}
+
+// Restore takes a ticket out of the trash
+func (s *SQLStore) Restore(ctx context.Context, id int64) error {
+	logger.Log.Debug("restoring ticket", "id", id)
+
+	result, err := s.db.ExecContext(ctx, "UPDATE tickets SET deleted_at = NULL WHERE id = ? AND deleted_at IS NOT NULL", id)
+	if err != nil {
+		logger.Log.Error("failed to restore ticket", "error", err, "id", id)
+		return fmt.Errorf("failed to restore ticket: %w", err)
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		logger.Log.Error("ticket not in trash", "id", id)
+		return fmt.Errorf("ticket %d is not in the trash", id)
+	}
+
+	logger.Log.Info("ticket restored", "id", id)
+	return nil
+}
+
+// Purge permanently removes tickets deleted before the cutoff, with everything
+// attached to them, and returns them
+func (s *SQLStore) Purge(ctx context.Context, before time.Time) ([]Ticket, error) {
+	logger.Log.Debug("purging trash", "before", before)
+
+	ids, err := s.idsBefore(ctx, before,
+		`SELECT id, deleted_at FROM tickets WHERE deleted_at IS NOT NULL ORDER BY deleted_at DESC`)
+	if err != nil {
+		return nil, err
+	}
+	candidates, err := s.loadTickets(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.Log.Error("failed to begin transaction", "error", err)
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Tickets restored since they were picked are skipped
+	var purged []Ticket
+	for _, t := range candidates {
+		ok, err := purgeTicket(ctx, tx, t.Project, t.ID, before)
+		if err != nil {
+			return nil, fmt.Errorf("ticket %d: %w", t.ID, err)
+		}
+		if ok {
+			purged = append(purged, t)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		logger.Log.Error("failed to commit purge transaction", "error", err)
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	logger.Log.Info("trash purged", "count", len(purged))
+	return purged, nil
+}
+
+// idsBefore runs query, which selects a ticket ID and a timestamp, and returns the
+// IDs whose timestamp is before the cutoff, in the order selected. The comparison
+// is made here rather than in SQL because SQLite stores timestamps as text, which
+// does not order correctly across time zones.
+func (s *SQLStore) idsBefore(ctx context.Context, cutoff time.Time, query string, args ...interface{}) ([]int64, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		logger.Log.Error("failed to query tickets", "error", err)
+		return nil, fmt.Errorf("failed to query tickets: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		var at time.Time
+		if err := rows.Scan(&id, &at); err != nil {
+			logger.Log.Error("failed to scan ticket", "error", err)
+			return nil, fmt.Errorf("failed to scan ticket: %w", err)
+		}
+		if at.Before(cutoff) {
+			ids = append(ids, id)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		logger.Log.Error("error iterating tickets", "error", err)
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+	return ids, nil
+}
+
+// loadTickets loads tickets by ID with everything attached to them, including
+// tickets in the trash
+func (s *SQLStore) loadTickets(ctx context.Context, ids []int64) ([]Ticket, error) {
+	var tickets []Ticket
+	for _, id := range ids {
+		var t Ticket
+		if err := scanTicket(s.db.QueryRowContext(ctx, `SELECT `+ticketColumns+` FROM tickets WHERE id = ?`, id), &t); err != nil {
+			logger.Log.Error("failed to fetch ticket", "error", err, "id", id)
+			return nil, fmt.Errorf("failed to fetch ticket %d: %w", id, err)
+		}
+		if err := s.loadRelated(ctx, &t); err != nil {
+			return nil, err
+		}
+		tickets = append(tickets, t)
+	}
+	return tickets, nil
+}
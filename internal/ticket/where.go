@@ -0,0 +1,139 @@
+package ticket
+
+import (
+	"alexandria/internal/dates"
+	"fmt"
+	"strings"
+)
+
+// WhereHelp summarises the filter expressions ParseWhere accepts
+const WhereHelp = `Filter expression of space-separated terms, all of which must match:
+  project:NAME  status:STATUS  type:TYPE  priority:PRIORITY  assignee:USER
+  tag:TAG (repeat to match any of several tags)  due-within:7d  overdue
+  parent:ALX-10 (the ticket's direct children)  incomplete-checklist
+Quote values containing spaces, e.g. project:"Web App"`
+
+// ParseWhere parses a filter expression such as
+// "project:X tag:sprint-3 status:in-progress" into Filters
+func ParseWhere(expr string) (Filters, error) {
+	var filters Filters
+
+	terms, err := splitTerms(expr)
+	if err != nil {
+		return filters, err
+	}
+
+	for _, term := range terms {
+		key, value, hasValue := strings.Cut(term, ":")
+		key = strings.ToLower(key)
+
+		// Bare predicates take no value
+		if !hasValue {
+			switch key {
+			case "overdue":
+				filters.Overdue = true
+				continue
+			case "incomplete-checklist":
+				filters.IncompleteChecklist = true
+				continue
+			}
+			return filters, fmt.Errorf("invalid filter term %q (expected key:value)", term)
+		}
+		if value == "" {
+			return filters, fmt.Errorf("filter %s needs a value", key)
+		}
+
+		switch key {
+		case "project":
+			filters.Project = &value
+		case "status":
+			status := Status(value)
+			if !status.Valid() {
+				return filters, fmt.Errorf("invalid status: %s (must be: open, in-progress, or closed)", value)
+			}
+			filters.Status = &status
+		case "type":
+			tType := Type(value)
+			if !tType.Valid() {
+				return filters, fmt.Errorf("invalid type: %s (must be: bug, feature, or task)", value)
+			}
+			filters.Type = &tType
+		case "priority":
+			priority := Priority(value)
+			if !priority.Valid() {
+				return filters, fmt.Errorf("invalid priority: %s (must be: undefined, low, medium, or high)", value)
+			}
+			filters.Priority = &priority
+		case "assignee", "assigned-to":
+			filters.AssignedTo = &value
+		case "tag", "tags":
+			for _, tag := range strings.Split(value, ",") {
+				if tag = strings.TrimSpace(tag); tag != "" {
+					filters.Tags = append(filters.Tags, tag)
+				}
+			}
+		case "parent":
+			id, err := ParseRef(value)
+			if err != nil {
+				return filters, err
+			}
+			filters.Parent = &id
+		case "due-within":
+			within, err := dates.ParseDuration(value)
+			if err != nil || within <= 0 {
+				return filters, fmt.Errorf("invalid due-within: %s (use a duration like 7d, 2w or 48h)", value)
+			}
+			filters.DueWithin = within
+		default:
+			return filters, fmt.Errorf("unknown filter %q", key)
+		}
+	}
+
+	return filters, nil
+}
+
+// IsEmpty reports whether the filters match every ticket
+func (f Filters) IsEmpty() bool {
+	return f.Status == nil && f.Type == nil && f.Priority == nil && f.AssignedTo == nil &&
+		f.Project == nil && len(f.Tags) == 0 && !f.Overdue && f.DueWithin == 0 && f.Parent == nil &&
+		!f.IncompleteChecklist
+}
+
+// splitTerms splits a filter expression on whitespace, keeping quoted values together
+func splitTerms(expr string) ([]string, error) {
+	var (
+		terms   []string
+		current strings.Builder
+		quote   rune
+		inTerm  bool
+	)
+	for _, r := range expr {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				current.WriteRune(r)
+			}
+		case r == '"' || r == '\'':
+			quote = r
+			inTerm = true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inTerm {
+				terms = append(terms, current.String())
+				current.Reset()
+				inTerm = false
+			}
+		default:
+			current.WriteRune(r)
+			inTerm = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated quote in filter expression: %s", expr)
+	}
+	if inTerm {
+		terms = append(terms, current.String())
+	}
+	return terms, nil
+}
@@ -0,0 +1,245 @@
+package ticket
+
+import (
+	"alexandria/internal/logger"
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Worklog is a block of time a user spent on a ticket
+type Worklog struct {
+	ID        int64     `json:"id"`
+	TicketID  int64     `json:"ticket_id"`
+	User      string    `json:"user"`
+	StartedAt time.Time `json:"started_at"`
+	EndedAt   time.Time `json:"ended_at"`
+	Note      string    `json:"note,omitempty"`
+}
+
+// Duration returns the length of the worklog entry
+func (w Worklog) Duration() time.Duration {
+	return w.EndedAt.Sub(w.StartedAt)
+}
+
+// Timer is a running stopwatch for a user, persisted so it survives process exits
+type Timer struct {
+	User      string    `json:"user"`
+	TicketID  int64     `json:"ticket_id"`
+	StartedAt time.Time `json:"started_at"`
+	Note      string    `json:"note,omitempty"`
+}
+
+// AddWorklog records a completed worklog entry
+func (s *SQLStore) AddWorklog(ctx context.Context, w *Worklog) error {
+	logger.Log.Debug("adding worklog", "ticket_id", w.TicketID, "user", w.User, "duration", w.Duration())
+
+	if !w.EndedAt.After(w.StartedAt) {
+		logger.Log.Error("invalid worklog", "started_at", w.StartedAt, "ended_at", w.EndedAt)
+		return fmt.Errorf("worklog must end after it starts")
+	}
+
+	err := s.db.QueryRowContext(ctx,
+		`INSERT INTO ticket_worklogs (ticket_id, username, started_at, ended_at, note) VALUES (?, ?, ?, ?, ?) RETURNING id`,
+		w.TicketID, w.User, w.StartedAt, w.EndedAt, w.Note,
+	).Scan(&w.ID)
+	if err != nil {
+		logger.Log.Error("failed to insert worklog", "error", err, "ticket_id", w.TicketID)
+		return fmt.Errorf("failed to insert worklog: %w", err)
+	}
+
+	logger.Log.Info("worklog added", "id", w.ID, "ticket_id", w.TicketID)
+	return nil
+}
+
+// ListWorklogs returns worklogs that started at or after since, optionally limited to one ticket or user.
+// A zero ticketID or empty user disables that filter.
+func (s *SQLStore) ListWorklogs(ctx context.Context, ticketID int64, user string, since time.Time) ([]Worklog, error) {
+	logger.Log.Debug("listing worklogs", "ticket_id", ticketID, "user", user, "since", since)
+
+	query := `SELECT id, ticket_id, username, started_at, ended_at, note FROM ticket_worklogs WHERE started_at >= ?`
+	args := []interface{}{since}
+
+	if ticketID != 0 {
+		query += " AND ticket_id = ?"
+		args = append(args, ticketID)
+	}
+	if user != "" {
+		query += " AND username = ?"
+		args = append(args, user)
+	}
+	query += " ORDER BY started_at"
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		logger.Log.Error("failed to query worklogs", "error", err)
+		return nil, fmt.Errorf("failed to query worklogs: %w", err)
+	}
+	defer rows.Close()
+
+	var logs []Worklog
+	for rows.Next() {
+		var w Worklog
+		var note sql.NullString
+		if err := rows.Scan(&w.ID, &w.TicketID, &w.User, &w.StartedAt, &w.EndedAt, &note); err != nil {
+			logger.Log.Error("failed to scan worklog", "error", err)
+			return nil, fmt.Errorf("failed to scan worklog: %w", err)
+		}
+		w.Note = note.String
+		logs = append(logs, w)
+	}
+
+	if err := rows.Err(); err != nil {
+		logger.Log.Error("error iterating worklogs", "error", err)
+		return nil, fmt.Errorf("error iterating worklogs: %w", err)
+	}
+
+	logger.Log.Debug("worklogs loaded", "count", len(logs))
+	return logs, nil
+}
+
+// TotalLogged sums the time logged against a ticket
+func (s *SQLStore) TotalLogged(ctx context.Context, ticketID int64) (time.Duration, int, error) {
+	logs, err := s.ListWorklogs(ctx, ticketID, "", time.Time{})
+	if err != nil {
+		return 0, 0, err
+	}
+	return totalDuration(logs), len(logs), nil
+}
+
+// totalDuration sums the length of worklogs
+func totalDuration(logs []Worklog) time.Duration {
+	var total time.Duration
+	for _, w := range logs {
+		total += w.Duration()
+	}
+	return total
+}
+
+// GetTimer returns the running timer for a user, or nil if none is running
+func (s *SQLStore) GetTimer(ctx context.Context, user string) (*Timer, error) {
+	logger.Log.Debug("loading timer", "user", user)
+
+	timer := &Timer{}
+	var note sql.NullString
+	err := s.db.QueryRowContext(ctx,
+		`SELECT username, ticket_id, started_at, note FROM active_timers WHERE username = ?`, user,
+	).Scan(&timer.User, &timer.TicketID, &timer.StartedAt, &note)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		logger.Log.Error("failed to load timer", "error", err, "user", user)
+		return nil, fmt.Errorf("failed to load timer: %w", err)
+	}
+	timer.Note = note.String
+
+	return timer, nil
+}
+
+// StartTimer starts a timer for a user. Any timer already running for the user is
+// stopped and recorded as a worklog first, which is returned.
+func (s *SQLStore) StartTimer(ctx context.Context, timer *Timer) (*Worklog, error) {
+	logger.Log.Debug("starting timer", "user", timer.User, "ticket_id", timer.TicketID)
+
+	var stopped *Worklog
+	running, err := s.GetTimer(ctx, timer.User)
+	if err != nil {
+		return nil, err
+	}
+	if running != nil {
+		stopped, err = s.StopTimer(ctx, timer.User, timer.StartedAt, "")
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err := s.db.ExecContext(ctx,
+		`INSERT INTO active_timers (username, ticket_id, started_at, note) VALUES (?, ?, ?, ?)`,
+		timer.User, timer.TicketID, timer.StartedAt, timer.Note,
+	); err != nil {
+		logger.Log.Error("failed to start timer", "error", err, "user", timer.User)
+		return nil, fmt.Errorf("failed to start timer: %w", err)
+	}
+
+	logger.Log.Info("timer started", "user", timer.User, "ticket_id", timer.TicketID)
+	return stopped, nil
+}
+
+// StopTimer stops the user's running timer and records it as a worklog.
+// A non-empty note replaces the note given when the timer was started.
+func (s *SQLStore) StopTimer(ctx context.Context, user string, endedAt time.Time, note string) (*Worklog, error) {
+	logger.Log.Debug("stopping timer", "user", user)
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.Log.Error("failed to begin transaction", "error", err)
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	w := &Worklog{User: user, EndedAt: endedAt}
+	var startNote sql.NullString
+	err = tx.QueryRowContext(ctx,
+		`SELECT ticket_id, started_at, note FROM active_timers WHERE username = ?`, user,
+	).Scan(&w.TicketID, &w.StartedAt, &startNote)
+	if err == sql.ErrNoRows {
+		logger.Log.Error("no running timer", "user", user)
+		return nil, fmt.Errorf("no timer is running for %s", user)
+	}
+	if err != nil {
+		logger.Log.Error("failed to load timer", "error", err, "user", user)
+		return nil, fmt.Errorf("failed to load timer: %w", err)
+	}
+
+	w.Note = startNote.String
+	if note != "" {
+		w.Note = note
+	}
+
+	// A timer stopped within the same second still records a minimal entry
+	if !w.EndedAt.After(w.StartedAt) {
+		w.EndedAt = w.StartedAt.Add(time.Second)
+	}
+
+	err = tx.QueryRowContext(ctx,
+		`INSERT INTO ticket_worklogs (ticket_id, username, started_at, ended_at, note) VALUES (?, ?, ?, ?, ?) RETURNING id`,
+		w.TicketID, w.User, w.StartedAt, w.EndedAt, w.Note,
+	).Scan(&w.ID)
+	if err != nil {
+		logger.Log.Error("failed to insert worklog", "error", err, "ticket_id", w.TicketID)
+		return nil, fmt.Errorf("failed to insert worklog: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM active_timers WHERE username = ?`, user); err != nil {
+		logger.Log.Error("failed to clear timer", "error", err, "user", user)
+		return nil, fmt.Errorf("failed to clear timer: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		logger.Log.Error("failed to commit transaction", "error", err)
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	logger.Log.Info("timer stopped", "user", user, "ticket_id", w.TicketID, "duration", w.Duration())
+	return w, nil
+}
+
+// LoggedByTicket sums all time logged per ticket
+func (s *SQLStore) LoggedByTicket(ctx context.Context) (map[int64]time.Duration, error) {
+	logs, err := s.ListWorklogs(ctx, 0, "", time.Time{})
+	if err != nil {
+		return nil, err
+	}
+	return durationByTicket(logs), nil
+}
+
+// durationByTicket sums the length of worklogs per ticket
+func durationByTicket(logs []Worklog) map[int64]time.Duration {
+	totals := make(map[int64]time.Duration)
+	for _, w := range logs {
+		totals[w.TicketID] += w.Duration()
+	}
+	return totals
+}
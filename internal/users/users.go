@@ -0,0 +1,100 @@
+package users
+
+import (
+	"alexandria/internal/logger"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Roles a user can have
+const (
+	RoleAdmin  = "admin"
+	RoleUser   = "user"
+	RoleViewer = "viewer"
+)
+
+// User is someone tickets can be assigned to, identified by the username that
+// ALEXANDRIA_USER or their login name gives
+type User struct {
+	ID        int64     `json:"id"`
+	Username  string    `json:"username"`
+	Email     string    `json:"email"`
+	Fullname  string    `json:"fullname"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Save adds a user, or updates the email, name and role of an existing one with
+// the same username. Users added here have no password.
+func Save(db *sql.DB, u *User) error {
+	logger.Log.Debug("saving user", "username", u.Username, "email", u.Email)
+
+	if u.Username == "" {
+		return fmt.Errorf("username is required")
+	}
+	if !strings.Contains(u.Email, "@") {
+		return fmt.Errorf("invalid email address: %s", u.Email)
+	}
+	switch u.Role {
+	case RoleAdmin, RoleUser, RoleViewer:
+	default:
+		return fmt.Errorf("invalid role: %s (must be %s, %s or %s)", u.Role, RoleAdmin, RoleUser, RoleViewer)
+	}
+	if u.Fullname == "" {
+		u.Fullname = u.Username
+	}
+
+	now := time.Now()
+	err := db.QueryRow(`
+		INSERT INTO users (username, email, hashed_password, fullname, role, created_at, updated_at)
+		VALUES (?, ?, '', ?, ?, ?, ?)
+		ON CONFLICT(username) DO UPDATE SET
+			email = excluded.email, fullname = excluded.fullname, role = excluded.role, updated_at = excluded.updated_at
+		RETURNING id, created_at`,
+		u.Username, u.Email, u.Fullname, u.Role, now, now,
+	).Scan(&u.ID, &u.CreatedAt)
+	if err != nil {
+		logger.Log.Error("failed to save user", "error", err, "username", u.Username)
+		return fmt.Errorf("failed to save user: %w", err)
+	}
+
+	logger.Log.Info("user saved", "id", u.ID, "username", u.Username)
+	return nil
+}
+
+// List returns every user, sorted by username
+func List(db *sql.DB) ([]User, error) {
+	rows, err := db.Query(`SELECT id, username, email, fullname, role, created_at FROM users ORDER BY username`)
+	if err != nil {
+		logger.Log.Error("failed to query users", "error", err)
+		return nil, fmt.Errorf("failed to query users: %w", err)
+	}
+	defer rows.Close()
+
+	var list []User
+	for rows.Next() {
+		var u User
+		if err := rows.Scan(&u.ID, &u.Username, &u.Email, &u.Fullname, &u.Role, &u.CreatedAt); err != nil {
+			logger.Log.Error("failed to scan user", "error", err)
+			return nil, fmt.Errorf("failed to scan user: %w", err)
+		}
+		list = append(list, u)
+	}
+	return list, rows.Err()
+}
+
+// Email returns a user's address, or "" if there is no such user
+func Email(db *sql.DB, username string) (string, error) {
+	var email string
+	err := db.QueryRow(`SELECT email FROM users WHERE username = ?`, username).Scan(&email)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		logger.Log.Error("failed to look up email", "error", err, "username", username)
+		return "", fmt.Errorf("failed to look up email: %w", err)
+	}
+	return email, nil
+}
@@ -0,0 +1,196 @@
+package views
+
+import (
+	"alexandria/internal/config"
+	"alexandria/internal/logger"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Scopes a saved view can be stored in
+const (
+	ScopeShared   = "shared"   // stored in the database, visible to everyone using it
+	ScopePersonal = "personal" // stored in the user's config file
+)
+
+// View is a named set of list arguments (filters, output format and sort order)
+type View struct {
+	Name      string    `json:"name"`
+	Args      []string  `json:"args"`
+	Scope     string    `json:"scope"`
+	CreatedBy string    `json:"created_by,omitempty"`
+	UpdatedAt time.Time `json:"updated_at,omitempty"`
+}
+
+// Save stores a view in the database (shared) or the user's config (personal)
+func Save(db *sql.DB, v *View) error {
+	logger.Log.Debug("saving view", "name", v.Name, "scope", v.Scope, "args", v.Args)
+
+	if v.Name == "" {
+		return fmt.Errorf("view name is required")
+	}
+
+	switch v.Scope {
+	case ScopePersonal:
+		cfg, err := config.Load()
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+		if cfg.Views == nil {
+			cfg.Views = map[string][]string{}
+		}
+		cfg.Views[v.Name] = v.Args
+		if err := config.Save(cfg); err != nil {
+			return fmt.Errorf("failed to save personal view: %w", err)
+		}
+
+	case ScopeShared:
+		args, err := json.Marshal(v.Args)
+		if err != nil {
+			logger.Log.Error("failed to marshal view arguments", "error", err)
+			return fmt.Errorf("failed to marshal view arguments: %w", err)
+		}
+		now := time.Now()
+		_, err = db.Exec(`
+			INSERT INTO saved_views (name, args, created_by, created_at, updated_at)
+			VALUES (?, ?, ?, ?, ?)
+			ON CONFLICT(name) DO UPDATE SET args = excluded.args, updated_at = excluded.updated_at`,
+			v.Name, string(args), v.CreatedBy, now, now,
+		)
+		if err != nil {
+			logger.Log.Error("failed to save view", "error", err, "name", v.Name)
+			return fmt.Errorf("failed to save view: %w", err)
+		}
+
+	default:
+		return fmt.Errorf("invalid view scope: %s (must be %s or %s)", v.Scope, ScopeShared, ScopePersonal)
+	}
+
+	logger.Log.Info("view saved", "name", v.Name, "scope", v.Scope)
+	return nil
+}
+
+// Get finds a view by name. Personal views take precedence over shared ones.
+func Get(db *sql.DB, name string) (*View, error) {
+	logger.Log.Debug("loading view", "name", name)
+
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+	if args, ok := cfg.Views[name]; ok {
+		return &View{Name: name, Args: args, Scope: ScopePersonal}, nil
+	}
+
+	v := &View{Name: name, Scope: ScopeShared}
+	var args string
+	var createdBy sql.NullString
+	err = db.QueryRow(`SELECT args, created_by, updated_at FROM saved_views WHERE name = ?`, name).
+		Scan(&args, &createdBy, &v.UpdatedAt)
+	if err == sql.ErrNoRows {
+		logger.Log.Error("view not found", "name", name)
+		return nil, fmt.Errorf("no saved view named '%s'", name)
+	}
+	if err != nil {
+		logger.Log.Error("failed to load view", "error", err, "name", name)
+		return nil, fmt.Errorf("failed to load view: %w", err)
+	}
+	v.CreatedBy = createdBy.String
+
+	if err := json.Unmarshal([]byte(args), &v.Args); err != nil {
+		logger.Log.Error("failed to parse view arguments", "error", err, "name", name)
+		return nil, fmt.Errorf("failed to parse view arguments: %w", err)
+	}
+
+	return v, nil
+}
+
+// List returns every personal and shared view, sorted by name
+func List(db *sql.DB) ([]View, error) {
+	logger.Log.Debug("listing views")
+
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+
+	var all []View
+	for name, args := range cfg.Views {
+		all = append(all, View{Name: name, Args: args, Scope: ScopePersonal})
+	}
+
+	rows, err := db.Query(`SELECT name, args, created_by, updated_at FROM saved_views`)
+	if err != nil {
+		logger.Log.Error("failed to query views", "error", err)
+		return nil, fmt.Errorf("failed to query views: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		v := View{Scope: ScopeShared}
+		var args string
+		var createdBy sql.NullString
+		if err := rows.Scan(&v.Name, &args, &createdBy, &v.UpdatedAt); err != nil {
+			logger.Log.Error("failed to scan view", "error", err)
+			return nil, fmt.Errorf("failed to scan view: %w", err)
+		}
+		v.CreatedBy = createdBy.String
+		if err := json.Unmarshal([]byte(args), &v.Args); err != nil {
+			logger.Log.Error("failed to parse view arguments", "error", err, "name", v.Name)
+			return nil, fmt.Errorf("failed to parse view arguments: %w", err)
+		}
+		all = append(all, v)
+	}
+
+	if err := rows.Err(); err != nil {
+		logger.Log.Error("error iterating views", "error", err)
+		return nil, fmt.Errorf("error iterating views: %w", err)
+	}
+
+	sort.Slice(all, func(i, j int) bool {
+		if all[i].Name != all[j].Name {
+			return all[i].Name < all[j].Name
+		}
+		return all[i].Scope < all[j].Scope
+	})
+	return all, nil
+}
+
+// Delete removes a view from the given scope
+func Delete(db *sql.DB, name, scope string) error {
+	logger.Log.Debug("deleting view", "name", name, "scope", scope)
+
+	switch scope {
+	case ScopePersonal:
+		cfg, err := config.Load()
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+		if _, ok := cfg.Views[name]; !ok {
+			return fmt.Errorf("no personal view named '%s'", name)
+		}
+		delete(cfg.Views, name)
+		if err := config.Save(cfg); err != nil {
+			return fmt.Errorf("failed to delete personal view: %w", err)
+		}
+
+	case ScopeShared:
+		result, err := db.Exec(`DELETE FROM saved_views WHERE name = ?`, name)
+		if err != nil {
+			logger.Log.Error("failed to delete view", "error", err, "name", name)
+			return fmt.Errorf("failed to delete view: %w", err)
+		}
+		if n, err := result.RowsAffected(); err == nil && n == 0 {
+			return fmt.Errorf("no shared view named '%s'", name)
+		}
+
+	default:
+		return fmt.Errorf("invalid view scope: %s (must be %s or %s)", scope, ScopeShared, ScopePersonal)
+	}
+
+	logger.Log.Info("view deleted", "name", name, "scope", scope)
+	return nil
+}
@@ -0,0 +1,202 @@
+package webhooks
+
+import (
+	"alexandria/internal/logger"
+	"bytes"
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Delivery statuses
+const (
+	StatusPending   = "pending"   // waiting for its first or next attempt
+	StatusDelivered = "delivered" // the receiver answered with a 2xx status
+	StatusFailed    = "failed"    // gave up after MaxAttempts
+)
+
+// MaxAttempts is how many times a delivery is tried before it is marked failed
+const MaxAttempts = 6
+
+// BaseBackoff is the wait before the first retry; each later retry waits twice as long
+const BaseBackoff = 30 * time.Second
+
+// Timeout bounds each delivery attempt
+const Timeout = 10 * time.Second
+
+// Delivery is a queued or attempted post of a payload to a hook
+type Delivery struct {
+	ID            int64      `json:"id"`
+	WebhookID     int64      `json:"webhook_id"`
+	URL           string     `json:"url"`
+	Event         string     `json:"event"`
+	TicketID      int64      `json:"ticket_id"`
+	Status        string     `json:"status"`
+	Attempts      int        `json:"attempts"`
+	NextAttemptAt time.Time  `json:"next_attempt_at"`
+	LastError     string     `json:"last_error,omitempty"`
+	ResponseCode  int        `json:"response_code,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	DeliveredAt   *time.Time `json:"delivered_at,omitempty"`
+
+	payload string
+	secret  string
+}
+
+// Backoff returns how long to wait after the given number of failed attempts
+func Backoff(attempts int) time.Duration {
+	if attempts < 1 {
+		return 0
+	}
+	return BaseBackoff << (attempts - 1)
+}
+
+const deliveryColumns = `d.id, d.webhook_id, w.url, d.event, d.ticket_id, d.status, d.attempts, d.next_attempt_at,
+	d.last_error, d.response_code, d.created_at, d.delivered_at, d.payload, w.secret`
+
+// scanDelivery reads a row selected with deliveryColumns
+func scanDelivery(rows *sql.Rows) (*Delivery, error) {
+	var (
+		d         Delivery
+		lastError sql.NullString
+		code      sql.NullInt64
+	)
+	if err := rows.Scan(&d.ID, &d.WebhookID, &d.URL, &d.Event, &d.TicketID, &d.Status, &d.Attempts, &d.NextAttemptAt,
+		&lastError, &code, &d.CreatedAt, &d.DeliveredAt, &d.payload, &d.secret); err != nil {
+		logger.Log.Error("failed to scan delivery", "error", err)
+		return nil, fmt.Errorf("failed to scan delivery: %w", err)
+	}
+	d.LastError = lastError.String
+	d.ResponseCode = int(code.Int64)
+	return &d, nil
+}
+
+// ListDeliveries returns the most recent deliveries, newest first, optionally only
+// those of one project's hooks and those with a given status
+func ListDeliveries(db *sql.DB, project, status string, limit int) ([]Delivery, error) {
+	logger.Log.Debug("listing deliveries", "project", project, "status", status, "limit", limit)
+
+	query := `SELECT ` + deliveryColumns + ` FROM webhook_deliveries d JOIN webhooks w ON w.id = d.webhook_id WHERE 1 = 1`
+	var args []interface{}
+	if project != "" {
+		query += ` AND w.project = ?`
+		args = append(args, project)
+	}
+	if status != "" {
+		query += ` AND d.status = ?`
+		args = append(args, status)
+	}
+	query += ` ORDER BY d.id DESC LIMIT ?`
+	args = append(args, limit)
+
+	return queryDeliveries(db, query, args...)
+}
+
+// queryDeliveries runs a query selecting deliveryColumns
+func queryDeliveries(db *sql.DB, query string, args ...interface{}) ([]Delivery, error) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		logger.Log.Error("failed to query deliveries", "error", err)
+		return nil, fmt.Errorf("failed to query deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	var deliveries []Delivery
+	for rows.Next() {
+		d, err := scanDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, *d)
+	}
+	return deliveries, rows.Err()
+}
+
+// Result counts what a call to Deliver did
+type Result struct {
+	Delivered int
+	Retrying  int
+	Failed    int
+}
+
+// Deliver attempts every pending delivery that is due at now. A delivery that does
+// not get a 2xx answer is retried after an exponentially growing wait, and marked
+// failed after MaxAttempts.
+func Deliver(ctx context.Context, db *sql.DB, client *http.Client, now time.Time) (Result, error) {
+	var res Result
+
+	pending, err := queryDeliveries(db, `SELECT `+deliveryColumns+`
+		FROM webhook_deliveries d JOIN webhooks w ON w.id = d.webhook_id
+		WHERE d.status = ? ORDER BY d.id`, StatusPending)
+	if err != nil {
+		return res, err
+	}
+
+	for _, d := range pending {
+		// The due time is compared here so it behaves the same on every backend
+		if d.NextAttemptAt.After(now) {
+			continue
+		}
+		code, sendErr := send(ctx, client, &d)
+		d.Attempts++
+
+		switch {
+		case sendErr == nil:
+			_, err = db.Exec(`UPDATE webhook_deliveries SET status = ?, attempts = ?, response_code = ?, last_error = NULL, delivered_at = ? WHERE id = ?`,
+				StatusDelivered, d.Attempts, code, now, d.ID)
+			res.Delivered++
+		case d.Attempts >= MaxAttempts:
+			_, err = db.Exec(`UPDATE webhook_deliveries SET status = ?, attempts = ?, response_code = ?, last_error = ? WHERE id = ?`,
+				StatusFailed, d.Attempts, code, sendErr.Error(), d.ID)
+			res.Failed++
+		default:
+			_, err = db.Exec(`UPDATE webhook_deliveries SET attempts = ?, response_code = ?, last_error = ?, next_attempt_at = ? WHERE id = ?`,
+				d.Attempts, code, sendErr.Error(), now.Add(Backoff(d.Attempts)), d.ID)
+			res.Retrying++
+		}
+		if err != nil {
+			logger.Log.Error("failed to record delivery attempt", "error", err, "delivery", d.ID)
+			return res, fmt.Errorf("failed to record delivery attempt: %w", err)
+		}
+		if sendErr != nil {
+			logger.Log.Warn("webhook delivery failed", "delivery", d.ID, "url", d.URL, "attempt", d.Attempts, "error", sendErr)
+		}
+	}
+
+	logger.Log.Debug("deliveries attempted", "delivered", res.Delivered, "retrying", res.Retrying, "failed", res.Failed)
+	return res, nil
+}
+
+// send posts a delivery's payload, returning the response status code (0 if there
+// was no response) and an error unless the receiver accepted it
+func send(ctx context.Context, client *http.Client, d *Delivery) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, Timeout)
+	defer cancel()
+
+	body := []byte(d.payload)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "alexandria-webhooks")
+	req.Header.Set("X-Alexandria-Event", d.Event)
+	req.Header.Set("X-Alexandria-Delivery", strconv.FormatInt(d.ID, 10))
+	req.Header.Set("X-Alexandria-Signature", Sign(d.secret, body))
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("receiver answered %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,171 @@
+package webhooks
+
+import (
+	"alexandria/internal/logger"
+	"alexandria/internal/ticket"
+	"context"
+	"database/sql"
+	"time"
+)
+
+// Store is a TicketStore that queues a delivery to every subscribed hook when a
+// ticket changes. Reads and everything else go straight to the wrapped store.
+// Queueing happens after the change is saved; a failure to queue is logged but
+// does not undo the change.
+type Store struct {
+	ticket.TicketStore
+	db     *sql.DB
+	queued int
+}
+
+// NewStore wraps tickets, queueing deliveries in db
+func NewStore(tickets ticket.TicketStore, db *sql.DB) *Store {
+	return &Store{TicketStore: tickets, db: db}
+}
+
+// Queued returns how many deliveries have been queued through the store
+func (s *Store) Queued() int {
+	return s.queued
+}
+
+// Create saves a new ticket and queues the created event
+func (s *Store) Create(ctx context.Context, project string, t *ticket.Ticket) error {
+	if err := s.TicketStore.Create(ctx, project, t); err != nil {
+		return err
+	}
+	s.changed(ctx, EventCreated, t.ID, nil, "")
+	return nil
+}
+
+// Update saves a ticket and queues the updated event, and status_changed if its status changed
+func (s *Store) Update(ctx context.Context, project string, t *ticket.Ticket) error {
+	before, _ := s.TicketStore.Get(ctx, t.ID)
+	if err := s.TicketStore.Update(ctx, project, t); err != nil {
+		return err
+	}
+	s.changed(ctx, EventUpdated, t.ID, before, "")
+	return nil
+}
+
+// UpdateMany saves several tickets and queues their events as Update does
+func (s *Store) UpdateMany(ctx context.Context, tickets []ticket.Ticket) error {
+	before := make([]*ticket.Ticket, len(tickets))
+	for i := range tickets {
+		before[i], _ = s.TicketStore.Get(ctx, tickets[i].ID)
+	}
+	if err := s.TicketStore.UpdateMany(ctx, tickets); err != nil {
+		return err
+	}
+	for i := range tickets {
+		s.changed(ctx, EventUpdated, tickets[i].ID, before[i], "")
+	}
+	return nil
+}
+
+// Delete moves a ticket to the trash and queues the deleted event
+func (s *Store) Delete(ctx context.Context, project string, id int64) error {
+	before, _ := s.TicketStore.Get(ctx, id)
+	if err := s.TicketStore.Delete(ctx, project, id); err != nil {
+		return err
+	}
+	s.queue(ctx, EventDeleted, before, nil, "")
+	return nil
+}
+
+// DeleteMany moves several tickets to the trash and queues their deleted events
+func (s *Store) DeleteMany(ctx context.Context, tickets []ticket.Ticket) error {
+	before := make([]*ticket.Ticket, len(tickets))
+	for i := range tickets {
+		before[i], _ = s.TicketStore.Get(ctx, tickets[i].ID)
+	}
+	if err := s.TicketStore.DeleteMany(ctx, tickets); err != nil {
+		return err
+	}
+	for _, t := range before {
+		s.queue(ctx, EventDeleted, t, nil, "")
+	}
+	return nil
+}
+
+// Restore takes a ticket out of the trash and queues the restored event
+func (s *Store) Restore(ctx context.Context, id int64) error {
+	if err := s.TicketStore.Restore(ctx, id); err != nil {
+		return err
+	}
+	s.changed(ctx, EventRestored, id, nil, "")
+	return nil
+}
+
+// AddComment appends a comment and queues the commented event
+func (s *Store) AddComment(ctx context.Context, ticketID int64, text string) error {
+	if err := s.TicketStore.AddComment(ctx, ticketID, text); err != nil {
+		return err
+	}
+	s.changed(ctx, EventCommented, ticketID, nil, text)
+	return nil
+}
+
+// Undo reverts the user's last change and queues the event for what the undo did:
+// undoing a create deletes the ticket, undoing a delete restores it and undoing an
+// update updates it, with status_changed if that put its status back
+func (s *Store) Undo(ctx context.Context, user string) (*ticket.JournalEntry, error) {
+	var before *ticket.Ticket
+	if next, err := s.TicketStore.ListJournal(ctx, user, 1); err == nil && len(next) == 1 {
+		before, _ = s.TicketStore.Get(ctx, next[0].TicketID)
+	}
+	e, err := s.TicketStore.Undo(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	if before != nil && before.ID != e.TicketID {
+		before = nil
+	}
+
+	switch e.Op {
+	case ticket.OpCreate:
+		s.queue(ctx, EventDeleted, before, nil, "")
+	case ticket.OpDelete:
+		s.changed(ctx, EventRestored, e.TicketID, nil, "")
+	case ticket.OpUpdate:
+		s.changed(ctx, EventUpdated, e.TicketID, before, "")
+	}
+	return e, nil
+}
+
+// changed queues an event describing a ticket as it is now, followed by
+// status_changed when before shows a different status
+func (s *Store) changed(ctx context.Context, event string, id int64, before *ticket.Ticket, comment string) {
+	t, err := s.TicketStore.Get(ctx, id)
+	if err != nil {
+		logger.Log.Warn("failed to load changed ticket for webhooks", "error", err, "id", id)
+		return
+	}
+	s.queue(ctx, event, t, nil, comment)
+	if before != nil && before.Status != t.Status {
+		s.queue(ctx, EventStatusChanged, t, before, "")
+	}
+}
+
+// queue records a delivery of the event to each subscribed hook of the ticket's project
+func (s *Store) queue(ctx context.Context, event string, t, before *ticket.Ticket, comment string) {
+	if t == nil {
+		return
+	}
+	p := &Payload{
+		Event:     event,
+		Project:   t.Project,
+		Actor:     ticket.ActorFrom(ctx),
+		Timestamp: time.Now(),
+		Ticket:    t,
+		Comment:   comment,
+	}
+	if before != nil {
+		p.PreviousStatus = before.Status
+	}
+
+	n, err := Enqueue(s.db, p)
+	if err != nil {
+		logger.Log.Warn("failed to queue webhook deliveries", "error", err, "event", event, "ticket_id", t.ID)
+	}
+	s.queued += n
+}
@@ -0,0 +1,222 @@
+package webhooks
+
+import (
+	"alexandria/internal/logger"
+	"alexandria/internal/ticket"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Events a webhook can subscribe to
+const (
+	EventCreated       = "created"
+	EventUpdated       = "updated"
+	EventStatusChanged = "status_changed"
+	EventCommented     = "commented"
+	EventDeleted       = "deleted"
+	EventRestored      = "restored"
+)
+
+// Events lists every event, in the order they are documented
+var Events = []string{EventCreated, EventUpdated, EventStatusChanged, EventCommented, EventDeleted, EventRestored}
+
+// Hook is a project's subscription to ticket events
+type Hook struct {
+	ID        int64     `json:"id"`
+	Project   string    `json:"project"`
+	URL       string    `json:"url"`
+	Events    []string  `json:"events"`
+	Secret    string    `json:"-"` // key for the payload signature, never printed after creation
+	CreatedBy string    `json:"created_by,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Wants reports whether the hook subscribes to event
+func (h *Hook) Wants(event string) bool {
+	for _, e := range h.Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// Payload is the JSON body posted to a hook
+type Payload struct {
+	Event          string         `json:"event"`
+	Project        string         `json:"project"`
+	Actor          string         `json:"actor,omitempty"`
+	Timestamp      time.Time      `json:"timestamp"`
+	Ticket         *ticket.Ticket `json:"ticket"`
+	PreviousStatus ticket.Status  `json:"previous_status,omitempty"` // for status_changed
+	Comment        string         `json:"comment,omitempty"`         // for commented
+}
+
+// ParseEvents parses a comma-separated event list. An empty list means every event.
+func ParseEvents(value string) ([]string, error) {
+	if strings.TrimSpace(value) == "" {
+		return append([]string{}, Events...), nil
+	}
+
+	var events []string
+	seen := make(map[string]bool)
+	for _, e := range strings.Split(value, ",") {
+		e = strings.TrimSpace(e)
+		if e == "" || seen[e] {
+			continue
+		}
+		known := false
+		for _, k := range Events {
+			known = known || k == e
+		}
+		if !known {
+			return nil, fmt.Errorf("unknown event: %s (must be one of: %s)", e, strings.Join(Events, ", "))
+		}
+		seen[e] = true
+		events = append(events, e)
+	}
+	return events, nil
+}
+
+// NewSecret returns a random signing secret
+func NewSecret() (string, error) {
+	b := make([]byte, 20)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate secret: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// Sign returns the signature sent in the X-Alexandria-Signature header: the
+// hex-encoded HMAC-SHA256 of the body keyed with the hook's secret
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Add stores a new hook and sets its ID
+func Add(db *sql.DB, h *Hook) error {
+	logger.Log.Debug("adding webhook", "project", h.Project, "url", h.URL, "events", h.Events)
+
+	if h.Project == "" {
+		return fmt.Errorf("webhook project is required")
+	}
+	if !strings.HasPrefix(h.URL, "http://") && !strings.HasPrefix(h.URL, "https://") {
+		return fmt.Errorf("invalid webhook URL: %s (must start with http:// or https://)", h.URL)
+	}
+
+	h.CreatedAt = time.Now()
+	err := db.QueryRow(`
+		INSERT INTO webhooks (project, url, events, secret, created_by, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+		RETURNING id`,
+		h.Project, h.URL, strings.Join(h.Events, ","), h.Secret, h.CreatedBy, h.CreatedAt,
+	).Scan(&h.ID)
+	if err != nil {
+		logger.Log.Error("failed to add webhook", "error", err, "project", h.Project)
+		return fmt.Errorf("failed to add webhook: %w", err)
+	}
+
+	logger.Log.Info("webhook added", "id", h.ID, "project", h.Project)
+	return nil
+}
+
+// List returns a project's hooks, or every hook when project is empty
+func List(db *sql.DB, project string) ([]Hook, error) {
+	logger.Log.Debug("listing webhooks", "project", project)
+
+	query := `SELECT id, project, url, events, secret, created_by, created_at FROM webhooks`
+	var args []interface{}
+	if project != "" {
+		query += ` WHERE project = ?`
+		args = append(args, project)
+	}
+	query += ` ORDER BY id`
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		logger.Log.Error("failed to query webhooks", "error", err)
+		return nil, fmt.Errorf("failed to query webhooks: %w", err)
+	}
+	defer rows.Close()
+
+	var hooks []Hook
+	for rows.Next() {
+		var (
+			h         Hook
+			events    string
+			createdBy sql.NullString
+		)
+		if err := rows.Scan(&h.ID, &h.Project, &h.URL, &events, &h.Secret, &createdBy, &h.CreatedAt); err != nil {
+			logger.Log.Error("failed to scan webhook", "error", err)
+			return nil, fmt.Errorf("failed to scan webhook: %w", err)
+		}
+		h.Events = strings.Split(events, ",")
+		h.CreatedBy = createdBy.String
+		hooks = append(hooks, h)
+	}
+	return hooks, rows.Err()
+}
+
+// Remove deletes a hook along with its queued and past deliveries
+func Remove(db *sql.DB, id int64) error {
+	logger.Log.Debug("removing webhook", "id", id)
+
+	result, err := db.Exec(`DELETE FROM webhooks WHERE id = ?`, id)
+	if err != nil {
+		logger.Log.Error("failed to remove webhook", "error", err, "id", id)
+		return fmt.Errorf("failed to remove webhook: %w", err)
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("webhook %d not found", id)
+	}
+	if _, err := db.Exec(`DELETE FROM webhook_deliveries WHERE webhook_id = ?`, id); err != nil {
+		logger.Log.Error("failed to remove deliveries", "error", err, "id", id)
+		return fmt.Errorf("failed to remove deliveries: %w", err)
+	}
+
+	logger.Log.Info("webhook removed", "id", id)
+	return nil
+}
+
+// Enqueue queues a delivery of the payload to each of the project's hooks that
+// subscribes to its event, and returns how many were queued
+func Enqueue(db *sql.DB, p *Payload) (int, error) {
+	hooks, err := List(db, p.Project)
+	if err != nil {
+		return 0, err
+	}
+
+	body, err := json.Marshal(p)
+	if err != nil {
+		logger.Log.Error("failed to marshal payload", "error", err)
+		return 0, fmt.Errorf("failed to marshal payload: %w", err)
+	}
+
+	queued := 0
+	for _, h := range hooks {
+		if !h.Wants(p.Event) {
+			continue
+		}
+		if _, err := db.Exec(`
+			INSERT INTO webhook_deliveries (webhook_id, event, ticket_id, payload, status, attempts, next_attempt_at, created_at)
+			VALUES (?, ?, ?, ?, ?, 0, ?, ?)`,
+			h.ID, p.Event, p.Ticket.ID, string(body), StatusPending, p.Timestamp, p.Timestamp,
+		); err != nil {
+			logger.Log.Error("failed to queue delivery", "error", err, "webhook", h.ID)
+			return queued, fmt.Errorf("failed to queue delivery: %w", err)
+		}
+		queued++
+	}
+
+	logger.Log.Debug("deliveries queued", "event", p.Event, "ticket_id", p.Ticket.ID, "count", queued)
+	return queued, nil
+}
@@ -0,0 +1,275 @@
+package webhooks_test
+
+import (
+	"alexandria/internal/testdb"
+	"alexandria/internal/ticket"
+	"alexandria/internal/webhooks"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// request is what the test receiver saw of one delivery
+type request struct {
+	event     string
+	signature string
+	body      []byte
+}
+
+// receiver is an httptest server standing in for a webhook endpoint. It answers
+// with status, which tests may change between deliveries.
+type receiver struct {
+	*httptest.Server
+	mu       sync.Mutex
+	status   int
+	requests []request
+}
+
+func newReceiver(t *testing.T) *receiver {
+	t.Helper()
+	r := &receiver{status: http.StatusOK}
+	r.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := io.ReadAll(req.Body)
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.requests = append(r.requests, request{
+			event:     req.Header.Get("X-Alexandria-Event"),
+			signature: req.Header.Get("X-Alexandria-Signature"),
+			body:      body,
+		})
+		w.WriteHeader(r.status)
+	}))
+	t.Cleanup(r.Close)
+	return r
+}
+
+func (r *receiver) setStatus(status int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.status = status
+}
+
+func (r *receiver) received() []request {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]request{}, r.requests...)
+}
+
+func addHook(t *testing.T, db *sql.DB, project, url, events string) *webhooks.Hook {
+	t.Helper()
+	parsed, err := webhooks.ParseEvents(events)
+	if err != nil {
+		t.Fatalf("failed to parse events: %v", err)
+	}
+	h := &webhooks.Hook{Project: project, URL: url, Events: parsed, Secret: "s3cret"}
+	if err := webhooks.Add(db, h); err != nil {
+		t.Fatalf("failed to add hook: %v", err)
+	}
+	return h
+}
+
+func deliver(t *testing.T, db *sql.DB, now time.Time) webhooks.Result {
+	t.Helper()
+	res, err := webhooks.Deliver(context.Background(), db, http.DefaultClient, now)
+	if err != nil {
+		t.Fatalf("deliver failed: %v", err)
+	}
+	return res
+}
+
+func TestSignedDeliveryOfSubscribedEvents(t *testing.T) {
+	db := testdb.Open(t)
+	recv := newReceiver(t)
+	addHook(t, db, "Backend", recv.URL, "created,status_changed")
+	addHook(t, db, "Frontend", recv.URL, "")
+
+	store := webhooks.NewStore(ticket.NewSQLStore(db), db)
+	ctx := ticket.WithActor(context.Background(), "alice")
+
+	tk := &ticket.Ticket{Title: "Fix login", Type: ticket.TypeBug, Status: ticket.StatusOpen, Priority: ticket.PriorityHigh}
+	if err := store.Create(ctx, "Backend", tk); err != nil {
+		t.Fatalf("failed to create ticket: %v", err)
+	}
+	if err := store.AddComment(ctx, tk.ID, "looking into it"); err != nil {
+		t.Fatalf("failed to comment: %v", err)
+	}
+	tk.Status = ticket.StatusInProgress
+	if err := store.Update(ctx, "Backend", tk); err != nil {
+		t.Fatalf("failed to update ticket: %v", err)
+	}
+
+	// The hook wants created and status_changed, not commented or updated
+	if store.Queued() != 2 {
+		t.Fatalf("expected 2 queued deliveries, got %d", store.Queued())
+	}
+	if res := deliver(t, db, time.Now()); res.Delivered != 2 {
+		t.Fatalf("expected 2 deliveries, got %+v", res)
+	}
+
+	got := recv.received()
+	if len(got) != 2 || got[0].event != webhooks.EventCreated || got[1].event != webhooks.EventStatusChanged {
+		t.Fatalf("unexpected requests: %+v", got)
+	}
+	for _, r := range got {
+		if r.signature != webhooks.Sign("s3cret", r.body) {
+			t.Errorf("signature %q does not match the body", r.signature)
+		}
+	}
+
+	var p webhooks.Payload
+	if err := json.Unmarshal(got[1].body, &p); err != nil {
+		t.Fatalf("failed to decode payload: %v", err)
+	}
+	if p.Project != "Backend" || p.Actor != "alice" || p.Ticket == nil || p.Ticket.ID != tk.ID ||
+		p.Ticket.Status != ticket.StatusInProgress || p.PreviousStatus != ticket.StatusOpen {
+		t.Errorf("unexpected payload: %+v", p)
+	}
+
+	// Nothing is left to send
+	if res := deliver(t, db, time.Now()); res != (webhooks.Result{}) {
+		t.Errorf("expected nothing to deliver, got %+v", res)
+	}
+}
+
+func TestFailedDeliveriesBackOffAndGiveUp(t *testing.T) {
+	db := testdb.Open(t)
+	recv := newReceiver(t)
+	recv.setStatus(http.StatusInternalServerError)
+	h := addHook(t, db, "Backend", recv.URL, "created")
+
+	now := time.Now()
+	p := &webhooks.Payload{Event: webhooks.EventCreated, Project: "Backend", Timestamp: now, Ticket: &ticket.Ticket{ID: 7}}
+	if n, err := webhooks.Enqueue(db, p); err != nil || n != 1 {
+		t.Fatalf("expected 1 queued delivery, got %d (%v)", n, err)
+	}
+
+	if res := deliver(t, db, now); res.Retrying != 1 {
+		t.Fatalf("expected a retry after the first failure, got %+v", res)
+	}
+
+	// Not retried before the backoff has passed, then retried with a doubled wait
+	if res := deliver(t, db, now.Add(webhooks.Backoff(1)-time.Second)); res != (webhooks.Result{}) {
+		t.Fatalf("expected no attempt before the backoff, got %+v", res)
+	}
+	now = now.Add(webhooks.Backoff(1))
+	if res := deliver(t, db, now); res.Retrying != 1 {
+		t.Fatalf("expected a second retry, got %+v", res)
+	}
+	if webhooks.Backoff(2) != 2*webhooks.Backoff(1) {
+		t.Errorf("expected backoff to double, got %v then %v", webhooks.Backoff(1), webhooks.Backoff(2))
+	}
+
+	for attempt := 3; attempt <= webhooks.MaxAttempts; attempt++ {
+		now = now.Add(webhooks.Backoff(attempt - 1))
+		deliver(t, db, now)
+	}
+
+	deliveries, err := webhooks.ListDeliveries(db, "Backend", "", 10)
+	if err != nil {
+		t.Fatalf("failed to list deliveries: %v", err)
+	}
+	if len(deliveries) != 1 {
+		t.Fatalf("expected 1 delivery, got %d", len(deliveries))
+	}
+	d := deliveries[0]
+	if d.Status != webhooks.StatusFailed || d.Attempts != webhooks.MaxAttempts || d.ResponseCode != http.StatusInternalServerError || d.WebhookID != h.ID {
+		t.Errorf("unexpected delivery: %+v", d)
+	}
+	if len(recv.received()) != webhooks.MaxAttempts {
+		t.Errorf("expected %d requests, got %d", webhooks.MaxAttempts, len(recv.received()))
+	}
+
+	// A failed delivery is not retried again
+	if res := deliver(t, db, now.Add(24*time.Hour)); res != (webhooks.Result{}) {
+		t.Errorf("expected failed delivery to stay failed, got %+v", res)
+	}
+}
+
+func TestRetriedDeliverySucceeds(t *testing.T) {
+	db := testdb.Open(t)
+	recv := newReceiver(t)
+	recv.setStatus(http.StatusServiceUnavailable)
+	addHook(t, db, "Backend", recv.URL, "")
+
+	now := time.Now()
+	p := &webhooks.Payload{Event: webhooks.EventDeleted, Project: "Backend", Timestamp: now, Ticket: &ticket.Ticket{ID: 3}}
+	if _, err := webhooks.Enqueue(db, p); err != nil {
+		t.Fatalf("failed to queue: %v", err)
+	}
+	deliver(t, db, now)
+
+	recv.setStatus(http.StatusNoContent)
+	if res := deliver(t, db, now.Add(webhooks.Backoff(1))); res.Delivered != 1 {
+		t.Fatalf("expected the retry to be delivered, got %+v", res)
+	}
+
+	delivered, err := webhooks.ListDeliveries(db, "", webhooks.StatusDelivered, 10)
+	if err != nil {
+		t.Fatalf("failed to list deliveries: %v", err)
+	}
+	if len(delivered) != 1 || delivered[0].Attempts != 2 || delivered[0].LastError != "" || delivered[0].DeliveredAt == nil {
+		t.Errorf("unexpected delivered deliveries: %+v", delivered)
+	}
+}
+
+func TestUndoQueuesEvents(t *testing.T) {
+	db := testdb.Open(t)
+	recv := newReceiver(t)
+	addHook(t, db, "Backend", recv.URL, "deleted,restored,status_changed")
+
+	store := webhooks.NewStore(ticket.NewSQLStore(db), db)
+	ctx := ticket.WithActor(context.Background(), "alice")
+
+	tk := &ticket.Ticket{Title: "Fix login", Type: ticket.TypeBug, Status: ticket.StatusOpen, Priority: ticket.PriorityHigh}
+	if err := store.Create(ctx, "Backend", tk); err != nil {
+		t.Fatalf("failed to create ticket: %v", err)
+	}
+	tk.Status = ticket.StatusInProgress
+	if err := store.Update(ctx, "Backend", tk); err != nil {
+		t.Fatalf("failed to update ticket: %v", err)
+	}
+	undo := func() {
+		t.Helper()
+		if _, err := store.Undo(ctx, "alice"); err != nil {
+			t.Fatalf("failed to undo: %v", err)
+		}
+	}
+	undo()
+	if err := store.Delete(ctx, "Backend", tk.ID); err != nil {
+		t.Fatalf("failed to delete ticket: %v", err)
+	}
+	undo()
+	undo()
+
+	// The status change and its undo, the delete and its undo, then the create undone
+	want := []string{
+		webhooks.EventStatusChanged, webhooks.EventStatusChanged,
+		webhooks.EventDeleted, webhooks.EventRestored, webhooks.EventDeleted,
+	}
+	if res := deliver(t, db, time.Now()); res.Delivered != len(want) {
+		t.Fatalf("expected %d deliveries, got %+v", len(want), res)
+	}
+	got := recv.received()
+	if len(got) != len(want) {
+		t.Fatalf("expected %d requests, got %d", len(want), len(got))
+	}
+	for i, r := range got {
+		if r.event != want[i] {
+			t.Errorf("request %d: event = %s, want %s", i, r.event, want[i])
+		}
+	}
+
+	var p webhooks.Payload
+	if err := json.Unmarshal(got[1].body, &p); err != nil {
+		t.Fatalf("failed to decode payload: %v", err)
+	}
+	if p.Ticket == nil || p.Ticket.Status != ticket.StatusOpen || p.PreviousStatus != ticket.StatusInProgress {
+		t.Errorf("unexpected payload for the undone update: %+v", p)
+	}
+}
@@ -2,16 +2,28 @@ package test
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 )
 
 var (
 	binaryPath string
+	// suiteSource is the database the suite runs against. Setting
+	// ALEXANDRIA_TEST_POSTGRES_DSN runs it against PostgreSQL instead of SQLite.
+	suiteSource = "sqlite"
 )
 
 // TestMain builds the binary before running tests
@@ -25,15 +37,46 @@ func TestMain(m *testing.M) {
 		os.Exit(1)
 	}
 
+	// Run against a private home directory and database so the suite leaves the
+	// user's config and tickets alone and starts from an empty database every time
+	home, err := os.MkdirTemp("", "alexandria-e2e")
+	if err != nil {
+		fmt.Printf("Failed to create home directory: %v\n", err)
+		os.Exit(1)
+	}
+	os.Setenv("HOME", home)
+	os.Setenv("ALEXANDRIA_DB_PATH", filepath.Join(home, "e2e.db"))
+
+	if dsn := os.Getenv("ALEXANDRIA_TEST_POSTGRES_DSN"); dsn != "" {
+		os.Setenv("POSTGRES_DSN", dsn)
+		suiteSource = "postgres"
+
+		if output, err := exec.Command(binaryPath, "source", "postgres").CombinedOutput(); err != nil {
+			fmt.Printf("Failed to switch to PostgreSQL: %v\n%s\n", err, output)
+			os.RemoveAll(home)
+			os.Exit(1)
+		}
+	}
+
 	// Run tests using standard database connection
 	code := m.Run()
 
 	// Cleanup binary
 	os.Remove(binaryPath)
+	os.RemoveAll(home)
 
 	os.Exit(code)
 }
 
+// restoreSuiteSource switches back to the suite's database after a test that changes source
+func restoreSuiteSource(t *testing.T) {
+	t.Cleanup(func() {
+		if _, stderr, err := runCommand(t, "source", suiteSource); err != nil {
+			t.Errorf("Failed to switch back to %s: %v\nStderr: %s", suiteSource, err, stderr)
+		}
+	})
+}
+
 // runCommand executes the Alexandria binary with given arguments
 func runCommand(t *testing.T, args ...string) (string, string, error) {
 	cmd := exec.Command(binaryPath, args...)
@@ -73,13 +116,14 @@ func TestSourceStatusCommand(t *testing.T) {
 		t.Error("Source status output doesn't contain 'Database Type:'")
 	}
 
-	// Should default to sqlite
-	if !strings.Contains(stdout, "sqlite") {
-		t.Error("Default database type should be sqlite")
+	// Should be the suite's database, sqlite by default
+	if !strings.Contains(stdout, suiteSource) {
+		t.Errorf("Database type should be %s", suiteSource)
 	}
 }
 
 func TestSourceSwitchToSQLite(t *testing.T) {
+	restoreSuiteSource(t)
 	stdout, stderr, err := runCommand(t, "source", "sqlite")
 	if err != nil {
 		t.Fatalf("Source switch to sqlite failed: %v\nStderr: %s", err, stderr)
@@ -102,6 +146,18 @@ func TestSourceSwitchInvalidType(t *testing.T) {
 	}
 }
 
+func TestSourceSwitchToPostgresRequiresDSN(t *testing.T) {
+	cmd := exec.Command(binaryPath, "source", "postgres")
+	cmd.Env = append(os.Environ(), "HOME="+t.TempDir(), "POSTGRES_DSN=")
+	output, err := cmd.CombinedOutput()
+	if err == nil {
+		t.Fatal("Expected switching to postgres without POSTGRES_DSN to fail")
+	}
+	if !strings.Contains(string(output), "POSTGRES_DSN") {
+		t.Errorf("Expected error naming POSTGRES_DSN, got: %s", output)
+	}
+}
+
 func TestCreateTicket(t *testing.T) {
 	stdout, stderr, err := runCommand(t, "create",
 		"--title", "Test Ticket",
@@ -245,6 +301,7 @@ func TestDeleteTicket(t *testing.T) {
 }
 
 func TestDatabaseSwitchingWorkflow(t *testing.T) {
+	restoreSuiteSource(t)
 	// Start with SQLite
 	t.Log("Switching to SQLite...")
 	stdout, stderr, err := runCommand(t, "source", "sqlite")
@@ -280,3 +337,1159 @@ func TestDatabaseSwitchingWorkflow(t *testing.T) {
 		t.Log("Note: Ticket may not be visible in list output")
 	}
 }
+
+func TestDueDatesAndOverdueFilter(t *testing.T) {
+	stdout, stderr, err := runCommand(t, "create",
+		"--title", "Overdue Test Ticket",
+		"--type", "task",
+		"--project", "DueProject",
+		"--due", "2020-01-01",
+	)
+	if err != nil {
+		t.Fatalf("Failed to create ticket with due date: %v\nStdout: %s\nStderr: %s", err, stdout, stderr)
+	}
+
+	if !strings.Contains(stdout, "due_at") {
+		t.Errorf("Expected due_at in created ticket, got: %s", stdout)
+	}
+
+	stdout, stderr, err = runCommand(t, "list", "--project", "DueProject", "--overdue")
+	if err != nil {
+		t.Fatalf("List overdue tickets failed: %v\nStderr: %s", err, stderr)
+	}
+
+	if !strings.Contains(stdout, "Overdue Test Ticket") || !strings.Contains(stdout, "Overdue:") {
+		t.Errorf("Expected overdue ticket to be listed and highlighted, got: %s", stdout)
+	}
+
+	_, _, err = runCommand(t, "list", "--due-within", "soon")
+	if err == nil {
+		t.Error("Expected error for invalid --due-within value")
+	}
+}
+
+func TestTimeTracking(t *testing.T) {
+	stdout, stderr, err := runCommand(t, "create",
+		"--title", "Time Tracking Ticket",
+		"--type", "task",
+		"--project", "TimeProject",
+	)
+	if err != nil {
+		t.Fatalf("Failed to create ticket: %v\nStdout: %s\nStderr: %s", err, stdout, stderr)
+	}
+	id := createdTicketID(t, stdout)
+
+	stdout, stderr, err = runCommand(t, "log", id, "1h30m", "e2e worklog")
+	if err != nil {
+		t.Fatalf("Log time failed: %v\nStdout: %s\nStderr: %s", err, stdout, stderr)
+	}
+	if !strings.Contains(stdout, "Logged 1h30m") {
+		t.Errorf("Expected logged time confirmation, got: %s", stdout)
+	}
+
+	if _, stderr, err = runCommand(t, "start", "ALX-"+id); err != nil {
+		t.Fatalf("Start timer failed: %v\nStderr: %s", err, stderr)
+	}
+	if _, stderr, err = runCommand(t, "stop"); err != nil {
+		t.Fatalf("Stop timer failed: %v\nStderr: %s", err, stderr)
+	}
+
+	stdout, stderr, err = runCommand(t, "view", "--id", id, "--project", "TimeProject")
+	if err != nil {
+		t.Fatalf("View ticket failed: %v\nStderr: %s", err, stderr)
+	}
+	if !strings.Contains(stdout, "Time logged: 1h30m") {
+		t.Errorf("Expected time logged summary in view output, got: %s", stdout)
+	}
+
+	stdout, stderr, err = runCommand(t, "timesheet", "--week")
+	if err != nil {
+		t.Fatalf("Timesheet failed: %v\nStderr: %s", err, stderr)
+	}
+	if !strings.Contains(stdout, "TimeProject") {
+		t.Errorf("Expected project in timesheet output, got: %s", stdout)
+	}
+	if _, _, err := runCommand(t, "timesheet", "--week", "--since", "monday"); err == nil {
+		t.Error("Expected --week and --since together to be rejected")
+	}
+}
+
+// createdTicketID extracts the ticket ID from the JSON printed by the create command
+func createdTicketID(t *testing.T, stdout string) string {
+	t.Helper()
+	start := strings.Index(stdout, "{")
+	if start < 0 {
+		t.Fatalf("No JSON found in create output: %s", stdout)
+	}
+
+	var created struct {
+		ID int64 `json:"id"`
+	}
+	if err := json.Unmarshal([]byte(stdout[start:]), &created); err != nil {
+		t.Fatalf("Failed to parse create output: %v\n%s", err, stdout)
+	}
+	return strconv.FormatInt(created.ID, 10)
+}
+
+func TestVelocityReport(t *testing.T) {
+	stdout, stderr, err := runCommand(t, "create",
+		"--title", "Velocity Ticket",
+		"--project", "VelocityProject",
+		"--points", "5",
+		"--estimate", "2h",
+		"--tags", "sprint-e2e",
+	)
+	if err != nil {
+		t.Fatalf("Failed to create ticket: %v\nStdout: %s\nStderr: %s", err, stdout, stderr)
+	}
+	id := createdTicketID(t, stdout)
+
+	if _, stderr, err = runCommand(t, "log", id, "3h"); err != nil {
+		t.Fatalf("Log time failed: %v\nStderr: %s", err, stderr)
+	}
+	if _, stderr, err = runCommand(t, "update", "--project", "VelocityProject", "--id", id, "--status", "closed"); err != nil {
+		t.Fatalf("Close ticket failed: %v\nStderr: %s", err, stderr)
+	}
+
+	stdout, stderr, err = runCommand(t, "report", "velocity", "--project", "VelocityProject", "--by", "sprint")
+	if err != nil {
+		t.Fatalf("Velocity report failed: %v\nStderr: %s", err, stderr)
+	}
+	if !strings.Contains(stdout, "sprint-e2e") || !strings.Contains(stdout, "Average cycle time") {
+		t.Errorf("Expected sprint row and cycle time in velocity report, got: %s", stdout)
+	}
+	if !strings.Contains(stdout, "Estimate vs actual") {
+		t.Errorf("Expected estimate ratio in velocity report, got: %s", stdout)
+	}
+}
+
+func TestCharts(t *testing.T) {
+	stdout, stderr, err := runCommand(t, "create",
+		"--title", "Chart Ticket",
+		"--project", "ChartProject",
+		"--points", "3",
+		"--tags", "sprint-chart",
+	)
+	if err != nil {
+		t.Fatalf("Failed to create ticket: %v\nStdout: %s\nStderr: %s", err, stdout, stderr)
+	}
+
+	stdout, stderr, err = runCommand(t, "chart", "burndown", "--sprint", "sprint-chart")
+	if err != nil {
+		t.Fatalf("Burndown chart failed: %v\nStderr: %s", err, stderr)
+	}
+	if !strings.Contains(stdout, "Burndown for sprint-chart") || !strings.Contains(stdout, "remaining 3") {
+		t.Errorf("Expected burndown with remaining points, got: %s", stdout)
+	}
+
+	stdout, stderr, err = runCommand(t, "chart", "cfd", "--project", "ChartProject", "--since", "7d", "--format", "csv")
+	if err != nil {
+		t.Fatalf("CFD chart failed: %v\nStderr: %s", err, stderr)
+	}
+	if !strings.HasPrefix(stdout, "date,closed,in-progress,open") {
+		t.Errorf("Expected CSV header in CFD output, got: %s", stdout)
+	}
+
+	stdout, stderr, err = runCommand(t, "chart", "cfd", "--project", "ChartProject", "--format", "svg")
+	if err != nil {
+		t.Fatalf("CFD SVG chart failed: %v\nStderr: %s", err, stderr)
+	}
+	if !strings.Contains(stdout, "<svg") {
+		t.Errorf("Expected SVG output, got: %s", stdout)
+	}
+}
+
+func TestStatsCommand(t *testing.T) {
+	stdout, stderr, err := runCommand(t, "create",
+		"--title", "Stats Ticket",
+		"--type", "bug",
+		"--project", "StatsProject",
+		"--criticalpath",
+	)
+	if err != nil {
+		t.Fatalf("Failed to create ticket: %v\nStdout: %s\nStderr: %s", err, stdout, stderr)
+	}
+
+	stdout, stderr, err = runCommand(t, "stats", "--project", "StatsProject", "-o", "json")
+	if err != nil {
+		t.Fatalf("Stats command failed: %v\nStderr: %s", err, stderr)
+	}
+
+	var stats struct {
+		ByType           map[string]int `json:"by_type"`
+		OpenCriticalPath int            `json:"open_critical_path"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &stats); err != nil {
+		t.Fatalf("Failed to parse stats JSON: %v\n%s", err, stdout)
+	}
+	if stats.ByType["bug"] < 1 || stats.OpenCriticalPath < 1 {
+		t.Errorf("Expected bug count and open critical-path count, got: %s", stdout)
+	}
+}
+
+func TestSavedViews(t *testing.T) {
+	name := fmt.Sprintf("e2e-view-%d", os.Getpid())
+
+	stdout, stderr, err := runCommand(t, "view", "save", name, "--",
+		"--project", "TestProject", "--type", "bug", "--sort", "priority:desc", "-o", "summary")
+	if err != nil {
+		t.Fatalf("Failed to save view: %v\nStdout: %s\nStderr: %s", err, stdout, stderr)
+	}
+	defer runCommand(t, "view", "rm", name)
+
+	stdout, _, err = runCommand(t, "view", "list")
+	if err != nil || !strings.Contains(stdout, name) {
+		t.Fatalf("Saved view not listed: %v\n%s", err, stdout)
+	}
+
+	// Explicit flags override the view's output format
+	stdout, stderr, err = runCommand(t, "list", "--view", name, "-o", "json")
+	if err != nil {
+		t.Fatalf("List with view failed: %v\nStderr: %s", err, stderr)
+	}
+	if strings.TrimSpace(stdout) != "No tickets found." {
+		var tickets []struct {
+			Type string `json:"type"`
+		}
+		if err := json.Unmarshal([]byte(stdout), &tickets); err != nil {
+			t.Fatalf("Expected JSON output from view override: %v\n%s", err, stdout)
+		}
+		for _, tk := range tickets {
+			if tk.Type != "bug" {
+				t.Errorf("View filter not applied, got type %s", tk.Type)
+			}
+		}
+	}
+
+	if _, _, err := runCommand(t, "view", "save", "bad-view", "--", "--no-such-flag"); err == nil {
+		t.Error("Expected saving a view with an unknown flag to fail")
+	}
+}
+
+func TestGitCommitLinking(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	stdout, stderr, err := runCommand(t, "create", "--title", "Git Linked Ticket", "--type", "bug", "--project", "GitProject")
+	if err != nil {
+		t.Fatalf("Failed to create ticket: %v\nStdout: %s\nStderr: %s", err, stdout, stderr)
+	}
+	id := createdTicketID(t, stdout)
+	for _, text := range []string{"Seen on staging", "Sessions expire after an hour"} {
+		if _, stderr, err := runCommand(t, "comment", "add", id, text); err != nil {
+			t.Fatalf("Failed to add comment: %v\nStderr: %s", err, stderr)
+		}
+	}
+
+	repo := t.TempDir()
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-C", repo}, args...)...)
+		if output, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, output)
+		}
+	}
+	git("init", "-q")
+	git("-c", "user.name=E2E", "-c", "user.email=e2e@example.com",
+		"commit", "-q", "--allow-empty", "-m", "Handle expired sessions, fixes ALX-"+id)
+
+	absBinary, err := filepath.Abs(binaryPath)
+	if err != nil {
+		t.Fatalf("Failed to resolve binary path: %v", err)
+	}
+	scan := exec.Command(absBinary, "git", "scan")
+	scan.Dir = repo
+	if output, err := scan.CombinedOutput(); err != nil {
+		t.Fatalf("Git scan failed: %v\n%s", err, output)
+	}
+
+	stdout, stderr, err = runCommand(t, "view", "--id", id, "--project", "GitProject")
+	if err != nil {
+		t.Fatalf("Failed to view ticket: %v\nStderr: %s", err, stderr)
+	}
+	if !strings.Contains(stdout, "Handle expired sessions") {
+		t.Errorf("Expected linked commit in view output, got: %s", stdout)
+	}
+	if !strings.Contains(stdout, `"status": "closed"`) {
+		t.Errorf("Expected 'fixes' commit to close the ticket, got: %s", stdout)
+	}
+	if strings.Count(stdout, "Seen on staging") != 1 || strings.Count(stdout, "Sessions expire after an hour") != 1 {
+		t.Errorf("Expected closing the ticket to keep its 2 comments once each, got: %s", stdout)
+	}
+}
+
+func TestBranchContext(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	stdout, stderr, err := runCommand(t, "create", "--title", "Branch Context Ticket", "--type", "task", "--project", "GitProject")
+	if err != nil {
+		t.Fatalf("Failed to create ticket: %v\nStdout: %s\nStderr: %s", err, stdout, stderr)
+	}
+	id := createdTicketID(t, stdout)
+
+	repo := t.TempDir()
+	if output, err := exec.Command("git", "-C", repo, "init", "-q").CombinedOutput(); err != nil {
+		t.Fatalf("git init failed: %v\n%s", err, output)
+	}
+
+	absBinary, err := filepath.Abs(binaryPath)
+	if err != nil {
+		t.Fatalf("Failed to resolve binary path: %v", err)
+	}
+	runInRepo := func(args ...string) string {
+		t.Helper()
+		cmd := exec.Command(absBinary, args...)
+		cmd.Dir = repo
+		output, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("%v failed: %v\n%s", args, err, output)
+		}
+		return string(output)
+	}
+
+	if _, stderr, err := runCommand(t, "comment", "add", id, "Planned for this sprint"); err != nil {
+		t.Fatalf("Failed to add comment: %v\nStderr: %s", err, stderr)
+	}
+
+	output := runInRepo("checkout", "ALX-"+id)
+	if !strings.Contains(output, "alx-"+id+"-branch-context-ticket") {
+		t.Errorf("Expected branch named after the ticket, got: %s", output)
+	}
+
+	output = runInRepo("current")
+	if !strings.Contains(output, "Branch Context Ticket") || !strings.Contains(output, "in-progress") {
+		t.Errorf("Expected current ticket in progress, got: %s", output)
+	}
+
+	runInRepo("comment", "add", "Comment from the branch")
+	output = runInRepo("view")
+	if !strings.Contains(output, "Comment from the branch") {
+		t.Errorf("Expected comment on the branch's ticket, got: %s", output)
+	}
+	if strings.Count(output, "Planned for this sprint") != 1 {
+		t.Errorf("Expected starting work to keep the existing comment once, got: %s", output)
+	}
+}
+
+func TestSourceMigrate(t *testing.T) {
+	// Run against a private home directory so the migration never touches the real databases
+	home := t.TempDir()
+	run := func(args ...string) (string, error) {
+		t.Helper()
+		cmd := exec.Command(binaryPath, args...)
+		cmd.Env = append(os.Environ(), "HOME="+home, "ALEXANDRIA_DB_PATH=", "TURSO_URL=http://127.0.0.1:1", "TURSO_AUTH_TOKEN=test")
+		output, err := cmd.CombinedOutput()
+		return string(output), err
+	}
+
+	if output, err := run("create", "--title", "Migrated Ticket", "--type", "bug", "--project", "MigrateProject", "--tags", "moved"); err != nil {
+		t.Fatalf("Failed to create ticket: %v\n%s", err, output)
+	}
+
+	output, err := run("source", "migrate", "--from", "sqlite", "--to", "sync")
+	if err != nil {
+		t.Fatalf("Migration failed: %v\n%s", err, output)
+	}
+	if !strings.Contains(output, "Migration complete") {
+		t.Errorf("Expected migration summary, got: %s", output)
+	}
+
+	output, err = run("source", "migrate", "--from", "sqlite", "--to", "sync")
+	if err == nil || !strings.Contains(output, "--merge") {
+		t.Errorf("Expected migration into a non-empty target to be refused, got: %s", output)
+	}
+
+	if output, err := run("source", "migrate", "--from", "sqlite", "--to", "sync", "--merge"); err != nil {
+		t.Fatalf("Merge migration failed: %v\n%s", err, output)
+	}
+
+	if output, err := run("source", "sync"); err != nil {
+		t.Fatalf("Failed to switch source: %v\n%s", err, output)
+	}
+	output, err = run("view", "--id", "1")
+	if err != nil {
+		t.Fatalf("Failed to view migrated ticket: %v\n%s", err, output)
+	}
+	if !strings.Contains(output, "Migrated Ticket") || !strings.Contains(output, "moved") {
+		t.Errorf("Expected migrated ticket with its tags, got: %s", output)
+	}
+}
+
+func TestDirectoryConfig(t *testing.T) {
+	root := t.TempDir()
+	config := "project = \"DirConfigProject\"\nassignee = \"dir-owner\"\ntags = [\"from-dir\"]\n"
+	if err := os.WriteFile(filepath.Join(root, ".alexandria.toml"), []byte(config), 0644); err != nil {
+		t.Fatalf("Failed to write directory config: %v", err)
+	}
+	nested := filepath.Join(root, "src", "pkg")
+	if err := os.MkdirAll(nested, 0755); err != nil {
+		t.Fatalf("Failed to create nested directory: %v", err)
+	}
+
+	absBinary, err := filepath.Abs(binaryPath)
+	if err != nil {
+		t.Fatalf("Failed to resolve binary path: %v", err)
+	}
+	runIn := func(dir string, args ...string) (string, error) {
+		t.Helper()
+		cmd := exec.Command(absBinary, args...)
+		cmd.Dir = dir
+		output, err := cmd.CombinedOutput()
+		return string(output), err
+	}
+
+	// A command deep inside the tree picks up the defaults
+	output, err := runIn(nested, "create", "--title", "Directory Config Ticket", "--type", "task")
+	if err != nil {
+		t.Fatalf("Create without --project failed: %v\n%s", err, output)
+	}
+	for _, want := range []string{`"project": "DirConfigProject"`, `"assigned_to": "dir-owner"`, "from-dir"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("Expected %s in created ticket, got: %s", want, output)
+		}
+	}
+
+	// A child goes in its parent's project rather than the directory's
+	output, err = runIn(nested, "create", "--title", "Directory Config Epic", "--project", "DirConfigOther")
+	if err != nil {
+		t.Fatalf("Create with --project failed: %v\n%s", err, output)
+	}
+	epicID := createdTicketID(t, output)
+	output, err = runIn(nested, "create", "--title", "Directory Config Child", "--parent", "ALX-"+epicID)
+	if err != nil {
+		t.Fatalf("Create with --parent failed: %v\n%s", err, output)
+	}
+	if !strings.Contains(output, `"project": "DirConfigOther"`) {
+		t.Errorf("Expected the child in its parent's project, got: %s", output)
+	}
+
+	if output, err := runIn(nested, "update", "--title", "Directory Config Ticket", "--status", "in-progress"); err != nil {
+		t.Fatalf("Update without --project failed: %v\n%s", err, output)
+	}
+	output, err = runIn(nested, "view", "--title", "Directory Config Ticket")
+	if err != nil {
+		t.Fatalf("View without --project failed: %v\n%s", err, output)
+	}
+	if !strings.Contains(output, "in-progress") {
+		t.Errorf("Expected updated status, got: %s", output)
+	}
+
+	if output, err := runIn(nested, "delete", "--title", "Directory Config Ticket"); err != nil {
+		t.Fatalf("Delete without --project failed: %v\n%s", err, output)
+	}
+
+	// Outside the tree the project is still required for title lookups
+	output, err = runIn(t.TempDir(), "view", "--title", "Directory Config Ticket")
+	if err == nil || !strings.Contains(output, "project is required") {
+		t.Errorf("Expected view by title outside the tree to need a project, got: %s", output)
+	}
+}
+
+func TestProfiles(t *testing.T) {
+	// Profiles live in the user config, so use a private home directory
+	home := t.TempDir()
+	run := func(args ...string) (string, error) {
+		t.Helper()
+		cmd := exec.Command(binaryPath, args...)
+		cmd.Env = append(os.Environ(), "HOME="+home)
+		output, err := cmd.CombinedOutput()
+		return string(output), err
+	}
+
+	for _, name := range []string{"personal", "client-x"} {
+		if output, err := run("profile", "add", name, "--type", "sqlite", "--path", filepath.Join(home, name+".db")); err != nil {
+			t.Fatalf("Failed to add profile %s: %v\n%s", name, err, output)
+		}
+	}
+	if output, err := run("profile", "add", "team", "--type", "turso", "--url", "libsql://team.example.io", "--token-env", "TEAM_TURSO_TOKEN"); err != nil {
+		t.Fatalf("Failed to add turso profile: %v\n%s", err, output)
+	}
+	if output, err := run("profile", "add", "broken", "--type", "mysql"); err == nil {
+		t.Errorf("Expected an invalid profile type to be rejected, got: %s", output)
+	}
+
+	output, err := run("create", "--title", "Personal Profile Ticket", "--project", "ProfileProject", "--profile", "personal")
+	if err != nil {
+		t.Fatalf("Failed to create ticket with --profile: %v\n%s", err, output)
+	}
+
+	output, err = run("list", "--profile", "client-x", "--project", "ProfileProject")
+	if err != nil {
+		t.Fatalf("Failed to list with --profile: %v\n%s", err, output)
+	}
+	if strings.Contains(output, "Personal Profile Ticket") {
+		t.Errorf("Expected client-x profile to have its own database, got: %s", output)
+	}
+
+	if output, err := run("profile", "use", "personal"); err != nil {
+		t.Fatalf("Failed to use profile: %v\n%s", err, output)
+	}
+	output, err = run("list", "--project", "ProfileProject")
+	if err != nil {
+		t.Fatalf("Failed to list with the default profile: %v\n%s", err, output)
+	}
+	if !strings.Contains(output, "Personal Profile Ticket") {
+		t.Errorf("Expected the selected profile's ticket, got: %s", output)
+	}
+
+	output, err = run("profile", "list")
+	if err != nil {
+		t.Fatalf("Failed to list profiles: %v\n%s", err, output)
+	}
+	if !strings.Contains(output, "* personal") || !strings.Contains(output, "TEAM_TURSO_TOKEN") {
+		t.Errorf("Expected personal marked active and the team token variable, got: %s", output)
+	}
+
+	// The team profile's token variable is unset, so connecting names it
+	output, err = run("list", "--profile", "team")
+	if err == nil || !strings.Contains(output, "TEAM_TURSO_TOKEN") {
+		t.Errorf("Expected a missing token error naming TEAM_TURSO_TOKEN, got: %s", output)
+	}
+}
+
+func TestDBFlagAndMemoryDatabase(t *testing.T) {
+	dbFile := filepath.Join(t.TempDir(), "flag.db")
+
+	stdout, stderr, err := runCommand(t, "create", "--title", "DB Flag Ticket", "--project", "DBFlagProject", "--db", dbFile)
+	if err != nil {
+		t.Fatalf("Create with --db failed: %v\nStderr: %s", err, stderr)
+	}
+	if _, err := os.Stat(dbFile); err != nil {
+		t.Fatalf("Expected --db to create %s: %v", dbFile, err)
+	}
+
+	stdout, _, err = runCommand(t, "list", "--project", "DBFlagProject", "--db", dbFile)
+	if err != nil || !strings.Contains(stdout, "DB Flag Ticket") {
+		t.Errorf("Expected ticket in the --db database, got: %s (%v)", stdout, err)
+	}
+	stdout, _, err = runCommand(t, "list", "--project", "DBFlagProject")
+	if err != nil || strings.Contains(stdout, "DB Flag Ticket") {
+		t.Errorf("Expected the suite database to be untouched, got: %s (%v)", stdout, err)
+	}
+
+	// Each process gets its own empty in-memory database
+	if _, stderr, err := runCommand(t, "create", "--title", "Memory Ticket", "--project", "MemoryProject", "--db", ":memory:"); err != nil {
+		t.Fatalf("Create with --db :memory: failed: %v\nStderr: %s", err, stderr)
+	}
+	stdout, _, err = runCommand(t, "list", "--project", "MemoryProject", "--db", ":memory:")
+	if err != nil || strings.Contains(stdout, "Memory Ticket") {
+		t.Errorf("Expected a fresh in-memory database, got: %s (%v)", stdout, err)
+	}
+	if _, err := os.Stat(":memory:"); err == nil {
+		t.Error("In-memory mode should not create a file named :memory:")
+	}
+}
+
+func TestTemplates(t *testing.T) {
+	skeleton := filepath.Join(t.TempDir(), "bug-report.md")
+	body := "## Steps to Reproduce\n\n## Expected\n\n## Actual\n\nReported by {{user}} on {{date}} in {{project}}"
+	if err := os.WriteFile(skeleton, []byte(body), 0644); err != nil {
+		t.Fatalf("Failed to write skeleton: %v", err)
+	}
+
+	stdout, stderr, err := runCommand(t, "template", "add", "bug-report", "--project", "TemplateProject",
+		"--type", "bug", "--priority", "high", "--tags", "triage,customer", "--description-file", skeleton)
+	if err != nil {
+		t.Fatalf("Failed to add template: %v\nStdout: %s\nStderr: %s", err, stdout, stderr)
+	}
+
+	stdout, _, err = runCommand(t, "template", "list", "--project", "TemplateProject")
+	if err != nil || !strings.Contains(stdout, "bug-report") {
+		t.Fatalf("Template not listed: %v\n%s", err, stdout)
+	}
+	stdout, _, err = runCommand(t, "template", "list", "--project", "OtherProject")
+	if err != nil || strings.Contains(stdout, "bug-report") {
+		t.Errorf("Templates should be scoped to their project: %v\n%s", err, stdout)
+	}
+
+	create := func(args ...string) (map[string]any, error) {
+		cmd := exec.Command(binaryPath, append([]string{"create", "--project", "TemplateProject", "--template", "bug-report"}, args...)...)
+		cmd.Env = append(os.Environ(), "ALEXANDRIA_USER=templater")
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			return nil, fmt.Errorf("%v: %s", err, out)
+		}
+		_, jsonPart, _ := strings.Cut(string(out), "\n")
+		var created map[string]any
+		if err := json.Unmarshal([]byte(jsonPart), &created); err != nil {
+			return nil, fmt.Errorf("invalid JSON: %v: %s", err, out)
+		}
+		return created, nil
+	}
+
+	created, err := create("--title", "Login fails")
+	if err != nil {
+		t.Fatalf("Create from template failed: %v", err)
+	}
+	if created["type"] != "bug" || created["priority"] != "high" {
+		t.Errorf("Expected template type and priority, got %v and %v", created["type"], created["priority"])
+	}
+	if tags := fmt.Sprint(created["tags"]); tags != "[triage customer]" {
+		t.Errorf("Expected template tags, got %s", tags)
+	}
+	desc, _ := created["description"].(string)
+	wantLine := "Reported by templater on " + time.Now().Format("2006-01-02") + " in TemplateProject"
+	if !strings.Contains(desc, "## Steps to Reproduce") || !strings.Contains(desc, wantLine) {
+		t.Errorf("Expected expanded skeleton, got:\n%s", desc)
+	}
+
+	// Flags given on the command line win over the template
+	created, err = create("--title", "Minor glitch", "--priority", "low", "--tags", "ui")
+	if err != nil {
+		t.Fatalf("Create with overrides failed: %v", err)
+	}
+	if created["priority"] != "low" || fmt.Sprint(created["tags"]) != "[ui]" {
+		t.Errorf("Expected command line overrides, got priority %v tags %v", created["priority"], created["tags"])
+	}
+
+	if _, err := create("--title", "Nope", "--template", "missing"); err == nil {
+		t.Error("Expected an unknown template to fail")
+	}
+
+	if _, _, err := runCommand(t, "template", "rm", "bug-report", "--project", "TemplateProject"); err != nil {
+		t.Errorf("Failed to remove template: %v", err)
+	}
+}
+
+func TestBulkOperations(t *testing.T) {
+	project := fmt.Sprintf("Bulk%d", os.Getpid())
+	for i := 1; i <= 12; i++ {
+		args := []string{"create", "--title", fmt.Sprintf("Bulk ticket %d", i), "--project", project, "--tags", "sprint-3"}
+		if i <= 3 {
+			args = append(args, "--type", "bug")
+		}
+		if _, stderr, err := runCommand(t, args...); err != nil {
+			t.Fatalf("Failed to create ticket: %v\nStderr: %s", err, stderr)
+		}
+	}
+	where := "project:" + project + " tag:sprint-3"
+
+	// A dry run previews without changing anything
+	stdout, stderr, err := runCommand(t, "bulk", "update", "--status", "closed", "--where", where+" type:bug", "--dry-run")
+	if err != nil || !strings.Contains(stdout, "3 ticket(s) match") {
+		t.Fatalf("Dry run failed: %v\nStdout: %s\nStderr: %s", err, stdout, stderr)
+	}
+	stdout, _, _ = runCommand(t, "list", "--where", where+" status:closed")
+	if !strings.Contains(stdout, "No tickets found.") {
+		t.Errorf("Dry run changed tickets:\n%s", stdout)
+	}
+
+	if stdout, stderr, err = runCommand(t, "bulk", "update", "--status", "closed", "--where", where+" type:bug"); err != nil {
+		t.Fatalf("Bulk update failed: %v\nStderr: %s", err, stderr)
+	}
+	stdout, _, _ = runCommand(t, "list", "--where", where+" status:closed", "-o", "json")
+	var closed []map[string]any
+	if err := json.Unmarshal([]byte(stdout), &closed); err != nil || len(closed) != 3 {
+		t.Errorf("Expected 3 closed tickets, got %d (%v)\n%s", len(closed), err, stdout)
+	}
+
+	// More than the limit needs --yes
+	if _, _, err := runCommand(t, "bulk", "tag", "--add", "sprint-4", "--remove", "sprint-3", "--where", where); err == nil {
+		t.Error("Expected bulk tag of 12 tickets without --yes to fail")
+	}
+	if _, stderr, err := runCommand(t, "bulk", "tag", "--add", "sprint-4", "--remove", "sprint-3", "--where", where, "--yes"); err != nil {
+		t.Fatalf("Bulk tag failed: %v\nStderr: %s", err, stderr)
+	}
+	stdout, _, _ = runCommand(t, "list", "--where", "project:"+project+" tag:sprint-4")
+	if !strings.Contains(stdout, "Total: 12 ticket(s)") {
+		t.Errorf("Expected 12 retagged tickets:\n%s", stdout)
+	}
+
+	if _, _, err := runCommand(t, "bulk", "delete", "--where", ""); err == nil {
+		t.Error("Expected an empty --where to be rejected")
+	}
+	if _, stderr, err := runCommand(t, "bulk", "delete", "--where", "project:"+project, "--yes"); err != nil {
+		t.Fatalf("Bulk delete failed: %v\nStderr: %s", err, stderr)
+	}
+	stdout, _, _ = runCommand(t, "list", "--project", project)
+	if !strings.Contains(stdout, "No tickets found.") {
+		t.Errorf("Expected every ticket deleted:\n%s", stdout)
+	}
+}
+
+func TestTrashAndRestore(t *testing.T) {
+	project := fmt.Sprintf("Trash%d", os.Getpid())
+	stdout, stderr, err := runCommand(t, "create", "--title", "Trash me", "--project", project, "--tags", "keep")
+	if err != nil {
+		t.Fatalf("Failed to create ticket: %v\nStderr: %s", err, stderr)
+	}
+	id := createdTicketID(t, stdout)
+
+	if stdout, stderr, err = runCommand(t, "delete", "--project", project, "--id", id); err != nil {
+		t.Fatalf("Delete failed: %v\nStderr: %s", err, stderr)
+	}
+	if !strings.Contains(stdout, "alexandria restore "+id) {
+		t.Errorf("Expected delete to explain how to restore, got: %s", stdout)
+	}
+
+	// Deleted tickets are hidden from list and view
+	stdout, _, _ = runCommand(t, "list", "--project", project)
+	if !strings.Contains(stdout, "No tickets found.") {
+		t.Errorf("Deleted ticket still listed:\n%s", stdout)
+	}
+	if _, _, err := runCommand(t, "view", "--id", id); err == nil {
+		t.Error("Expected view of a deleted ticket to fail")
+	}
+
+	stdout, _, err = runCommand(t, "trash", "list", "--project", project)
+	if err != nil || !strings.Contains(stdout, "Trash me") {
+		t.Fatalf("Deleted ticket not in trash: %v\n%s", err, stdout)
+	}
+
+	if stdout, stderr, err = runCommand(t, "restore", "ALX-"+id); err != nil {
+		t.Fatalf("Restore failed: %v\nStderr: %s", err, stderr)
+	}
+	stdout, _, _ = runCommand(t, "view", "--id", id)
+	if !strings.Contains(stdout, "Trash me") || !strings.Contains(stdout, "keep") {
+		t.Errorf("Restored ticket lost its data:\n%s", stdout)
+	}
+	if _, _, err := runCommand(t, "restore", id); err == nil {
+		t.Error("Expected restoring a ticket that is not in the trash to fail")
+	}
+
+	// Purging only removes tickets deleted before the cutoff
+	if _, stderr, err := runCommand(t, "delete", "--project", project, "--id", id); err != nil {
+		t.Fatalf("Delete failed: %v\nStderr: %s", err, stderr)
+	}
+	if _, _, err := runCommand(t, "trash", "purge"); err == nil {
+		t.Error("Expected purge without --older-than or --all to fail")
+	}
+	stdout, _, _ = runCommand(t, "trash", "purge", "--older-than", "30d")
+	if !strings.Contains(stdout, "removed 0 ticket(s)") {
+		t.Errorf("Expected nothing purged yet, got: %s", stdout)
+	}
+	if _, stderr, err := runCommand(t, "trash", "purge", "--all"); err != nil {
+		t.Fatalf("Purge failed: %v\nStderr: %s", err, stderr)
+	}
+	stdout, _, _ = runCommand(t, "trash", "list", "--project", project)
+	if !strings.Contains(stdout, "The trash is empty.") {
+		t.Errorf("Expected purged ticket gone from the trash:\n%s", stdout)
+	}
+	if _, _, err := runCommand(t, "restore", id); err == nil {
+		t.Error("Expected restoring a purged ticket to fail")
+	}
+}
+
+func TestUndo(t *testing.T) {
+	project := fmt.Sprintf("Undo%d", os.Getpid())
+	as := func(user string, args ...string) (string, error) {
+		cmd := exec.Command(binaryPath, args...)
+		cmd.Env = append(os.Environ(), "ALEXANDRIA_USER="+user)
+		out, err := cmd.CombinedOutput()
+		return string(out), err
+	}
+
+	out, err := as("undo-alice", "create", "--title", "Undo me", "--project", project, "--priority", "low")
+	if err != nil {
+		t.Fatalf("Failed to create ticket: %v\n%s", err, out)
+	}
+	id := createdTicketID(t, out)
+
+	if out, err = as("undo-alice", "update", "--project", project, "--id", id, "--priority", "high", "--title", "Changed"); err != nil {
+		t.Fatalf("Update failed: %v\n%s", err, out)
+	}
+	out, err = as("undo-alice", "undo", "--list")
+	if err != nil || !strings.Contains(out, "update") || !strings.Contains(out, "create") {
+		t.Fatalf("Expected the update and create to be undoable: %v\n%s", err, out)
+	}
+
+	if out, err = as("undo-alice", "undo"); err != nil || !strings.Contains(out, "Undid update of ticket "+id) {
+		t.Fatalf("Undo failed: %v\n%s", err, out)
+	}
+	stdout, _, _ := runCommand(t, "view", "--id", id)
+	if !strings.Contains(stdout, "Undo me") || !strings.Contains(stdout, "low") {
+		t.Errorf("Update not undone:\n%s", stdout)
+	}
+
+	// Someone else's later change blocks undoing the create
+	if out, err = as("undo-bob", "update", "--project", project, "--id", id, "--status", "in-progress"); err != nil {
+		t.Fatalf("Update by another user failed: %v\n%s", err, out)
+	}
+	if out, err = as("undo-alice", "undo"); err == nil || !strings.Contains(out, "undo-bob has changed it since") {
+		t.Errorf("Expected undo to refuse after another user's change: %v\n%s", err, out)
+	}
+
+	if out, err = as("undo-bob", "undo"); err != nil {
+		t.Fatalf("Undo by other user failed: %v\n%s", err, out)
+	}
+	if out, err = as("undo-alice", "undo", "--steps", "5"); err != nil || !strings.Contains(out, "Undid create of ticket "+id) || !strings.Contains(out, "Nothing more to undo after 1 step(s).") {
+		t.Fatalf("Multi-step undo failed: %v\n%s", err, out)
+	}
+	if _, _, err := runCommand(t, "view", "--id", id); err == nil {
+		t.Error("Expected the ticket to be gone after undoing its create")
+	}
+}
+
+func TestArchive(t *testing.T) {
+	project := fmt.Sprintf("Archive%d", os.Getpid())
+	stdout, stderr, err := runCommand(t, "create", "--title", "Old news", "--project", project, "--tags", "history")
+	if err != nil {
+		t.Fatalf("Failed to create ticket: %v\nStderr: %s", err, stderr)
+	}
+	id := createdTicketID(t, stdout)
+	if _, stderr, err = runCommand(t, "create", "--title", "Still open", "--project", project); err != nil {
+		t.Fatalf("Failed to create ticket: %v\nStderr: %s", err, stderr)
+	}
+	if _, stderr, err = runCommand(t, "update", "--project", project, "--id", id, "--status", "closed"); err != nil {
+		t.Fatalf("Failed to close ticket: %v\nStderr: %s", err, stderr)
+	}
+
+	if _, _, err := runCommand(t, "archive", "--project", project); err == nil {
+		t.Error("Expected archive without --closed-before to fail")
+	}
+	stdout, _, err = runCommand(t, "archive", "--project", project, "--closed-before", "90d")
+	if err != nil || !strings.Contains(stdout, "Archived 0 ticket(s)") {
+		t.Errorf("Expected nothing closed 90 days ago: %v\n%s", err, stdout)
+	}
+	stdout, _, err = runCommand(t, "archive", "--project", project, "--closed-before", "0d", "--dry-run")
+	if err != nil || !strings.Contains(stdout, "Would archive "+id) {
+		t.Fatalf("Dry run failed: %v\n%s", err, stdout)
+	}
+	if stdout, stderr, err = runCommand(t, "archive", "--project", project, "--closed-before", "0d"); err != nil || !strings.Contains(stdout, "Archived 1 ticket(s)") {
+		t.Fatalf("Archive failed: %v\n%s%s", err, stdout, stderr)
+	}
+
+	stdout, _, _ = runCommand(t, "list", "--project", project)
+	if strings.Contains(stdout, "Old news") || !strings.Contains(stdout, "Still open") {
+		t.Errorf("Expected only the open ticket listed:\n%s", stdout)
+	}
+	stdout, _, _ = runCommand(t, "list", "--project", project, "--include-archived")
+	if !strings.Contains(stdout, "Old news") || !strings.Contains(stdout, "archived") || !strings.Contains(stdout, "Total: 2") {
+		t.Errorf("Expected the archived ticket listed with --include-archived:\n%s", stdout)
+	}
+
+	stdout, stderr, err = runCommand(t, "view", "--id", "ALX-"+id)
+	if err != nil || !strings.Contains(stdout, "archived") || !strings.Contains(stdout, "history") {
+		t.Errorf("Expected view to fall back to the archive: %v\n%s%s", err, stdout, stderr)
+	}
+}
+
+func TestWebhooks(t *testing.T) {
+	var (
+		mu     sync.Mutex
+		events []string
+		bodies []string
+	)
+	recv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		defer mu.Unlock()
+		events = append(events, r.Header.Get("X-Alexandria-Event"))
+		bodies = append(bodies, string(body))
+	}))
+	defer recv.Close()
+
+	project := fmt.Sprintf("Hooks%d", os.Getpid())
+	if _, _, err := runCommand(t, "hook", "add", "--project", project, "--url", recv.URL, "--events", "created,bogus"); err == nil {
+		t.Error("Expected an unknown event to be rejected")
+	}
+	stdout, stderr, err := runCommand(t, "hook", "add", "--project", project, "--url", recv.URL, "--events", "created,status_changed")
+	if err != nil || !strings.Contains(stdout, "Secret:") {
+		t.Fatalf("Failed to add webhook: %v\n%s%s", err, stdout, stderr)
+	}
+	stdout, _, _ = runCommand(t, "hook", "list", "--project", project)
+	if !strings.Contains(stdout, recv.URL) || !strings.Contains(stdout, "created,status_changed") {
+		t.Errorf("Expected the webhook listed:\n%s", stdout)
+	}
+
+	stdout, stderr, err = runCommand(t, "create", "--title", "Hooked", "--project", project)
+	if err != nil {
+		t.Fatalf("Failed to create ticket: %v\nStderr: %s", err, stderr)
+	}
+	id := createdTicketID(t, stdout)
+	if _, stderr, err = runCommand(t, "update", "--project", project, "--id", id, "--status", "in-progress"); err != nil {
+		t.Fatalf("Failed to update ticket: %v\nStderr: %s", err, stderr)
+	}
+
+	mu.Lock()
+	if len(events) != 2 || events[0] != "created" || events[1] != "status_changed" {
+		t.Errorf("Expected created and status_changed deliveries, got %v", events)
+	} else if !strings.Contains(bodies[1], `"previous_status":"open"`) || !strings.Contains(bodies[1], "Hooked") {
+		t.Errorf("Unexpected status_changed payload: %s", bodies[1])
+	}
+	mu.Unlock()
+
+	stdout, _, err = runCommand(t, "hook", "deliveries", "--project", project)
+	if err != nil || strings.Count(stdout, "delivered") != 2 || !strings.Contains(stdout, "HTTP 200") {
+		t.Errorf("Expected two delivered deliveries: %v\n%s", err, stdout)
+	}
+}
+
+// startSMTPStandIn runs a minimal SMTP server that accepts every message, and
+// returns its address and a function listing the messages received so far
+func startSMTPStandIn(t *testing.T) (string, func() []string) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	var (
+		mu    sync.Mutex
+		mails []string
+	)
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				tp := textproto.NewConn(conn)
+				tp.PrintfLine("220 localhost")
+				for {
+					line, err := tp.ReadLine()
+					if err != nil {
+						return
+					}
+					switch strings.ToUpper(strings.SplitN(line, " ", 2)[0]) {
+					case "DATA":
+						tp.PrintfLine("354 go ahead")
+						data, err := tp.ReadDotBytes()
+						if err != nil {
+							return
+						}
+						mu.Lock()
+						mails = append(mails, string(data))
+						mu.Unlock()
+						tp.PrintfLine("250 OK")
+					case "QUIT":
+						tp.PrintfLine("221 bye")
+						return
+					default:
+						tp.PrintfLine("250 OK")
+					}
+				}
+			}()
+		}
+	}()
+
+	return ln.Addr().String(), func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string{}, mails...)
+	}
+}
+
+func TestNotifications(t *testing.T) {
+	addr, received := startSMTPStandIn(t)
+	project := fmt.Sprintf("Notify%d", os.Getpid())
+	alice, bob := fmt.Sprintf("notify-alice-%d", os.Getpid()), fmt.Sprintf("notify-bob-%d", os.Getpid())
+	as := func(user string, args ...string) (string, error) {
+		cmd := exec.Command(binaryPath, args...)
+		cmd.Env = append(os.Environ(), "ALEXANDRIA_USER="+user)
+		out, err := cmd.CombinedOutput()
+		return string(out), err
+	}
+
+	for _, user := range []string{alice, bob} {
+		if out, err := as(user, "user", "add", user, "--email", user+"@example.com"); err != nil {
+			t.Fatalf("Failed to add user: %v\n%s", err, out)
+		}
+	}
+	if out, err := as(alice, "notify", "smtp", "--addr", addr, "--from", "alexandria@example.com"); err != nil {
+		t.Fatalf("Failed to set SMTP server: %v\n%s", err, out)
+	}
+	if out, err := as(alice, "notify", "enable", "--project", project); err != nil {
+		t.Fatalf("Failed to enable notifications: %v\n%s", err, out)
+	}
+	defer as(alice, "notify", "disable", "--project", project)
+
+	out, err := as(alice, "create", "--title", "Notify me", "--project", project, "--assigned-to", bob, "--created-by", alice)
+	if err != nil {
+		t.Fatalf("Failed to create ticket: %v\n%s", err, out)
+	}
+	id := createdTicketID(t, out)
+	if mails := received(); len(mails) != 1 || !strings.Contains(mails[0], "To: "+bob+"@example.com") || !strings.Contains(mails[0], "Assigned to you") {
+		t.Fatalf("Expected an assignment email to bob, got %q", mails)
+	}
+
+	// Bob watches and switches to a digest; alice's comment waits for it
+	if out, err = as(bob, "notify", "prefs", "--mode", "digest"); err != nil || !strings.Contains(out, "Mode:   digest") {
+		t.Fatalf("Failed to set preferences: %v\n%s", err, out)
+	}
+	if out, err = as(bob, "watch", id); err != nil || !strings.Contains(out, "now watching") {
+		t.Fatalf("Failed to watch: %v\n%s", err, out)
+	}
+	if out, err = as(alice, "comment", "add", id, "Looks like a race"); err != nil {
+		t.Fatalf("Failed to comment: %v\n%s", err, out)
+	}
+	if mails := received(); len(mails) != 1 {
+		t.Fatalf("Expected the comment to wait for the digest, got %d emails", len(mails))
+	}
+	stdout, _, _ := runCommand(t, "view", "--id", id)
+	if !strings.Contains(stdout, "Watchers: "+bob) {
+		t.Errorf("Expected bob listed as a watcher:\n%s", stdout)
+	}
+
+	if out, err = as(alice, "notify", "digest"); err != nil || !strings.Contains(out, "Sent 1 email(s)") {
+		t.Fatalf("Digest failed: %v\n%s", err, out)
+	}
+	if mails := received(); len(mails) != 2 || !strings.Contains(mails[1], "Looks like a race") {
+		t.Errorf("Expected a digest with the comment, got %q", mails)
+	}
+}
+
+func TestRecurring(t *testing.T) {
+	project := fmt.Sprintf("Recur%d", os.Getpid())
+	if _, stderr, err := runCommand(t, "template", "add", "weekly-ops", "--project", project, "--tags", "oncall"); err != nil {
+		t.Fatalf("Failed to add template: %v\nStderr: %s", err, stderr)
+	}
+
+	if _, _, err := runCommand(t, "recur", "add", "--every", "monday", "--cron", "0 9 * * 1", "--template", "weekly-ops", "--project", project); err == nil {
+		t.Error("Expected two schedules to be rejected")
+	}
+	if _, _, err := runCommand(t, "recur", "add", "--every", "fortnight", "--template", "weekly-ops", "--project", project); err == nil {
+		t.Error("Expected an unknown schedule to be rejected")
+	}
+	if _, _, err := runCommand(t, "recur", "add", "--every", "monday", "--template", "missing", "--project", project); err == nil {
+		t.Error("Expected a missing template to be rejected")
+	}
+
+	stdout, stderr, err := runCommand(t, "recur", "add", "--every", "monday", "--at", "09:00", "--template", "weekly-ops", "--project", project)
+	if err != nil || !strings.Contains(stdout, "cron: 0 9 * * 1") || !strings.Contains(stdout, "Next ticket: Mon") {
+		t.Fatalf("Failed to add schedule: %v\n%s%s", err, stdout, stderr)
+	}
+	if _, _, err := runCommand(t, "recur", "add", "weekly-ops", "--rrule", "FREQ=WEEKLY;BYDAY=MO", "--template", "weekly-ops", "--project", project); err == nil {
+		t.Error("Expected a duplicate schedule name to be rejected")
+	}
+	stdout, stderr, err = runCommand(t, "recur", "add", "standup", "--rrule", "FREQ=DAILY;BYHOUR=9;BYMINUTE=30", "--template", "weekly-ops", "--project", project)
+	if err != nil || !strings.Contains(stdout, "cron: 30 9 * * *") {
+		t.Fatalf("Failed to add RRULE schedule: %v\n%s%s", err, stdout, stderr)
+	}
+
+	stdout, _, err = runCommand(t, "recur", "list", "--project", project)
+	if err != nil || !strings.Contains(stdout, "every monday at 09:00") || !strings.Contains(stdout, "standup") || !strings.Contains(stdout, "never") {
+		t.Errorf("Expected both schedules listed: %v\n%s", err, stdout)
+	}
+
+	// Nothing comes due until the first occurrence after the schedule was added
+	stdout, _, err = runCommand(t, "recur", "run")
+	if err != nil || !strings.Contains(stdout, "No recurring tickets are due") {
+		t.Errorf("Expected nothing due yet: %v\n%s", err, stdout)
+	}
+
+	if _, _, err := runCommand(t, "recur", "rm", "standup", "--project", project); err != nil {
+		t.Fatalf("Failed to remove schedule: %v", err)
+	}
+	if _, _, err := runCommand(t, "recur", "rm", "standup", "--project", project); err == nil {
+		t.Error("Expected removing a missing schedule to fail")
+	}
+}
+
+func TestParentChildTickets(t *testing.T) {
+	project := fmt.Sprintf("Epics%d", os.Getpid())
+	create := func(args ...string) string {
+		t.Helper()
+		stdout, stderr, err := runCommand(t, append([]string{"create"}, args...)...)
+		if err != nil {
+			t.Fatalf("Failed to create ticket: %v\nStderr: %s", err, stderr)
+		}
+		return createdTicketID(t, stdout)
+	}
+
+	epic := create("--title", "Billing epic", "--type", "feature", "--project", project)
+	// Children default to their parent's project
+	story := create("--title", "Invoices", "--parent", "ALX-"+epic, "--points", "5")
+	task := create("--title", "Invoice PDF", "--parent", story, "--points", "3")
+	create("--title", "Pricing page", "--parent", epic, "--points", "2")
+
+	// The template is looked up in the parent's project
+	if _, stderr, err := runCommand(t, "template", "add", "bug", "--project", project, "--type", "bug"); err != nil {
+		t.Fatalf("Failed to add template: %v\nStderr: %s", err, stderr)
+	}
+	support := create("--title", "Support epic", "--type", "feature", "--project", project)
+	stdout, stderr, err := runCommand(t, "create", "--title", "Refund crash", "--parent", support, "--template", "bug")
+	if err != nil || !strings.Contains(stdout, `"type": "bug"`) {
+		t.Fatalf("Expected a templated child in the parent's project: %v\n%s%s", err, stdout, stderr)
+	}
+
+	if _, _, err := runCommand(t, "create", "--title", "Orphan", "--parent", "ALX-999999", "--project", project); err == nil {
+		t.Error("Expected a missing parent to be rejected")
+	}
+	if _, _, err := runCommand(t, "update", "--id", epic, "--parent", task); err == nil {
+		t.Error("Expected a parent cycle to be rejected")
+	}
+
+	if _, stderr, err := runCommand(t, "update", "--id", task, "--status", "closed"); err != nil {
+		t.Fatalf("Failed to close task: %v\nStderr: %s", err, stderr)
+	}
+	stdout, _, err = runCommand(t, "view", "--id", epic)
+	if err != nil || !strings.Contains(stdout, "Children: 1/3 done, 7 point(s) remaining") ||
+		!strings.Contains(stdout, "    ALX-"+task+" [closed] Invoice PDF") {
+		t.Errorf("Expected a child tree with a rollup: %v\n%s", err, stdout)
+	}
+	stdout, _, _ = runCommand(t, "view", "--id", task)
+	if !strings.Contains(stdout, "Parent: ALX-"+story+" Invoices") {
+		t.Errorf("Expected the parent shown:\n%s", stdout)
+	}
+
+	stdout, _, err = runCommand(t, "list", "--tree", "--project", project, "--sort", "id:asc")
+	if err != nil || !strings.Contains(stdout, "└ Invoices") || !strings.Contains(stdout, "  └ Invoice PDF") {
+		t.Errorf("Expected nested output: %v\n%s", err, stdout)
+	}
+	if _, _, err := runCommand(t, "list", "--tree", "-o", "json", "--project", project); err == nil {
+		t.Error("Expected --tree to need table output")
+	}
+	stdout, _, _ = runCommand(t, "list", "--where", "parent:ALX-"+epic)
+	if !strings.Contains(stdout, "Total: 2 ticket(s)") {
+		t.Errorf("Expected the epic's two children:\n%s", stdout)
+	}
+
+	_, stderr, err = runCommand(t, "update", "--id", epic, "--status", "closed")
+	if err != nil || !strings.Contains(stderr, "closed with 2 of its 3 child ticket(s) still open") {
+		t.Errorf("Expected a warning about open children: %v\nStderr: %s", err, stderr)
+	}
+}
+
+func TestChecklists(t *testing.T) {
+	project := fmt.Sprintf("Checks%d", os.Getpid())
+	stdout, stderr, err := runCommand(t, "create", "--title", "Schema change", "--project", project)
+	if err != nil {
+		t.Fatalf("Failed to create ticket: %v\nStderr: %s", err, stderr)
+	}
+	id := createdTicketID(t, stdout)
+
+	for _, item := range []string{"write migration", "backfill", "drop old column"} {
+		if _, stderr, err := runCommand(t, "check", "add", "ALX-"+id, item); err != nil {
+			t.Fatalf("Failed to add checklist item: %v\nStderr: %s", err, stderr)
+		}
+	}
+	stdout, _, err = runCommand(t, "check", "done", "ALX-"+id, "1")
+	if err != nil || !strings.Contains(stdout, "(1/3 done)") {
+		t.Errorf("Expected item 1 checked off: %v\n%s", err, stdout)
+	}
+	if _, _, err := runCommand(t, "check", "done", "ALX-"+id, "7"); err == nil {
+		t.Error("Expected a missing item to be rejected")
+	}
+	if _, stderr, err := runCommand(t, "check", "rm", "ALX-"+id, "2"); err != nil {
+		t.Fatalf("Failed to remove checklist item: %v\nStderr: %s", err, stderr)
+	}
+
+	stdout, _, _ = runCommand(t, "view", "--id", id)
+	if !strings.Contains(stdout, "Checklist: 1/2 done") || !strings.Contains(stdout, "  2. [ ] drop old column") {
+		t.Errorf("Expected the checklist in view:\n%s", stdout)
+	}
+	stdout, _, _ = runCommand(t, "list", "--project", project)
+	if !strings.Contains(stdout, "CHECKLIST") || !strings.Contains(stdout, " 1/2 ") {
+		t.Errorf("Expected checklist progress in the table:\n%s", stdout)
+	}
+	stdout, _, _ = runCommand(t, "list", "--project", project, "-o", "summary")
+	if !strings.Contains(stdout, "Checklist: 1/2 done") {
+		t.Errorf("Expected checklist progress in the summary:\n%s", stdout)
+	}
+
+	stdout, _, _ = runCommand(t, "list", "--where", "project:"+project+" incomplete-checklist")
+	if !strings.Contains(stdout, "Total: 1 ticket(s)") {
+		t.Errorf("Expected the ticket with open items:\n%s", stdout)
+	}
+	runCommand(t, "check", "done", "ALX-"+id, "2")
+	stdout, _, _ = runCommand(t, "list", "--where", "project:"+project+" incomplete-checklist")
+	if !strings.Contains(stdout, "No tickets found") {
+		t.Errorf("Expected no tickets once the checklist is done:\n%s", stdout)
+	}
+}